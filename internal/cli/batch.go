@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,11 +18,24 @@ import (
 
 type batchFlags struct {
 	jobs               int
+	autoJobs           bool // --jobs auto
 	timeout            int
+	isolate            bool
+	isolateMemory      string
 	recursive          bool
 	maxDepth           int
 	extensions         []string
 	ignore             []string
+	ignoreFiles        []string
+	followSymlinks     bool
+	minSize            string
+	maxSize            string
+	newerThan          string
+	olderThan          string
+	changedSinceLast   bool
+	filesFrom          string
+	nullSeparated      bool
+	category           string
 	progress           string
 	summaryOnly        bool
 	backupDir          string
@@ -30,8 +44,19 @@ type batchFlags struct {
 	aggressive         bool
 	skipValidation     bool
 	removeSystemErrors bool
+	removeKinds        []string
+	timeoutPerMB       float64
+	maxTimeout         int
+	retries            int
+	finalPassFactor    float64
+	order              string
+	memoryBudget       string
 	moveFailedRepairs  bool
+	routes             []string
 	cleanupEmptyDirs   bool
+	dryRun             bool // List the cleanup without applying it
+	yes                bool // Apply the cleanup without asking
+	trashDir           string
 }
 
 func newBatchCmd(rootFlags *RootFlags) *cobra.Command {
@@ -65,9 +90,11 @@ func newBatchValidateCmd(rootFlags *RootFlags) *cobra.Command {
 	flags := &batchFlags{}
 
 	cmd := &cobra.Command{
-		Use:   "validate <directory>",
+		Use:   "validate [path...]",
 		Short: "Validate multiple EPUB and PDF files",
-		Long: `Validate all EPUB and PDF files in a directory concurrently.
+		Long: `Validate all EPUB and PDF files in the given files, directories and glob
+patterns concurrently. Files reached through more than one path are only
+processed once.
 
 Files are processed using a worker pool for efficient parallel validation.
 Progress is displayed in real-time showing completed/total files.`,
@@ -78,24 +105,53 @@ Progress is displayed in real-time showing completed/total files.`,
   ebm batch validate ./library --jobs 8
 
   # Validate recursively with JSON output
-  ebm batch validate ./books --recursive --format json`,
-		Args: cobra.ExactArgs(1),
+  ebm batch validate ./books --recursive --format json
+
+  # Validate several libraries and an extra file in one report
+  ebm batch validate ./lib1 ./lib2 extra.epub
+
+  # Validate a list of files from find
+  find ./books -name '*.epub' -mtime -1 -print0 | ebm batch validate --files-from - -0
+
+  # Re-validate the invalid files from a previous JSON report
+  ebm batch validate --files-from last-night.json --category invalid`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBatchValidate(cmd.Context(), args[0], flags, rootFlags)
+			if err := checkBatchArgs(args, flags); err != nil {
+				return err
+			}
+			return runBatchValidate(cmd.Context(), args, flags, rootFlags)
 		},
 	}
 
-	cmd.Flags().IntVarP(&flags.jobs, "jobs", "j", runtime.NumCPU(), "Number of concurrent workers")
-	cmd.Flags().IntVar(&flags.timeout, "timeout", 30, "Timeout per file in seconds")
+	flags.jobs = runtime.NumCPU()
+	cmd.Flags().VarP(jobsValue{flags}, "jobs", "j", "Number of concurrent workers, or auto to tune during the run")
+	addRetryFlags(cmd, flags, 30)
+	addScheduleFlags(cmd, flags)
+	cmd.Flags().BoolVar(&flags.isolate, "isolate", false, "Process each file in a child process that is killed on timeout or memory limit")
+	cmd.Flags().StringVar(&flags.isolateMemory, "isolate-memory", "2GB", "Memory limit for each child process with --isolate (0 = unlimited)")
 	cmd.Flags().BoolVarP(&flags.recursive, "recursive", "r", true, "Process subdirectories recursively")
 	cmd.Flags().IntVar(&flags.maxDepth, "max-depth", -1, "Maximum directory depth (-1 = unlimited)")
 	cmd.Flags().StringSliceVar(&flags.extensions, "ext", nil, "File extensions to include (default: .epub, .pdf)")
-	cmd.Flags().StringSliceVar(&flags.ignore, "ignore", nil, "Glob patterns to ignore")
+	cmd.Flags().StringSliceVar(&flags.ignore, "ignore", nil, "Gitignore-style patterns to ignore (e.g. '**/drafts/**')")
+	cmd.Flags().StringSliceVar(&flags.ignoreFiles, "ignore-file", nil, "Read ignore patterns from file (in addition to .ebmignore files)")
+	cmd.Flags().BoolVar(&flags.followSymlinks, "follow-symlinks", false, "Descend into symlinked directories (loops and duplicates are skipped)")
+	cmd.Flags().StringVar(&flags.minSize, "min-size", "", "Skip files smaller than this size (e.g. 10KB)")
+	cmd.Flags().StringVar(&flags.maxSize, "max-size", "", "Skip files larger than this size (e.g. 500MB)")
+	cmd.Flags().StringVar(&flags.newerThan, "newer-than", "", "Only files modified after a duration ago (7d), date (2024-05-01) or file's time")
+	cmd.Flags().StringVar(&flags.olderThan, "older-than", "", "Only files modified before a duration ago (30d), date or file's time")
+	cmd.Flags().BoolVar(&flags.changedSinceLast, "changed-since-last-run", false, "Only files modified since the last run with this flag over the same paths")
+	cmd.Flags().StringVar(&flags.filesFrom, "files-from", "", "Read files to process from a list or JSON batch report ('-' for stdin)")
+	cmd.Flags().BoolVarP(&flags.nullSeparated, "null", "0", false, "File list entries are NUL-separated (with --files-from)")
+	cmd.Flags().StringVar(&flags.category, "category", CategoryAll, "Files to take from a JSON report (all, valid, invalid, errored, failed)")
 	cmd.Flags().StringVar(&flags.progress, "progress", "auto", "Progress output mode (auto, simple, none)")
 	cmd.Flags().BoolVar(&flags.summaryOnly, "summary-only", false, "Only print summary output")
 	cmd.Flags().BoolVar(&flags.continueOnError, "continue-on-error", true, "Continue processing on individual file errors")
 	cmd.Flags().BoolVar(&flags.removeSystemErrors, "remove-system-errors", false, "Remove files with system errors after processing")
+	cmd.Flags().StringSliceVar(&flags.removeKinds, "remove-kinds", errorKindNames(operations.DefaultRemovableKinds), "Error kinds removed by --remove-system-errors (see docs/ERROR_CODES.md)")
+	cmd.Flags().StringSliceVar(&flags.routes, "route", nil, "Move files by outcome: OUTCOME=DIR[;keep-path][;name=TEMPLATE][;collision=suffix|skip] (repeatable)")
 	cmd.Flags().BoolVar(&flags.cleanupEmptyDirs, "cleanup-empty-dirs", true, "Clean up empty parent directories after file removal")
+	addCleanupApplyFlags(cmd, flags)
 
 	return cmd
 }
@@ -104,9 +160,11 @@ func newBatchRepairCmd(rootFlags *RootFlags) *cobra.Command {
 	flags := &batchFlags{}
 
 	cmd := &cobra.Command{
-		Use:   "repair <directory>",
+		Use:   "repair [path...]",
 		Short: "Repair multiple EPUB and PDF files",
-		Long: `Repair all EPUB and PDF files in a directory concurrently.
+		Long: `Repair all EPUB and PDF files in the given files, directories and glob
+patterns concurrently. Files reached through more than one path are only
+processed once.
 
 Files are processed using a worker pool for efficient parallel repairs.
 Progress is displayed in real-time showing completed/total files.`,
@@ -120,19 +178,39 @@ Progress is displayed in real-time showing completed/total files.`,
   ebm batch repair ./books --no-backup
 
   # Repair with 4 workers
-  ebm batch repair ./books --jobs 4`,
-		Args: cobra.ExactArgs(1),
+  ebm batch repair ./books --jobs 4
+
+  # Repair the files that failed validation in a previous JSON report
+  ebm batch repair --files-from last-night.json --category failed`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runBatchRepair(cmd.Context(), args[0], flags, rootFlags)
+			if err := checkBatchArgs(args, flags); err != nil {
+				return err
+			}
+			return runBatchRepair(cmd.Context(), args, flags, rootFlags)
 		},
 	}
 
-	cmd.Flags().IntVarP(&flags.jobs, "jobs", "j", runtime.NumCPU(), "Number of concurrent workers")
-	cmd.Flags().IntVar(&flags.timeout, "timeout", 60, "Timeout per file in seconds")
+	flags.jobs = runtime.NumCPU()
+	cmd.Flags().VarP(jobsValue{flags}, "jobs", "j", "Number of concurrent workers, or auto to tune during the run")
+	addRetryFlags(cmd, flags, 60)
+	addScheduleFlags(cmd, flags)
+	cmd.Flags().BoolVar(&flags.isolate, "isolate", false, "Process each file in a child process that is killed on timeout or memory limit")
+	cmd.Flags().StringVar(&flags.isolateMemory, "isolate-memory", "2GB", "Memory limit for each child process with --isolate (0 = unlimited)")
 	cmd.Flags().BoolVarP(&flags.recursive, "recursive", "r", true, "Process subdirectories recursively")
 	cmd.Flags().IntVar(&flags.maxDepth, "max-depth", -1, "Maximum directory depth (-1 = unlimited)")
 	cmd.Flags().StringSliceVar(&flags.extensions, "ext", nil, "File extensions to include (default: .epub, .pdf)")
-	cmd.Flags().StringSliceVar(&flags.ignore, "ignore", nil, "Glob patterns to ignore")
+	cmd.Flags().StringSliceVar(&flags.ignore, "ignore", nil, "Gitignore-style patterns to ignore (e.g. '**/drafts/**')")
+	cmd.Flags().StringSliceVar(&flags.ignoreFiles, "ignore-file", nil, "Read ignore patterns from file (in addition to .ebmignore files)")
+	cmd.Flags().BoolVar(&flags.followSymlinks, "follow-symlinks", false, "Descend into symlinked directories (loops and duplicates are skipped)")
+	cmd.Flags().StringVar(&flags.minSize, "min-size", "", "Skip files smaller than this size (e.g. 10KB)")
+	cmd.Flags().StringVar(&flags.maxSize, "max-size", "", "Skip files larger than this size (e.g. 500MB)")
+	cmd.Flags().StringVar(&flags.newerThan, "newer-than", "", "Only files modified after a duration ago (7d), date (2024-05-01) or file's time")
+	cmd.Flags().StringVar(&flags.olderThan, "older-than", "", "Only files modified before a duration ago (30d), date or file's time")
+	cmd.Flags().BoolVar(&flags.changedSinceLast, "changed-since-last-run", false, "Only files modified since the last run with this flag over the same paths")
+	cmd.Flags().StringVar(&flags.filesFrom, "files-from", "", "Read files to process from a list or JSON batch report ('-' for stdin)")
+	cmd.Flags().BoolVarP(&flags.nullSeparated, "null", "0", false, "File list entries are NUL-separated (with --files-from)")
+	cmd.Flags().StringVar(&flags.category, "category", CategoryAll, "Files to take from a JSON report (all, valid, invalid, errored, failed)")
 	cmd.Flags().StringVar(&flags.progress, "progress", "auto", "Progress output mode (auto, simple, none)")
 	cmd.Flags().BoolVar(&flags.summaryOnly, "summary-only", false, "Only print summary output")
 	cmd.Flags().StringVar(&flags.backupDir, "backup-dir", "", "Directory for backup files")
@@ -141,32 +219,36 @@ Progress is displayed in real-time showing completed/total files.`,
 	cmd.Flags().BoolVar(&flags.continueOnError, "continue-on-error", true, "Continue processing on individual file errors")
 	cmd.Flags().BoolVar(&flags.skipValidation, "skip-validation", false, "Skip post-repair validation")
 	cmd.Flags().BoolVar(&flags.removeSystemErrors, "remove-system-errors", false, "Remove files with system errors after processing")
-	cmd.Flags().BoolVar(&flags.moveFailedRepairs, "move-failed-repairs", false, "Move unrepairable files to INVALID folder")
+	cmd.Flags().StringSliceVar(&flags.removeKinds, "remove-kinds", errorKindNames(operations.DefaultRemovableKinds), "Error kinds removed by --remove-system-errors (see docs/ERROR_CODES.md)")
+	cmd.Flags().BoolVar(&flags.moveFailedRepairs, "move-failed-repairs", false, "Move unrepairable files to INVALID, keeping their folders (same as --route reverted=INVALID;keep-path)")
+	cmd.Flags().StringSliceVar(&flags.routes, "route", nil, "Move files by outcome: OUTCOME=DIR[;keep-path][;name=TEMPLATE][;collision=suffix|skip] (repeatable)")
 	cmd.Flags().BoolVar(&flags.cleanupEmptyDirs, "cleanup-empty-dirs", true, "Clean up empty parent directories and Calibre metadata folders")
+	addCleanupApplyFlags(cmd, flags)
 
 	return cmd
 }
 
-func runBatchValidate(ctx context.Context, dir string, flags *batchFlags, rootFlags *RootFlags) error {
+func runBatchValidate(ctx context.Context, paths []string, flags *batchFlags, rootFlags *RootFlags) error {
 	// Handle default jobs if not set (e.g. called from root command)
 	if flags.jobs <= 0 {
 		flags.jobs = runtime.NumCPU()
 	}
 
-	// Find all matching files
-	findOpts := operations.FindFilesOptions{
-		Recursive:  flags.recursive,
-		MaxDepth:   flags.maxDepth,
-		Extensions: flags.extensions,
-		Ignore:     flags.ignore,
+	removeKinds, err := parseErrorKinds(flags.removeKinds)
+	if err != nil {
+		return fmt.Errorf("--remove-kinds: %w", err)
 	}
-	files, err := operations.FindFiles(dir, findOpts)
+	rules, err := routeRules(flags)
 	if err != nil {
-		return fmt.Errorf("failed to find files: %w", err)
+		return err
 	}
 
-	if len(files) == 0 {
-		return fmt.Errorf("no matching files found in %s", dir)
+	runStart := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := startBatchInputs(ctx, paths, flags, operations.OperationValidate)
+	if err != nil {
+		return err
 	}
 
 	// Create batch processor
@@ -176,6 +258,16 @@ func runBatchValidate(ctx context.Context, dir string, flags *batchFlags, rootFl
 		ProgressRate: 100 * time.Millisecond,
 		Timeout:      time.Duration(flags.timeout) * time.Second,
 	}
+	applyJobsFlag(&config, flags)
+	applyRetryFlags(&config, flags)
+	if err := applyScheduleFlags(&config, flags); err != nil {
+		return err
+	}
+	if flags.isolate {
+		if config.Isolate, err = isolateConfig(operations.OperationValidate, flags); err != nil {
+			return err
+		}
+	}
 	processor := operations.NewBatchProcessor(ctx, config)
 
 	// Set up progress reporting
@@ -200,7 +292,7 @@ func runBatchValidate(ctx context.Context, dir string, flags *batchFlags, rootFl
 			}()
 		} else {
 			// Progress bar (auto with terminal or explicit auto/bar)
-			bar = progressbar.NewOptions(len(files),
+			bar = progressbar.NewOptions(-1,
 				progressbar.OptionSetDescription("Validating"),
 				progressbar.OptionSetWriter(os.Stderr),
 				progressbar.OptionShowCount(),
@@ -217,6 +309,8 @@ func runBatchValidate(ctx context.Context, dir string, flags *batchFlags, rootFl
 					case <-done:
 						return
 					case update := <-processor.ProgressChannel():
+						// The total grows while directories are searched
+						bar.ChangeMax(update.Total)
 						_ = bar.Set(update.Completed)
 					}
 				}
@@ -226,38 +320,45 @@ func runBatchValidate(ctx context.Context, dir string, flags *batchFlags, rootFl
 
 	// Execute batch validation
 	start := time.Now()
-	results := processor.Execute(files, operations.OperationValidate)
+	results := processor.ExecuteStream(stream.inputs, operations.OperationValidate)
 	duration := time.Since(start)
+	stopped := ctx.Err() != nil
 	close(done)
 	if bar != nil {
 		_ = bar.Finish()
 	}
+	cancel()
+	symlinks, scanErr := stream.wait()
+	if scanErr != nil && len(results) == 0 {
+		return scanErr
+	}
 
-	// Aggregate results
+	// Aggregate results; a scan error still reports the files found before it
 	batchResult := operations.AggregateResults(results, duration, operations.OperationValidate)
+	batchResult.Symlinks = symlinks
+	batchResult.ScanError = scanErr
+	if stopped {
+		// Files still undiscovered when the run was interrupted are not counted
+		batchResult.Incomplete = true
+		batchResult.Unprocessed = processor.Received() - len(results)
+	}
 
 	// Record the options used for this batch
 	batchResult.Options = operations.BatchOptions{
-		NumWorkers:         flags.jobs,
+		Profile:            rootFlags.Profile,
+		NumWorkers:         processor.Workers(),
+		AutoJobs:           flags.autoJobs,
 		SkipValidation:     false, // N/A for validation
 		NoBackup:           false, // N/A for validation
 		Aggressive:         false, // N/A for validation
 		RemoveSystemErrors: flags.removeSystemErrors,
 		MoveFailedRepairs:  false, // N/A for validation
 		CleanupEmptyDirs:   flags.cleanupEmptyDirs,
+		Routes:             rules.Strings(),
 	}
 
 	// Perform post-processing cleanup if requested
-	if flags.removeSystemErrors && len(batchResult.Errored) > 0 {
-		batchResult.RemovedFiles = make([]string, 0, len(batchResult.Errored))
-		for _, r := range batchResult.Errored {
-			batchResult.RemovedFiles = append(batchResult.RemovedFiles, r.FilePath)
-			_ = os.Remove(r.FilePath)
-			if flags.cleanupEmptyDirs {
-				removeEmptyParentDirs(filepath.Dir(r.FilePath), dir)
-			}
-		}
-	}
+	runCleanup(os.Stderr, &batchResult, cleanupOptions(removeKinds, rules, flags), flags)
 
 	// Create report options
 	opts, err := NewReportOptions(rootFlags)
@@ -271,15 +372,22 @@ func runBatchValidate(ctx context.Context, dir string, flags *batchFlags, rootFl
 		return fmt.Errorf("failed to write report: %w", err)
 	}
 
+	// Remember this run for the next --changed-since-last-run
+	if flags.changedSinceLast {
+		if err := markRun(operations.OperationValidate, paths, runStart, stopped || scanErr != nil, &batchResult); err != nil {
+			return err
+		}
+	}
+
 	// Exit with non-zero if any files failed
-	if len(batchResult.Invalid) > 0 || len(batchResult.Errored) > 0 {
-		osExit(1)
+	if code := batchExitCode(&batchResult); code != 0 {
+		osExit(code)
 	}
 
 	return nil
 }
 
-func runBatchRepair(ctx context.Context, dir string, flags *batchFlags, rootFlags *RootFlags) error {
+func runBatchRepair(ctx context.Context, paths []string, flags *batchFlags, rootFlags *RootFlags) error {
 	// Handle default jobs if not set
 	if flags.jobs <= 0 {
 		flags.jobs = runtime.NumCPU()
@@ -297,20 +405,21 @@ func runBatchRepair(ctx context.Context, dir string, flags *batchFlags, rootFlag
 		mode = operations.RepairSaveModeNoBackup
 	}
 
-	// Find all matching files
-	findOpts := operations.FindFilesOptions{
-		Recursive:  flags.recursive,
-		MaxDepth:   flags.maxDepth,
-		Extensions: flags.extensions,
-		Ignore:     flags.ignore,
+	removeKinds, err := parseErrorKinds(flags.removeKinds)
+	if err != nil {
+		return fmt.Errorf("--remove-kinds: %w", err)
 	}
-	files, err := operations.FindFiles(dir, findOpts)
+	rules, err := routeRules(flags)
 	if err != nil {
-		return fmt.Errorf("failed to find files: %w", err)
+		return err
 	}
 
-	if len(files) == 0 {
-		return fmt.Errorf("no matching files found in %s", dir)
+	runStart := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := startBatchInputs(ctx, paths, flags, operations.OperationRepair)
+	if err != nil {
+		return err
 	}
 
 	// Create backup directory if needed
@@ -330,6 +439,16 @@ func runBatchRepair(ctx context.Context, dir string, flags *batchFlags, rootFlag
 		BackupDir:    flags.backupDir,
 		Aggressive:   flags.aggressive,
 	}
+	applyJobsFlag(&config, flags)
+	applyRetryFlags(&config, flags)
+	if err := applyScheduleFlags(&config, flags); err != nil {
+		return err
+	}
+	if flags.isolate {
+		if config.Isolate, err = isolateConfig(operations.OperationRepair, flags); err != nil {
+			return err
+		}
+	}
 	processor := operations.NewBatchProcessor(ctx, config)
 
 	// Set up progress reporting
@@ -354,7 +473,7 @@ func runBatchRepair(ctx context.Context, dir string, flags *batchFlags, rootFlag
 			}()
 		} else {
 			// Progress bar
-			bar = progressbar.NewOptions(len(files),
+			bar = progressbar.NewOptions(-1,
 				progressbar.OptionSetDescription("Repairing"),
 				progressbar.OptionSetWriter(os.Stderr),
 				progressbar.OptionShowCount(),
@@ -371,6 +490,8 @@ func runBatchRepair(ctx context.Context, dir string, flags *batchFlags, rootFlag
 					case <-done:
 						return
 					case update := <-processor.ProgressChannel():
+						// The total grows while directories are searched
+						bar.ChangeMax(update.Total)
 						_ = bar.Set(update.Completed)
 					}
 				}
@@ -380,51 +501,45 @@ func runBatchRepair(ctx context.Context, dir string, flags *batchFlags, rootFlag
 
 	// Execute batch repair
 	start := time.Now()
-	results := processor.Execute(files, operations.OperationRepair)
+	results := processor.ExecuteStream(stream.inputs, operations.OperationRepair)
 	duration := time.Since(start)
+	stopped := ctx.Err() != nil
 	close(done)
 	if bar != nil {
 		_ = bar.Finish()
 	}
+	cancel()
+	symlinks, scanErr := stream.wait()
+	if scanErr != nil && len(results) == 0 {
+		return scanErr
+	}
 
-	// Aggregate results
+	// Aggregate results; a scan error still reports the files found before it
 	batchResult := operations.AggregateResults(results, duration, operations.OperationRepair)
+	batchResult.Symlinks = symlinks
+	batchResult.ScanError = scanErr
+	if stopped {
+		// Files still undiscovered when the run was interrupted are not counted
+		batchResult.Incomplete = true
+		batchResult.Unprocessed = processor.Received() - len(results)
+	}
 
 	// Record the options used for this batch
 	batchResult.Options = operations.BatchOptions{
-		NumWorkers:         flags.jobs,
+		Profile:            rootFlags.Profile,
+		NumWorkers:         processor.Workers(),
+		AutoJobs:           flags.autoJobs,
 		SkipValidation:     flags.skipValidation,
 		NoBackup:           flags.noBackup,
 		Aggressive:         flags.aggressive,
 		RemoveSystemErrors: flags.removeSystemErrors,
 		MoveFailedRepairs:  flags.moveFailedRepairs,
 		CleanupEmptyDirs:   flags.cleanupEmptyDirs,
+		Routes:             rules.Strings(),
 	}
 
 	// Perform post-processing cleanup if requested
-	if flags.removeSystemErrors && len(batchResult.Errored) > 0 {
-		batchResult.RemovedFiles = make([]string, 0, len(batchResult.Errored))
-		for _, r := range batchResult.Errored {
-			batchResult.RemovedFiles = append(batchResult.RemovedFiles, r.FilePath)
-			_ = os.Remove(r.FilePath)
-			if flags.cleanupEmptyDirs {
-				removeEmptyParentDirs(filepath.Dir(r.FilePath), dir)
-			}
-		}
-	}
-	if flags.moveFailedRepairs && len(batchResult.Invalid) > 0 {
-		invalidDir := filepath.Join(dir, "INVALID")
-		_ = os.MkdirAll(invalidDir, 0755)
-		batchResult.MovedFiles = make([]string, 0, len(batchResult.Invalid))
-		for _, r := range batchResult.Invalid {
-			batchResult.MovedFiles = append(batchResult.MovedFiles, r.FilePath)
-			dstPath := filepath.Join(invalidDir, filepath.Base(r.FilePath))
-			_ = os.Rename(r.FilePath, dstPath)
-			if flags.cleanupEmptyDirs {
-				removeEmptyParentDirs(filepath.Dir(r.FilePath), dir)
-			}
-		}
-	}
+	runCleanup(os.Stderr, &batchResult, cleanupOptions(removeKinds, rules, flags), flags)
 
 	// Create report options
 	opts, err := NewReportOptions(rootFlags)
@@ -438,107 +553,351 @@ func runBatchRepair(ctx context.Context, dir string, flags *batchFlags, rootFlag
 		return fmt.Errorf("failed to write report: %w", err)
 	}
 
+	// Remember this run for the next --changed-since-last-run
+	if flags.changedSinceLast {
+		if err := markRun(operations.OperationRepair, paths, runStart, stopped || scanErr != nil, &batchResult); err != nil {
+			return err
+		}
+	}
+
 	// Exit with non-zero if any files failed
-	if len(batchResult.Invalid) > 0 || len(batchResult.Errored) > 0 {
-		osExit(1)
+	if code := batchExitCode(&batchResult); code != 0 {
+		osExit(code)
 	}
 
 	return nil
 }
 
-// isTerminal returns true if stderr is a terminal
-func isTerminal() bool {
-	fileInfo, _ := os.Stderr.Stat()
-	return (fileInfo.Mode() & os.ModeCharDevice) != 0
+// defaultBatchFlags returns the batch flag defaults for commands that fall
+// back to a batch run without registering the batch flags
+func defaultBatchFlags() *batchFlags {
+	defaults := operations.DefaultBatchConfig()
+	return &batchFlags{
+		jobs:            runtime.NumCPU(),
+		timeout:         int(defaults.Timeout / time.Second),
+		timeoutPerMB:    defaults.TimeoutPerMB.Seconds(),
+		maxTimeout:      int(defaults.MaxTimeout / time.Second),
+		retries:         defaults.Retries,
+		finalPassFactor: defaults.FinalPassFactor,
+		order:           string(operations.OrderWalk),
+		recursive:       true,
+		maxDepth:        -1,
+		progress:        "auto",
+		continueOnError: true,
+	}
 }
 
-// isCalibreMetadataDirectory checks if a directory contains only Calibre metadata files
-// (no ebooks, only cover.jpg, metadata.opf, .DS_Store, etc.)
-func isCalibreMetadataDirectory(dirPath string) bool {
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		return false
+// jobsValue is the --jobs flag: a worker count or "auto"
+type jobsValue struct {
+	flags *batchFlags
+}
+
+func (v jobsValue) String() string {
+	if v.flags.autoJobs {
+		return jobsAuto
 	}
+	return strconv.Itoa(v.flags.jobs)
+}
 
-	// Empty directory
-	if len(entries) == 0 {
-		return true
+func (v jobsValue) Set(s string) error {
+	if s == jobsAuto {
+		v.flags.autoJobs = true
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return fmt.Errorf("must be a positive number or %q", jobsAuto)
 	}
+	v.flags.jobs = n
+	v.flags.autoJobs = false
+	return nil
+}
 
-	// Check for ebook files - if any exist, not metadata-only
-	hasEbook := false
-	hasMetadata := false
+func (v jobsValue) Type() string {
+	return "int|auto"
+}
 
-	for _, entry := range entries {
-		name := strings.ToLower(entry.Name())
+// jobsAuto is the --jobs value that tunes the worker count during the run
+const jobsAuto = "auto"
 
-		// Skip hidden files and common system files
-		if strings.HasPrefix(name, ".") {
-			continue
-		}
+// applyJobsFlag sets up auto-tuning for --jobs auto
+func applyJobsFlag(config *operations.BatchConfig, flags *batchFlags) {
+	if flags.autoJobs {
+		config.NumWorkers = operations.AutoTuneMaxWorkers()
+		config.AutoTune = true
+	}
+}
+
+// addRetryFlags registers the timeout and retry flags with the given base
+// timeout in seconds
+func addRetryFlags(cmd *cobra.Command, flags *batchFlags, timeout int) {
+	defaults := operations.DefaultBatchConfig()
+	cmd.Flags().IntVar(&flags.timeout, "timeout", timeout, "Base timeout per file in seconds")
+	cmd.Flags().Float64Var(&flags.timeoutPerMB, "timeout-per-mb", defaults.TimeoutPerMB.Seconds(), "Seconds added to the timeout for each MB of file size")
+	cmd.Flags().IntVar(&flags.maxTimeout, "max-timeout", int(defaults.MaxTimeout/time.Second), "Cap on the size-scaled timeout in seconds (0 = no cap)")
+	cmd.Flags().IntVar(&flags.retries, "retries", defaults.Retries, "Retries for files failing with a transient I/O error")
+	cmd.Flags().Float64Var(&flags.finalPassFactor, "final-pass-factor", defaults.FinalPassFactor, "Retry timed-out files after the batch with their timeout multiplied by this (0 = no final pass)")
+}
+
+// addScheduleFlags registers the scheduling flags
+func addScheduleFlags(cmd *cobra.Command, flags *batchFlags) {
+	cmd.Flags().StringVar(&flags.order, "order", string(operations.OrderWalk), "Order files are processed in (walk, largest, smallest, directory)")
+	cmd.Flags().StringVar(&flags.memoryBudget, "memory-budget", "", "Estimated memory for files processed at once, e.g. 4GB (default: unlimited)")
+}
 
-		if entry.IsDir() {
-			// Nested directory means not a simple Calibre metadata folder
-			return false
+// applyScheduleFlags sets the order and memory budget of a batch config
+func applyScheduleFlags(config *operations.BatchConfig, flags *batchFlags) error {
+	if flags.order != "" {
+		order, ok := operations.ParseScheduleOrder(flags.order)
+		if !ok {
+			return fmt.Errorf("--order: unknown order %q (valid: %s)", flags.order, strings.Join(scheduleOrderNames(), ", "))
 		}
+		config.Order = order
+	}
+	if flags.memoryBudget != "" {
+		budget, err := parseSize(flags.memoryBudget)
+		if err != nil {
+			return fmt.Errorf("--memory-budget: %w", err)
+		}
+		config.MemoryBudget = budget
+	}
+	return nil
+}
 
-		ext := filepath.Ext(name)
-		if ext == ".epub" || ext == ".pdf" {
-			hasEbook = true
-			break
+// scheduleOrderNames returns the names of the schedule orders
+func scheduleOrderNames() []string {
+	orders := operations.ScheduleOrders()
+	names := make([]string, len(orders))
+	for i, order := range orders {
+		names[i] = string(order)
+	}
+	return names
+}
+
+// applyRetryFlags sets the retry policy of a batch config from the flags
+func applyRetryFlags(config *operations.BatchConfig, flags *batchFlags) {
+	config.TimeoutPerMB = time.Duration(flags.timeoutPerMB * float64(time.Second))
+	config.MaxTimeout = time.Duration(flags.maxTimeout) * time.Second
+	config.Retries = flags.retries
+	config.RetryBackoff = operations.DefaultBatchConfig().RetryBackoff
+	config.FinalPassFactor = flags.finalPassFactor
+}
+
+// checkBatchArgs requires paths or --files-from, but not both
+func checkBatchArgs(args []string, flags *batchFlags) error {
+	switch {
+	case len(args) > 0 && flags.filesFrom != "":
+		return fmt.Errorf("paths cannot be combined with --files-from")
+	case len(args) == 0 && flags.filesFrom == "":
+		return fmt.Errorf("requires at least one path or --files-from")
+	}
+	return nil
+}
+
+// batchInputStream delivers the files of a batch run while directories are
+// still being searched
+type batchInputStream struct {
+	inputs   chan operations.Input
+	done     chan struct{}
+	symlinks []operations.SymlinkEvent
+	err      error
+}
+
+// wait blocks until discovery has finished and returns the symbolic links
+// met while searching directories
+func (s *batchInputStream) wait() ([]operations.SymlinkEvent, error) {
+	<-s.done
+	return s.symlinks, s.err
+}
+
+// startBatchInputs starts finding the files to process. Files come from
+// --files-from when set, otherwise from the given files, directories and glob
+// patterns. Arguments are checked before returning; errors met while
+// searching directories are returned by wait. Discovery stops without an
+// error if ctx is cancelled.
+func startBatchInputs(ctx context.Context, paths []string, flags *batchFlags, operation operations.OperationType) (*batchInputStream, error) {
+	if flags.filesFrom != "" {
+		if flags.changedSinceLast {
+			return nil, fmt.Errorf("--changed-since-last-run cannot be combined with --files-from")
+		}
+		files, err := ReadFilesFrom(flags.filesFrom, flags.nullSeparated, flags.category)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no files listed in %s", flags.filesFrom)
 		}
 
-		// Check for Calibre metadata files
-		if name == "cover.jpg" || name == "cover.jpeg" || name == "cover.png" ||
-			name == "metadata.opf" {
-			hasMetadata = true
+		// Listed files share the deepest common directory as their root
+		root := commonDir(files)
+		s := &batchInputStream{inputs: make(chan operations.Input, len(files)), done: make(chan struct{})}
+		for _, f := range files {
+			s.inputs <- operations.Input{Path: f, Root: filesFromRoot(root, f)}
 		}
+		close(s.inputs)
+		close(s.done)
+		return s, nil
 	}
 
-	// If no ebooks and has metadata files, or just empty/system files, it's metadata-only
-	return !hasEbook && (hasMetadata || len(entries) == 0)
+	findOpts, err := discoveryOptions(paths, flags, operation, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	expanded, err := operations.ExpandArgs(paths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find files: %w", err)
+	}
+
+	s := &batchInputStream{inputs: make(chan operations.Input), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer close(s.inputs)
+
+		found := 0
+		s.symlinks, s.err = operations.StreamInputs(expanded, findOpts, func(in operations.Input) error {
+			select {
+			case s.inputs <- in:
+				found++
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		switch {
+		case ctx.Err() != nil:
+			// Processing was stopped; report what was done
+			s.err = nil
+		case s.err != nil:
+			s.err = fmt.Errorf("failed to find files: %w", s.err)
+		case found == 0:
+			s.err = fmt.Errorf("no matching files found in %s", strings.Join(paths, ", "))
+		}
+	}()
+	return s, nil
 }
 
-// removeEmptyParentDirs removes empty parent directories and Calibre metadata-only directories up to the batch root
-// Bails out if a parent has more than 3 siblings to avoid scanning large shallow hierarchies
-func removeEmptyParentDirs(dir string, rootPath string) {
-	// Ensure both paths are absolute and clean
-	rootPath = filepath.Clean(rootPath)
-	dir = filepath.Clean(dir)
-
-	// Walk up the directory tree
-	for dir != rootPath && strings.HasPrefix(dir, rootPath) {
-		// Check sibling count before attempting removal
-		parent := filepath.Dir(dir)
-		if parent != rootPath { // Don't bail at root level
-			entries, err := os.ReadDir(parent)
-			if err == nil && len(entries) > 3 {
-				// Too many siblings, skip this entire branch to avoid performance hit
-				break
-			}
+// discoveryOptions builds the FindFilesOptions for a batch run, parsing the
+// size and time filters relative to now
+func discoveryOptions(paths []string, flags *batchFlags, operation operations.OperationType, now time.Time) (operations.FindFilesOptions, error) {
+	opts := operations.FindFilesOptions{
+		Recursive:      flags.recursive,
+		MaxDepth:       flags.maxDepth,
+		Extensions:     flags.extensions,
+		Ignore:         flags.ignore,
+		IgnoreFiles:    flags.ignoreFiles,
+		FollowSymlinks: flags.followSymlinks,
+	}
+
+	var err error
+	if flags.minSize != "" {
+		if opts.MinSize, err = parseSize(flags.minSize); err != nil {
+			return opts, fmt.Errorf("--min-size: %w", err)
+		}
+	}
+	if flags.maxSize != "" {
+		if opts.MaxSize, err = parseSize(flags.maxSize); err != nil {
+			return opts, fmt.Errorf("--max-size: %w", err)
 		}
+	}
+	if flags.newerThan != "" {
+		if opts.NewerThan, err = parseTimeBound(flags.newerThan, now); err != nil {
+			return opts, fmt.Errorf("--newer-than: %w", err)
+		}
+	}
+	if flags.olderThan != "" {
+		if opts.OlderThan, err = parseTimeBound(flags.olderThan, now); err != nil {
+			return opts, fmt.Errorf("--older-than: %w", err)
+		}
+	}
 
-		// First try to remove if it's a Calibre metadata-only directory
-		if isCalibreMetadataDirectory(dir) {
-			// Remove all files in the directory first
-			entries, err := os.ReadDir(dir)
-			if err == nil {
-				for _, entry := range entries {
-					if !entry.IsDir() {
-						_ = os.Remove(filepath.Join(dir, entry.Name()))
-					}
-				}
+	if flags.changedSinceLast {
+		last, err := loadRunMarker(operation, paths)
+		if err != nil {
+			return opts, err
+		}
+		if last.Start.After(opts.NewerThan) {
+			opts.NewerThan = last.Start
+		}
+		if len(last.Retry) > 0 {
+			opts.Retry = make(map[string]bool, len(last.Retry))
+			for _, path := range last.Retry {
+				opts.Retry[path] = true
 			}
 		}
+	}
 
-		// Try to remove the directory if empty
-		err := os.Remove(dir)
-		if err != nil {
-			// Directory not empty or other error, stop
-			break
+	return opts, nil
+}
+
+// Exit codes for batch runs
+const (
+	exitFailed      = 1   // Some files were invalid or could not be processed, or cleanup or discovery failed
+	exitTimeout     = 124 // Some files timed out, as timeout(1) exits
+	exitInterrupted = 130 // The run was interrupted, as shells report SIGINT
+)
+
+// batchExitCode returns the exit status for a finished batch. An interrupted
+// run exits as interrupted; otherwise timeouts take precedence so that
+// schedulers can retry with a longer timeout.
+func batchExitCode(result *operations.BatchResult) int {
+	if result.Incomplete {
+		return exitInterrupted
+	}
+	for _, r := range result.Errored {
+		if r.ErrorKind == operations.ErrorKindTimeout {
+			return exitTimeout
 		}
+	}
+	if len(result.Invalid) > 0 || len(result.Errored) > 0 || len(result.Cleanup.Failed()) > 0 || result.ScanError != nil {
+		return exitFailed
+	}
+	return 0
+}
+
+// parseErrorKinds parses error kind names
+func parseErrorKinds(names []string) ([]operations.ErrorKind, error) {
+	kinds := make([]operations.ErrorKind, 0, len(names))
+	for _, name := range names {
+		kind, ok := operations.ParseErrorKind(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown error kind %q (valid: %s)", name, strings.Join(errorKindNames(operations.ErrorKinds()), ", "))
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+func errorKindNames(kinds []operations.ErrorKind) []string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = string(kind)
+	}
+	return names
+}
 
-		// Move to parent
-		dir = parent
+// routeRules parses --route, adding the rule of --move-failed-repairs
+func routeRules(flags *batchFlags) (operations.RouteRules, error) {
+	rules, err := operations.ParseRouteRules(flags.routes)
+	if err != nil {
+		return nil, fmt.Errorf("--route: %w", err)
+	}
+	if flags.moveFailedRepairs {
+		rules = rules.WithMoveFailedRepairs()
 	}
+	return rules, nil
+}
+
+// cleanupRoot returns the directory above which cleanup must not remove
+// directories for a result
+func cleanupRoot(r operations.Result) string {
+	if r.Root == "" || filepath.Clean(r.Root) == filepath.Clean(r.FilePath) {
+		return filepath.Dir(r.FilePath)
+	}
+	return r.Root
+}
+
+// isTerminal returns true if stderr is a terminal
+func isTerminal() bool {
+	fileInfo, _ := os.Stderr.Stat()
+	return (fileInfo.Mode() & os.ModeCharDevice) != 0
 }
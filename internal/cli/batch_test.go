@@ -2,9 +2,13 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
 )
 
 func TestRunBatchValidate_WithFile(t *testing.T) {
@@ -27,7 +31,7 @@ func TestRunBatchValidate_WithFile(t *testing.T) {
 	}
 	rootFlags := &RootFlags{Color: false, Format: "text"}
 
-	_ = runBatchValidate(ctx, tmpDir, flags, rootFlags)
+	_ = runBatchValidate(ctx, []string{tmpDir}, flags, rootFlags)
 }
 
 func TestRunBatchRepair_WithFile(t *testing.T) {
@@ -50,7 +54,7 @@ func TestRunBatchRepair_WithFile(t *testing.T) {
 	}
 	rootFlags := &RootFlags{Color: false, Format: "text"}
 
-	_ = runBatchRepair(ctx, tmpDir, flags, rootFlags)
+	_ = runBatchRepair(ctx, []string{tmpDir}, flags, rootFlags)
 }
 
 func TestRunBatchValidate_ProgressModes(t *testing.T) {
@@ -73,7 +77,7 @@ func TestRunBatchValidate_ProgressModes(t *testing.T) {
 				maxDepth: -1,
 			}
 			rootFlags := &RootFlags{Color: false}
-			_ = runBatchValidate(context.Background(), tmpDir, flags, rootFlags)
+			_ = runBatchValidate(context.Background(), []string{tmpDir}, flags, rootFlags)
 		})
 	}
 }
@@ -94,7 +98,7 @@ func TestRunBatchRepair_WithBackup(t *testing.T) {
 	}
 	rootFlags := &RootFlags{Color: false, Format: "text"}
 
-	_ = runBatchRepair(ctx, tmpDir, flags, rootFlags)
+	_ = runBatchRepair(ctx, []string{tmpDir}, flags, rootFlags)
 }
 
 func TestIsTerminal(t *testing.T) {
@@ -113,7 +117,7 @@ func TestRunBatchRepair_DefaultJobs(t *testing.T) {
 		maxDepth: -1,
 	}
 	rootFlags := &RootFlags{Color: false}
-	_ = runBatchRepair(ctx, tmpDir, flags, rootFlags)
+	_ = runBatchRepair(ctx, []string{tmpDir}, flags, rootFlags)
 }
 
 func TestRunBatchValidate_SummaryOnly(t *testing.T) {
@@ -135,7 +139,7 @@ func TestRunBatchValidate_SummaryOnly(t *testing.T) {
 	}
 	rootFlags := &RootFlags{Color: false, Format: "text"}
 
-	_ = runBatchValidate(ctx, tmpDir, flags, rootFlags)
+	_ = runBatchValidate(ctx, []string{tmpDir}, flags, rootFlags)
 }
 
 func TestRunBatchValidate_WithIgnore(t *testing.T) {
@@ -157,7 +161,7 @@ func TestRunBatchValidate_WithIgnore(t *testing.T) {
 	}
 	rootFlags := &RootFlags{}
 
-	err := runBatchValidate(ctx, tmpDir, flags, rootFlags)
+	err := runBatchValidate(ctx, []string{tmpDir}, flags, rootFlags)
 	if err == nil {
 		t.Error("Expected error because all files were ignored")
 	}
@@ -173,7 +177,7 @@ func TestRunBatchRepair_InvalidOpts(t *testing.T) {
 	}
 	rootFlags := &RootFlags{}
 
-	err := runBatchRepair(ctx, tmpDir, flags, rootFlags)
+	err := runBatchRepair(ctx, []string{tmpDir}, flags, rootFlags)
 	if err == nil {
 		t.Error("Expected error for invalid backup dir")
 	}
@@ -194,7 +198,7 @@ func TestRunBatchValidate_InvalidReport(t *testing.T) {
 	flags := &batchFlags{jobs: 1, maxDepth: -1}
 	rootFlags := &RootFlags{Output: "/invalid/path"}
 
-	err := runBatchValidate(ctx, tmpDir, flags, rootFlags)
+	err := runBatchValidate(ctx, []string{tmpDir}, flags, rootFlags)
 	if err == nil {
 		t.Error("Expected error for invalid output path")
 	}
@@ -210,7 +214,7 @@ func TestBatchRepair_NoFiles(t *testing.T) {
 	}
 	rootFlags := &RootFlags{Color: false}
 
-	err := runBatchRepair(ctx, tmpDir, flags, rootFlags)
+	err := runBatchRepair(ctx, []string{tmpDir}, flags, rootFlags)
 	if err == nil {
 		t.Error("expected error when no files found in directory")
 	}
@@ -227,7 +231,7 @@ func TestRunBatchRepair_InvalidBackupDir(t *testing.T) {
 		maxDepth:  -1,
 	}
 	rootFlags := &RootFlags{}
-	err := runBatchRepair(ctx, tmpDir, flags, rootFlags)
+	err := runBatchRepair(ctx, []string{tmpDir}, flags, rootFlags)
 	if err == nil {
 		t.Error("Expected error for invalid backup dir")
 	}
@@ -237,7 +241,7 @@ func TestRunBatchRepair_InvalidDir(t *testing.T) {
 	ctx := context.Background()
 	flags := &batchFlags{jobs: 1}
 	rootFlags := &RootFlags{}
-	err := runBatchRepair(ctx, "/non/existent/dir/12345", flags, rootFlags)
+	err := runBatchRepair(ctx, []string{"/non/existent/dir/12345"}, flags, rootFlags)
 	if err == nil {
 		t.Error("Expected error for non-existent dir")
 	}
@@ -251,5 +255,260 @@ func TestRunBatchValidate_DefaultJobs(t *testing.T) {
 	ctx := context.Background()
 	flags := &batchFlags{jobs: 0, maxDepth: -1}
 	rootFlags := &RootFlags{Color: false}
-	_ = runBatchValidate(ctx, tmpDir, flags, rootFlags)
+	_ = runBatchValidate(ctx, []string{tmpDir}, flags, rootFlags)
+}
+
+func TestRunBatchValidate_FollowSymlinks(t *testing.T) {
+	lib := t.TempDir()
+	disk := t.TempDir()
+	if err := os.WriteFile(filepath.Join(disk, "a.epub"), []byte("t"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(disk, filepath.Join(lib, "author")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	flags := &batchFlags{recursive: true, jobs: 1, progress: "none", maxDepth: -1}
+	rootFlags := &RootFlags{Format: "text"}
+
+	// Without --follow-symlinks the only file is behind the link
+	if err := runBatchValidate(context.Background(), []string{lib}, flags, rootFlags); err == nil {
+		t.Error("Expected no files without --follow-symlinks")
+	}
+
+	flags.followSymlinks = true
+	output := filepath.Join(t.TempDir(), "report.json")
+	rootFlags = &RootFlags{Format: "json", Output: output}
+	if err := runBatchValidate(context.Background(), []string{lib}, flags, rootFlags); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ParseSavedReport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Batch.Total != 1 || len(saved.Batch.Symlinks) != 1 || !saved.Batch.Symlinks[0].Followed {
+		t.Errorf("Expected one file through a followed link, got %+v", saved.Batch)
+	}
+}
+
+func TestRunBatchRepair_Routes(t *testing.T) {
+	lib := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(lib, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(lib, dir, "book.epub"), []byte("not a zip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldExit := osExit
+	osExit = func(int) {}
+	defer func() { osExit = oldExit }()
+
+	// Same-named books keep their folders, whatever becomes of them
+	flags := &batchFlags{recursive: true, jobs: 1, progress: "none", maxDepth: -1, noBackup: true, cleanupEmptyDirs: true, yes: true, trashDir: t.TempDir(),
+		routes: []string{"valid=sorted;keep-path", "reverted=sorted;keep-path", "errored=sorted;keep-path"}}
+	output := filepath.Join(t.TempDir(), "report.json")
+	if err := runBatchRepair(context.Background(), []string{lib}, flags, &RootFlags{Format: "json", Output: output}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ParseSavedReport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Batch.Routed) != 2 || len(saved.Batch.Options.Routes) != 3 {
+		t.Fatalf("Expected both books to be routed, got %+v", saved.Batch.Routed)
+	}
+	for _, routed := range saved.Batch.Routed {
+		if !routed.Moved() {
+			t.Errorf("Expected %s to be moved, got %+v", routed.Path, routed)
+			continue
+		}
+		if _, err := os.Stat(routed.Dest); err != nil {
+			t.Errorf("Expected %s to exist: %v", routed.Dest, err)
+		}
+		rel, _ := filepath.Rel(lib, routed.Dest)
+		if filepath.Dir(filepath.Dir(rel)) != "sorted" {
+			t.Errorf("Expected %s to keep its folder under sorted", rel)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(lib, "a")); !os.IsNotExist(err) {
+		t.Errorf("Expected the emptied folder to be removed, got %v", err)
+	}
+
+	flags.routes = []string{"broken=somewhere"}
+	if err := runBatchRepair(context.Background(), []string{lib}, flags, &RootFlags{Format: "json"}); err == nil {
+		t.Error("Expected an error for an unknown outcome")
+	}
+}
+
+// collectBatchInputs drains a batch input stream
+func collectBatchInputs(t *testing.T, s *batchInputStream) ([]operations.Input, error) {
+	t.Helper()
+	var inputs []operations.Input
+	for in := range s.inputs {
+		inputs = append(inputs, in)
+	}
+	_, err := s.wait()
+	return inputs, err
+}
+
+func TestStartBatchInputs_Stream(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"b/2.epub", "a/1.epub", "c.pdf"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("t"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flags := &batchFlags{recursive: true, maxDepth: -1}
+
+	stream, err := startBatchInputs(context.Background(), []string{root}, flags, operations.OperationValidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, err := collectBatchInputs(t, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a/1.epub", "b/2.epub", "c.pdf"}
+	if len(inputs) != len(want) {
+		t.Fatalf("startBatchInputs() = %+v", inputs)
+	}
+	for i, in := range inputs {
+		if in.Path != filepath.Join(root, want[i]) || in.Root != root {
+			t.Errorf("input %d = %+v, want %s", i, in, want[i])
+		}
+	}
+
+	// Bad arguments fail before discovery starts
+	if _, err := startBatchInputs(context.Background(), []string{filepath.Join(root, "missing")}, flags, operations.OperationValidate); err == nil {
+		t.Error("Expected error for a missing path")
+	}
+
+	// An empty tree is reported once discovery finishes
+	stream, err = startBatchInputs(context.Background(), []string{t.TempDir()}, flags, operations.OperationValidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collectBatchInputs(t, stream); err == nil {
+		t.Error("Expected error for a tree with no matching files")
+	}
+}
+
+func TestRunBatchValidate_Interrupted(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.epub", "b.epub"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report := filepath.Join(t.TempDir(), "report.txt")
+
+	code := 0
+	oldExit := osExit
+	osExit = func(c int) { code = c }
+	defer func() { osExit = oldExit }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	flags := &batchFlags{recursive: true, jobs: 1, progress: "none", maxDepth: -1, timeout: 30}
+	if err := runBatchValidate(ctx, []string{tmpDir}, flags, &RootFlags{Format: "text", Output: report}); err != nil {
+		t.Fatal(err)
+	}
+	if code != exitInterrupted {
+		t.Errorf("exit code = %d, want %d", code, exitInterrupted)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Interrupted:") {
+		t.Errorf("Report does not say the run was interrupted:\n%s", data)
+	}
+}
+
+func TestBatchExitCode(t *testing.T) {
+	timeout := operations.Result{FilePath: "a.pdf", Error: errors.New("slow"), ErrorKind: operations.ErrorKindTimeout}
+	denied := operations.Result{FilePath: "b.pdf", Error: errors.New("denied"), ErrorKind: operations.ErrorKindPermission}
+
+	tests := []struct {
+		name   string
+		result operations.BatchResult
+		want   int
+	}{
+		{"clean", operations.BatchResult{Valid: []operations.Result{{FilePath: "ok.epub"}}}, 0},
+		{"invalid", operations.BatchResult{Invalid: []operations.Result{{FilePath: "bad.epub"}}}, exitFailed},
+		{"errored", operations.BatchResult{Errored: []operations.Result{denied}}, exitFailed},
+		{"timeout", operations.BatchResult{Errored: []operations.Result{denied, timeout}}, exitTimeout},
+		{"cleanup failed", operations.BatchResult{Valid: []operations.Result{{FilePath: "ok.epub"}},
+			Cleanup: operations.CleanupPlan{{Action: operations.CleanupMove, Path: "ok.epub", Err: errors.New("denied")}}}, exitFailed},
+		{"scan failed", operations.BatchResult{Valid: []operations.Result{{FilePath: "ok.epub"}}, ScanError: errors.New("denied")}, exitFailed},
+		{"interrupted", operations.BatchResult{Errored: []operations.Result{timeout}, Incomplete: true}, exitInterrupted},
+	}
+	for _, tt := range tests {
+		if got := batchExitCode(&tt.result); got != tt.want {
+			t.Errorf("%s: batchExitCode() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrorKinds(t *testing.T) {
+	kinds, err := parseErrorKinds([]string{"corrupt", " timeout"})
+	if err != nil || len(kinds) != 2 || kinds[1] != operations.ErrorKindTimeout {
+		t.Errorf("parseErrorKinds() = %v, %v", kinds, err)
+	}
+	if _, err := parseErrorKinds([]string{"gremlins"}); err == nil {
+		t.Error("Expected error for an unknown kind")
+	}
+}
+
+func TestApplyScheduleFlags(t *testing.T) {
+	var config operations.BatchConfig
+	if err := applyScheduleFlags(&config, &batchFlags{order: "largest", memoryBudget: "4GB"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Order != operations.OrderLargest || config.MemoryBudget != 4<<30 {
+		t.Errorf("applyScheduleFlags() = %q, %d", config.Order, config.MemoryBudget)
+	}
+
+	for _, flags := range []*batchFlags{{order: "random"}, {memoryBudget: "lots"}} {
+		if err := applyScheduleFlags(&config, flags); err == nil {
+			t.Errorf("Expected error for %+v", *flags)
+		}
+	}
+}
+
+func TestJobsValue(t *testing.T) {
+	flags := &batchFlags{jobs: 4}
+	value := jobsValue{flags}
+	if err := value.Set("auto"); err != nil || !flags.autoJobs || value.String() != "auto" {
+		t.Errorf("Set(auto) = %v, autoJobs %v", err, flags.autoJobs)
+	}
+
+	var config operations.BatchConfig
+	applyJobsFlag(&config, flags)
+	if !config.AutoTune || config.NumWorkers != operations.AutoTuneMaxWorkers() {
+		t.Errorf("applyJobsFlag() = %+v", config)
+	}
+
+	if err := value.Set("6"); err != nil || flags.autoJobs || flags.jobs != 6 || value.String() != "6" {
+		t.Errorf("Set(6) = %v, jobs %d", err, flags.jobs)
+	}
+	for _, bad := range []string{"0", "many", "-2"} {
+		if err := value.Set(bad); err == nil {
+			t.Errorf("Expected error for --jobs %s", bad)
+		}
+	}
 }
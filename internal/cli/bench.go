@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+type benchFlags struct {
+	sample     int
+	maxJobs    int
+	extensions []string
+}
+
+func newBenchCmd() *cobra.Command {
+	flags := &benchFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "bench <dir>",
+		Short: "Measure validation throughput and recommend a --jobs value",
+		Long: `Validate samples of a library with increasing worker counts and recommend
+the smallest --jobs value that comes within 5% of the best throughput.
+
+Each worker count validates different files where the library is large
+enough, so files cached by one run do not speed up the next. Files are only
+read; nothing is repaired.`,
+		Example: `  # Benchmark a network library
+  ebm bench /mnt/nas/books
+
+  # Larger samples, trying up to 32 workers
+  ebm bench ./library --sample 50 --max-jobs 32`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBench(cmd.Context(), cmd.OutOrStdout(), args[0], flags)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.sample, "sample", 20, "Files validated with each worker count")
+	cmd.Flags().IntVar(&flags.maxJobs, "max-jobs", 2*runtime.NumCPU(), "Largest worker count to try")
+	cmd.Flags().StringSliceVar(&flags.extensions, "ext", nil, "File extensions to include (default: .epub, .pdf)")
+
+	return cmd
+}
+
+func runBench(ctx context.Context, out io.Writer, dir string, flags *benchFlags) error {
+	if flags.sample < 1 || flags.maxJobs < 1 {
+		return fmt.Errorf("--sample and --max-jobs must be at least 1")
+	}
+
+	files, err := operations.FindFiles(dir, operations.FindFilesOptions{
+		Recursive:  true,
+		MaxDepth:   -1,
+		Extensions: flags.extensions,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find files: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no matching files found in %s", dir)
+	}
+
+	levels := operations.BenchLevels(flags.maxJobs)
+	fmt.Fprintf(out, "Benchmarking %s: %d files, %d per run\n\n", dir, len(files), flags.sample)
+
+	samples, err := operations.Bench(ctx, files, levels, flags.sample)
+	if err != nil {
+		return fmt.Errorf("benchmark interrupted: %w", err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Workers\tFiles\tTime\tFiles/s\tMB/s")
+	for _, s := range samples {
+		mbps := float64(s.Bytes) / (1 << 20) / s.Duration.Seconds()
+		fmt.Fprintf(w, "%d\t%d\t%s\t%.1f\t%.1f\n", s.Workers, s.Files, s.Duration.Round(time.Millisecond), s.FilesPerSecond(), mbps)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\nRecommended: --jobs %d\n", operations.RecommendWorkers(samples))
+	if len(files) < flags.sample*len(levels) {
+		fmt.Fprintln(out, "Note: the library is smaller than the samples, so later runs may read cached files.")
+	}
+	return nil
+}
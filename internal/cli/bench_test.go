@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBench(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 6; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("book%d.epub", i)), []byte("not a zip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runBench(context.Background(), &out, dir, &benchFlags{sample: 3, maxJobs: 4}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"6 files, 3 per run", "Workers", "Recommended: --jobs ", "smaller than the samples"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunBench_NoFiles(t *testing.T) {
+	if err := runBench(context.Background(), &bytes.Buffer{}, t.TempDir(), &benchFlags{sample: 2, maxJobs: 2}); err == nil {
+		t.Error("Expected error for a directory without books")
+	}
+	if err := runBench(context.Background(), &bytes.Buffer{}, t.TempDir(), &benchFlags{sample: 0, maxJobs: 2}); err == nil {
+		t.Error("Expected error for an empty sample")
+	}
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
+)
+
+// addCleanupApplyFlags adds the flags that decide whether and how the
+// cleanup of a batch is applied
+func addCleanupApplyFlags(cmd *cobra.Command, flags *batchFlags) {
+	cmd.Flags().BoolVar(&flags.dryRun, "dry-run", false, "List the cleanup the batch would make without changing anything")
+	cmd.Flags().BoolVarP(&flags.yes, "yes", "y", false, "Apply the cleanup without asking")
+	cmd.Flags().StringVar(&flags.trashDir, "trash-dir", "", "Trash directory removed files go to (default $XDG_DATA_HOME/ebm/trash)")
+}
+
+// cleanupOptions builds the cleanup of a batch from the flags
+func cleanupOptions(removeKinds []operations.ErrorKind, rules operations.RouteRules, flags *batchFlags) operations.CleanupOptions {
+	opts := operations.CleanupOptions{
+		Routes:     rules,
+		RemoveDirs: flags.cleanupEmptyDirs,
+		RootOf:     cleanupRoot,
+	}
+	if flags.removeSystemErrors {
+		opts.RemoveKinds = removeKinds
+	}
+	return opts
+}
+
+// confirm asks a yes or no question on the terminal. Without a terminal to
+// ask on, the answer is no. Tests replace it.
+var confirm = func(out io.Writer, question string) bool {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// runCleanup plans the cleanup of a batch and lists it on out. The plan is
+// applied with --yes or once confirmed, removing files to the trash, and
+// recorded in the result either way, so the report shows what was done,
+// what failed and what was left.
+func runCleanup(out io.Writer, batchResult *operations.BatchResult, opts operations.CleanupOptions, flags *batchFlags) {
+	plan := operations.PlanCleanup(batchResult, opts)
+	if plan.Pending() == 0 {
+		batchResult.RecordCleanup(plan)
+		return
+	}
+
+	bin := trash.New(flags.trashDir)
+	writeCleanupPlan(out, plan)
+	if hasRemovals(plan) {
+		fmt.Fprintf(out, "Removed files and folders go to the trash in %s\n", bin.Dir)
+	}
+	switch {
+	case flags.dryRun:
+		fmt.Fprintln(out, "Dry run: nothing was changed.")
+	case flags.yes || confirm(out, "Apply this cleanup?"):
+		plan = plan.Apply(bin)
+		if failed := plan.Failed(); len(failed) > 0 {
+			fmt.Fprintf(out, "Warning: %d cleanup operation(s) failed:\n", len(failed))
+			for _, op := range failed {
+				fmt.Fprintf(out, "  %s\n", cleanupLine(op))
+			}
+		}
+	default:
+		fmt.Fprintln(out, "Cleanup not applied; pass --yes to apply it without asking.")
+	}
+	batchResult.RecordCleanup(plan)
+}
+
+// hasRemovals reports whether a plan removes files or directories
+func hasRemovals(plan operations.CleanupPlan) bool {
+	for _, op := range plan {
+		if op.Action != operations.CleanupMove {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCleanupPlan lists every operation of a cleanup plan
+func writeCleanupPlan(out io.Writer, plan operations.CleanupPlan) {
+	fmt.Fprintf(out, "Cleanup plan: %s\n", plan.Summary())
+	for _, op := range plan {
+		fmt.Fprintf(out, "  %s\n", cleanupLine(op))
+	}
+}
+
+// cleanupLine describes an operation, e.g. "move    a.epub -> INVALID/a.epub"
+func cleanupLine(op operations.CleanupOp) string {
+	var line string
+	switch op.Action {
+	case operations.CleanupRemove:
+		line = fmt.Sprintf("delete  %s", op.Path)
+		if op.Kind != "" {
+			line += fmt.Sprintf(" (%s)", op.Kind)
+		}
+	case operations.CleanupMove:
+		if op.Skipped {
+			return fmt.Sprintf("skip    %s (%s exists)", op.Path, op.Dest)
+		}
+		line = fmt.Sprintf("move    %s -> %s", op.Path, op.Dest)
+	case operations.CleanupRemoveDir:
+		line = fmt.Sprintf("rmdir   %s", op.Path)
+		if len(op.Files) > 0 {
+			line += fmt.Sprintf(" (with %s)", strings.Join(op.Files, ", "))
+		}
+	}
+	if op.Err != nil {
+		line += ": " + op.Err.Error()
+	}
+	return line
+}
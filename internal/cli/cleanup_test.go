@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
+)
+
+func TestRunCleanup(t *testing.T) {
+	setup := func(t *testing.T) (*operations.BatchResult, string) {
+		lib := t.TempDir()
+		book := filepath.Join(lib, "Author", "book.epub")
+		if err := os.MkdirAll(filepath.Dir(book), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(book, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		errored := operations.Result{FilePath: book, Error: errors.New("zip: not a valid zip file"), ErrorKind: operations.ErrorKindCorrupt}
+		return &operations.BatchResult{Errored: []operations.Result{errored}}, book
+	}
+	opts := func(lib string) operations.CleanupOptions {
+		flags := &batchFlags{removeSystemErrors: true, cleanupEmptyDirs: true}
+		o := cleanupOptions(operations.DefaultRemovableKinds, nil, flags)
+		o.RootOf = func(operations.Result) string { return lib }
+		return o
+	}
+
+	oldConfirm := confirm
+	defer func() { confirm = oldConfirm }()
+	asked := false
+	confirm = func(io.Writer, string) bool {
+		asked = true
+		return false
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		result, book := setup(t)
+		var out bytes.Buffer
+		runCleanup(&out, result, opts(filepath.Dir(filepath.Dir(book))), &batchFlags{dryRun: true})
+		if _, err := os.Stat(book); err != nil || asked {
+			t.Fatalf("Expected a dry run to change nothing without asking, got %v", err)
+		}
+		for _, want := range []string{"Cleanup plan: 1 deletion, 1 directory removal", "delete  " + book + " (corrupt)", "Dry run"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("Expected %q in:\n%s", want, out.String())
+			}
+		}
+		if result.Cleanup.Pending() != 2 || len(result.RemovedFiles) != 0 {
+			t.Errorf("Expected the plan to be recorded as pending, got %+v", result.Cleanup)
+		}
+	})
+
+	t.Run("declined", func(t *testing.T) {
+		result, book := setup(t)
+		var out bytes.Buffer
+		runCleanup(&out, result, opts(filepath.Dir(filepath.Dir(book))), &batchFlags{})
+		if _, err := os.Stat(book); err != nil || !asked {
+			t.Fatalf("Expected to be asked and the book to be kept, got %v", err)
+		}
+		if !strings.Contains(out.String(), "pass --yes") {
+			t.Errorf("Expected a hint about --yes, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("yes", func(t *testing.T) {
+		result, book := setup(t)
+		confirm = func(io.Writer, string) bool {
+			t.Error("Expected --yes not to ask")
+			return false
+		}
+		var out bytes.Buffer
+		bin := trash.New(t.TempDir())
+		runCleanup(&out, result, opts(filepath.Dir(filepath.Dir(book))), &batchFlags{yes: true, trashDir: bin.Dir})
+		if _, err := os.Stat(filepath.Dir(book)); !os.IsNotExist(err) {
+			t.Errorf("Expected the book and its folder to be removed, got %v", err)
+		}
+		if entries, _, err := bin.List(); err != nil || len(entries) != 2 {
+			t.Errorf("Expected the book and its folder in the trash, got %+v (%v)", entries, err)
+		}
+		if _, err := bin.Find(book); err != nil {
+			t.Error(err)
+		}
+		if !strings.Contains(out.String(), "go to the trash in "+bin.Dir) {
+			t.Errorf("Expected the trash to be named, got:\n%s", out.String())
+		}
+		if len(result.RemovedFiles) != 1 || result.Cleanup.Pending() != 0 || len(result.Cleanup.Failed()) != 0 {
+			t.Errorf("Unexpected cleanup %+v", result.Cleanup)
+		}
+	})
+}
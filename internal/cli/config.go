@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/config"
+)
+
+// configOptions selects the config layers loaded for a library directory.
+// Tests replace it to keep the user's own files out.
+var configOptions = config.DefaultLoadOptions
+
+// applyConfig fills the flags of cmd that were not given on the command line
+// from the config files and environment
+func applyConfig(cmd *cobra.Command, args []string) error {
+	if cmd.Hidden {
+		return nil // Worker processes get their settings from the parent
+	}
+	cfg, err := loadConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	return cfg.Apply(cmd.Flags())
+}
+
+// loadConfig loads the config layers for the library of args, with the
+// profile given by --profile
+func loadConfig(cmd *cobra.Command, args []string) (*config.Config, error) {
+	opts := configOptions(configDir(args))
+	if f := cmd.Flags().Lookup("profile"); f != nil && f.Changed {
+		opts.Profile = f.Value.String()
+	}
+	cfg, err := config.Load(opts)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+	return cfg, nil
+}
+
+// configDir returns the directory searched for a per-library config file:
+// that of the first path argument, or the working directory
+func configDir(args []string) string {
+	if len(args) > 0 {
+		if info, err := os.Stat(args[0]); err == nil {
+			if info.IsDir() {
+				return args[0]
+			}
+			return filepath.Dir(args[0])
+		}
+	}
+	return "."
+}
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect configuration files",
+		Long: `Settings are read from these layers, each overriding the one before:
+
+  1. The system file, /etc/ebm/config
+  2. The user file, $XDG_CONFIG_HOME/ebm/config (~/.config/ebm/config)
+  3. A .ebm.toml or .ebm.yaml file in the library or one of its parents
+  4. The profile chosen with --profile NAME, EBM_PROFILE or a profile
+     setting in the files above, from $XDG_CONFIG_HOME/ebm/profiles
+  5. EBM_* environment variables, e.g. EBM_JOBS=8
+  6. Command-line flags
+
+Keys are flag names, such as jobs, no-backup or cleanup-empty-dirs. The
+TUI settings screen saves to the user file, or to the profile chosen on it.`,
+	}
+
+	cmd.AddCommand(newConfigShowCmd())
+	return cmd
+}
+
+func newConfigShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [library]",
+		Short: "Print the effective settings and where each came from",
+		Example: `  # Settings used for commands run in the current directory
+  ebm config show
+
+  # Settings used for a library with its own .ebm.toml
+  ebm config show ./library
+
+  # Settings of the archive profile
+  ebm config show --profile archive`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(cmd, args)
+			if err != nil {
+				return err
+			}
+			// Record the global flags given, such as --profile
+			if err := cfg.Apply(cmd.Flags()); err != nil {
+				return err
+			}
+			return writeConfig(cmd.OutOrStdout(), cfg, flagDefaults(cmd.Root()))
+		},
+	}
+}
+
+// flagDefaults returns the default value of each configurable flag, taken
+// from batch repair, which has them all
+func flagDefaults(root *cobra.Command) map[string]string {
+	defaults := make(map[string]string)
+	repair, _, err := root.Find([]string{"batch", "repair"})
+	if err != nil {
+		return defaults
+	}
+	for _, key := range config.Keys {
+		for _, flags := range []*pflag.FlagSet{repair.Flags(), repair.InheritedFlags()} {
+			if f := flags.Lookup(key.Name); f != nil {
+				defaults[key.Name] = f.DefValue
+				break
+			}
+		}
+	}
+	return defaults
+}
+
+// writeConfig prints the config files considered and a table of every
+// setting with its value and source
+func writeConfig(out io.Writer, cfg *config.Config, defaults map[string]string) error {
+	fmt.Fprintln(out, "Config files:")
+	for _, f := range cfg.Files {
+		status := ""
+		if !f.Found {
+			status = " (not found)"
+		}
+		fmt.Fprintf(out, "  %-8s %s%s\n", f.Source, f.Path, status)
+	}
+	if profile := cfg.Profile(); profile != "" {
+		fmt.Fprintf(out, "\nProfile: %s\n", profile)
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, key := range config.Keys {
+		s, ok := cfg.Lookup(key.Name)
+		if !ok {
+			s = config.Setting{Value: defaults[key.Name], Source: config.SourceDefault}
+		}
+		source := string(s.Source)
+		if s.Origin != "" {
+			source += " (" + s.Origin + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key.Name, s.Value, source)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/config"
+)
+
+// useConfig loads the given user file and environment instead of the real
+// ones for the rest of the test
+func useConfig(t *testing.T, userFile string, environ ...string) {
+	t.Helper()
+	previous := configOptions
+	profiles := filepath.Join(filepath.Dir(userFile), "profiles")
+	configOptions = func(dir string) config.LoadOptions {
+		return config.LoadOptions{UserFile: userFile, LibraryDir: dir, Environ: environ, ProfilesFile: profiles}
+	}
+	t.Cleanup(func() { configOptions = previous })
+}
+
+func TestApplyConfig(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "config")
+	if err := os.WriteFile(user, []byte("jobs = 3\naggressive = true\nformat = \"json\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	library := filepath.Join(dir, "library")
+	if err := os.MkdirAll(library, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(library, ".ebm.yaml"), []byte("jobs: auto\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useConfig(t, user, "EBM_NO_BACKUP=true")
+
+	cmd, args, err := NewRootCmd().Find([]string{"batch", "repair", library})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ParseFlags([]string{"--aggressive=false"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(cmd, args); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+
+	for name, want := range map[string]string{"jobs": "auto", "aggressive": "false", "format": "json", "no-backup": "true", "cleanup-empty-dirs": "true"} {
+		if got := cmd.Flags().Lookup(name).Value.String(); got != want {
+			t.Errorf("--%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestApplyConfig_InvalidValue(t *testing.T) {
+	useConfig(t, "", "EBM_ORDER=sideways")
+	root := NewRootCmd()
+	root.SetArgs([]string{"batch", "validate", t.TempDir()})
+	err := root.Execute()
+	if err == nil || !strings.Contains(err.Error(), `unknown order "sideways"`) {
+		t.Errorf("Expected the configured order to be rejected, got %v", err)
+	}
+
+	useConfig(t, "", "EBM_JOBS=none")
+	root = NewRootCmd()
+	root.SetArgs([]string{"batch", "validate", t.TempDir()})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "EBM_JOBS") {
+		t.Errorf("Expected an error naming EBM_JOBS, got %v", err)
+	}
+}
+
+func TestConfigShow(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "config")
+	if err := os.WriteFile(user, []byte("jobs = 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useConfig(t, user, "EBM_RETRIES=7")
+
+	var out bytes.Buffer
+	root := NewRootCmd()
+	root.SetOut(&out)
+	root.SetArgs([]string{"config", "show", dir})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("config show: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"  user     " + user + "\n",
+		"jobs ", "user (" + user + ")",
+		"retries ", "env (EBM_RETRIES)",
+		"timeout ", "60 ", "default\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestApplyConfig_Profile(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "config")
+	if err := os.WriteFile(user, []byte("jobs = 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profiles := "[archive]\njobs = 1\ncleanup-empty-dirs = false\n\n[staging]\naggressive = true\n"
+	if err := os.WriteFile(filepath.Join(dir, "profiles"), []byte(profiles), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useConfig(t, user)
+
+	cmd, args, err := NewRootCmd().Find([]string{"batch", "repair", dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.ParseFlags([]string{"--profile", "archive"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(cmd, args); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+	for name, want := range map[string]string{"jobs": "1", "cleanup-empty-dirs": "false", "aggressive": "false", "profile": "archive"} {
+		if got := cmd.Flags().Lookup(name).Value.String(); got != want {
+			t.Errorf("--%s = %q, want %q", name, got, want)
+		}
+	}
+
+	root := NewRootCmd()
+	root.SetArgs([]string{"config", "show", "--profile", "nightly"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), `unknown profile "nightly" (defined: archive, staging)`) {
+		t.Errorf("Expected an unknown profile error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	root = NewRootCmd()
+	root.SetOut(&out)
+	root.SetArgs([]string{"config", "show", "--profile", "staging"})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("config show: %v", err)
+	}
+	for _, want := range []string{"Profile: staging", "profile (" + filepath.Join(dir, "profiles") + " [staging])", "flag (--profile)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,702 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+// SchemaVersion is the version of the JSON documents written by JSONFormatter.
+// The minor version is bumped when optional fields are added; the major version
+// is bumped for any change that can break an existing consumer.
+const SchemaVersion = "1.13"
+
+// Document kinds written to the "kind" field of JSON output
+const (
+	DocumentKindValidation = "validation"
+	DocumentKindRepair     = "repair"
+	DocumentKindBatch      = "batch"
+)
+
+// ValidationDocument is the JSON form of a validation report
+type ValidationDocument struct {
+	SchemaVersion string          `json:"schema_version,omitempty"`
+	Kind          string          `json:"kind,omitempty"`
+	FilePath      string          `json:"file_path"`
+	IsValid       bool            `json:"is_valid"`
+	Summary       IssueSummary    `json:"summary"`
+	Errors        []IssueDocument `json:"errors"`
+	Warnings      []IssueDocument `json:"warnings"`
+	Info          []IssueDocument `json:"info"`
+}
+
+// IssueSummary counts the issues of a validation report by severity
+type IssueSummary struct {
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	Info     int `json:"info"`
+}
+
+// IssueDocument is the JSON form of a single validation issue
+type IssueDocument struct {
+	Code     string            `json:"code"`
+	Message  string            `json:"message"`
+	Severity string            `json:"severity"`
+	Location *LocationDocument `json:"location,omitempty"`
+}
+
+// LocationDocument is the JSON form of an issue location
+type LocationDocument struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+}
+
+// RepairDocument is the JSON form of a repair result
+type RepairDocument struct {
+	SchemaVersion    string              `json:"schema_version,omitempty"`
+	Kind             string              `json:"kind,omitempty"`
+	Success          bool                `json:"success"`
+	ActionsApplied   []ActionDocument    `json:"actions_applied"`
+	BackupPath       string              `json:"backup_path,omitempty"`
+	Error            string              `json:"error,omitempty"`
+	ValidationReport *ValidationDocument `json:"validation_report,omitempty"`
+}
+
+// ActionDocument is the JSON form of an applied repair action
+type ActionDocument struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// BatchDocument is the JSON form of a batch result
+type BatchDocument struct {
+	SchemaVersion string                `json:"schema_version"`
+	Kind          string                `json:"kind"`
+	Operation     string                `json:"operation"`
+	Total         int                   `json:"total"`
+	Successful    int                   `json:"successful"`
+	Failed        int                   `json:"failed"`
+	NoOp          int                   `json:"no_op"`
+	Duration      int64                 `json:"duration"` // milliseconds
+	Summary       BatchSummaryDocument  `json:"summary"`
+	Options       BatchOptionsDocument  `json:"options"`
+	RemovedFiles  []string              `json:"removed_files"`
+	MovedFiles    []string              `json:"moved_files"`
+	RoutedFiles   []RoutedFileDocument  `json:"routed_files,omitempty"`
+	Cleanup       []CleanupOpDocument   `json:"cleanup,omitempty"`
+	TopIssues     []IssueStatDocument   `json:"top_issues"`
+	Roots         []RootStatDocument    `json:"roots,omitempty"` // only with several roots
+	Symlinks      []SymlinkDocument     `json:"symlinks,omitempty"`
+	ScanError     string                `json:"scan_error,omitempty"`
+	Timing        *TimingDocument       `json:"timing,omitempty"`
+	Results       *BatchResultsDocument `json:"results,omitempty"` // omitted with --summary-only
+}
+
+// BatchSummaryDocument holds the per-category counts of a batch
+type BatchSummaryDocument struct {
+	Valid            int `json:"valid"`
+	Invalid          int `json:"invalid"`
+	Errored          int `json:"errored"`
+	RepairsAttempted int `json:"repairs_attempted"`
+	RepairsSucceeded int `json:"repairs_succeeded"`
+	RepairsNoOp      int `json:"repairs_no_op"`
+
+	ErrorsByKind map[string]int `json:"errors_by_kind,omitempty"`
+	Retried      int            `json:"retried,omitempty"`
+}
+
+// BatchOptionsDocument is the JSON form of operations.BatchOptions
+type BatchOptionsDocument struct {
+	Profile            string   `json:"profile,omitempty"`
+	NumWorkers         int      `json:"num_workers"`
+	AutoJobs           bool     `json:"auto_jobs,omitempty"`
+	SkipValidation     bool     `json:"skip_validation"`
+	NoBackup           bool     `json:"no_backup"`
+	Aggressive         bool     `json:"aggressive"`
+	RemoveSystemErrors bool     `json:"remove_system_errors"`
+	MoveFailedRepairs  bool     `json:"move_failed_repairs"`
+	CleanupEmptyDirs   bool     `json:"cleanup_empty_dirs"`
+	Routes             []string `json:"routes,omitempty"`
+}
+
+// BatchResultsDocument lists per-file results by category
+type BatchResultsDocument struct {
+	Valid   []ResultDocument `json:"valid"`
+	Invalid []ResultDocument `json:"invalid"`
+	Errored []ResultDocument `json:"errored"`
+}
+
+// IssueStatDocument is the JSON form of operations.IssueStat
+type IssueStatDocument struct {
+	Code        string   `json:"code"`
+	Severity    string   `json:"severity"`
+	Message     string   `json:"message"`
+	Occurrences int      `json:"occurrences"`
+	Files       int      `json:"files"`
+	Examples    []string `json:"examples"`
+}
+
+// RootStatDocument is the JSON form of operations.RootStat
+type RootStatDocument struct {
+	Root    string `json:"root"`
+	Total   int    `json:"total"`
+	Valid   int    `json:"valid"`
+	Invalid int    `json:"invalid"`
+	Errored int    `json:"errored"`
+}
+
+// SymlinkDocument is the JSON form of operations.SymlinkEvent
+type SymlinkDocument struct {
+	Path     string `json:"path"`
+	Target   string `json:"target,omitempty"`
+	Followed bool   `json:"followed"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// RoutedFileDocument is the JSON form of operations.RoutedFile
+type RoutedFileDocument struct {
+	Path    string `json:"path"`
+	Dest    string `json:"dest,omitempty"`
+	Outcome string `json:"outcome"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CleanupOpDocument is the JSON form of operations.CleanupOp. Status is
+// done, skipped, failed or pending (planned but not applied).
+type CleanupOpDocument struct {
+	Action  string   `json:"action"`
+	Path    string   `json:"path"`
+	Dest    string   `json:"dest,omitempty"`
+	Outcome string   `json:"outcome,omitempty"`
+	Kind    string   `json:"kind,omitempty"`
+	Files   []string `json:"files,omitempty"`
+	Trashed string   `json:"trashed,omitempty"`
+	Status  string   `json:"status"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Statuses of a cleanup operation
+const (
+	cleanupDone    = "done"
+	cleanupSkipped = "skipped"
+	cleanupFailed  = "failed"
+	cleanupPending = "pending"
+)
+
+// newCleanupOpDocument converts a cleanup operation
+func newCleanupOpDocument(op operations.CleanupOp) CleanupOpDocument {
+	doc := CleanupOpDocument{
+		Action:  string(op.Action),
+		Path:    op.Path,
+		Dest:    op.Dest,
+		Outcome: string(op.Outcome),
+		Kind:    string(op.Kind),
+		Files:   op.Files,
+		Trashed: op.Trashed,
+		Status:  cleanupPending,
+	}
+	switch {
+	case op.Err != nil:
+		doc.Status = cleanupFailed
+		doc.Error = op.Err.Error()
+	case op.Skipped:
+		doc.Status = cleanupSkipped
+	case op.Done:
+		doc.Status = cleanupDone
+	}
+	return doc
+}
+
+// cleanupOp converts the document back
+func (d CleanupOpDocument) cleanupOp() operations.CleanupOp {
+	op := operations.CleanupOp{
+		Action:  operations.CleanupAction(d.Action),
+		Path:    d.Path,
+		Dest:    d.Dest,
+		Outcome: operations.Outcome(d.Outcome),
+		Kind:    operations.ErrorKind(d.Kind),
+		Files:   d.Files,
+		Trashed: d.Trashed,
+		Skipped: d.Status == cleanupSkipped,
+		Done:    d.Status == cleanupDone,
+	}
+	if d.Status == cleanupFailed {
+		op.Err = errors.New(d.Error)
+	}
+	return op
+}
+
+// TimingDocument is the JSON form of operations.TimingStats. Durations are
+// in milliseconds.
+type TimingDocument struct {
+	Files          int                  `json:"files"`
+	Bytes          int64                `json:"bytes"`
+	FilesPerSecond float64              `json:"files_per_second"`
+	BytesPerSecond float64              `json:"bytes_per_second"`
+	P50            float64              `json:"p50"`
+	P90            float64              `json:"p90"`
+	P99            float64              `json:"p99"`
+	Max            float64              `json:"max"`
+	Slowest        []FileTimingDocument `json:"slowest"`
+}
+
+// FileTimingDocument is the JSON form of operations.FileTiming
+type FileTimingDocument struct {
+	FilePath string          `json:"file_path"`
+	Duration float64         `json:"duration"` // milliseconds
+	Bytes    int64           `json:"bytes"`
+	Phases   *PhasesDocument `json:"phases,omitempty"`
+}
+
+// PhasesDocument is the JSON form of operations.PhaseTimings, in
+// milliseconds
+type PhasesDocument struct {
+	Validate float64 `json:"validate,omitempty"`
+	Preview  float64 `json:"preview,omitempty"`
+	Repair   float64 `json:"repair,omitempty"`
+}
+
+// ResultDocument is the JSON form of a single file result in a batch
+type ResultDocument struct {
+	FilePath  string              `json:"file_path"`
+	Root      string              `json:"root,omitempty"`
+	Report    *ValidationDocument `json:"report,omitempty"`
+	Repair    *RepairDocument     `json:"repair,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	ErrorKind string              `json:"error_kind,omitempty"`
+	Stack     string              `json:"stack,omitempty"`
+	Attempts  int                 `json:"attempts,omitempty"`
+	Timeout   int64               `json:"timeout,omitempty"`  // milliseconds
+	Duration  float64             `json:"duration,omitempty"` // milliseconds
+	Bytes     int64               `json:"bytes,omitempty"`
+	Phases    *PhasesDocument     `json:"phases,omitempty"`
+}
+
+// NewValidationDocument converts a validation report to its JSON document
+func NewValidationDocument(report *ebmlib.ValidationReport) *ValidationDocument {
+	doc := newValidationBody(report)
+	doc.SchemaVersion = SchemaVersion
+	doc.Kind = DocumentKindValidation
+	return doc
+}
+
+// NewRepairDocument converts a repair result and optional validation report to its JSON document
+func NewRepairDocument(result *ebmlib.RepairResult, report *ebmlib.ValidationReport) *RepairDocument {
+	doc := newRepairBody(result)
+	doc.SchemaVersion = SchemaVersion
+	doc.Kind = DocumentKindRepair
+	if report != nil {
+		doc.ValidationReport = newValidationBody(report)
+	}
+	return doc
+}
+
+// NewBatchDocument converts a batch result to its JSON document
+func NewBatchDocument(result *operations.BatchResult, summaryOnly bool) *BatchDocument {
+	doc := &BatchDocument{
+		SchemaVersion: SchemaVersion,
+		Kind:          DocumentKindBatch,
+		Operation:     string(result.Operation),
+		Total:         result.Total,
+		Successful:    len(result.Successful),
+		Failed:        len(result.Failed),
+		NoOp:          result.RepairsNoOp,
+		Duration:      result.Duration.Milliseconds(),
+		Summary: BatchSummaryDocument{
+			Valid:            len(result.Valid),
+			Invalid:          len(result.Invalid),
+			Errored:          len(result.Errored),
+			RepairsAttempted: result.RepairsAttempted,
+			RepairsSucceeded: result.RepairsSucceeded,
+			RepairsNoOp:      result.RepairsNoOp,
+			Retried:          result.Retried,
+		},
+		Options: BatchOptionsDocument{
+			Profile:            result.Options.Profile,
+			NumWorkers:         result.Options.NumWorkers,
+			AutoJobs:           result.Options.AutoJobs,
+			SkipValidation:     result.Options.SkipValidation,
+			NoBackup:           result.Options.NoBackup,
+			Aggressive:         result.Options.Aggressive,
+			RemoveSystemErrors: result.Options.RemoveSystemErrors,
+			MoveFailedRepairs:  result.Options.MoveFailedRepairs,
+			CleanupEmptyDirs:   result.Options.CleanupEmptyDirs,
+			Routes:             result.Options.Routes,
+		},
+		RemovedFiles: nonNilStrings(result.RemovedFiles),
+		MovedFiles:   nonNilStrings(result.MovedFiles),
+		TopIssues:    make([]IssueStatDocument, 0, len(result.TopIssues)),
+	}
+	if len(result.ErrorKinds) > 0 {
+		doc.Summary.ErrorsByKind = make(map[string]int, len(result.ErrorKinds))
+		for _, stat := range result.ErrorKinds {
+			doc.Summary.ErrorsByKind[string(stat.Kind)] = stat.Count
+		}
+	}
+	for _, stat := range result.TopIssues {
+		doc.TopIssues = append(doc.TopIssues, IssueStatDocument{
+			Code:        stat.Code,
+			Severity:    severityName(stat.Severity),
+			Message:     stat.Message,
+			Occurrences: stat.Occurrences,
+			Files:       stat.Files,
+			Examples:    nonNilStrings(stat.Examples),
+		})
+	}
+	if len(result.Roots) > 1 {
+		for _, root := range result.Roots {
+			doc.Roots = append(doc.Roots, RootStatDocument(root))
+		}
+	}
+	for _, link := range result.Symlinks {
+		doc.Symlinks = append(doc.Symlinks, SymlinkDocument(link))
+	}
+	if result.ScanError != nil {
+		doc.ScanError = result.ScanError.Error()
+	}
+	for _, f := range result.Routed {
+		routed := RoutedFileDocument{Path: f.Path, Dest: f.Dest, Outcome: string(f.Outcome), Skipped: f.Skipped}
+		if f.Err != nil {
+			routed.Error = f.Err.Error()
+		}
+		doc.RoutedFiles = append(doc.RoutedFiles, routed)
+	}
+	for _, op := range result.Cleanup {
+		doc.Cleanup = append(doc.Cleanup, newCleanupOpDocument(op))
+	}
+	if result.Timing.Files > 0 {
+		doc.Timing = newTimingDocument(result.Timing)
+	}
+	if doc.Operation == "" {
+		doc.Operation = string(operations.OperationValidate)
+	}
+
+	if !summaryOnly {
+		doc.Results = &BatchResultsDocument{
+			Valid:   newResultDocuments(result.Valid),
+			Invalid: newResultDocuments(result.Invalid),
+			Errored: newResultDocuments(result.Errored),
+		}
+	}
+
+	return doc
+}
+
+func newValidationBody(report *ebmlib.ValidationReport) *ValidationDocument {
+	return &ValidationDocument{
+		FilePath: report.FilePath,
+		IsValid:  report.IsValid,
+		Summary: IssueSummary{
+			Errors:   report.ErrorCount(),
+			Warnings: report.WarningCount(),
+			Info:     report.InfoCount(),
+		},
+		Errors:   newIssueDocuments(report.Errors, "error"),
+		Warnings: newIssueDocuments(report.Warnings, "warning"),
+		Info:     newIssueDocuments(report.Info, "info"),
+	}
+}
+
+// newIssueDocuments converts issues; severity is taken from the list the
+// issue belongs to so that the document does not depend on ebmlib's encoding.
+func newIssueDocuments(issues []ebmlib.ValidationError, severity string) []IssueDocument {
+	docs := make([]IssueDocument, 0, len(issues))
+	for _, issue := range issues {
+		doc := IssueDocument{
+			Code:     issue.Code,
+			Message:  issue.Message,
+			Severity: severity,
+		}
+		if issue.Location != nil {
+			doc.Location = &LocationDocument{File: issue.Location.File, Line: issue.Location.Line}
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func newRepairBody(result *ebmlib.RepairResult) *RepairDocument {
+	doc := &RepairDocument{
+		Success:        result.Success,
+		ActionsApplied: make([]ActionDocument, 0, len(result.ActionsApplied)),
+		BackupPath:     result.BackupPath,
+	}
+	for _, action := range result.ActionsApplied {
+		doc.ActionsApplied = append(doc.ActionsApplied, ActionDocument{Type: action.Type, Description: action.Description})
+	}
+	if result.Error != nil {
+		doc.Error = result.Error.Error()
+	}
+	return doc
+}
+
+func newResultDocuments(results []operations.Result) []ResultDocument {
+	docs := make([]ResultDocument, 0, len(results))
+	for _, r := range results {
+		doc := ResultDocument{
+			FilePath: r.FilePath,
+			Root:     r.Root,
+			Attempts: r.Attempts,
+			Timeout:  r.Timeout.Milliseconds(),
+			Duration: millis(r.Duration),
+			Bytes:    r.Bytes,
+			Phases:   newPhasesDocument(r.Phases),
+		}
+		if r.Report != nil {
+			doc.Report = newValidationBody(r.Report)
+		}
+		if r.Repair != nil {
+			doc.Repair = newRepairBody(r.Repair)
+		}
+		if r.Error != nil {
+			doc.Error = r.Error.Error()
+			doc.ErrorKind = string(r.ErrorKind)
+			var panicErr *operations.PanicError
+			if errors.As(r.Error, &panicErr) {
+				doc.Stack = panicErr.Stack
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func newTimingDocument(stats operations.TimingStats) *TimingDocument {
+	doc := &TimingDocument{
+		Files:          stats.Files,
+		Bytes:          stats.Bytes,
+		FilesPerSecond: stats.FilesPerSecond,
+		BytesPerSecond: stats.BytesPerSecond,
+		P50:            millis(stats.P50),
+		P90:            millis(stats.P90),
+		P99:            millis(stats.P99),
+		Max:            millis(stats.Max),
+		Slowest:        make([]FileTimingDocument, 0, len(stats.Slowest)),
+	}
+	for _, file := range stats.Slowest {
+		doc.Slowest = append(doc.Slowest, FileTimingDocument{
+			FilePath: file.FilePath,
+			Duration: millis(file.Duration),
+			Bytes:    file.Bytes,
+			Phases:   newPhasesDocument(file.Phases),
+		})
+	}
+	return doc
+}
+
+// newPhasesDocument returns nil when no phase was timed
+func newPhasesDocument(phases operations.PhaseTimings) *PhasesDocument {
+	if phases == (operations.PhaseTimings{}) {
+		return nil
+	}
+	return &PhasesDocument{
+		Validate: millis(phases.Validate),
+		Preview:  millis(phases.Preview),
+		Repair:   millis(phases.Repair),
+	}
+}
+
+// PhaseTimings converts the document back to phase durations
+func (d *PhasesDocument) PhaseTimings() operations.PhaseTimings {
+	return operations.PhaseTimings{
+		Validate: fromMillis(d.Validate),
+		Preview:  fromMillis(d.Preview),
+		Repair:   fromMillis(d.Repair),
+	}
+}
+
+// millis returns d in fractional milliseconds
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// fromMillis converts fractional milliseconds to a duration
+func fromMillis(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// severityName returns the document name of an ebmlib severity
+func severityName(severity ebmlib.Severity) string {
+	switch severity {
+	case ebmlib.SeverityError:
+		return "error"
+	case ebmlib.SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func severityFromName(name string) ebmlib.Severity {
+	switch name {
+	case "error":
+		return ebmlib.SeverityError
+	case "warning":
+		return ebmlib.SeverityWarning
+	default:
+		return ebmlib.SeverityInfo
+	}
+}
+
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// ValidationReport converts the document back to a validation report
+func (d *ValidationDocument) ValidationReport() *ebmlib.ValidationReport {
+	return &ebmlib.ValidationReport{
+		FilePath: d.FilePath,
+		IsValid:  d.IsValid,
+		Errors:   issuesFromDocuments(d.Errors, ebmlib.SeverityError),
+		Warnings: issuesFromDocuments(d.Warnings, ebmlib.SeverityWarning),
+		Info:     issuesFromDocuments(d.Info, ebmlib.SeverityInfo),
+	}
+}
+
+func issuesFromDocuments(docs []IssueDocument, severity ebmlib.Severity) []ebmlib.ValidationError {
+	if len(docs) == 0 {
+		return nil
+	}
+	issues := make([]ebmlib.ValidationError, 0, len(docs))
+	for _, doc := range docs {
+		issue := ebmlib.ValidationError{
+			Code:     doc.Code,
+			Message:  doc.Message,
+			Severity: severity,
+		}
+		if doc.Location != nil {
+			issue.Location = &ebmlib.ErrorLocation{File: doc.Location.File, Line: doc.Location.Line}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// RepairResult converts the document back to a repair result
+func (d *RepairDocument) RepairResult() *ebmlib.RepairResult {
+	result := &ebmlib.RepairResult{
+		Success:        d.Success,
+		ActionsApplied: make([]ebmlib.RepairAction, 0, len(d.ActionsApplied)),
+		BackupPath:     d.BackupPath,
+	}
+	for _, action := range d.ActionsApplied {
+		result.ActionsApplied = append(result.ActionsApplied, ebmlib.RepairAction{Type: action.Type, Description: action.Description})
+	}
+	if d.Error != "" {
+		result.Error = errors.New(d.Error)
+	}
+	return result
+}
+
+// BatchResult converts the document back to a batch result. Results are
+// required; documents written with --summary-only cannot be converted.
+func (d *BatchDocument) BatchResult() (*operations.BatchResult, error) {
+	if d.Results == nil {
+		return nil, errors.New("batch report has no per-file results (was it written with --summary-only?)")
+	}
+
+	result := &operations.BatchResult{
+		Valid:            resultsFromDocuments(d.Results.Valid),
+		Invalid:          resultsFromDocuments(d.Results.Invalid),
+		Errored:          resultsFromDocuments(d.Results.Errored),
+		Duration:         time.Duration(d.Duration) * time.Millisecond,
+		Total:            d.Total,
+		Operation:        operations.OperationType(d.Operation),
+		RepairsAttempted: d.Summary.RepairsAttempted,
+		RepairsSucceeded: d.Summary.RepairsSucceeded,
+		RepairsNoOp:      d.Summary.RepairsNoOp,
+		RemovedFiles:     d.RemovedFiles,
+		MovedFiles:       d.MovedFiles,
+		Options: operations.BatchOptions{
+			Profile:            d.Options.Profile,
+			NumWorkers:         d.Options.NumWorkers,
+			AutoJobs:           d.Options.AutoJobs,
+			SkipValidation:     d.Options.SkipValidation,
+			NoBackup:           d.Options.NoBackup,
+			Aggressive:         d.Options.Aggressive,
+			RemoveSystemErrors: d.Options.RemoveSystemErrors,
+			MoveFailedRepairs:  d.Options.MoveFailedRepairs,
+			CleanupEmptyDirs:   d.Options.CleanupEmptyDirs,
+			Routes:             d.Options.Routes,
+		},
+	}
+	for _, stat := range d.TopIssues {
+		result.TopIssues = append(result.TopIssues, operations.IssueStat{
+			Code:        stat.Code,
+			Severity:    severityFromName(stat.Severity),
+			Message:     stat.Message,
+			Occurrences: stat.Occurrences,
+			Files:       stat.Files,
+			Examples:    stat.Examples,
+		})
+	}
+	for _, root := range d.Roots {
+		result.Roots = append(result.Roots, operations.RootStat(root))
+	}
+	for _, link := range d.Symlinks {
+		result.Symlinks = append(result.Symlinks, operations.SymlinkEvent(link))
+	}
+	if d.ScanError != "" {
+		result.ScanError = errors.New(d.ScanError)
+	}
+	for _, f := range d.RoutedFiles {
+		routed := operations.RoutedFile{Path: f.Path, Dest: f.Dest, Outcome: operations.Outcome(f.Outcome), Skipped: f.Skipped}
+		if f.Error != "" {
+			routed.Err = errors.New(f.Error)
+		}
+		result.Routed = append(result.Routed, routed)
+	}
+	for _, op := range d.Cleanup {
+		result.Cleanup = append(result.Cleanup, op.cleanupOp())
+	}
+	result.ErrorKinds = operations.ComputeKindStats(result.Errored)
+	result.Retried = d.Summary.Retried
+	all := append(append(append([]operations.Result{}, result.Valid...), result.Invalid...), result.Errored...)
+	result.Timing = operations.ComputeTimingStats(all, result.Duration)
+	result.Successful = result.Valid
+	result.Failed = append(append([]operations.Result{}, result.Invalid...), result.Errored...)
+	if result.Operation == "" {
+		result.Operation = operations.OperationValidate
+	}
+
+	return result, nil
+}
+
+func resultsFromDocuments(docs []ResultDocument) []operations.Result {
+	results := make([]operations.Result, 0, len(docs))
+	for _, doc := range docs {
+		r := operations.Result{
+			FilePath:  doc.FilePath,
+			Root:      doc.Root,
+			ErrorKind: operations.ErrorKind(doc.ErrorKind),
+			Attempts:  doc.Attempts,
+			Timeout:   time.Duration(doc.Timeout) * time.Millisecond,
+			Duration:  fromMillis(doc.Duration),
+			Bytes:     doc.Bytes,
+		}
+		if doc.Phases != nil {
+			r.Phases = doc.Phases.PhaseTimings()
+		}
+		if doc.Report != nil {
+			r.Report = doc.Report.ValidationReport()
+		}
+		if doc.Repair != nil {
+			r.Repair = doc.Repair.RepairResult()
+		}
+		switch {
+		case doc.Stack != "":
+			r.Error = &operations.PanicError{Value: strings.TrimPrefix(doc.Error, "panic: "), Stack: doc.Stack}
+		case doc.Error != "":
+			r.Error = errors.New(doc.Error)
+		}
+		results = append(results, r)
+	}
+	return results
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func TestValidationDocument_RoundTrip(t *testing.T) {
+	report := sampleReport("a.epub")
+
+	doc := NewValidationDocument(report)
+	if doc.SchemaVersion != SchemaVersion || doc.Kind != DocumentKindValidation {
+		t.Errorf("Unexpected header %q/%q", doc.SchemaVersion, doc.Kind)
+	}
+	if doc.Summary.Errors != 1 || doc.Summary.Warnings != 1 {
+		t.Errorf("Unexpected summary %+v", doc.Summary)
+	}
+	if doc.Errors[0].Severity != "error" || doc.Warnings[0].Severity != "warning" {
+		t.Errorf("Unexpected severities %q/%q", doc.Errors[0].Severity, doc.Warnings[0].Severity)
+	}
+	if doc.Info == nil {
+		t.Error("Expected empty info list to be non-nil")
+	}
+
+	back := doc.ValidationReport()
+	if back.FilePath != "a.epub" || back.ErrorCount() != 1 || back.WarningCount() != 1 {
+		t.Errorf("Unexpected round trip %+v", back)
+	}
+	if back.Errors[0].Location == nil || back.Errors[0].Location.Line != 1 {
+		t.Errorf("Expected location to survive round trip, got %+v", back.Errors[0].Location)
+	}
+}
+
+func TestRepairDocument_RoundTrip(t *testing.T) {
+	result := &ebmlib.RepairResult{
+		ActionsApplied: []ebmlib.RepairAction{{Type: "fix", Description: "Fixed"}},
+		Error:          errors.New("write failed"),
+	}
+
+	doc := NewRepairDocument(result, nil)
+	if doc.Error != "write failed" || doc.ValidationReport != nil {
+		t.Errorf("Unexpected document %+v", doc)
+	}
+
+	back := doc.RepairResult()
+	if back.Error == nil || back.Error.Error() != "write failed" || len(back.ActionsApplied) != 1 {
+		t.Errorf("Unexpected round trip %+v", back)
+	}
+}
+
+func TestBatchDocument_RoundTrip(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{
+		{FilePath: "lib/a.epub", Root: "lib", Report: sampleReport("a.epub"), Duration: 1500 * time.Microsecond, Bytes: 2048,
+			Phases: operations.PhaseTimings{Validate: 1500 * time.Microsecond}},
+		{FilePath: "c.epub", Root: "c.epub", Error: errors.New("unreadable"), Attempts: 3, Timeout: 45 * time.Second},
+		{FilePath: "d.epub", Root: "d.epub", Error: &operations.PanicError{Value: "bad spine", Stack: "goroutine 7"}},
+	}, 2*time.Second, operations.OperationValidate)
+	result.Options.NumWorkers = 4
+	result.Options.AutoJobs = true
+	result.Options.Profile = "archive"
+	result.Symlinks = []operations.SymlinkEvent{
+		{Path: "lib/author", Target: "/disk/author", Followed: true},
+		{Path: "lib/loop", Target: "lib", Reason: operations.SymlinkLoop},
+	}
+	result.ScanError = errors.New("failed to find files: lib/private: permission denied")
+	result.Options.Routes = []string{"invalid=INVALID;keep-path"}
+	result.Routed = []operations.RoutedFile{
+		{Path: "lib/a.epub", Dest: "lib/INVALID/a.epub", Outcome: operations.OutcomeInvalid},
+		{Path: "c.epub", Outcome: operations.OutcomeErrored, Err: errors.New("permission denied")},
+	}
+	result.Cleanup = operations.CleanupPlan{
+		{Action: operations.CleanupRemove, Path: "b.epub", Kind: operations.ErrorKindCorrupt, Trashed: "b_1.epub", Done: true},
+		{Action: operations.CleanupRemoveDir, Path: "lib/b", Files: []string{"cover.jpg"}, Err: errors.New("busy")},
+		{Action: operations.CleanupMove, Path: "lib/d.epub", Dest: "lib/INVALID/d.epub"},
+	}
+
+	doc := NewBatchDocument(&result, false)
+	if doc.Total != 3 || doc.Summary.Invalid != 1 || doc.Summary.Errored != 2 || doc.Duration != 2000 {
+		t.Errorf("Unexpected document %+v", doc)
+	}
+
+	back, err := doc.BatchResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Invalid) != 1 || len(back.Errored) != 2 || len(back.Failed) != 3 {
+		t.Errorf("Unexpected round trip %+v", back)
+	}
+	if doc.Results.Errored[1].Stack != "goroutine 7" {
+		t.Errorf("Expected the panic stack in the document, got %+v", doc.Results.Errored[1])
+	}
+	var panicErr *operations.PanicError
+	if !errors.As(back.Errored[1].Error, &panicErr) || panicErr.Value != "bad spine" || panicErr.Stack != "goroutine 7" {
+		t.Errorf("Expected the panic to survive round trip, got %#v", back.Errored[1].Error)
+	}
+	if len(back.TopIssues) != 2 || back.TopIssues[0].Severity != ebmlib.SeverityError {
+		t.Errorf("Expected top issues to survive round trip, got %+v", back.TopIssues)
+	}
+	if len(back.Roots) != 3 || back.Roots[0].Root != "c.epub" || back.Invalid[0].Root != "lib" {
+		t.Errorf("Expected roots to survive round trip, got %+v", back.Roots)
+	}
+	if back.ScanError == nil || back.ScanError.Error() != result.ScanError.Error() {
+		t.Errorf("Expected the scan error to survive round trip, got %v", back.ScanError)
+	}
+	if len(back.Symlinks) != 2 || !back.Symlinks[0].Followed || back.Symlinks[1].Reason != operations.SymlinkLoop {
+		t.Errorf("Expected symlinks to survive round trip, got %+v", back.Symlinks)
+	}
+	if len(back.Routed) != 2 || back.Routed[0].Dest != "lib/INVALID/a.epub" || back.Routed[1].Err == nil || back.Routed[1].Err.Error() != "permission denied" {
+		t.Errorf("Expected routed files to survive round trip, got %+v", back.Routed)
+	}
+	if len(back.Cleanup) != 3 || !back.Cleanup[0].Done || back.Cleanup[0].Trashed != "b_1.epub" || back.Cleanup[0].Kind != operations.ErrorKindCorrupt ||
+		back.Cleanup[1].Err == nil || back.Cleanup[1].Files[0] != "cover.jpg" || !back.Cleanup[2].Pending() {
+		t.Errorf("Expected the cleanup to survive round trip, got %+v", back.Cleanup)
+	}
+	if len(back.Options.Routes) != 1 || back.Options.Routes[0] != "invalid=INVALID;keep-path" {
+		t.Errorf("Expected routes to survive round trip, got %v", back.Options.Routes)
+	}
+	if back.Retried != 1 || back.Errored[0].Attempts != 3 || back.Errored[0].Timeout != 45*time.Second {
+		t.Errorf("Expected attempts and timeouts to survive round trip, got %d retried, %+v", back.Retried, back.Errored[0])
+	}
+	if doc.Timing == nil || doc.Timing.Files != 1 || doc.Timing.P50 != 1.5 || doc.Timing.Slowest[0].Phases.Validate != 1.5 {
+		t.Errorf("Unexpected timing document %+v", doc.Timing)
+	}
+	if back.Timing.Files != 1 || back.Timing.Max != 1500*time.Microsecond || back.Invalid[0].Bytes != 2048 ||
+		back.Invalid[0].Phases.Validate != 1500*time.Microsecond {
+		t.Errorf("Expected timing to survive round trip, got %+v", back.Timing)
+	}
+	if back.Options.NumWorkers != 4 || !back.Options.AutoJobs || back.Options.Profile != "archive" || back.Duration != 2*time.Second {
+		t.Errorf("Expected options and duration to survive round trip, got %+v", back)
+	}
+
+	if _, err := NewBatchDocument(&result, true).BatchResult(); err == nil {
+		t.Error("Expected error converting a summary-only document")
+	}
+}
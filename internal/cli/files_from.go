@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+// stdin is the reader used for --files-from -; replaced in tests
+var stdin io.Reader = os.Stdin
+
+// Report categories accepted by --category
+const (
+	CategoryAll     = "all"
+	CategoryValid   = "valid"
+	CategoryInvalid = "invalid"
+	CategoryErrored = "errored"
+	CategoryFailed  = "failed" // invalid and errored
+)
+
+// ReadFilesFrom reads a list of files for a batch run from path, or from
+// stdin when path is "-". The input is either a newline separated list (NUL
+// separated when nulSeparated is set) or a JSON batch report written with
+// --format json, in which case the files in category are selected. Input
+// that is not a JSON object is read as a list, even if it starts with '{'.
+func ReadFilesFrom(path string, nulSeparated bool, category string) ([]string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file list: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(data); isReport(trimmed) {
+		return filesFromReport(trimmed, category)
+	}
+	if category != "" && category != CategoryAll {
+		return nil, fmt.Errorf("--category requires a JSON batch report as input")
+	}
+
+	sep := "\n"
+	if nulSeparated {
+		sep = "\x00"
+	}
+
+	var files []string
+	for _, entry := range strings.Split(string(data), sep) {
+		if !nulSeparated {
+			entry = strings.TrimSpace(entry)
+		}
+		if entry != "" {
+			files = append(files, entry)
+		}
+	}
+
+	return dedupeFiles(files), nil
+}
+
+// isReport reports whether --files-from input is a JSON report rather than
+// a list whose first path starts with '{'. A damaged report still counts
+// when it names its schema version, so its decoding error is shown.
+func isReport(data []byte) bool {
+	if len(data) == 0 || data[0] != '{' {
+		return false
+	}
+	return json.Valid(data) || bytes.Contains(data, []byte(`"schema_version"`))
+}
+
+// filesFromReport selects the files of a category from a saved batch report
+func filesFromReport(data []byte, category string) ([]string, error) {
+	saved, err := ParseSavedReport(data)
+	if err != nil {
+		return nil, err
+	}
+	if saved.Batch == nil {
+		return nil, fmt.Errorf("--files-from report must be a batch report")
+	}
+
+	var groups [][]operations.Result
+	switch strings.ToLower(category) {
+	case "", CategoryAll:
+		groups = [][]operations.Result{saved.Batch.Valid, saved.Batch.Invalid, saved.Batch.Errored}
+	case CategoryValid:
+		groups = [][]operations.Result{saved.Batch.Valid}
+	case CategoryInvalid:
+		groups = [][]operations.Result{saved.Batch.Invalid}
+	case CategoryErrored:
+		groups = [][]operations.Result{saved.Batch.Errored}
+	case CategoryFailed:
+		groups = [][]operations.Result{saved.Batch.Invalid, saved.Batch.Errored}
+	default:
+		return nil, fmt.Errorf("invalid category: %s (valid: all, valid, invalid, errored, failed)", category)
+	}
+
+	var files []string
+	for _, group := range groups {
+		for _, r := range group {
+			files = append(files, r.FilePath)
+		}
+	}
+
+	return dedupeFiles(files), nil
+}
+
+// dedupeFiles removes repeated paths, keeping the first occurrence
+func dedupeFiles(files []string) []string {
+	seen := make(map[string]bool, len(files))
+	unique := make([]string, 0, len(files))
+	for _, f := range files {
+		key := filepath.Clean(f)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, f)
+	}
+	return unique
+}
+
+// commonDir returns the deepest directory containing every file. It is used
+// as the root for cleanup when files do not come from a single directory.
+func commonDir(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	common := filepath.Dir(filepath.Clean(files[0]))
+	for _, f := range files[1:] {
+		d := filepath.Dir(filepath.Clean(f))
+		for common != d && !strings.HasPrefix(d, common+string(filepath.Separator)) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				break
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+// filesFromRoot returns the root of a listed file: the directory the listed
+// files share, or the file's own directory when that is the root of the
+// filesystem or does not contain the file. Cleanups stop at the root and
+// relative routes are resolved under it, so neither may reach across the
+// disk.
+func filesFromRoot(common, file string) string {
+	rel, err := filepath.Rel(common, file)
+	outside := err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	if outside || (filepath.IsAbs(common) && filepath.Dir(common) == common) {
+		return filepath.Dir(filepath.Clean(file))
+	}
+	return common
+}
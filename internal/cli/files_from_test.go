@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func writeFileList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "files")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFilesFrom_Newline(t *testing.T) {
+	path := writeFileList(t, "a.epub\r\n\n  b/c.pdf  \na.epub\n./a.epub\n")
+
+	files, err := ReadFilesFrom(path, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(files, ",") != "a.epub,b/c.pdf" {
+		t.Errorf("ReadFilesFrom() = %v", files)
+	}
+}
+
+func TestReadFilesFrom_NulSeparated(t *testing.T) {
+	path := writeFileList(t, "a b.epub\x00line\nbreak.epub\x00\x00")
+
+	files, err := ReadFilesFrom(path, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0] != "a b.epub" || files[1] != "line\nbreak.epub" {
+		t.Errorf("ReadFilesFrom() = %q", files)
+	}
+}
+
+func TestReadFilesFrom_Stdin(t *testing.T) {
+	orig := stdin
+	defer func() { stdin = orig }()
+	stdin = strings.NewReader("x.epub\ny.epub\n")
+
+	files, err := ReadFilesFrom("-", false, CategoryAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0] != "x.epub" {
+		t.Errorf("ReadFilesFrom() = %v", files)
+	}
+}
+
+func TestReadFilesFrom_BraceNames(t *testing.T) {
+	files, err := ReadFilesFrom(writeFileList(t, "{draft} a.epub\n{}\nb.epub\n"), false, "")
+	if err != nil {
+		t.Fatalf("ReadFilesFrom() error = %v", err)
+	}
+	if got := strings.Join(files, ","); got != "{draft} a.epub,{},b.epub" {
+		t.Errorf("ReadFilesFrom() = %v", files)
+	}
+}
+
+func TestReadFilesFrom_Report(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{
+		{FilePath: "valid.epub", Report: &ebmlib.ValidationReport{FilePath: "valid.epub", IsValid: true}},
+		{FilePath: "invalid.epub", Report: sampleReport("invalid.epub")},
+		{FilePath: "errored.epub", Error: errors.New("unreadable")},
+	}, time.Second, operations.OperationValidate)
+	data, err := json.Marshal(NewBatchDocument(&result, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeFileList(t, string(data))
+
+	tests := []struct {
+		category string
+		want     string
+	}{
+		{"", "valid.epub,invalid.epub,errored.epub"},
+		{CategoryValid, "valid.epub"},
+		{"Invalid", "invalid.epub"},
+		{CategoryErrored, "errored.epub"},
+		{CategoryFailed, "invalid.epub,errored.epub"},
+	}
+	for _, tt := range tests {
+		files, err := ReadFilesFrom(path, false, tt.category)
+		if err != nil {
+			t.Fatalf("category %q: %v", tt.category, err)
+		}
+		if got := strings.Join(files, ","); got != tt.want {
+			t.Errorf("category %q = %s, want %s", tt.category, got, tt.want)
+		}
+	}
+
+	if _, err := ReadFilesFrom(path, false, "broken"); err == nil {
+		t.Error("Expected error for unknown category")
+	}
+}
+
+func TestReadFilesFrom_Errors(t *testing.T) {
+	if _, err := ReadFilesFrom(filepath.Join(t.TempDir(), "missing"), false, ""); err == nil {
+		t.Error("Expected error for missing file")
+	}
+
+	if _, err := ReadFilesFrom(writeFileList(t, "a.epub\n"), false, CategoryInvalid); err == nil {
+		t.Error("Expected error for --category with a plain list")
+	}
+
+	data, err := json.Marshal(NewValidationDocument(sampleReport("a.epub")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFilesFrom(writeFileList(t, string(data)), false, ""); err == nil {
+		t.Error("Expected error for a single-file report")
+	}
+	if _, err := ReadFilesFrom(writeFileList(t, string(data[:len(data)/2])), false, ""); err == nil || !strings.Contains(err.Error(), "report") {
+		t.Errorf("Expected a report error for a truncated report, got %v", err)
+	}
+}
+
+func TestCommonDir(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"/lib/a/x.epub"}, "/lib/a"},
+		{[]string{"/lib/a/x.epub", "/lib/a/b/y.epub"}, "/lib/a"},
+		{[]string{"/lib/a/x.epub", "/lib/ab/y.epub"}, "/lib"},
+		{[]string{"/lib/a/x.epub", "/other/y.epub"}, "/"},
+		{[]string{"a/x.epub", "b/y.epub"}, "."},
+	}
+	for _, tt := range tests {
+		files := make([]string, len(tt.files))
+		for i, f := range tt.files {
+			files[i] = filepath.FromSlash(f)
+		}
+		if got := commonDir(files); got != filepath.FromSlash(tt.want) {
+			t.Errorf("commonDir(%v) = %s, want %s", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestFilesFromRoot(t *testing.T) {
+	tests := []struct {
+		common, file, want string
+	}{
+		{"/lib", "/lib/a/x.epub", "/lib"},
+		{"/", "/lib/a/x.epub", "/lib/a"},
+		{".", "a/x.epub", "."},
+		{".", "../a/x.epub", "../a"},
+	}
+	for _, tt := range tests {
+		common, file := filepath.FromSlash(tt.common), filepath.FromSlash(tt.file)
+		if filepath.IsAbs(tt.common) && !filepath.IsAbs(common) {
+			continue // No such absolute path on this platform
+		}
+		if got := filesFromRoot(common, file); got != filepath.FromSlash(tt.want) {
+			t.Errorf("filesFromRoot(%s, %s) = %s, want %s", tt.common, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestCheckBatchArgs(t *testing.T) {
+	if err := checkBatchArgs([]string{"a", "b"}, &batchFlags{}); err != nil {
+		t.Errorf("checkBatchArgs() error = %v", err)
+	}
+	if err := checkBatchArgs(nil, &batchFlags{}); err == nil {
+		t.Error("Expected error without paths or --files-from")
+	}
+	if err := checkBatchArgs([]string{"books"}, &batchFlags{filesFrom: "-"}); err == nil {
+		t.Error("Expected error combining paths with --files-from")
+	}
+	if err := checkBatchArgs(nil, &batchFlags{filesFrom: "-"}); err != nil {
+		t.Errorf("checkBatchArgs() error = %v", err)
+	}
+}
+
+func TestStartBatchInputs_FilesFrom(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a", "x.epub")
+	b := filepath.Join(root, "b", "y.epub")
+	path := writeFileList(t, a+"\n"+b+"\n")
+
+	// Files are used as listed, without extension or ignore filtering
+	stream, err := startBatchInputs(context.Background(), nil, &batchFlags{filesFrom: path, ignore: []string{"*.epub"}}, operations.OperationValidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, _ := collectBatchInputs(t, stream)
+	if len(inputs) != 2 || inputs[0].Root != root || inputs[1].Path != b {
+		t.Errorf("startBatchInputs() = %+v", inputs)
+	}
+
+	if _, err := startBatchInputs(context.Background(), nil, &batchFlags{filesFrom: writeFileList(t, "\n\n")}, operations.OperationValidate); err == nil {
+		t.Error("Expected error for an empty list")
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// sizeUnits maps size suffixes to multipliers; KB and KiB are both 1024 bytes
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+}
+
+// parseSize parses sizes such as "512", "200KB", "1.5MiB" or "2G" into bytes
+func parseSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	i := strings.IndexFunc(value, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		i = len(value)
+	}
+
+	number, unit := value[:i], strings.ToLower(strings.TrimSpace(value[i:]))
+	n, err := strconv.ParseFloat(number, 64)
+	mult, ok := sizeUnits[unit]
+	if err != nil || !ok || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500KB, 1.5MB, 2GB)", value)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// timeLayouts are the date formats accepted by --newer-than and --older-than
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"}
+
+// parseTimeBound parses a time for --newer-than and --older-than: a
+// duration before now ("36h", "7d", "2w"), a date ("2024-05-01" or RFC 3339)
+// or the path of a file whose modification time is used
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	if d, err := parseAge(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if info, err := os.Stat(value); err == nil {
+		return info.ModTime(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a duration like 7d, a date like 2024-05-01, or a file)", value)
+}
+
+// parseAge parses a Go duration, also accepting day ("d") and week ("w") units
+func parseAge(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(value, suffix); ok {
+			f, err := strconv.ParseFloat(n, 64)
+			if err != nil || f < 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(f * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
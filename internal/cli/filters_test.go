@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"512", 512},
+		{"10KB", 10 << 10},
+		{"10 kb", 10 << 10},
+		{"1.5MiB", 3 << 19},
+		{"2G", 2 << 30},
+		{"7b", 7},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "-1KB", "10XB", "MB", "1.2.3"} {
+		if _, err := parseSize(value); err == nil {
+			t.Errorf("parseSize(%q) expected error", value)
+		}
+	}
+}
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"36h", now.Add(-36 * time.Hour)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)},
+		{"2024-05-01 08:30", time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local)},
+		{"2024-05-01T08:30:00Z", time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.value, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseTimeBound(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+
+	ref := filepath.Join(t.TempDir(), "ref")
+	if err := os.WriteFile(ref, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.Local)
+	if err := os.Chtimes(ref, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := parseTimeBound(ref, now); err != nil || !got.Equal(mtime) {
+		t.Errorf("parseTimeBound(file) = %v, %v, want %v", got, err, mtime)
+	}
+
+	if _, err := parseTimeBound("last tuesday", now); err == nil {
+		t.Error("Expected error for unparseable time")
+	}
+}
+
+func TestDiscoveryOptions(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	flags := &batchFlags{maxDepth: -1, minSize: "1KB", maxSize: "2MB", newerThan: "1d", olderThan: "2024-05-10"}
+
+	opts, err := discoveryOptions(nil, flags, "validate", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.MinSize != 1<<10 || opts.MaxSize != 2<<20 || !opts.NewerThan.Equal(now.AddDate(0, 0, -1)) || opts.OlderThan.IsZero() {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+
+	for _, bad := range []*batchFlags{{minSize: "x"}, {maxSize: "x"}, {newerThan: "x"}, {olderThan: "x"}} {
+		if _, err := discoveryOptions(nil, bad, "validate", now); err == nil {
+			t.Errorf("Expected error for %+v", bad)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -20,6 +21,7 @@ const (
 	FormatText OutputFormat = iota
 	FormatJSON
 	FormatMarkdown
+	FormatTemplate
 )
 
 // ParseFormat converts a string to OutputFormat
@@ -31,8 +33,10 @@ func ParseFormat(s string) (OutputFormat, error) {
 		return FormatJSON, nil
 	case "markdown", "md":
 		return FormatMarkdown, nil
+	case "template", "tmpl":
+		return FormatTemplate, nil
 	default:
-		return FormatText, fmt.Errorf("invalid format: %s (valid: text, json, markdown)", s)
+		return FormatText, fmt.Errorf("invalid format: %s (valid: text, json, markdown, template)", s)
 	}
 }
 
@@ -59,6 +63,7 @@ func NewFormatter(format OutputFormat, colorEnabled bool) Formatter {
 // TextFormatter formats output as human-readable text
 type TextFormatter struct {
 	ColorEnabled bool
+	ContextLines int // Lines of source shown around each issue location (0 = off)
 }
 
 func (f *TextFormatter) FormatValidation(report *ebmlib.ValidationReport) string {
@@ -85,11 +90,13 @@ func (f *TextFormatter) FormatValidation(report *ebmlib.ValidationReport) string
 	b.WriteString(f.field("Info", fmt.Sprintf("%d", report.InfoCount())))
 	b.WriteString("\n")
 
-	// Issues
+	// Issues; the container is opened once for all their source context
+	snippets := operations.NewSnippetReader(report.FilePath)
+	defer snippets.Close()
 	if len(report.Errors) > 0 {
 		b.WriteString(f.subheader("Errors"))
 		for _, err := range report.Errors {
-			b.WriteString(f.formatIssue(err, "error"))
+			b.WriteString(f.formatIssue(snippets, err, "error"))
 		}
 		b.WriteString("\n")
 	}
@@ -97,7 +104,7 @@ func (f *TextFormatter) FormatValidation(report *ebmlib.ValidationReport) string
 	if len(report.Warnings) > 0 {
 		b.WriteString(f.subheader("Warnings"))
 		for _, warn := range report.Warnings {
-			b.WriteString(f.formatIssue(warn, "warning"))
+			b.WriteString(f.formatIssue(snippets, warn, "warning"))
 		}
 		b.WriteString("\n")
 	}
@@ -105,7 +112,7 @@ func (f *TextFormatter) FormatValidation(report *ebmlib.ValidationReport) string
 	if len(report.Info) > 0 {
 		b.WriteString(f.subheader("Information"))
 		for _, info := range report.Info {
-			b.WriteString(f.formatIssue(info, "info"))
+			b.WriteString(f.formatIssue(snippets, info, "info"))
 		}
 		b.WriteString("\n")
 	}
@@ -157,7 +164,7 @@ func (f *TextFormatter) FormatRepair(result *ebmlib.RepairResult, report *ebmlib
 	return b.String()
 }
 
-func (f *TextFormatter) formatIssue(issue ebmlib.ValidationError, issueType string) string {
+func (f *TextFormatter) formatIssue(snippets *operations.SnippetReader, issue ebmlib.ValidationError, issueType string) string {
 	var icon, prefix string
 	if f.ColorEnabled {
 		switch issueType {
@@ -184,6 +191,20 @@ func (f *TextFormatter) formatIssue(issue ebmlib.ValidationError, issueType stri
 			location += fmt.Sprintf(":%d", issue.Location.Line)
 		}
 		line += f.muted(location) + "\n"
+
+		if snippet := readIssueSnippet(snippets, issue, f.ContextLines); snippet != nil {
+			for i := range snippet.Lines {
+				text := "      " + snippet.FormatLine(i)
+				if snippet.LineNumber(i) == snippet.Line {
+					if f.ColorEnabled {
+						text = color.RedString("%s", text)
+					}
+				} else {
+					text = f.muted(text)
+				}
+				line += text + "\n"
+			}
+		}
 	}
 
 	return line
@@ -231,6 +252,154 @@ func (f *TextFormatter) muted(s string) string {
 	return s
 }
 
+// formatRoots renders per-root counts when a batch spans several roots
+func (f *TextFormatter) formatRoots(roots []operations.RootStat) string {
+	if len(roots) < 2 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(f.subheader("By Root"))
+	for _, root := range roots {
+		b.WriteString(fmt.Sprintf("  %s\n", root.Root))
+		b.WriteString(f.muted(fmt.Sprintf("      %d file(s): %d valid, %d invalid, %d errored",
+			root.Total, root.Valid, root.Invalid, root.Errored)) + "\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatIncomplete warns that a batch was interrupted or that file discovery
+// failed before every file was found
+func (f *TextFormatter) formatIncomplete(result *operations.BatchResult) string {
+	var b strings.Builder
+	if result.Incomplete {
+		b.WriteString(f.error(fmt.Sprintf("⚠ Interrupted: %d file(s) found were not processed", result.Unprocessed)) + "\n\n")
+	}
+	if result.ScanError != nil {
+		b.WriteString(f.error(fmt.Sprintf("⚠ Discovery stopped early: %s\n  Files not found by then were not processed", result.ScanError)) + "\n\n")
+	}
+	return b.String()
+}
+
+// formatSymlinks lists the symbolic links followed or skipped during discovery
+func (f *TextFormatter) formatSymlinks(links []operations.SymlinkEvent) string {
+	if len(links) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(f.subheader("Symlinks"))
+	for _, link := range links {
+		if link.Followed {
+			b.WriteString(fmt.Sprintf("  → %s -> %s\n", link.Path, link.Target))
+			continue
+		}
+		b.WriteString(f.muted(fmt.Sprintf("  ⊘ %s: skipped, %s", link.Path, link.Reason)) + "\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatRouted lists the files moved by routing rules
+func (f *TextFormatter) formatRouted(files []operations.RoutedFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(f.subheader("Moved Files"))
+	for _, file := range files {
+		switch {
+		case file.Skipped:
+			b.WriteString(f.muted(fmt.Sprintf("  ⊘ %s: not moved, %s exists", file.Path, file.Dest)) + "\n")
+		case file.Err != nil:
+			b.WriteString(f.error(fmt.Sprintf("  ✗ %s: not moved: %v", file.Path, file.Err)) + "\n")
+		default:
+			b.WriteString(fmt.Sprintf("  → %s -> %s\n", file.Path, file.Dest))
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatCleanup lists the deletions and directory removals of a batch,
+// and the moves that were not applied; moves made are in formatRouted
+func (f *TextFormatter) formatCleanup(plan operations.CleanupPlan) string {
+	ops := reportedCleanup(plan)
+	if len(ops) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(f.subheader("Cleanup"))
+	for _, op := range ops {
+		switch {
+		case op.Err != nil:
+			b.WriteString(f.error(fmt.Sprintf("  ✗ %s: %s failed: %v", op.Path, cleanupAction(op), op.Err)) + "\n")
+		case op.Pending():
+			b.WriteString(f.muted(fmt.Sprintf("  · %s: %s not applied", op.Path, cleanupAction(op))) + "\n")
+		case op.Trashed != "":
+			b.WriteString(fmt.Sprintf("  ✓ %s: %s, in the trash as %s\n", op.Path, cleanupAction(op), op.Trashed))
+		default:
+			b.WriteString(fmt.Sprintf("  ✓ %s: %s\n", op.Path, cleanupAction(op)))
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatTopIssues renders the most common issue codes of a batch
+func (f *TextFormatter) formatTopIssues(stats []operations.IssueStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(f.subheader("Top Issues"))
+	for i, stat := range topIssues(stats) {
+		code := fmt.Sprintf("[%s]", stat.Code)
+		if f.ColorEnabled {
+			code = severityColorString(stat.Severity, code)
+		}
+		b.WriteString(fmt.Sprintf("  %2d. %s %s\n", i+1, code, stat.Message))
+		b.WriteString(f.muted(fmt.Sprintf("      %d occurrence(s) in %d file(s), e.g. %s",
+			stat.Occurrences, stat.Files, exampleNames(stat.Examples))) + "\n")
+	}
+	if more := len(stats) - maxTopIssuesShown; more > 0 {
+		b.WriteString(f.muted(fmt.Sprintf("  … and %d more code(s)", more)) + "\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatTiming renders throughput, percentiles and the slowest files
+func (f *TextFormatter) formatTiming(stats operations.TimingStats) string {
+	if stats.Files == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(f.subheader("Throughput"))
+	b.WriteString(f.field("Rate", throughput(stats)))
+	b.WriteString(f.field("Per File", percentiles(stats)))
+	b.WriteString("\n")
+
+	b.WriteString(f.subheader("Slowest Files"))
+	for i, t := range stats.Slowest {
+		b.WriteString(fmt.Sprintf("  %2d. %s %s\n", i+1, filepath.Base(t.FilePath), roundDuration(t.Duration)))
+		b.WriteString(f.muted("      "+timingDetail(t)) + "\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
 func (f *TextFormatter) FormatBatchValidation(result *operations.BatchResult, summaryOnly bool) string {
 	var b strings.Builder
 
@@ -244,9 +413,18 @@ func (f *TextFormatter) FormatBatchValidation(result *operations.BatchResult, su
 	b.WriteString(f.field("Valid", fmt.Sprintf("%d", len(result.Valid))))
 	b.WriteString(f.field("Invalid", fmt.Sprintf("%d", len(result.Invalid))))
 	if len(result.Errored) > 0 {
-		b.WriteString(f.field("System Errors", fmt.Sprintf("%d", len(result.Errored))))
+		b.WriteString(f.field("System Errors", fmt.Sprintf("%d%s", len(result.Errored), kindCounts(result.ErrorKinds))))
+	}
+	if result.Retried > 0 {
+		b.WriteString(f.field("Retried", fmt.Sprintf("%d", result.Retried)))
 	}
 	b.WriteString(f.field("Duration", result.Duration.Round(time.Millisecond).String()))
+	if result.Options.AutoJobs {
+		b.WriteString(f.field("Workers", fmt.Sprintf("%d (auto)", result.Options.NumWorkers)))
+	}
+	if result.Options.Profile != "" {
+		b.WriteString(f.field("Profile", result.Options.Profile))
+	}
 	b.WriteString("\n")
 
 	// Overall status
@@ -260,6 +438,14 @@ func (f *TextFormatter) FormatBatchValidation(result *operations.BatchResult, su
 		}
 	}
 	b.WriteString("\n\n")
+	b.WriteString(f.formatIncomplete(result))
+
+	b.WriteString(f.formatRoots(result.Roots))
+	b.WriteString(f.formatSymlinks(result.Symlinks))
+	b.WriteString(f.formatRouted(result.Routed))
+	b.WriteString(f.formatCleanup(result.Cleanup))
+	b.WriteString(f.formatTopIssues(result.TopIssues))
+	b.WriteString(f.formatTiming(result.Timing))
 
 	// Problematic files (Invalid or Errored)
 	if !summaryOnly && (len(result.Invalid) > 0 || len(result.Errored) > 0) {
@@ -268,7 +454,8 @@ func (f *TextFormatter) FormatBatchValidation(result *operations.BatchResult, su
 		// List system errors first
 		for _, r := range result.Errored {
 			fileName := filepath.Base(r.FilePath)
-			b.WriteString(f.error(fmt.Sprintf("  ⚠ %s: System Error: %s\n", fileName, r.Error)))
+			b.WriteString(f.error(fmt.Sprintf("  ⚠ %s: System Error%s: %s%s\n", fileName, kindTag(r), r.Error, attemptNote(r))))
+			b.WriteString(f.panicStack(r))
 		}
 
 		// List invalid files
@@ -300,9 +487,18 @@ func (f *TextFormatter) FormatBatchRepair(result *operations.BatchResult, summar
 		b.WriteString(f.field("No-Op Repairs", fmt.Sprintf("%d", result.RepairsNoOp)))
 	}
 	if len(result.Errored) > 0 {
-		b.WriteString(f.field("System Errors", fmt.Sprintf("%d", len(result.Errored))))
+		b.WriteString(f.field("System Errors", fmt.Sprintf("%d%s", len(result.Errored), kindCounts(result.ErrorKinds))))
+	}
+	if result.Retried > 0 {
+		b.WriteString(f.field("Retried", fmt.Sprintf("%d", result.Retried)))
 	}
 	b.WriteString(f.field("Duration", result.Duration.Round(time.Millisecond).String()))
+	if result.Options.AutoJobs {
+		b.WriteString(f.field("Workers", fmt.Sprintf("%d (auto)", result.Options.NumWorkers)))
+	}
+	if result.Options.Profile != "" {
+		b.WriteString(f.field("Profile", result.Options.Profile))
+	}
 	b.WriteString("\n")
 
 	// Overall status
@@ -316,6 +512,14 @@ func (f *TextFormatter) FormatBatchRepair(result *operations.BatchResult, summar
 		}
 	}
 	b.WriteString("\n\n")
+	b.WriteString(f.formatIncomplete(result))
+
+	b.WriteString(f.formatRoots(result.Roots))
+	b.WriteString(f.formatSymlinks(result.Symlinks))
+	b.WriteString(f.formatRouted(result.Routed))
+	b.WriteString(f.formatCleanup(result.Cleanup))
+	b.WriteString(f.formatTopIssues(result.TopIssues))
+	b.WriteString(f.formatTiming(result.Timing))
 
 	// Problematic files
 	if !summaryOnly && (len(result.Invalid) > 0 || len(result.Errored) > 0) {
@@ -324,7 +528,8 @@ func (f *TextFormatter) FormatBatchRepair(result *operations.BatchResult, summar
 		// List system errors first
 		for _, r := range result.Errored {
 			fileName := filepath.Base(r.FilePath)
-			b.WriteString(f.error(fmt.Sprintf("  ⚠ %s: System Error: %s\n", fileName, r.Error)))
+			b.WriteString(f.error(fmt.Sprintf("  ⚠ %s: System Error%s: %s%s\n", fileName, kindTag(r), r.Error, attemptNote(r))))
+			b.WriteString(f.panicStack(r))
 		}
 
 		// List failed repairs
@@ -346,79 +551,33 @@ func (f *TextFormatter) FormatBatchRepair(result *operations.BatchResult, summar
 type JSONFormatter struct{}
 
 func (f *JSONFormatter) FormatValidation(report *ebmlib.ValidationReport) string {
-	data, err := json.MarshalIndent(report, "", "  ")
-	if err != nil {
-		return fmt.Sprintf(`{"error": "failed to marshal report: %s"}`, err)
-	}
-	return string(data)
+	return f.marshal(NewValidationDocument(report), "report")
 }
 
 func (f *JSONFormatter) FormatRepair(result *ebmlib.RepairResult, report *ebmlib.ValidationReport) string {
-	output := map[string]interface{}{
-		"success":         result.Success,
-		"actions_applied": result.ActionsApplied,
-	}
-
-	if result.BackupPath != "" {
-		output["backup_path"] = result.BackupPath
-	}
-
-	if result.Error != nil {
-		output["error"] = result.Error.Error()
-	}
-
-	if report != nil {
-		output["validation_report"] = report
-	}
-
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return fmt.Sprintf(`{"error": "failed to marshal result: %s"}`, err)
-	}
-	return string(data)
+	return f.marshal(NewRepairDocument(result, report), "result")
 }
 
 func (f *JSONFormatter) FormatBatchValidation(result *operations.BatchResult, summaryOnly bool) string {
-	output := map[string]interface{}{
-		"total":      result.Total,
-		"successful": len(result.Successful),
-		"failed":     len(result.Failed),
-		"no_op":      result.RepairsNoOp,
-		"duration":   result.Duration.Milliseconds(),
-	}
-
-	if !summaryOnly {
-		output["results"] = result
-	}
-
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return fmt.Sprintf(`{"error": "failed to marshal batch result: %s"}`, err)
-	}
-	return string(data)
+	return f.marshal(NewBatchDocument(result, summaryOnly), "batch result")
 }
 
 func (f *JSONFormatter) FormatBatchRepair(result *operations.BatchResult, summaryOnly bool) string {
-	output := map[string]interface{}{
-		"total":      result.Total,
-		"successful": len(result.Successful),
-		"failed":     len(result.Failed),
-		"duration":   result.Duration.Milliseconds(),
-	}
-
-	if !summaryOnly {
-		output["results"] = result
-	}
+	return f.marshal(NewBatchDocument(result, summaryOnly), "batch result")
+}
 
-	data, err := json.MarshalIndent(output, "", "  ")
+func (f *JSONFormatter) marshal(doc interface{}, what string) string {
+	data, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
-		return fmt.Sprintf(`{"error": "failed to marshal batch result: %s"}`, err)
+		return fmt.Sprintf(`{"error": "failed to marshal %s: %s"}`, what, err)
 	}
 	return string(data)
 }
 
 // MarkdownFormatter formats output as GitHub-flavored Markdown
-type MarkdownFormatter struct{}
+type MarkdownFormatter struct {
+	ContextLines int // Lines of source shown around each issue location (0 = off)
+}
 
 func (f *MarkdownFormatter) FormatValidation(report *ebmlib.ValidationReport) string {
 	var b strings.Builder
@@ -442,11 +601,13 @@ func (f *MarkdownFormatter) FormatValidation(report *ebmlib.ValidationReport) st
 	b.WriteString(fmt.Sprintf("| Warnings | %d |\n", report.WarningCount()))
 	b.WriteString(fmt.Sprintf("| Info | %d |\n\n", report.InfoCount()))
 
-	// Issues
+	// Issues; the container is opened once for all their source context
+	snippets := operations.NewSnippetReader(report.FilePath)
+	defer snippets.Close()
 	if len(report.Errors) > 0 {
 		b.WriteString("## Errors\n\n")
 		for _, err := range report.Errors {
-			b.WriteString(f.formatIssue(err))
+			b.WriteString(f.formatIssue(snippets, err))
 		}
 		b.WriteString("\n")
 	}
@@ -454,7 +615,7 @@ func (f *MarkdownFormatter) FormatValidation(report *ebmlib.ValidationReport) st
 	if len(report.Warnings) > 0 {
 		b.WriteString("## Warnings\n\n")
 		for _, warn := range report.Warnings {
-			b.WriteString(f.formatIssue(warn))
+			b.WriteString(f.formatIssue(snippets, warn))
 		}
 		b.WriteString("\n")
 	}
@@ -462,7 +623,7 @@ func (f *MarkdownFormatter) FormatValidation(report *ebmlib.ValidationReport) st
 	if len(report.Info) > 0 {
 		b.WriteString("## Information\n\n")
 		for _, info := range report.Info {
-			b.WriteString(f.formatIssue(info))
+			b.WriteString(f.formatIssue(snippets, info))
 		}
 		b.WriteString("\n")
 	}
@@ -509,7 +670,7 @@ func (f *MarkdownFormatter) FormatRepair(result *ebmlib.RepairResult, report *eb
 	return b.String()
 }
 
-func (f *MarkdownFormatter) formatIssue(issue ebmlib.ValidationError) string {
+func (f *MarkdownFormatter) formatIssue(snippets *operations.SnippetReader, issue ebmlib.ValidationError) string {
 	line := fmt.Sprintf("- **[%s]** %s", issue.Code, issue.Message)
 
 	if issue.Location != nil {
@@ -519,11 +680,197 @@ func (f *MarkdownFormatter) formatIssue(issue ebmlib.ValidationError) string {
 		}
 		location += "`"
 		line += fmt.Sprintf("\n  - Location: %s", location)
+
+		if snippet := readIssueSnippet(snippets, issue, f.ContextLines); snippet != nil {
+			line += fmt.Sprintf("\n\n    ```%s\n", snippetLanguage(snippet.Entry))
+			for i := range snippet.Lines {
+				line += "    " + snippet.FormatLine(i) + "\n"
+			}
+			line += "    ```\n"
+		}
 	}
 
 	return line + "\n"
 }
 
+// readIssueSnippet returns the source context for an issue, or nil when
+// context is disabled or the location cannot be read from the container
+func readIssueSnippet(snippets *operations.SnippetReader, issue ebmlib.ValidationError, contextLines int) *operations.Snippet {
+	if contextLines <= 0 || issue.Location == nil || issue.Location.Line <= 0 {
+		return nil
+	}
+	snippet, err := snippets.Read(issue.Location.File, issue.Location.Line, contextLines)
+	if err != nil {
+		return nil
+	}
+	return snippet
+}
+
+// snippetLanguage returns the fenced code block language for a container entry
+func snippetLanguage(entry string) string {
+	switch strings.ToLower(filepath.Ext(entry)) {
+	case ".xhtml", ".html", ".htm":
+		return "html"
+	case ".css":
+		return "css"
+	default:
+		return "xml"
+	}
+}
+
+// formatRoots renders per-root counts as a table when a batch spans several roots
+func (f *MarkdownFormatter) formatRoots(roots []operations.RootStat) string {
+	if len(roots) < 2 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## By Root\n\n")
+	b.WriteString("| Root | Files | Valid | Invalid | Errored |\n")
+	b.WriteString("|------|-------|-------|---------|---------|\n")
+	for _, root := range roots {
+		b.WriteString(fmt.Sprintf("| `%s` | %d | %d | %d | %d |\n",
+			root.Root, root.Total, root.Valid, root.Invalid, root.Errored))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatIncomplete warns that a batch was interrupted or that file discovery
+// failed before every file was found
+func (f *MarkdownFormatter) formatIncomplete(result *operations.BatchResult) string {
+	var b strings.Builder
+	if result.Incomplete {
+		b.WriteString(fmt.Sprintf("**Warning:** ⚠️ Interrupted: %d file(s) found were not processed.\n\n", result.Unprocessed))
+	}
+	if result.ScanError != nil {
+		b.WriteString(fmt.Sprintf("**Warning:** ⚠️ Discovery stopped early: %s. Files not found by then were not processed.\n\n", result.ScanError))
+	}
+	return b.String()
+}
+
+// formatSymlinks lists the symbolic links followed or skipped during discovery
+func (f *MarkdownFormatter) formatSymlinks(links []operations.SymlinkEvent) string {
+	if len(links) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Symlinks\n\n")
+	b.WriteString("| Link | Target | Status |\n")
+	b.WriteString("|------|--------|--------|\n")
+	for _, link := range links {
+		status := "followed"
+		if !link.Followed {
+			status = "skipped: " + link.Reason
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", link.Path, link.Target, status))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatRouted lists the files moved by routing rules
+func (f *MarkdownFormatter) formatRouted(files []operations.RoutedFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Moved Files\n\n")
+	b.WriteString("| File | Outcome | Destination | Status |\n")
+	b.WriteString("|------|---------|-------------|--------|\n")
+	for _, file := range files {
+		status := "moved"
+		switch {
+		case file.Skipped:
+			status = "skipped: destination exists"
+		case file.Err != nil:
+			status = "failed: " + file.Err.Error()
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | %s | `%s` | %s |\n", file.Path, file.Outcome, file.Dest, status))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatCleanup lists the deletions and directory removals of a batch,
+// and the moves that were not applied
+func (f *MarkdownFormatter) formatCleanup(plan operations.CleanupPlan) string {
+	ops := reportedCleanup(plan)
+	if len(ops) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Cleanup\n\n")
+	b.WriteString("| Path | Action | Status |\n")
+	b.WriteString("|------|--------|--------|\n")
+	for _, op := range ops {
+		status := "done"
+		switch {
+		case op.Err != nil:
+			status = "failed: " + op.Err.Error()
+		case op.Pending():
+			status = "not applied"
+		case op.Trashed != "":
+			status = fmt.Sprintf("in the trash as `%s`", op.Trashed)
+		}
+		b.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", op.Path, cleanupAction(op), status))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatTopIssues renders the most common issue codes of a batch as a ranked table
+func (f *MarkdownFormatter) formatTopIssues(stats []operations.IssueStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Top Issues\n\n")
+	b.WriteString("| # | Code | Severity | Occurrences | Files | Examples |\n")
+	b.WriteString("|---|------|----------|-------------|-------|----------|\n")
+	for i, stat := range topIssues(stats) {
+		b.WriteString(fmt.Sprintf("| %d | `%s` | %s | %d | %d | %s |\n",
+			i+1, stat.Code, severityName(stat.Severity), stat.Occurrences, stat.Files, exampleNames(stat.Examples)))
+	}
+	if more := len(stats) - maxTopIssuesShown; more > 0 {
+		b.WriteString(fmt.Sprintf("\n_… and %d more code(s)_\n", more))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatTiming renders throughput, percentiles and the slowest files
+func (f *MarkdownFormatter) formatTiming(stats operations.TimingStats) string {
+	if stats.Files == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("## Throughput\n\n")
+	b.WriteString(fmt.Sprintf("**Rate:** %s  \n", throughput(stats)))
+	b.WriteString(fmt.Sprintf("**Per File:** %s\n\n", percentiles(stats)))
+
+	b.WriteString("## Slowest Files\n\n")
+	b.WriteString("| # | File | Time | Details |\n")
+	b.WriteString("|---|------|------|---------|\n")
+	for i, t := range stats.Slowest {
+		b.WriteString(fmt.Sprintf("| %d | `%s` | %s | %s |\n",
+			i+1, filepath.Base(t.FilePath), roundDuration(t.Duration), timingDetail(t)))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
 func (f *MarkdownFormatter) FormatBatchValidation(result *operations.BatchResult, summaryOnly bool) string {
 	var b strings.Builder
 
@@ -537,10 +884,23 @@ func (f *MarkdownFormatter) FormatBatchValidation(result *operations.BatchResult
 	b.WriteString(fmt.Sprintf("| Total Files | %d |\n", result.Total))
 	b.WriteString(fmt.Sprintf("| Successful | %d |\n", len(result.Successful)))
 	b.WriteString(fmt.Sprintf("| Failed | %d |\n", len(result.Failed)))
+	if len(result.Errored) > 0 {
+		b.WriteString(fmt.Sprintf("| System Errors | %d%s |\n", len(result.Errored), kindCounts(result.ErrorKinds)))
+	}
+	if result.Retried > 0 {
+		b.WriteString(fmt.Sprintf("| Retried | %d |\n", result.Retried))
+	}
 	if result.RepairsNoOp > 0 {
 		b.WriteString(fmt.Sprintf("| No-Op Repairs | %d |\n", result.RepairsNoOp))
 	}
-	b.WriteString(fmt.Sprintf("| Duration | %s |\n\n", result.Duration.Round(time.Millisecond)))
+	b.WriteString(fmt.Sprintf("| Duration | %s |\n", result.Duration.Round(time.Millisecond)))
+	if result.Options.AutoJobs {
+		b.WriteString(fmt.Sprintf("| Workers | %d (auto) |\n", result.Options.NumWorkers))
+	}
+	if result.Options.Profile != "" {
+		b.WriteString(fmt.Sprintf("| Profile | %s |\n", result.Options.Profile))
+	}
+	b.WriteString("\n")
 
 	// Status
 	if len(result.Failed) == 0 {
@@ -548,6 +908,14 @@ func (f *MarkdownFormatter) FormatBatchValidation(result *operations.BatchResult
 	} else {
 		b.WriteString(fmt.Sprintf("**Status:** ❌ %d file(s) have errors\n\n", len(result.Failed)))
 	}
+	b.WriteString(f.formatIncomplete(result))
+
+	b.WriteString(f.formatRoots(result.Roots))
+	b.WriteString(f.formatSymlinks(result.Symlinks))
+	b.WriteString(f.formatRouted(result.Routed))
+	b.WriteString(f.formatCleanup(result.Cleanup))
+	b.WriteString(f.formatTopIssues(result.TopIssues))
+	b.WriteString(f.formatTiming(result.Timing))
 
 	// Failed files
 	if !summaryOnly && len(result.Failed) > 0 {
@@ -555,7 +923,8 @@ func (f *MarkdownFormatter) FormatBatchValidation(result *operations.BatchResult
 		for _, r := range result.Failed {
 			fileName := filepath.Base(r.FilePath)
 			if r.Error != nil {
-				b.WriteString(fmt.Sprintf("- ❌ **%s**: %s\n", fileName, r.Error))
+				b.WriteString(fmt.Sprintf("- ❌ **%s**%s: %s%s\n", fileName, kindTag(r), r.Error, attemptNote(r)))
+				b.WriteString(f.panicStack(r))
 			} else if r.Report != nil && !r.Report.IsValid {
 				b.WriteString(fmt.Sprintf("- ❌ **%s**: %d errors\n", fileName, r.Report.ErrorCount()))
 			}
@@ -579,7 +948,20 @@ func (f *MarkdownFormatter) FormatBatchRepair(result *operations.BatchResult, su
 	b.WriteString(fmt.Sprintf("| Total Files | %d |\n", result.Total))
 	b.WriteString(fmt.Sprintf("| Successful | %d |\n", len(result.Successful)))
 	b.WriteString(fmt.Sprintf("| Failed | %d |\n", len(result.Failed)))
-	b.WriteString(fmt.Sprintf("| Duration | %s |\n\n", result.Duration.Round(time.Millisecond)))
+	if len(result.Errored) > 0 {
+		b.WriteString(fmt.Sprintf("| System Errors | %d%s |\n", len(result.Errored), kindCounts(result.ErrorKinds)))
+	}
+	if result.Retried > 0 {
+		b.WriteString(fmt.Sprintf("| Retried | %d |\n", result.Retried))
+	}
+	b.WriteString(fmt.Sprintf("| Duration | %s |\n", result.Duration.Round(time.Millisecond)))
+	if result.Options.AutoJobs {
+		b.WriteString(fmt.Sprintf("| Workers | %d (auto) |\n", result.Options.NumWorkers))
+	}
+	if result.Options.Profile != "" {
+		b.WriteString(fmt.Sprintf("| Profile | %s |\n", result.Options.Profile))
+	}
+	b.WriteString("\n")
 
 	// Status
 	if len(result.Failed) == 0 {
@@ -587,6 +969,14 @@ func (f *MarkdownFormatter) FormatBatchRepair(result *operations.BatchResult, su
 	} else {
 		b.WriteString(fmt.Sprintf("**Status:** ❌ %d file(s) failed to repair\n\n", len(result.Failed)))
 	}
+	b.WriteString(f.formatIncomplete(result))
+
+	b.WriteString(f.formatRoots(result.Roots))
+	b.WriteString(f.formatSymlinks(result.Symlinks))
+	b.WriteString(f.formatRouted(result.Routed))
+	b.WriteString(f.formatCleanup(result.Cleanup))
+	b.WriteString(f.formatTopIssues(result.TopIssues))
+	b.WriteString(f.formatTiming(result.Timing))
 
 	// Failed files
 	if !summaryOnly && len(result.Failed) > 0 {
@@ -594,7 +984,8 @@ func (f *MarkdownFormatter) FormatBatchRepair(result *operations.BatchResult, su
 		for _, r := range result.Failed {
 			fileName := filepath.Base(r.FilePath)
 			if r.Error != nil {
-				b.WriteString(fmt.Sprintf("- ❌ **%s**: %s\n", fileName, r.Error))
+				b.WriteString(fmt.Sprintf("- ❌ **%s**%s: %s%s\n", fileName, kindTag(r), r.Error, attemptNote(r)))
+				b.WriteString(f.panicStack(r))
 			} else if r.Repair != nil && !r.Repair.Success {
 				errMsg := "unknown error"
 				if r.Repair.Error != nil {
@@ -609,8 +1000,183 @@ func (f *MarkdownFormatter) FormatBatchRepair(result *operations.BatchResult, su
 	return b.String()
 }
 
+// kindCounts formats error kind counts as " (timeout 2, io 1)"
+func kindCounts(stats []operations.KindStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	parts := make([]string, len(stats))
+	for i, stat := range stats {
+		parts[i] = fmt.Sprintf("%s %d", stat.Kind, stat.Count)
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// panicStack lists the stack of a file whose processing panicked, indented
+// below its error
+func (f *TextFormatter) panicStack(r operations.Result) string {
+	stack := resultStack(r)
+	if stack == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(stack, "\n") {
+		b.WriteString(f.muted("      "+line) + "\n")
+	}
+	return b.String()
+}
+
+// panicStack shows the stack of a file whose processing panicked in a
+// collapsed block inside its list item
+func (f *MarkdownFormatter) panicStack(r operations.Result) string {
+	stack := resultStack(r)
+	if stack == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n  <details><summary>Stack trace</summary>\n\n  ```text\n")
+	for _, line := range strings.Split(stack, "\n") {
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("  ```\n\n  </details>\n\n")
+	return b.String()
+}
+
+// resultStack returns the stack of a result whose error is a panic
+func resultStack(r operations.Result) string {
+	var panicErr *operations.PanicError
+	if !errors.As(r.Error, &panicErr) {
+		return ""
+	}
+	return strings.TrimRight(panicErr.Stack, "\n")
+}
+
+// kindTag formats a result's error kind as " [timeout]"
+func kindTag(r operations.Result) string {
+	if r.ErrorKind == "" {
+		return ""
+	}
+	return " [" + string(r.ErrorKind) + "]"
+}
+
+// attemptNote formats retries and the timeout of a timed-out file as
+// " (3 attempts, timeout 2m0s)"
+func attemptNote(r operations.Result) string {
+	var parts []string
+	if r.Attempts > 1 {
+		parts = append(parts, fmt.Sprintf("%d attempts", r.Attempts))
+	}
+	if r.ErrorKind == operations.ErrorKindTimeout && r.Timeout > 0 {
+		parts = append(parts, "timeout "+r.Timeout.String())
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// throughput formats files and megabytes per second as "12.5 files/s, 48.2 MB/s"
+func throughput(stats operations.TimingStats) string {
+	return fmt.Sprintf("%.1f files/s, %.1f MB/s", stats.FilesPerSecond, stats.BytesPerSecond/(1<<20))
+}
+
+// percentiles formats per-file time percentiles as "p50 120ms, p90 1.2s, ..."
+func percentiles(stats operations.TimingStats) string {
+	return fmt.Sprintf("p50 %s, p90 %s, p99 %s, max %s",
+		roundDuration(stats.P50), roundDuration(stats.P90), roundDuration(stats.P99), roundDuration(stats.Max))
+}
+
+// timingDetail formats a file's size and phase times as
+// "2.1 MB; validate 1.2s, repair 300ms"
+func timingDetail(t operations.FileTiming) string {
+	detail := fmt.Sprintf("%.1f MB", float64(t.Bytes)/(1<<20))
+	var phases []string
+	for _, phase := range []struct {
+		name string
+		d    time.Duration
+	}{{"validate", t.Phases.Validate}, {"preview", t.Phases.Preview}, {"repair", t.Phases.Repair}} {
+		if phase.d > 0 {
+			phases = append(phases, phase.name+" "+roundDuration(phase.d))
+		}
+	}
+	if len(phases) > 0 {
+		detail += "; " + strings.Join(phases, ", ")
+	}
+	return detail
+}
+
+// roundDuration rounds to milliseconds, keeping sub-millisecond times visible
+func roundDuration(d time.Duration) string {
+	if d < time.Millisecond {
+		return d.Round(time.Microsecond).String()
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// maxTopIssuesShown limits the Top Issues section of text and markdown reports
+const maxTopIssuesShown = 10
+
+func topIssues(stats []operations.IssueStat) []operations.IssueStat {
+	if len(stats) > maxTopIssuesShown {
+		return stats[:maxTopIssuesShown]
+	}
+	return stats
+}
+
+// exampleNames joins the base names of example files
+func exampleNames(paths []string) string {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = filepath.Base(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+// severityColorString colours text according to an ebmlib severity
+func severityColorString(severity ebmlib.Severity, text string) string {
+	switch severity {
+	case ebmlib.SeverityError:
+		return color.RedString("%s", text)
+	case ebmlib.SeverityWarning:
+		return color.YellowString("%s", text)
+	default:
+		return color.CyanString("%s", text)
+	}
+}
+
 // WriteOutput writes formatted output to a writer or file
 func WriteOutput(w io.Writer, content string) error {
 	_, err := fmt.Fprint(w, content)
 	return err
 }
+
+// reportedCleanup returns the cleanup operations reported apart from the
+// moved files: deletions, directory removals and moves not applied
+func reportedCleanup(plan operations.CleanupPlan) []operations.CleanupOp {
+	var ops []operations.CleanupOp
+	for _, op := range plan {
+		if op.Action != operations.CleanupMove || op.Pending() {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// cleanupAction describes what a cleanup operation does, e.g. "delete (corrupt)"
+func cleanupAction(op operations.CleanupOp) string {
+	switch op.Action {
+	case operations.CleanupRemove:
+		if op.Kind != "" {
+			return fmt.Sprintf("delete (%s)", op.Kind)
+		}
+		return "delete"
+	case operations.CleanupMove:
+		return "move to " + op.Dest
+	case operations.CleanupRemoveDir:
+		if len(op.Files) > 0 {
+			return fmt.Sprintf("remove folder with %s", strings.Join(op.Files, ", "))
+		}
+		return "remove folder"
+	}
+	return string(op.Action)
+}
@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"archive/zip"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -131,6 +135,106 @@ func TestTextFormatter_FormatBatchValidation(t *testing.T) {
 	}
 }
 
+func TestFormatBatchValidation_ErrorKinds(t *testing.T) {
+	errored := []operations.Result{
+		{FilePath: "a.epub", Error: assertError("deadline"), ErrorKind: operations.ErrorKindTimeout},
+		{FilePath: "b.epub", Error: assertError("denied"), ErrorKind: operations.ErrorKindPermission},
+		{FilePath: "c.epub", Error: assertError("slow"), ErrorKind: operations.ErrorKindTimeout},
+	}
+	result := &operations.BatchResult{
+		Total:      3,
+		Errored:    errored,
+		Failed:     errored,
+		ErrorKinds: operations.ComputeKindStats(errored),
+	}
+
+	text := (&TextFormatter{}).FormatBatchValidation(result, false)
+	for _, want := range []string{"System Errors: 3 (timeout 2, permission_denied 1)", "a.epub: System Error [timeout]: deadline"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text output missing %q:\n%s", want, text)
+		}
+	}
+
+	md := (&MarkdownFormatter{}).FormatBatchValidation(result, false)
+	for _, want := range []string{"| System Errors | 3 (timeout 2, permission_denied 1) |", "- ❌ **b.epub** [permission_denied]: denied"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown output missing %q:\n%s", want, md)
+		}
+	}
+
+	doc := NewBatchDocument(result, false)
+	if doc.Summary.ErrorsByKind["timeout"] != 2 || doc.Results.Errored[1].ErrorKind != "permission_denied" {
+		t.Errorf("Unexpected JSON error kinds %+v %+v", doc.Summary, doc.Results.Errored)
+	}
+}
+
+func TestFormatBatchValidation_Attempts(t *testing.T) {
+	errored := []operations.Result{
+		{FilePath: "scan.pdf", Error: assertError("deadline"), ErrorKind: operations.ErrorKindTimeout, Attempts: 2, Timeout: 2 * time.Minute},
+		{FilePath: "nfs.epub", Error: assertError("i/o error"), ErrorKind: operations.ErrorKindIO, Attempts: 3, Timeout: 30 * time.Second},
+	}
+	result := operations.AggregateResults(append(errored, operations.Result{FilePath: "ok.epub", Attempts: 1}), time.Second, operations.OperationValidate)
+	result.Options = operations.BatchOptions{NumWorkers: 6, AutoJobs: true}
+
+	text := (&TextFormatter{}).FormatBatchValidation(&result, false)
+	for _, want := range []string{"Retried: 2", "Workers: 6 (auto)", "scan.pdf: System Error [timeout]: deadline (2 attempts, timeout 2m0s)", "nfs.epub: System Error [io]: i/o error (3 attempts)\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text output missing %q:\n%s", want, text)
+		}
+	}
+
+	md := (&MarkdownFormatter{}).FormatBatchValidation(&result, false)
+	for _, want := range []string{"| Retried | 2 |", "| Workers | 6 (auto) |", "- ❌ **scan.pdf** [timeout]: deadline (2 attempts, timeout 2m0s)"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown output missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestFormatBatchRepair_Profile(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{{FilePath: "ok.epub"}}, time.Second, operations.OperationRepair)
+	result.Options = operations.BatchOptions{Profile: "archive", NumWorkers: 2}
+
+	if text := (&TextFormatter{}).FormatBatchRepair(&result, false); !strings.Contains(text, "Profile: archive") {
+		t.Errorf("Text output missing the profile:\n%s", text)
+	}
+	if md := (&MarkdownFormatter{}).FormatBatchRepair(&result, false); !strings.Contains(md, "| Profile | archive |") {
+		t.Errorf("Markdown output missing the profile:\n%s", md)
+	}
+
+	result.Options.Profile = ""
+	if text := (&TextFormatter{}).FormatBatchRepair(&result, false); strings.Contains(text, "Profile") {
+		t.Errorf("Expected no profile line without a profile:\n%s", text)
+	}
+}
+
+func TestFormatBatchRepair_Timing(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{
+		{FilePath: "lib/big.epub", Duration: 2 * time.Second, Bytes: 3 << 20,
+			Phases: operations.PhaseTimings{Validate: 1200 * time.Millisecond, Repair: 800 * time.Millisecond}},
+		{FilePath: "lib/small.epub", Duration: 100 * time.Millisecond, Bytes: 1 << 20},
+	}, 4*time.Second, operations.OperationRepair)
+
+	text := (&TextFormatter{}).FormatBatchRepair(&result, true)
+	for _, want := range []string{"Rate: 0.5 files/s, 1.0 MB/s", "Per File: p50 100ms, p90 2s, p99 2s, max 2s", " 1. big.epub 2s", "3.0 MB; validate 1.2s, repair 800ms", " 2. small.epub 100ms"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text output missing %q:\n%s", want, text)
+		}
+	}
+
+	md := (&MarkdownFormatter{}).FormatBatchRepair(&result, true)
+	for _, want := range []string{"## Slowest Files", "| 1 | `big.epub` | 2s | 3.0 MB; validate 1.2s, repair 800ms |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown output missing %q:\n%s", want, md)
+		}
+	}
+
+	empty := operations.AggregateResults([]operations.Result{{FilePath: "old.epub"}}, time.Second, operations.OperationValidate)
+	if text := (&TextFormatter{}).FormatBatchValidation(&empty, false); strings.Contains(text, "Throughput") {
+		t.Errorf("Expected no throughput without timings:\n%s", text)
+	}
+}
+
 func TestNewFormatter(t *testing.T) {
 	tests := []struct {
 		format   OutputFormat
@@ -292,3 +396,278 @@ func TestMarkdownFormatter_FormatBatchRepair(t *testing.T) {
 		t.Error("Markdown output missing header")
 	}
 }
+
+func TestFormatBatch_PanicStack(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{
+		{FilePath: "bad.epub", Error: &operations.PanicError{Value: "bad spine", Stack: "goroutine 7 [running]:\nebmlib.parse()\n"}},
+		{FilePath: "gone.epub", Error: errors.New("unreadable")},
+	}, time.Second, operations.OperationValidate)
+
+	text := (&TextFormatter{}).FormatBatchValidation(&result, false)
+	if !strings.Contains(text, "      goroutine 7 [running]:\n      ebmlib.parse()\n") {
+		t.Errorf("Text output missing the indented stack:\n%s", text)
+	}
+	markdown := (&MarkdownFormatter{}).FormatBatchRepair(&result, false)
+	if !strings.Contains(markdown, "<summary>Stack trace</summary>\n\n  ```text\n  goroutine 7 [running]:\n  ebmlib.parse()\n  ```") {
+		t.Errorf("Markdown output missing the stack block:\n%s", markdown)
+	}
+	if strings.Count(markdown, "Stack trace") != 1 {
+		t.Errorf("Expected a stack only for the panicked file:\n%s", markdown)
+	}
+}
+
+func TestFormatBatch_ScanError(t *testing.T) {
+	result := &operations.BatchResult{
+		Total:     1,
+		Valid:     []operations.Result{{FilePath: "v.epub"}},
+		ScanError: errors.New("failed to find files: lib/private: permission denied"),
+	}
+	for name, output := range map[string]string{
+		"text validation":     (&TextFormatter{}).FormatBatchValidation(result, false),
+		"text repair":         (&TextFormatter{}).FormatBatchRepair(result, false),
+		"markdown validation": (&MarkdownFormatter{}).FormatBatchValidation(result, false),
+		"markdown repair":     (&MarkdownFormatter{}).FormatBatchRepair(result, false),
+	} {
+		if !strings.Contains(output, "Discovery stopped early: failed to find files: lib/private: permission denied") {
+			t.Errorf("%s: expected the scan error, got:\n%s", name, output)
+		}
+	}
+
+	if output := (&TextFormatter{}).FormatBatchValidation(&operations.BatchResult{Total: 1}, false); strings.Contains(output, "Discovery stopped early") {
+		t.Errorf("Unexpected scan error note:\n%s", output)
+	}
+}
+
+func writeContextEPUB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("OEBPS/ch1.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("<html>\n<body>\n<p>broken\n</body>\n</html>\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func contextReport(path string) *ebmlib.ValidationReport {
+	return &ebmlib.ValidationReport{
+		FilePath: path,
+		Errors: []ebmlib.ValidationError{{
+			Code:     "HTM-004",
+			Message:  "Unclosed element",
+			Severity: ebmlib.SeverityError,
+			Location: &ebmlib.ErrorLocation{File: "ch1.xhtml", Line: 3},
+		}},
+	}
+}
+
+func TestTextFormatter_Context(t *testing.T) {
+	report := contextReport(writeContextEPUB(t))
+
+	output := (&TextFormatter{ContextLines: 1}).FormatValidation(report)
+	for _, want := range []string{"  2 | <body>", "> 3 | <p>broken", "  4 | </body>"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "<html>") {
+		t.Error("Expected context to be limited to 1 line either side")
+	}
+
+	if output := (&TextFormatter{}).FormatValidation(report); strings.Contains(output, "<p>broken") {
+		t.Error("Expected no context when ContextLines is 0")
+	}
+}
+
+func TestMarkdownFormatter_Context(t *testing.T) {
+	report := contextReport(writeContextEPUB(t))
+
+	output := (&MarkdownFormatter{ContextLines: 2}).FormatValidation(report)
+	if !strings.Contains(output, "    ```html\n") {
+		t.Errorf("Expected fenced html block, got:\n%s", output)
+	}
+	if !strings.Contains(output, "    > 3 | <p>broken\n") {
+		t.Errorf("Expected highlighted line, got:\n%s", output)
+	}
+}
+
+func TestTextFormatter_ContextUnreadable(t *testing.T) {
+	report := contextReport(filepath.Join(t.TempDir(), "missing.epub"))
+
+	output := (&TextFormatter{ContextLines: 3}).FormatValidation(report)
+	if !strings.Contains(output, "Location: ch1.xhtml:3") {
+		t.Errorf("Expected location to still be shown, got:\n%s", output)
+	}
+}
+
+func TestNewReportOptions_Context(t *testing.T) {
+	opts, err := NewReportOptions(&RootFlags{Format: "markdown", Context: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md, ok := opts.Formatter.(*MarkdownFormatter); !ok || md.ContextLines != 3 {
+		t.Errorf("Expected markdown formatter with 3 context lines, got %+v", opts.Formatter)
+	}
+
+	if _, err := NewReportOptions(&RootFlags{Format: "text", Context: -1}); err == nil {
+		t.Error("Expected error for negative --context")
+	}
+}
+
+func topIssuesBatch() *operations.BatchResult {
+	batch := operations.AggregateResults([]operations.Result{
+		{FilePath: "/lib/a.epub", Report: &ebmlib.ValidationReport{
+			Errors:   []ebmlib.ValidationError{{Code: "OPF-014", Message: "Missing property"}},
+			Warnings: []ebmlib.ValidationError{{Code: "CSS-008", Message: "Unknown font"}},
+		}},
+		{FilePath: "/lib/b.epub", Report: &ebmlib.ValidationReport{
+			Errors: []ebmlib.ValidationError{{Code: "OPF-014", Message: "Missing property"}},
+		}},
+	}, time.Second, operations.OperationValidate)
+	return &batch
+}
+
+func TestTextFormatter_TopIssues(t *testing.T) {
+	output := (&TextFormatter{}).FormatBatchValidation(topIssuesBatch(), true)
+
+	if !strings.Contains(output, "Top Issues") {
+		t.Fatalf("Expected Top Issues section, got:\n%s", output)
+	}
+	first := strings.Index(output, "[OPF-014]")
+	second := strings.Index(output, "[CSS-008]")
+	if first < 0 || second < 0 || first > second {
+		t.Errorf("Expected OPF-014 ranked above CSS-008, got:\n%s", output)
+	}
+	if !strings.Contains(output, "2 occurrence(s) in 2 file(s), e.g. a.epub, b.epub") {
+		t.Errorf("Expected occurrence details, got:\n%s", output)
+	}
+}
+
+func TestMarkdownFormatter_TopIssues(t *testing.T) {
+	output := (&MarkdownFormatter{}).FormatBatchRepair(topIssuesBatch(), false)
+
+	if !strings.Contains(output, "| 1 | `OPF-014` | error | 2 | 2 | a.epub, b.epub |") {
+		t.Errorf("Expected ranked table row, got:\n%s", output)
+	}
+	if !strings.Contains(output, "| 2 | `CSS-008` | warning | 1 | 1 | a.epub |") {
+		t.Errorf("Expected warning row, got:\n%s", output)
+	}
+}
+
+func TestTextFormatter_TopIssuesLimit(t *testing.T) {
+	batch := &operations.BatchResult{}
+	for i := 0; i < maxTopIssuesShown+2; i++ {
+		batch.TopIssues = append(batch.TopIssues, operations.IssueStat{Code: fmt.Sprintf("C%02d", i), Occurrences: 1, Files: 1})
+	}
+
+	output := (&TextFormatter{}).FormatBatchValidation(batch, true)
+	if strings.Contains(output, "[C10]") || !strings.Contains(output, "and 2 more code(s)") {
+		t.Errorf("Expected Top Issues to be truncated, got:\n%s", output)
+	}
+}
+
+func TestFormatters_Roots(t *testing.T) {
+	batch := &operations.BatchResult{
+		Roots: []operations.RootStat{
+			{Root: "lib1", Total: 3, Valid: 2, Invalid: 1},
+			{Root: "extra.epub", Total: 1, Errored: 1},
+		},
+	}
+
+	text := (&TextFormatter{}).FormatBatchValidation(batch, true)
+	if !strings.Contains(text, "By Root") || !strings.Contains(text, "3 file(s): 2 valid, 1 invalid, 0 errored") {
+		t.Errorf("Expected By Root section, got:\n%s", text)
+	}
+
+	markdown := (&MarkdownFormatter{}).FormatBatchRepair(batch, true)
+	if !strings.Contains(markdown, "| `extra.epub` | 1 | 0 | 0 | 1 |") {
+		t.Errorf("Expected root table row, got:\n%s", markdown)
+	}
+
+	// A single root adds nothing over the summary
+	batch.Roots = batch.Roots[:1]
+	if strings.Contains((&TextFormatter{}).FormatBatchValidation(batch, true), "By Root") {
+		t.Error("Expected no By Root section for a single root")
+	}
+}
+
+func TestFormatters_Symlinks(t *testing.T) {
+	batch := &operations.BatchResult{
+		Symlinks: []operations.SymlinkEvent{
+			{Path: "lib/author", Target: "/disk/author", Followed: true},
+			{Path: "lib/loop", Target: "lib", Reason: operations.SymlinkLoop},
+		},
+	}
+
+	text := (&TextFormatter{}).FormatBatchValidation(batch, true)
+	if !strings.Contains(text, "→ lib/author -> /disk/author") || !strings.Contains(text, "⊘ lib/loop: skipped, "+operations.SymlinkLoop) {
+		t.Errorf("Expected Symlinks section, got:\n%s", text)
+	}
+
+	markdown := (&MarkdownFormatter{}).FormatBatchRepair(batch, true)
+	if !strings.Contains(markdown, "| `lib/author` | `/disk/author` | followed |") {
+		t.Errorf("Expected symlink table row, got:\n%s", markdown)
+	}
+}
+
+func TestFormatters_Routed(t *testing.T) {
+	batch := &operations.BatchResult{
+		Routed: []operations.RoutedFile{
+			{Path: "lib/a/book.epub", Dest: "lib/INVALID/a/book.epub", Outcome: operations.OutcomeReverted},
+			{Path: "lib/b.pdf", Dest: "broken/b.pdf", Outcome: operations.OutcomeErrored, Skipped: true},
+		},
+	}
+
+	text := (&TextFormatter{}).FormatBatchRepair(batch, true)
+	if !strings.Contains(text, "→ lib/a/book.epub -> lib/INVALID/a/book.epub") || !strings.Contains(text, "⊘ lib/b.pdf: not moved, broken/b.pdf exists") {
+		t.Errorf("Expected Moved Files section, got:\n%s", text)
+	}
+
+	markdown := (&MarkdownFormatter{}).FormatBatchValidation(batch, true)
+	if !strings.Contains(markdown, "| `lib/b.pdf` | errored | `broken/b.pdf` | skipped: destination exists |") {
+		t.Errorf("Expected moved file table row, got:\n%s", markdown)
+	}
+}
+
+func TestFormatters_Cleanup(t *testing.T) {
+	batch := &operations.BatchResult{
+		Cleanup: operations.CleanupPlan{
+			{Action: operations.CleanupRemove, Path: "lib/a.epub", Kind: operations.ErrorKindCorrupt, Trashed: "a.epub", Done: true},
+			{Action: operations.CleanupMove, Path: "lib/b.epub", Dest: "lib/INVALID/b.epub", Done: true},
+			{Action: operations.CleanupRemoveDir, Path: "lib/a", Files: []string{"cover.jpg"}, Err: errors.New("busy")},
+			{Action: operations.CleanupMove, Path: "lib/c.epub", Dest: "lib/INVALID/c.epub"},
+		},
+	}
+
+	text := (&TextFormatter{}).FormatBatchRepair(batch, true)
+	for _, want := range []string{
+		"✓ lib/a.epub: delete (corrupt), in the trash as a.epub",
+		"✗ lib/a: remove folder with cover.jpg failed: busy",
+		"· lib/c.epub: move to lib/INVALID/c.epub not applied",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in Cleanup section, got:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "lib/b.epub") {
+		t.Errorf("Expected moves made to be left to Moved Files, got:\n%s", text)
+	}
+
+	markdown := (&MarkdownFormatter{}).FormatBatchValidation(batch, true)
+	if !strings.Contains(markdown, "| `lib/a` | remove folder with cover.jpg | failed: busy |") {
+		t.Errorf("Expected cleanup table row, got:\n%s", markdown)
+	}
+}
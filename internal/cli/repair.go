@@ -22,11 +22,13 @@ func newRepairCmd(rootFlags *RootFlags) *cobra.Command {
 	flags := &repairFlags{}
 
 	cmd := &cobra.Command{
-		Use:   "repair <file>",
-		Short: "Repair a single EPUB or PDF file",
-		Long: `Repair an EPUB or PDF file by fixing detected issues.
+		Use:   "repair <path>...",
+		Short: "Repair EPUB and PDF files",
+		Long: `Repair EPUB and PDF files by fixing detected issues.
 
-Repairs run in-place by default. Backups are created unless disabled.`,
+Repairs run in-place by default. Backups are created unless disabled.
+Several files, directories or glob patterns are repaired as a batch with
+results aggregated into one report.`,
 		Example: `  # Repair in-place with backup (default)
   ebm repair book.epub
 
@@ -37,10 +39,16 @@ Repairs run in-place by default. Backups are created unless disabled.`,
   ebm repair book.epub --no-backup
 
   # Repair without post-validation
-  ebm repair book.epub --skip-validate`,
-		Args: cobra.ExactArgs(1),
+  ebm repair book.epub --skip-validate
+
+  # Repair several files matching a pattern
+  ebm repair 'books/*.epub'`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runRepair(cmd.Context(), args[0], flags, rootFlags)
+			if isSingleFile(args) {
+				return runRepair(cmd.Context(), args[0], flags, rootFlags)
+			}
+			return runBatchRepair(cmd.Context(), args, flags.batchFlags(), rootFlags)
 		},
 	}
 
@@ -52,6 +60,17 @@ Repairs run in-place by default. Backups are created unless disabled.`,
 	return cmd
 }
 
+// batchFlags maps the single-file repair flags onto a batch run
+func (f *repairFlags) batchFlags() *batchFlags {
+	flags := defaultBatchFlags()
+	flags.timeout = 60
+	flags.backupDir = f.backupDir
+	flags.noBackup = f.noBackup
+	flags.aggressive = f.aggressive
+	flags.skipValidation = f.skipValidate
+	return flags
+}
+
 func runRepair(ctx context.Context, filePath string, flags *repairFlags, rootFlags *RootFlags) error {
 	// Validate flags
 	if flags.noBackup && flags.backupDir != "" {
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+type reportRenderFlags struct {
+	summaryOnly bool
+}
+
+func newReportCmd(rootFlags *RootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Work with saved reports",
+		Long: `Work with reports saved by earlier runs.
+
+Reports written with --format json can be rendered again in any output format
+without re-running validation or repair.`,
+		Example: `  # Render a saved batch report as markdown
+  ebm report render nightly.json --format markdown --output nightly.md`,
+	}
+
+	cmd.AddCommand(newReportRenderCmd(rootFlags))
+
+	return cmd
+}
+
+func newReportRenderCmd(rootFlags *RootFlags) *cobra.Command {
+	flags := &reportRenderFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "render <report.json>",
+		Short: "Render a saved JSON report in another format",
+		Long: `Load a validation, repair or batch report written with --format json and
+render it again with any output format.
+
+Severity filters (--min-severity, --severity, --max-errors) are applied at
+render time, so a full report can be archived once and narrowed later.`,
+		Example: `  # Render a saved validation report as text
+  ebm report render report.json
+
+  # Render a saved batch report as markdown, errors only
+  ebm report render nightly.json --format markdown --min-severity error
+
+  # Render with a custom template
+  ebm report render nightly.json --format template --template summary.tmpl`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReportRender(args[0], flags, rootFlags)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.summaryOnly, "summary-only", false, "Only print summary output for batch reports")
+
+	return cmd
+}
+
+func runReportRender(path string, flags *reportRenderFlags, rootFlags *RootFlags) error {
+	saved, err := LoadSavedReport(path)
+	if err != nil {
+		return err
+	}
+
+	opts, err := NewReportOptions(rootFlags)
+	if err != nil {
+		return fmt.Errorf("invalid report options: %w", err)
+	}
+	opts.SummaryOnly = flags.summaryOnly
+
+	switch {
+	case saved.Batch != nil:
+		batch := opts.Filter.FilterBatchResult(saved.Batch)
+		if batch.Operation == operations.OperationRepair {
+			err = WriteBatchRepairReport(batch, opts)
+		} else {
+			err = WriteBatchValidationReport(batch, opts)
+		}
+	case saved.Repair != nil:
+		err = WriteRepairReport(saved.Repair, saved.Validation, opts)
+	default:
+		err = WriteReport(saved.Validation, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+// SavedReport is a report loaded back from JSONFormatter output.
+// Exactly one of Batch, Repair or Validation is the primary document;
+// Validation is also set for repair reports that include post-repair validation.
+type SavedReport struct {
+	Validation *ebmlib.ValidationReport
+	Repair     *ebmlib.RepairResult
+	Batch      *operations.BatchResult
+}
+
+// savedRepair mirrors the document written by JSONFormatter.FormatRepair
+type savedRepair struct {
+	Success          bool                     `json:"success"`
+	ActionsApplied   []ebmlib.RepairAction    `json:"actions_applied"`
+	BackupPath       string                   `json:"backup_path"`
+	Error            string                   `json:"error"`
+	ValidationReport *ebmlib.ValidationReport `json:"validation_report"`
+}
+
+// LoadSavedReport reads a JSON report written by JSONFormatter
+func LoadSavedReport(path string) (*SavedReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	return ParseSavedReport(data)
+}
+
+// ParseSavedReport decodes a JSON report written by JSONFormatter, detecting
+// whether it is a validation, repair or batch document. Reports without a
+// schema_version are decoded using the pre-1.0 layout.
+func ParseSavedReport(data []byte) (*SavedReport, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON report: %w", err)
+	}
+
+	if _, ok := fields["schema_version"]; ok {
+		return parseVersionedReport(data)
+	}
+
+	// Reports written before schema_version was introduced.
+	// Batch documents carry a summary envelope with the full result under "results"
+	if _, ok := fields["total"]; ok {
+		raw, ok := fields["results"]
+		if !ok {
+			return nil, fmt.Errorf("batch report has no per-file results (was it written with --summary-only?)")
+		}
+		var batch operations.BatchResult
+		if err := json.Unmarshal(raw, &batch); err != nil {
+			return nil, fmt.Errorf("invalid batch report: %w", err)
+		}
+		if batch.Operation == "" {
+			batch.Operation = operations.OperationValidate
+		}
+		return &SavedReport{Batch: &batch}, nil
+	}
+
+	if _, ok := fields["actions_applied"]; ok {
+		var repair savedRepair
+		if err := json.Unmarshal(data, &repair); err != nil {
+			return nil, fmt.Errorf("invalid repair report: %w", err)
+		}
+		result := &ebmlib.RepairResult{
+			Success:        repair.Success,
+			ActionsApplied: repair.ActionsApplied,
+			BackupPath:     repair.BackupPath,
+		}
+		if repair.Error != "" {
+			result.Error = errors.New(repair.Error)
+		}
+		return &SavedReport{Repair: result, Validation: repair.ValidationReport}, nil
+	}
+
+	var report ebmlib.ValidationReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid validation report: %w", err)
+	}
+	if report.FilePath == "" {
+		return nil, fmt.Errorf("unrecognized report: expected a validation, repair or batch report")
+	}
+
+	return &SavedReport{Validation: &report}, nil
+}
+
+// parseVersionedReport decodes a document carrying schema_version and kind
+func parseVersionedReport(data []byte) (*SavedReport, error) {
+	var header struct {
+		SchemaVersion string `json:"schema_version"`
+		Kind          string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid JSON report: %w", err)
+	}
+	if err := checkSchemaVersion(header.SchemaVersion); err != nil {
+		return nil, err
+	}
+
+	switch header.Kind {
+	case DocumentKindBatch:
+		var doc BatchDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid batch report: %w", err)
+		}
+		batch, err := doc.BatchResult()
+		if err != nil {
+			return nil, err
+		}
+		return &SavedReport{Batch: batch}, nil
+
+	case DocumentKindRepair:
+		var doc RepairDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid repair report: %w", err)
+		}
+		saved := &SavedReport{Repair: doc.RepairResult()}
+		if doc.ValidationReport != nil {
+			saved.Validation = doc.ValidationReport.ValidationReport()
+		}
+		return saved, nil
+
+	case DocumentKindValidation:
+		var doc ValidationDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid validation report: %w", err)
+		}
+		return &SavedReport{Validation: doc.ValidationReport()}, nil
+
+	default:
+		return nil, fmt.Errorf("unrecognized report kind %q", header.Kind)
+	}
+}
+
+// checkSchemaVersion accepts any document with the same major version as SchemaVersion
+func checkSchemaVersion(version string) error {
+	major, _, _ := strings.Cut(version, ".")
+	want, _, _ := strings.Cut(SchemaVersion, ".")
+	if major != want {
+		return fmt.Errorf("unsupported report schema_version %q (supported: %s.x)", version, want)
+	}
+	return nil
+}
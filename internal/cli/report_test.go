@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func TestParseSavedReport_Validation(t *testing.T) {
+	report := &ebmlib.ValidationReport{
+		FilePath: "test.epub",
+		IsValid:  false,
+		Errors:   []ebmlib.ValidationError{{Code: "ERR1", Message: "Error 1", Severity: ebmlib.SeverityError}},
+	}
+	data := (&JSONFormatter{}).FormatValidation(report)
+
+	saved, err := ParseSavedReport([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseSavedReport() error = %v", err)
+	}
+	if saved.Validation == nil || saved.Validation.FilePath != "test.epub" {
+		t.Fatalf("Expected validation report for test.epub, got %+v", saved)
+	}
+	if saved.Validation.ErrorCount() != 1 {
+		t.Errorf("Expected 1 error, got %d", saved.Validation.ErrorCount())
+	}
+}
+
+func TestParseSavedReport_Repair(t *testing.T) {
+	result := &ebmlib.RepairResult{
+		Success:        false,
+		ActionsApplied: []ebmlib.RepairAction{{Description: "Action 1"}},
+		Error:          errors.New("boom"),
+	}
+	data := (&JSONFormatter{}).FormatRepair(result, &ebmlib.ValidationReport{FilePath: "test.epub"})
+
+	saved, err := ParseSavedReport([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseSavedReport() error = %v", err)
+	}
+	if saved.Repair == nil {
+		t.Fatal("Expected repair result")
+	}
+	if saved.Repair.Error == nil || saved.Repair.Error.Error() != "boom" {
+		t.Errorf("Expected repair error 'boom', got %v", saved.Repair.Error)
+	}
+	if len(saved.Repair.ActionsApplied) != 1 {
+		t.Errorf("Expected 1 action, got %d", len(saved.Repair.ActionsApplied))
+	}
+	if saved.Validation == nil || saved.Validation.FilePath != "test.epub" {
+		t.Error("Expected post-repair validation report")
+	}
+}
+
+func TestParseSavedReport_Batch(t *testing.T) {
+	results := []operations.Result{
+		{FilePath: "valid.epub", Report: &ebmlib.ValidationReport{FilePath: "valid.epub", IsValid: true}},
+		{FilePath: "invalid.epub", Report: &ebmlib.ValidationReport{FilePath: "invalid.epub", Errors: []ebmlib.ValidationError{{Code: "E1"}}}},
+		{FilePath: "broken.epub", Error: errors.New("permission denied")},
+	}
+	batch := operations.AggregateResults(results, 2*time.Second, operations.OperationValidate)
+	data := (&JSONFormatter{}).FormatBatchValidation(&batch, false)
+
+	saved, err := ParseSavedReport([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseSavedReport() error = %v", err)
+	}
+	if saved.Batch == nil {
+		t.Fatal("Expected batch result")
+	}
+	if len(saved.Batch.Valid) != 1 || len(saved.Batch.Invalid) != 1 || len(saved.Batch.Errored) != 1 {
+		t.Errorf("Unexpected categories: %d valid, %d invalid, %d errored",
+			len(saved.Batch.Valid), len(saved.Batch.Invalid), len(saved.Batch.Errored))
+	}
+	if saved.Batch.Errored[0].Error == nil || saved.Batch.Errored[0].Error.Error() != "permission denied" {
+		t.Errorf("Expected system error to survive round trip, got %v", saved.Batch.Errored[0].Error)
+	}
+	if saved.Batch.Duration != 2*time.Second {
+		t.Errorf("Expected duration 2s, got %v", saved.Batch.Duration)
+	}
+}
+
+func TestParseSavedReport_SummaryOnly(t *testing.T) {
+	batch := operations.AggregateResults(nil, time.Second, operations.OperationValidate)
+	data := (&JSONFormatter{}).FormatBatchValidation(&batch, true)
+
+	if _, err := ParseSavedReport([]byte(data)); err == nil {
+		t.Error("Expected error for summary-only batch report")
+	}
+}
+
+func TestParseSavedReport_Invalid(t *testing.T) {
+	inputs := []string{`not json`, `{}`, `[]`}
+	for _, in := range inputs {
+		if _, err := ParseSavedReport([]byte(in)); err == nil {
+			t.Errorf("ParseSavedReport(%q) expected error", in)
+		}
+	}
+}
+
+func TestParseSavedReport_Legacy(t *testing.T) {
+	// Reports written before schema_version embedded the BatchResult directly
+	batch := operations.AggregateResults([]operations.Result{
+		{FilePath: "broken.epub", Error: errors.New("permission denied")},
+	}, time.Second, operations.OperationValidate)
+	data, err := json.Marshal(map[string]interface{}{"total": batch.Total, "results": batch})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ParseSavedReport(data)
+	if err != nil {
+		t.Fatalf("ParseSavedReport() error = %v", err)
+	}
+	if saved.Batch == nil || len(saved.Batch.Errored) != 1 {
+		t.Fatalf("Expected legacy batch with 1 errored result, got %+v", saved)
+	}
+}
+
+func TestParseSavedReport_UnsupportedVersion(t *testing.T) {
+	inputs := []string{
+		`{"schema_version": "2.0", "kind": "validation", "file_path": "a.epub"}`,
+		`{"schema_version": "1.0", "kind": "unknown"}`,
+	}
+	for _, in := range inputs {
+		if _, err := ParseSavedReport([]byte(in)); err == nil {
+			t.Errorf("ParseSavedReport(%q) expected error", in)
+		}
+	}
+}
+
+func TestRunReportRender_Markdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	report := &ebmlib.ValidationReport{
+		FilePath: "test.epub",
+		Errors:   []ebmlib.ValidationError{{Code: "ERR1", Message: "Error 1", Severity: ebmlib.SeverityError}},
+		Warnings: []ebmlib.ValidationError{{Code: "WARN1", Message: "Warning 1", Severity: ebmlib.SeverityWarning}},
+	}
+	input := filepath.Join(tmpDir, "report.json")
+	if err := os.WriteFile(input, []byte((&JSONFormatter{}).FormatValidation(report)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tmpDir, "report.md")
+	rootFlags := &RootFlags{Format: "markdown", Output: output, MinSeverity: "error"}
+	if err := runReportRender(input, &reportRenderFlags{}, rootFlags); err != nil {
+		t.Fatalf("runReportRender() error = %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "# Validation Report") {
+		t.Error("Expected markdown validation report")
+	}
+	if !strings.Contains(string(content), "ERR1") {
+		t.Error("Expected error to be rendered")
+	}
+	if strings.Contains(string(content), "WARN1") {
+		t.Error("Expected warning to be filtered by --min-severity")
+	}
+}
+
+func TestRunReportRender_BatchRepair(t *testing.T) {
+	tmpDir := t.TempDir()
+	results := []operations.Result{
+		{FilePath: "failed.epub", Repair: &ebmlib.RepairResult{Success: false, Error: errors.New("cannot repair")}},
+	}
+	batch := operations.AggregateResults(results, time.Second, operations.OperationRepair)
+	input := filepath.Join(tmpDir, "batch.json")
+	if err := os.WriteFile(input, []byte((&JSONFormatter{}).FormatBatchRepair(&batch, false)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tmpDir, "batch.txt")
+	rootFlags := &RootFlags{Format: "text", Output: output}
+	if err := runReportRender(input, &reportRenderFlags{}, rootFlags); err != nil {
+		t.Fatalf("runReportRender() error = %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Batch Repair Report") {
+		t.Error("Expected batch repair report")
+	}
+	if !strings.Contains(string(content), "cannot repair") {
+		t.Error("Expected repair error to be rendered")
+	}
+}
+
+func TestRunReportRender_MissingFile(t *testing.T) {
+	err := runReportRender(filepath.Join(t.TempDir(), "missing.json"), &reportRenderFlags{}, &RootFlags{Format: "text"})
+	if err == nil {
+		t.Error("Expected error for missing report")
+	}
+}
+
+func TestSeverityFilter_FilterBatchResult(t *testing.T) {
+	filter, err := NewSeverityFilter("error", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := &operations.BatchResult{
+		Invalid: []operations.Result{{
+			FilePath: "a.epub",
+			Report: &ebmlib.ValidationReport{
+				Errors:   []ebmlib.ValidationError{{Code: "E1"}},
+				Warnings: []ebmlib.ValidationError{{Code: "W1"}},
+			},
+		}},
+	}
+
+	filtered := filter.FilterBatchResult(batch)
+	if len(filtered.Invalid[0].Report.Warnings) != 0 {
+		t.Error("Expected warnings to be filtered")
+	}
+	if len(batch.Invalid[0].Report.Warnings) != 1 {
+		t.Error("Expected original batch result to be unchanged")
+	}
+}
+
+func TestSeverityFilter_FilterBatchResult_TopIssues(t *testing.T) {
+	batch := operations.AggregateResults([]operations.Result{
+		{FilePath: "a.epub", Report: &ebmlib.ValidationReport{
+			Errors:   []ebmlib.ValidationError{{Code: "E1"}},
+			Warnings: []ebmlib.ValidationError{{Code: "W1"}},
+		}},
+	}, time.Second, operations.OperationValidate)
+
+	filter, err := NewSeverityFilter("error", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered := filter.FilterBatchResult(&batch)
+	if len(filtered.TopIssues) != 1 || filtered.TopIssues[0].Code != "E1" {
+		t.Errorf("Expected only E1 to remain, got %+v", filtered.TopIssues)
+	}
+	if len(batch.TopIssues) != 2 {
+		t.Error("Expected original batch result to be unchanged")
+	}
+
+	// Counts only cover the issues kept by --max-errors
+	batch = operations.AggregateResults([]operations.Result{
+		{FilePath: "a.epub", Report: &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "E1"}, {Code: "E2"}, {Code: "E2"}}}},
+		{FilePath: "b.epub", Report: &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "E2"}}}},
+	}, time.Second, operations.OperationValidate)
+	if filter, err = NewSeverityFilter("", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	filtered = filter.FilterBatchResult(&batch)
+	if len(filtered.TopIssues) != 2 || filtered.TopIssues[0].Occurrences != 1 || filtered.TopIssues[1].Occurrences != 1 {
+		t.Errorf("Expected E1 and E2 once each, got %+v", filtered.TopIssues)
+	}
+
+	// A summary-only report keeps its own top issues, by severity
+	summary := &operations.BatchResult{TopIssues: []operations.IssueStat{
+		{Code: "E1", Severity: ebmlib.SeverityError, Occurrences: 4},
+		{Code: "W1", Severity: ebmlib.SeverityWarning, Occurrences: 2},
+	}}
+	if filter, err = NewSeverityFilter("error", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if filtered := filter.FilterBatchResult(summary); len(filtered.TopIssues) != 1 || filtered.TopIssues[0].Occurrences != 4 {
+		t.Errorf("Expected only E1 to remain, got %+v", filtered.TopIssues)
+	}
+}
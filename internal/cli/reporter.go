@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/petergi/ebook-mechanic-cli/internal/operations"
@@ -105,6 +106,61 @@ func (f *SeverityFilter) FilterReport(report *ebmlib.ValidationReport) *ebmlib.V
 	return filtered
 }
 
+// FilterBatchResult applies severity filtering to every validation report in a batch result
+func (f *SeverityFilter) FilterBatchResult(result *operations.BatchResult) *operations.BatchResult {
+	if result == nil {
+		return nil
+	}
+
+	filtered := *result
+	filtered.Valid = f.filterResults(result.Valid)
+	filtered.Invalid = f.filterResults(result.Invalid)
+	filtered.Errored = f.filterResults(result.Errored)
+	filtered.Successful = f.filterResults(result.Successful)
+	filtered.Failed = f.filterResults(result.Failed)
+
+	// Top issues count what is left; a summary-only report has no files to
+	// count, so its own top issues are filtered instead
+	results := slices.Concat(filtered.Valid, filtered.Invalid, filtered.Errored)
+	if len(results) > 0 {
+		filtered.TopIssues = operations.ComputeIssueStats(results)
+		return &filtered
+	}
+	filtered.TopIssues = nil
+	for _, stat := range result.TopIssues {
+		if f.shouldInclude(libSeverity(stat.Severity)) {
+			filtered.TopIssues = append(filtered.TopIssues, stat)
+		}
+	}
+
+	return &filtered
+}
+
+func (f *SeverityFilter) filterResults(results []operations.Result) []operations.Result {
+	if results == nil {
+		return nil
+	}
+
+	filtered := make([]operations.Result, len(results))
+	for i, r := range results {
+		r.Report = f.FilterReport(r.Report)
+		filtered[i] = r
+	}
+	return filtered
+}
+
+// libSeverity maps an ebmlib severity to the filter's Severity
+func libSeverity(severity ebmlib.Severity) Severity {
+	switch severity {
+	case ebmlib.SeverityError:
+		return SeverityError
+	case ebmlib.SeverityWarning:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
 func (f *SeverityFilter) shouldInclude(sev Severity) bool {
 	// If specific severities are set, only include those
 	if len(f.Severities) > 0 {
@@ -145,13 +201,36 @@ func NewReportOptions(flags *RootFlags) (*ReportOptions, error) {
 
 	// Disable color for non-terminal output or if explicitly disabled
 	colorEnabled := flags.Color
-	if flags.Output != "" || format != FormatText {
+	if flags.Output != "" || (format != FormatText && format != FormatTemplate) {
 		colorEnabled = false
 	}
 
+	if flags.Context < 0 {
+		return nil, fmt.Errorf("--context must be zero or positive, got %d", flags.Context)
+	}
+
+	formatter := NewFormatter(format, colorEnabled)
+	switch f := formatter.(type) {
+	case *TextFormatter:
+		f.ContextLines = flags.Context
+	case *MarkdownFormatter:
+		f.ContextLines = flags.Context
+	}
+	if format == FormatTemplate {
+		if flags.Template == "" {
+			return nil, fmt.Errorf("--template is required with --format template")
+		}
+		formatter, err = NewTemplateFormatter(flags.Template, colorEnabled)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", flags.Template, err)
+		}
+	} else if flags.Template != "" {
+		return nil, fmt.Errorf("--template is only supported with --format template")
+	}
+
 	return &ReportOptions{
 		Format:       format,
-		Formatter:    NewFormatter(format, colorEnabled),
+		Formatter:    formatter,
 		Filter:       filter,
 		OutputPath:   flags.Output,
 		ColorEnabled: colorEnabled,
@@ -170,14 +249,14 @@ func WriteReport(report *ebmlib.ValidationReport, opts *ReportOptions) error {
 	filtered := opts.Filter.FilterReport(report)
 
 	// Format the report
-	content := opts.Formatter.FormatValidation(filtered)
-
-	// Write to file or stdout
-	if opts.OutputPath != "" {
-		return os.WriteFile(opts.OutputPath, []byte(content), 0644)
+	content, err := formatContent(opts, TemplateData{Kind: TemplateKindValidation, Report: filtered}, func() string {
+		return opts.Formatter.FormatValidation(filtered)
+	})
+	if err != nil {
+		return err
 	}
 
-	return WriteOutput(os.Stdout, content)
+	return writeContent(opts, content)
 }
 
 // WriteRepairReport writes a formatted repair result
@@ -193,14 +272,14 @@ func WriteRepairReport(result *ebmlib.RepairResult, report *ebmlib.ValidationRep
 	}
 
 	// Format the repair result
-	content := opts.Formatter.FormatRepair(result, filtered)
-
-	// Write to file or stdout
-	if opts.OutputPath != "" {
-		return os.WriteFile(opts.OutputPath, []byte(content), 0644)
+	content, err := formatContent(opts, TemplateData{Kind: TemplateKindRepair, Repair: result, Report: filtered}, func() string {
+		return opts.Formatter.FormatRepair(result, filtered)
+	})
+	if err != nil {
+		return err
 	}
 
-	return WriteOutput(os.Stdout, content)
+	return writeContent(opts, content)
 }
 
 // WriteBatchValidationReport writes a formatted batch validation result
@@ -210,14 +289,14 @@ func WriteBatchValidationReport(result *operations.BatchResult, opts *ReportOpti
 	}
 
 	// Format the batch result
-	content := opts.Formatter.FormatBatchValidation(result, opts.SummaryOnly)
-
-	// Write to file or stdout
-	if opts.OutputPath != "" {
-		return os.WriteFile(opts.OutputPath, []byte(content), 0644)
+	content, err := formatContent(opts, TemplateData{Kind: TemplateKindBatchValidation, Batch: result, SummaryOnly: opts.SummaryOnly}, func() string {
+		return opts.Formatter.FormatBatchValidation(result, opts.SummaryOnly)
+	})
+	if err != nil {
+		return err
 	}
 
-	return WriteOutput(os.Stdout, content)
+	return writeContent(opts, content)
 }
 
 // WriteBatchRepairReport writes a formatted batch repair result
@@ -227,9 +306,28 @@ func WriteBatchRepairReport(result *operations.BatchResult, opts *ReportOptions)
 	}
 
 	// Format the batch result
-	content := opts.Formatter.FormatBatchRepair(result, opts.SummaryOnly)
+	content, err := formatContent(opts, TemplateData{Kind: TemplateKindBatchRepair, Batch: result, SummaryOnly: opts.SummaryOnly}, func() string {
+		return opts.Formatter.FormatBatchRepair(result, opts.SummaryOnly)
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeContent(opts, content)
+}
+
+// formatContent renders data through the configured formatter. Template
+// formatters are executed directly so that template errors are returned
+// instead of being embedded in the report.
+func formatContent(opts *ReportOptions, data TemplateData, format func() string) (string, error) {
+	if tf, ok := opts.Formatter.(*TemplateFormatter); ok {
+		return tf.Execute(data)
+	}
+	return format(), nil
+}
 
-	// Write to file or stdout
+// writeContent writes report content to the output file or stdout
+func writeContent(opts *ReportOptions, content string) error {
 	if opts.OutputPath != "" {
 		return os.WriteFile(opts.OutputPath, []byte(content), 0644)
 	}
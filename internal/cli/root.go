@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
 )
 
 // osExit is a copy of os.Exit that can be mocked in tests
@@ -13,12 +18,15 @@ var osExit = os.Exit
 // RootFlags contains global flags shared across all commands
 type RootFlags struct {
 	Format      string
+	Template    string
 	Output      string
 	Verbose     bool
 	Color       bool
 	MinSeverity string
 	Severities  []string
 	MaxErrors   int
+	Context     int
+	Profile     string
 }
 
 // NewRootCmd creates the root command for the CLI
@@ -33,7 +41,7 @@ func NewRootCmd() *cobra.Command {
 Validate and repair EPUB and PDF files with comprehensive reporting.
 
   - Run without arguments to launch the interactive TUI.
-  - Run with a file or directory path to quick-validate (e.g. 'ebm mybook.epub').
+  - Run with file or directory paths to quick-validate (e.g. 'ebm mybook.epub').
   - Use subcommands ('repair', 'batch') for specific operations.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
@@ -43,6 +51,8 @@ Validate and repair EPUB and PDF files with comprehensive reporting.
   # CLI validation
   ebm validate book.epub
   ebm validate book.epub --format json --output report.json
+  ebm validate book.epub --format template --template report.tmpl
+  ebm validate book.epub --context 3
 
   # CLI repair (in-place with backup by default)
   ebm repair book.epub
@@ -50,17 +60,41 @@ Validate and repair EPUB and PDF files with comprehensive reporting.
 
   # Batch operations
   ebm batch validate ./books --jobs 8
-  ebm batch repair ./library`,
+  ebm batch repair ./library
+
+  # Sort books into valid/, invalid/ and error/ as they arrive
+  ebm watch ./inbox
+
+  # Re-render a saved JSON report
+  ebm report render report.json --format markdown
+
+  # Print the JSON Schema for machine-readable output
+  ebm schema batch
+
+  # Show the effective settings from config files and the environment
+  ebm config show
+
+  # Use the settings of a named profile
+  ebm batch repair ./archive --profile archive
+
+  # Put back a book removed by a cleanup
+  ebm trash restore ~/Books/Author/book.epub`,
+		// Settings from config files and EBM_* variables become the
+		// defaults of flags not given on the command line
+		PersistentPreRunE: applyConfig,
 	}
 
 	// Global flags available to all commands
-	cmd.PersistentFlags().StringVarP(&flags.Format, "format", "f", "text", "Output format: text, json, markdown")
+	cmd.PersistentFlags().StringVarP(&flags.Format, "format", "f", "text", "Output format: text, json, markdown, template")
+	cmd.PersistentFlags().StringVar(&flags.Template, "template", "", "Go text/template file used with --format template")
 	cmd.PersistentFlags().StringVarP(&flags.Output, "output", "o", "", "Write report to file instead of stdout")
 	cmd.PersistentFlags().BoolVarP(&flags.Verbose, "verbose", "v", false, "Enable verbose output")
 	cmd.PersistentFlags().BoolVar(&flags.Color, "color", true, "Enable colorized output")
 	cmd.PersistentFlags().StringVar(&flags.MinSeverity, "min-severity", "", "Minimum severity to include (info, warning, error)")
 	cmd.PersistentFlags().StringSliceVar(&flags.Severities, "severity", nil, "Include only specific severities (repeatable)")
 	cmd.PersistentFlags().IntVar(&flags.MaxErrors, "max-errors", 0, "Limit number of errors per report (0 = unlimited)")
+	cmd.PersistentFlags().IntVar(&flags.Context, "context", 0, "Show N lines of EPUB source around each issue location (text and markdown)")
+	cmd.PersistentFlags().StringVar(&flags.Profile, "profile", "", "Use the named settings profile from the profiles file")
 
 	// Default run behavior: if args are provided, try to validate them
 	cmd.Args = cobra.ArbitraryArgs
@@ -70,46 +104,29 @@ Validate and repair EPUB and PDF files with comprehensive reporting.
 			return c.Help()
 		}
 
-		// Check if first arg is a file or directory
+		// Check that the first arg is a path rather than a mistyped command
 		target := args[0]
-		info, err := os.Stat(target)
-		if err != nil {
+		if _, err := os.Stat(target); err != nil && !operations.HasGlobMeta(target) {
 			// If not a file/dir, and looks like a flag or unknown command, show help/error
 			return fmt.Errorf("unknown command or file: %q\n\nRun 'ebm --help' for usage", target)
 		}
 
-		// If it's a directory, run batch validate
-		if info.IsDir() {
-			batchFlags := &batchFlags{
-				// Set reasonable defaults for implicit batch mode
-				recursive:       true,
-				continueOnError: true,
-				maxDepth:        -1, // -1 means unlimited depth
-				// We can't easily access default values from flags here without parsing again or duplicating defaults
-				// but 0 int / false bool is usually fine, or we set specifics:
-				jobs: 0, // 0 will use runtime.NumCPU() in logic if we update it, or we set it here
-			}
-			// We need to ensure jobs is set to something valid if the struct defaults aren't used
-			// The runBatchValidate function expects flags to be populated.
-			// Let's rely on the fact that we can reuse the logic but we need to initialize flags manually
-			// or better: just invoke the validate/batch command explicitly?
-			// Cobra doesn't easily let us "redirect" to another command object's RunE without parsing its flags.
-
-			// Simpler approach: Call the logic function directly
-			// We need to handle the "jobs" default logic which is inside the flag definition usually.
-			// Let's update runBatchValidate to handle 0 jobs = NumCPU
-			return runBatchValidate(c.Context(), target, batchFlags, flags)
-		}
-
-		// If it's a file, run single validate
-		validateFlags := &validateFlags{}
-		return runValidate(c.Context(), target, validateFlags, flags)
+		// A single file gets a single-file report; directories, patterns
+		// and several paths run as a batch
+		return runValidatePaths(c.Context(), args, &validateFlags{}, flags)
 	}
 
 	// Add subcommands
 	cmd.AddCommand(newValidateCmd(flags))
 	cmd.AddCommand(newRepairCmd(flags))
 	cmd.AddCommand(newBatchCmd(flags))
+	cmd.AddCommand(newWatchCmd(flags))
+	cmd.AddCommand(newReportCmd(flags))
+	cmd.AddCommand(newSchemaCmd(flags))
+	cmd.AddCommand(newBenchCmd())
+	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newTrashCmd())
+	cmd.AddCommand(newWorkerCmd())
 	cmd.AddCommand(NewCompletionCmd(cmd))
 
 	cmd.SetOut(os.Stdout)
@@ -120,8 +137,17 @@ Validate and repair EPUB and PDF files with comprehensive reporting.
 
 // Execute runs the CLI command
 func Execute() error {
+	// Interrupting a batch stops it and still writes the report of what was
+	// done; a second interrupt exits at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	cmd := NewRootCmd()
-	if err := cmd.Execute(); err != nil {
+	if err := cmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		return err
 	}
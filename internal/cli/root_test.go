@@ -2,6 +2,8 @@ package cli
 
 import (
 	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/config"
 )
 
 func init() {
@@ -9,6 +11,10 @@ func init() {
 	osExit = func(code int) {
 		// Do nothing or record the code if needed
 	}
+	// Keep the system and user config files and EBM_* variables out of tests
+	configOptions = func(dir string) config.LoadOptions {
+		return config.LoadOptions{LibraryDir: dir}
+	}
 }
 
 func TestNewRootCmd(t *testing.T) {
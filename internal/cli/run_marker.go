@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+// runMarkerDir returns the directory holding --changed-since-last-run
+// markers; replaced in tests
+var runMarkerDir = func() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ebm", "run-markers"), nil
+}
+
+// runMarkerPath returns the marker file for an operation over a set of
+// paths. The same paths in any order share a marker.
+func runMarkerPath(operation operations.OperationType, paths []string) (string, error) {
+	dir, err := runMarkerDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate run marker directory: %w", err)
+	}
+
+	keys := make([]string, 0, len(paths))
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return "", err
+		}
+		keys = append(keys, abs)
+	}
+	sort.Strings(keys)
+
+	sum := sha256.Sum256([]byte(string(operation) + "\n" + strings.Join(keys, "\n")))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])), nil
+}
+
+// runMarker records a --changed-since-last-run run
+type runMarker struct {
+	Start time.Time `json:"start"`
+	Retry []string  `json:"retry,omitempty"` // Absolute paths of files that errored, found again even if unchanged
+}
+
+// loadRunMarker returns the last marked run, or a zero marker if there was
+// none
+func loadRunMarker(operation operations.OperationType, paths []string) (runMarker, error) {
+	path, err := runMarkerPath(operation, paths)
+	if err != nil {
+		return runMarker{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return runMarker{}, nil
+	}
+	if err != nil {
+		return runMarker{}, fmt.Errorf("failed to read run marker: %w", err)
+	}
+
+	var marker runMarker
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		err = json.Unmarshal(data, &marker)
+	} else {
+		// Markers used to hold only the start time
+		marker.Start, err = time.Parse(time.RFC3339Nano, string(data))
+	}
+	if err != nil {
+		return runMarker{}, fmt.Errorf("invalid run marker %s: %w", path, err)
+	}
+	return marker, nil
+}
+
+// saveRunMarker records a run for --changed-since-last-run
+func saveRunMarker(operation operations.OperationType, paths []string, marker runMarker) error {
+	path, err := runMarkerPath(operation, paths)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return fmt.Errorf("failed to encode run marker: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create run marker directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write run marker: %w", err)
+	}
+	return nil
+}
+
+// markRun remembers a run for the next --changed-since-last-run. Files
+// that errored are found again by the next run even if unchanged, and a
+// stopped run, or one whose discovery failed, keeps the start of the
+// previous one, so the files it never reached are not skipped either.
+func markRun(operation operations.OperationType, paths []string, start time.Time, stopped bool, result *operations.BatchResult) error {
+	marker := runMarker{Start: start}
+	if stopped {
+		previous, err := loadRunMarker(operation, paths)
+		if err != nil {
+			return err
+		}
+		marker = previous
+	}
+	for _, r := range result.Errored {
+		abs, err := filepath.Abs(r.FilePath)
+		if err != nil {
+			return err
+		}
+		marker.Retry = append(marker.Retry, abs)
+	}
+	sort.Strings(marker.Retry)
+	marker.Retry = slices.Compact(marker.Retry)
+	return saveRunMarker(operation, paths, marker)
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+func withRunMarkerDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	orig := runMarkerDir
+	runMarkerDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { runMarkerDir = orig })
+	return dir
+}
+
+func TestRunMarker_RoundTrip(t *testing.T) {
+	withRunMarkerDir(t)
+	paths := []string{"/lib/b", "/lib/a"}
+
+	last, err := loadRunMarker(operations.OperationValidate, paths)
+	if err != nil || !last.Start.IsZero() {
+		t.Fatalf("loadRunMarker() = %v, %v, want zero time", last, err)
+	}
+
+	start := time.Date(2024, 5, 10, 12, 0, 0, 123, time.UTC)
+	if err := saveRunMarker(operations.OperationValidate, paths, runMarker{Start: start, Retry: []string{"/lib/a/x.epub"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Path order does not matter, but the operation does
+	last, err = loadRunMarker(operations.OperationValidate, []string{"/lib/a", "/lib/b"})
+	if err != nil || !last.Start.Equal(start) || len(last.Retry) != 1 || last.Retry[0] != "/lib/a/x.epub" {
+		t.Errorf("loadRunMarker() = %v, %v, want %v", last, err, start)
+	}
+	if last, _ := loadRunMarker(operations.OperationRepair, paths); !last.Start.IsZero() {
+		t.Errorf("Expected no repair marker, got %v", last)
+	}
+}
+
+func TestRunMarker_StartOnly(t *testing.T) {
+	withRunMarkerDir(t)
+	path, err := runMarkerPath(operations.OperationValidate, []string{"/lib"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	if err := os.WriteFile(path, []byte(start.Format(time.RFC3339Nano)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if last, err := loadRunMarker(operations.OperationValidate, []string{"/lib"}); err != nil || !last.Start.Equal(start) {
+		t.Errorf("loadRunMarker() = %v, %v, want %v", last, err, start)
+	}
+}
+
+func TestMarkRun_RetriesErroredFiles(t *testing.T) {
+	withRunMarkerDir(t)
+	lib := t.TempDir()
+	past := time.Now().Add(-time.Hour)
+	for _, name := range []string{"ok.epub", "errored.epub"} {
+		path := filepath.Join(lib, name)
+		if err := os.WriteFile(path, []byte("t"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errored := filepath.Join(lib, "errored.epub")
+	result := &operations.BatchResult{
+		Valid:   []operations.Result{{FilePath: filepath.Join(lib, "ok.epub")}},
+		Errored: []operations.Result{{FilePath: errored, ErrorKind: operations.ErrorKindTimeout}},
+	}
+	start := time.Now()
+	if err := markRun(operations.OperationValidate, []string{lib}, start, false, result); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the errored file comes back, although neither changed
+	flags := &batchFlags{recursive: true, maxDepth: -1, changedSinceLast: true}
+	stream, err := startBatchInputs(context.Background(), []string{lib}, flags, operations.OperationValidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, _ := collectBatchInputs(t, stream)
+	if len(inputs) != 1 || inputs[0].Path != errored {
+		t.Fatalf("Expected only %s to be retried, got %+v", errored, inputs)
+	}
+
+	// A stopped run keeps the previous start and adds to the files to retry
+	later := start.Add(time.Hour)
+	result = &operations.BatchResult{Errored: []operations.Result{{FilePath: filepath.Join(lib, "ok.epub")}}}
+	if err := markRun(operations.OperationValidate, []string{lib}, later, true, result); err != nil {
+		t.Fatal(err)
+	}
+	last, err := loadRunMarker(operations.OperationValidate, []string{lib})
+	if err != nil || !last.Start.Equal(start) || len(last.Retry) != 2 {
+		t.Errorf("Expected the stopped run to keep the previous start, got %+v, %v", last, err)
+	}
+
+	// A complete run replaces the files to retry
+	if err := markRun(operations.OperationValidate, []string{lib}, later, false, &operations.BatchResult{}); err != nil {
+		t.Fatal(err)
+	}
+	if last, _ := loadRunMarker(operations.OperationValidate, []string{lib}); !last.Start.Equal(later) || len(last.Retry) != 0 {
+		t.Errorf("Expected a fresh marker, got %+v", last)
+	}
+}
+
+func TestRunMarker_Invalid(t *testing.T) {
+	dir := withRunMarkerDir(t)
+	path, err := runMarkerPath(operations.OperationValidate, []string{"/lib"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != dir {
+		t.Errorf("Expected marker in %s, got %s", dir, path)
+	}
+	if err := os.WriteFile(path, []byte("yesterday"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRunMarker(operations.OperationValidate, []string{"/lib"}); err == nil {
+		t.Error("Expected error for invalid marker")
+	}
+}
+
+func TestRunBatchValidate_ChangedSinceLastRun(t *testing.T) {
+	withRunMarkerDir(t)
+	lib := t.TempDir()
+	old := filepath.Join(lib, "old.epub")
+	if err := os.WriteFile(old, []byte("t"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := &batchFlags{recursive: true, jobs: 1, progress: "none", maxDepth: -1, timeout: 30, changedSinceLast: true}
+	rootFlags := &RootFlags{Format: "json", Output: filepath.Join(t.TempDir(), "report.json")}
+
+	// The first run has no marker and sees everything
+	if err := runBatchValidate(context.Background(), []string{lib}, flags, rootFlags); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed since, so the second run finds no files
+	if err := runBatchValidate(context.Background(), []string{lib}, flags, rootFlags); err == nil {
+		t.Error("Expected no files on the second run")
+	}
+
+	if _, err := startBatchInputs(context.Background(), nil, &batchFlags{filesFrom: "-", changedSinceLast: true}, operations.OperationValidate); err == nil {
+		t.Error("Expected error combining --changed-since-last-run with --files-from")
+	}
+}
@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaBuilders maps each document kind to the function building its JSON Schema
+var schemaBuilders = map[string]func() map[string]interface{}{
+	DocumentKindValidation: validationSchema,
+	DocumentKindRepair:     repairSchema,
+	DocumentKindBatch:      batchSchema,
+}
+
+// SchemaKinds returns the document kinds that have a published schema
+func SchemaKinds() []string {
+	kinds := make([]string, 0, len(schemaBuilders))
+	for kind := range schemaBuilders {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Schema returns the indented JSON Schema for a document kind
+func Schema(kind string) ([]byte, error) {
+	build, ok := schemaBuilders[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q (valid: %s)", kind, strings.Join(SchemaKinds(), ", "))
+	}
+	data, err := json.MarshalIndent(build(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func newSchemaCmd(rootFlags *RootFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema [validation|repair|batch]",
+		Short: "Print the JSON Schema for --format json output",
+		Long: fmt.Sprintf(`Print the JSON Schema describing the documents written with --format json.
+
+Every JSON document carries a "schema_version" field (currently %s) and a
+"kind" field naming the schema it conforms to. Minor versions only add
+optional fields; the major version changes when a field is removed, renamed
+or changes meaning.`, SchemaVersion),
+		Example: `  # Print the batch report schema
+  ebm schema batch
+
+  # Save the validation schema to a file
+  ebm schema validation --output validation.schema.json`,
+		ValidArgs: SchemaKinds(),
+		Args:      cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := Schema(args[0])
+			if err != nil {
+				return err
+			}
+			if rootFlags.Output != "" {
+				if err := os.WriteFile(rootFlags.Output, data, 0644); err != nil {
+					return fmt.Errorf("failed to write schema: %w", err)
+				}
+				return nil
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+}
+
+func validationSchema() map[string]interface{} {
+	schema := documentSchema("ebm validation report", DocumentKindValidation, validationReportSchema())
+	schema["$defs"] = map[string]interface{}{
+		"issue":    issueSchema(),
+		"location": locationSchema(),
+	}
+	return schema
+}
+
+func repairSchema() map[string]interface{} {
+	schema := documentSchema("ebm repair report", DocumentKindRepair, repairResultSchema())
+	schema["$defs"] = map[string]interface{}{
+		"validation_report": validationReportSchema(),
+		"issue":             issueSchema(),
+		"location":          locationSchema(),
+		"action":            actionSchema(),
+	}
+	return schema
+}
+
+func batchSchema() map[string]interface{} {
+	body := objectSchema(map[string]interface{}{
+		"operation":     enumSchema("validate", "repair"),
+		"total":         countSchema(),
+		"successful":    countSchema(),
+		"failed":        countSchema(),
+		"no_op":         countSchema(),
+		"duration":      withDescription(countSchema(), "Wall-clock duration in milliseconds"),
+		"summary":       refSchema("batch_summary"),
+		"options":       refSchema("batch_options"),
+		"removed_files": withDescription(arraySchema(typeSchema("string")), "Files deleted by the cleanup"),
+		"moved_files":   withDescription(arraySchema(typeSchema("string")), "Files moved by routing rules, before the move"),
+		"routed_files":  withDescription(arraySchema(refSchema("routed_file")), "Files a routing rule applied to, with where they went (since 1.10)"),
+		"cleanup":       withDescription(arraySchema(refSchema("cleanup_op")), "Planned deletions, moves and directory removals, with their status (since 1.11)"),
+		"top_issues":    withDescription(arraySchema(refSchema("issue_stat")), "Issue codes ranked by occurrences (since 1.1)"),
+		"roots":         withDescription(arraySchema(refSchema("root_stat")), "Per-root counts when several paths were given (since 1.2)"),
+		"symlinks":      withDescription(arraySchema(refSchema("symlink")), "Symbolic links followed or skipped during discovery (since 1.3)"),
+		"scan_error":    withDescription(typeSchema("string"), "Why file discovery stopped early; files not found by then were not processed (since 1.13)"),
+		"timing":        withDescription(refSchema("timing"), "Throughput, percentiles and the slowest files (since 1.8)"),
+		"results":       withDescription(refSchema("batch_results"), "Per-file results; omitted with --summary-only"),
+	}, "operation", "total", "successful", "failed", "no_op", "duration", "summary", "options", "removed_files", "moved_files")
+
+	schema := documentSchema("ebm batch report", DocumentKindBatch, body)
+	schema["$defs"] = map[string]interface{}{
+		"batch_summary": objectSchema(map[string]interface{}{
+			"valid":             countSchema(),
+			"invalid":           countSchema(),
+			"errored":           countSchema(),
+			"repairs_attempted": countSchema(),
+			"repairs_succeeded": countSchema(),
+			"repairs_no_op":     countSchema(),
+			"errors_by_kind":    withDescription(errorKindCountsSchema(), "Errored files counted by error kind (since 1.5)"),
+			"retried":           withDescription(countSchema(), "Files that needed more than one attempt (since 1.6)"),
+		}, "valid", "invalid", "errored", "repairs_attempted", "repairs_succeeded", "repairs_no_op"),
+		"batch_options": objectSchema(map[string]interface{}{
+			"profile":              withDescription(typeSchema("string"), "Settings profile chosen with --profile (since 1.9)"),
+			"num_workers":          withDescription(countSchema(), "Files processed at once; with auto_jobs, the count the run settled on"),
+			"auto_jobs":            withDescription(typeSchema("boolean"), "The worker count was tuned during the run with --jobs auto (since 1.7)"),
+			"skip_validation":      typeSchema("boolean"),
+			"no_backup":            typeSchema("boolean"),
+			"aggressive":           typeSchema("boolean"),
+			"remove_system_errors": typeSchema("boolean"),
+			"move_failed_repairs":  typeSchema("boolean"),
+			"cleanup_empty_dirs":   typeSchema("boolean"),
+			"routes":               withDescription(arraySchema(typeSchema("string")), "Routing rules, as given to --route (since 1.10)"),
+		}, "num_workers", "skip_validation", "no_backup", "aggressive", "remove_system_errors", "move_failed_repairs", "cleanup_empty_dirs"),
+		"batch_results": objectSchema(map[string]interface{}{
+			"valid":   arraySchema(refSchema("result")),
+			"invalid": arraySchema(refSchema("result")),
+			"errored": arraySchema(refSchema("result")),
+		}, "valid", "invalid", "errored"),
+		"issue_stat": objectSchema(map[string]interface{}{
+			"code":        typeSchema("string"),
+			"severity":    enumSchema("error", "warning", "info"),
+			"message":     typeSchema("string"),
+			"occurrences": countSchema(),
+			"files":       countSchema(),
+			"examples":    arraySchema(typeSchema("string")),
+		}, "code", "severity", "message", "occurrences", "files", "examples"),
+		"root_stat": objectSchema(map[string]interface{}{
+			"root":    typeSchema("string"),
+			"total":   countSchema(),
+			"valid":   countSchema(),
+			"invalid": countSchema(),
+			"errored": countSchema(),
+		}, "root", "total", "valid", "invalid", "errored"),
+		"routed_file": objectSchema(map[string]interface{}{
+			"path":    typeSchema("string"),
+			"dest":    withDescription(typeSchema("string"), "Where the file was moved, or the destination that was taken"),
+			"outcome": enumSchema("valid", "invalid", "reverted", "errored"),
+			"skipped": withDescription(typeSchema("boolean"), "Left in place because the destination was taken"),
+			"error":   withDescription(typeSchema("string"), "Why the file could not be moved"),
+		}, "path", "outcome"),
+		"cleanup_op": objectSchema(map[string]interface{}{
+			"action":  enumSchema("remove", "move", "rmdir"),
+			"path":    typeSchema("string"),
+			"dest":    withDescription(typeSchema("string"), "Where a file is moved"),
+			"outcome": enumSchema("valid", "invalid", "reverted", "errored"),
+			"kind":    withDescription(typeSchema("string"), "Error kind of a removed file"),
+			"files":   withDescription(arraySchema(typeSchema("string")), "Metadata files removed with a directory"),
+			"trashed": withDescription(typeSchema("string"), "Name in the trash of a removed file or directory (since 1.12)"),
+			"status":  withDescription(enumSchema("done", "skipped", "failed", "pending"), "pending when the cleanup was not applied"),
+			"error":   withDescription(typeSchema("string"), "Why the operation failed"),
+		}, "action", "path", "status"),
+		"symlink": objectSchema(map[string]interface{}{
+			"path":     typeSchema("string"),
+			"target":   typeSchema("string"),
+			"followed": typeSchema("boolean"),
+			"reason":   typeSchema("string"),
+		}, "path", "followed"),
+		"result": objectSchema(map[string]interface{}{
+			"file_path":  typeSchema("string"),
+			"root":       withDescription(typeSchema("string"), "Path argument the file was found under (since 1.2)"),
+			"report":     refSchema("validation_report"),
+			"repair":     refSchema("repair_result"),
+			"error":      typeSchema("string"),
+			"error_kind": withDescription(errorKindSchema(), "Classification of error (since 1.5)"),
+			"stack":      withDescription(typeSchema("string"), "Stack trace when processing the file panicked (since 1.4)"),
+			"attempts":   withDescription(countSchema(), "Times the file was processed, including retries (since 1.6)"),
+			"timeout":    withDescription(countSchema(), "Timeout of the last attempt in milliseconds (since 1.6)"),
+			"duration":   withDescription(numberSchema(), "Time spent on the file in milliseconds, including retries (since 1.8)"),
+			"bytes":      withDescription(countSchema(), "File size (since 1.8)"),
+			"phases":     withDescription(refSchema("phases"), "Time spent in each phase of the last attempt (since 1.8)"),
+		}, "file_path"),
+		"timing": objectSchema(map[string]interface{}{
+			"files":            withDescription(countSchema(), "Files with a recorded duration"),
+			"bytes":            countSchema(),
+			"files_per_second": numberSchema(),
+			"bytes_per_second": numberSchema(),
+			"p50":              withDescription(numberSchema(), "Median file time in milliseconds"),
+			"p90":              numberSchema(),
+			"p99":              numberSchema(),
+			"max":              numberSchema(),
+			"slowest":          withDescription(arraySchema(refSchema("file_timing")), "Slowest files first"),
+		}, "files", "bytes", "files_per_second", "bytes_per_second", "p50", "p90", "p99", "max", "slowest"),
+		"file_timing": objectSchema(map[string]interface{}{
+			"file_path": typeSchema("string"),
+			"duration":  withDescription(numberSchema(), "Milliseconds"),
+			"bytes":     countSchema(),
+			"phases":    refSchema("phases"),
+		}, "file_path", "duration", "bytes"),
+		"phases": withDescription(objectSchema(map[string]interface{}{
+			"validate": numberSchema(),
+			"preview":  numberSchema(),
+			"repair":   numberSchema(),
+		}), "Milliseconds spent in each phase; phases that did not run are omitted"),
+		"validation_report": validationReportSchema(),
+		"repair_result":     repairResultSchema(),
+		"issue":             issueSchema(),
+		"location":          locationSchema(),
+		"action":            actionSchema(),
+	}
+	return schema
+}
+
+// errorKindSchema is an enum of the operations.ErrorKind codes
+func errorKindSchema() map[string]interface{} {
+	return enumSchema(errorKindNames(operations.ErrorKinds())...)
+}
+
+// errorKindCountsSchema maps error kinds to counts
+func errorKindCountsSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"required":             []string{},
+		"propertyNames":        errorKindSchema(),
+		"additionalProperties": countSchema(),
+	}
+}
+
+// documentSchema turns a body schema into a top-level document schema by
+// adding the schema_version and kind fields
+func documentSchema(title, kind string, body map[string]interface{}) map[string]interface{} {
+	props := body["properties"].(map[string]interface{})
+	props["schema_version"] = map[string]interface{}{
+		"type":    "string",
+		"pattern": `^1\.[0-9]+$`,
+	}
+	props["kind"] = map[string]interface{}{"const": kind}
+	body["required"] = append([]string{"schema_version", "kind"}, body["required"].([]string)...)
+
+	body["$schema"] = jsonSchemaDraft
+	body["title"] = title
+	return body
+}
+
+func validationReportSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"file_path": typeSchema("string"),
+		"is_valid":  typeSchema("boolean"),
+		"summary": objectSchema(map[string]interface{}{
+			"errors":   countSchema(),
+			"warnings": countSchema(),
+			"info":     countSchema(),
+		}, "errors", "warnings", "info"),
+		"errors":   arraySchema(refSchema("issue")),
+		"warnings": arraySchema(refSchema("issue")),
+		"info":     arraySchema(refSchema("issue")),
+	}, "file_path", "is_valid", "summary", "errors", "warnings", "info")
+}
+
+func repairResultSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"success":           typeSchema("boolean"),
+		"actions_applied":   arraySchema(refSchema("action")),
+		"backup_path":       typeSchema("string"),
+		"error":             typeSchema("string"),
+		"validation_report": refSchema("validation_report"),
+	}, "success", "actions_applied")
+}
+
+func issueSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"code":     typeSchema("string"),
+		"message":  typeSchema("string"),
+		"severity": enumSchema("error", "warning", "info"),
+		"location": refSchema("location"),
+	}, "code", "message", "severity")
+}
+
+func locationSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"file": typeSchema("string"),
+		"line": countSchema(),
+	}, "file")
+}
+
+func actionSchema() map[string]interface{} {
+	return objectSchema(map[string]interface{}{
+		"type":        typeSchema("string"),
+		"description": typeSchema("string"),
+	}, "type", "description")
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	if required == nil {
+		required = []string{}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func arraySchema(items map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": items}
+}
+
+func typeSchema(t string) map[string]interface{} {
+	return map[string]interface{}{"type": t}
+}
+
+func countSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "minimum": 0}
+}
+
+// numberSchema is a non-negative number, such as fractional milliseconds
+func numberSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "number", "minimum": 0}
+}
+
+func enumSchema(values ...string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "enum": values}
+}
+
+func refSchema(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+func withDescription(schema map[string]interface{}, description string) map[string]interface{} {
+	schema["description"] = description
+	return schema
+}
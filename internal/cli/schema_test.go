@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+// checkSchema validates doc against the subset of JSON Schema used by the
+// published schemas: type, properties, required, additionalProperties,
+// items, enum, const, pattern, minimum and local $ref. The schemas leave
+// objects open so that consumers accept fields added by later minor
+// versions, but fields declared neither in properties nor by
+// additionalProperties are reported here, so that the output cannot drift
+// from the schema.
+func checkSchema(root, schema map[string]interface{}, doc interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		def, ok := root["$defs"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unresolved $ref %q", path, ref)
+		}
+		return checkSchema(root, def, doc, path)
+	}
+
+	if c, ok := schema["const"]; ok && doc != c {
+		return fmt.Errorf("%s: got %v, want const %v", path, doc, c)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, v := range enum {
+			if v == doc {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v not in enum %v", path, doc, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %T", path, doc)
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		for _, r := range schema["required"].([]interface{}) {
+			if _, ok := obj[r.(string)]; !ok {
+				return fmt.Errorf("%s: missing required field %q", path, r)
+			}
+		}
+		for key, value := range obj {
+			prop, ok := props[key].(map[string]interface{})
+			if !ok {
+				if _, declared := schema["additionalProperties"]; !declared {
+					return fmt.Errorf("%s: unexpected field %q", path, key)
+				}
+				continue
+			}
+			if err := checkSchema(root, prop, value, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := doc.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %T", path, doc)
+		}
+		items := schema["items"].(map[string]interface{})
+		for i, item := range arr {
+			if err := checkSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := doc.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected string, got %T", path, doc)
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			return fmt.Errorf("%s: %q does not match %s", path, s, pattern)
+		}
+	case "integer":
+		n, ok := doc.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: expected integer, got %v", path, doc)
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return fmt.Errorf("%s: %v below minimum %v", path, n, min)
+		}
+	case "boolean":
+		if _, ok := doc.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %T", path, doc)
+		}
+	}
+
+	return nil
+}
+
+func assertConforms(t *testing.T, kind, output string) {
+	t.Helper()
+
+	data, err := Schema(kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema %s is not valid JSON: %v", kind, err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	if err := checkSchema(schema, schema, doc, "$"); err != nil {
+		t.Errorf("output does not conform to %s schema: %v\n%s", kind, err, output)
+	}
+}
+
+func sampleReport(path string) *ebmlib.ValidationReport {
+	return &ebmlib.ValidationReport{
+		FilePath: path,
+		IsValid:  false,
+		Errors: []ebmlib.ValidationError{
+			{Code: "EPUB-001", Message: "Missing mimetype", Severity: ebmlib.SeverityError, Location: &ebmlib.ErrorLocation{File: "mimetype", Line: 1}},
+		},
+		Warnings: []ebmlib.ValidationError{
+			{Code: "EPUB-100", Message: "Deprecated element", Severity: ebmlib.SeverityWarning},
+		},
+	}
+}
+
+func TestJSONOutputConformsToSchema(t *testing.T) {
+	f := &JSONFormatter{}
+
+	assertConforms(t, DocumentKindValidation, f.FormatValidation(sampleReport("a.epub")))
+	assertConforms(t, DocumentKindValidation, f.FormatValidation(&ebmlib.ValidationReport{FilePath: "b.epub", IsValid: true}))
+
+	repair := &ebmlib.RepairResult{
+		Success:        true,
+		ActionsApplied: []ebmlib.RepairAction{{Type: "fix_mimetype", Description: "Rewrote mimetype"}},
+		BackupPath:     "a.epub.bak",
+	}
+	assertConforms(t, DocumentKindRepair, f.FormatRepair(repair, sampleReport("a.epub")))
+	assertConforms(t, DocumentKindRepair, f.FormatRepair(&ebmlib.RepairResult{Error: errors.New("boom")}, nil))
+
+	batch := operations.AggregateResults([]operations.Result{
+		{FilePath: "lib/a.epub", Root: "lib", Report: sampleReport("a.epub")},
+		{FilePath: "lib/b.epub", Root: "lib", Report: &ebmlib.ValidationReport{FilePath: "b.epub", IsValid: true}},
+		{FilePath: "c.epub", Root: "c.epub", Error: errors.New("unreadable"), ErrorKind: operations.ErrorKindIO},
+	}, 1500*time.Millisecond, operations.OperationValidate)
+	batch.Symlinks = []operations.SymlinkEvent{
+		{Path: "lib/author", Target: "/disk/author", Followed: true},
+		{Path: "lib/broken.epub", Reason: operations.SymlinkBroken},
+	}
+	assertConforms(t, DocumentKindBatch, f.FormatBatchValidation(&batch, false))
+	assertConforms(t, DocumentKindBatch, f.FormatBatchValidation(&batch, true))
+
+	repairBatch := operations.AggregateResults([]operations.Result{
+		{FilePath: "a.epub", Repair: repair},
+	}, time.Second, operations.OperationRepair)
+	repairBatch.MovedFiles = []string{"b.epub"}
+	repairBatch.Options.Routes = []string{"reverted=INVALID;keep-path"}
+	repairBatch.Routed = []operations.RoutedFile{
+		{Path: "b.epub", Dest: "INVALID/b.epub", Outcome: operations.OutcomeReverted},
+		{Path: "c.epub", Dest: "INVALID/c.epub", Outcome: operations.OutcomeReverted, Skipped: true},
+	}
+	repairBatch.Cleanup = operations.CleanupPlan{
+		{Action: operations.CleanupMove, Path: "b.epub", Dest: "INVALID/b.epub", Outcome: operations.OutcomeReverted, Done: true},
+		{Action: operations.CleanupRemoveDir, Path: "old", Files: []string{"metadata.opf"}, Err: errors.New("busy")},
+	}
+	assertConforms(t, DocumentKindBatch, f.FormatBatchRepair(&repairBatch, false))
+}
+
+func TestJSONOutputSchemaVersion(t *testing.T) {
+	f := &JSONFormatter{}
+	batch := operations.AggregateResults(nil, 0, operations.OperationValidate)
+
+	outputs := map[string]string{
+		DocumentKindValidation: f.FormatValidation(sampleReport("a.epub")),
+		DocumentKindRepair:     f.FormatRepair(&ebmlib.RepairResult{Success: true}, nil),
+		DocumentKindBatch:      f.FormatBatchValidation(&batch, true),
+	}
+
+	for kind, output := range outputs {
+		var header struct {
+			SchemaVersion string `json:"schema_version"`
+			Kind          string `json:"kind"`
+		}
+		if err := json.Unmarshal([]byte(output), &header); err != nil {
+			t.Fatal(err)
+		}
+		if header.SchemaVersion != SchemaVersion {
+			t.Errorf("%s: schema_version = %q, want %q", kind, header.SchemaVersion, SchemaVersion)
+		}
+		if header.Kind != kind {
+			t.Errorf("kind = %q, want %q", header.Kind, kind)
+		}
+	}
+}
+
+func TestPublishedSchemasUpToDate(t *testing.T) {
+	for _, kind := range SchemaKinds() {
+		cmd := newSchemaCmd(&RootFlags{})
+		var want bytes.Buffer
+		cmd.SetOut(&want)
+		cmd.SetArgs([]string{kind})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("schema %s error = %v", kind, err)
+		}
+		path := filepath.Join("..", "..", "docs", "schemas", kind+".schema.json")
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read published schema: %v", err)
+		}
+		if !bytes.Equal(got, want.Bytes()) {
+			t.Errorf("%s is out of date; regenerate with: ebm schema %s > %s", path, kind, path)
+		}
+	}
+}
+
+func TestSchemaCmd(t *testing.T) {
+	cmd := newSchemaCmd(&RootFlags{})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"batch"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("schema batch error = %v", err)
+	}
+	if !strings.Contains(out.String(), `"title": "ebm batch report"`) {
+		t.Errorf("Unexpected schema output:\n%s", out.String())
+	}
+
+	if _, err := Schema("nope"); err == nil {
+		t.Error("Expected error for unknown schema kind")
+	}
+}
+
+func TestSchemaCmd_Output(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "validation.schema.json")
+	cmd := newSchemaCmd(&RootFlags{Output: path})
+	cmd.SetArgs([]string{"validation"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(data) {
+		t.Error("Expected valid JSON schema file")
+	}
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+// Template data kinds exposed to report templates as TemplateData.Kind
+const (
+	TemplateKindValidation      = "validation"
+	TemplateKindRepair          = "repair"
+	TemplateKindBatchValidation = "batch-validation"
+	TemplateKindBatchRepair     = "batch-repair"
+)
+
+// TemplateData is the data model passed to user-supplied report templates.
+// Only the fields relevant to Kind are set; the others are nil.
+// See docs/REPORT_TEMPLATES.md for the full reference.
+type TemplateData struct {
+	Kind        string                   // One of the TemplateKind* constants
+	GeneratedAt time.Time                // When the report was rendered
+	SummaryOnly bool                     // True when --summary-only was requested
+	Report      *ebmlib.ValidationReport // Validation report (validation, or post-repair validation)
+	Repair      *ebmlib.RepairResult     // Repair result (repair only)
+	Batch       *operations.BatchResult  // Batch result (batch-validation, batch-repair)
+}
+
+// IssueGroup is a set of issues sharing the same code, as returned by groupByCode
+type IssueGroup struct {
+	Code     string
+	Severity ebmlib.Severity
+	Count    int
+	Issues   []ebmlib.ValidationError
+}
+
+// TemplateFormatter formats output by executing a user-supplied text/template
+type TemplateFormatter struct {
+	ColorEnabled bool
+	tmpl         *template.Template
+}
+
+// NewTemplateFormatter loads and parses the template at path
+func NewTemplateFormatter(path string, colorEnabled bool) (*TemplateFormatter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	f := &TemplateFormatter{ColorEnabled: colorEnabled}
+	tmpl, err := template.New(filepath.Base(path)).Funcs(f.funcs()).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	f.tmpl = tmpl
+
+	return f, nil
+}
+
+// Execute renders the template against data
+func (f *TemplateFormatter) Execute(data TemplateData) (string, error) {
+	if data.GeneratedAt.IsZero() {
+		data.GeneratedAt = time.Now()
+	}
+
+	var b strings.Builder
+	if err := f.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return b.String(), nil
+}
+
+func (f *TemplateFormatter) FormatValidation(report *ebmlib.ValidationReport) string {
+	return f.mustExecute(TemplateData{Kind: TemplateKindValidation, Report: report})
+}
+
+func (f *TemplateFormatter) FormatRepair(result *ebmlib.RepairResult, report *ebmlib.ValidationReport) string {
+	return f.mustExecute(TemplateData{Kind: TemplateKindRepair, Repair: result, Report: report})
+}
+
+func (f *TemplateFormatter) FormatBatchValidation(result *operations.BatchResult, summaryOnly bool) string {
+	return f.mustExecute(TemplateData{Kind: TemplateKindBatchValidation, Batch: result, SummaryOnly: summaryOnly})
+}
+
+func (f *TemplateFormatter) FormatBatchRepair(result *operations.BatchResult, summaryOnly bool) string {
+	return f.mustExecute(TemplateData{Kind: TemplateKindBatchRepair, Batch: result, SummaryOnly: summaryOnly})
+}
+
+// mustExecute satisfies the Formatter interface; template errors are rendered
+// into the output. Report writers call Execute directly to surface them instead.
+func (f *TemplateFormatter) mustExecute(data TemplateData) string {
+	out, err := f.Execute(data)
+	if err != nil {
+		return fmt.Sprintf("template error: %s\n", err)
+	}
+	return out
+}
+
+func (f *TemplateFormatter) funcs() template.FuncMap {
+	return template.FuncMap{
+		"severityColor": f.severityColor,
+		"relPath":       relPath,
+		"base":          filepath.Base,
+		"count":         count,
+		"groupByCode":   groupByCode,
+		"issues":        allIssues,
+		"upper":         strings.ToUpper,
+		"lower":         strings.ToLower,
+		"join":          strings.Join,
+		"repeat":        strings.Repeat,
+		"duration": func(d time.Duration) string {
+			return d.Round(time.Millisecond).String()
+		},
+	}
+}
+
+// severityColor colours text according to severity when color is enabled
+func (f *TemplateFormatter) severityColor(severity interface{}, text string) string {
+	if !f.ColorEnabled {
+		return text
+	}
+
+	switch strings.ToLower(fmt.Sprint(severity)) {
+	case "error":
+		return color.RedString("%s", text)
+	case "warning":
+		return color.YellowString("%s", text)
+	case "info":
+		return color.CyanString("%s", text)
+	default:
+		return text
+	}
+}
+
+// relPath returns path relative to the working directory when possible
+func relPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// count returns the length of a slice, array, map or string, treating nil as 0
+func count(v interface{}) int {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String, reflect.Chan:
+		return rv.Len()
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return 0
+		}
+		return count(rv.Elem().Interface())
+	default:
+		return 0
+	}
+}
+
+// allIssues returns the errors, warnings and info of a report in that order
+func allIssues(report *ebmlib.ValidationReport) []ebmlib.ValidationError {
+	if report == nil {
+		return nil
+	}
+	issues := make([]ebmlib.ValidationError, 0, len(report.Errors)+len(report.Warnings)+len(report.Info))
+	issues = append(issues, report.Errors...)
+	issues = append(issues, report.Warnings...)
+	issues = append(issues, report.Info...)
+	return issues
+}
+
+// groupByCode groups issues by code, most frequent first. It accepts a
+// []ebmlib.ValidationError, a *ebmlib.ValidationReport or a *operations.BatchResult.
+func groupByCode(v interface{}) ([]IssueGroup, error) {
+	var issues []ebmlib.ValidationError
+	switch src := v.(type) {
+	case nil:
+	case []ebmlib.ValidationError:
+		issues = src
+	case *ebmlib.ValidationReport:
+		issues = allIssues(src)
+	case *operations.BatchResult:
+		if src != nil {
+			for _, group := range [][]operations.Result{src.Valid, src.Invalid, src.Errored} {
+				for _, r := range group {
+					issues = append(issues, allIssues(r.Report)...)
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("groupByCode: unsupported type %T", v)
+	}
+
+	index := make(map[string]int)
+	var groups []IssueGroup
+	for _, issue := range issues {
+		i, ok := index[issue.Code]
+		if !ok {
+			i = len(groups)
+			index[issue.Code] = i
+			groups = append(groups, IssueGroup{Code: issue.Code, Severity: issue.Severity})
+		}
+		groups[i].Count++
+		groups[i].Issues = append(groups[i].Issues, issue)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+
+	return groups, nil
+}
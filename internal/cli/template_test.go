@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func writeTemplate(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTemplateFormatter_FormatValidation(t *testing.T) {
+	path := writeTemplate(t, `{{.Kind}} {{base .Report.FilePath}} errors={{count .Report.Errors}}{{range .Report.Errors}} [{{.Code}}]{{end}}`)
+	f, err := NewTemplateFormatter(path, false)
+	if err != nil {
+		t.Fatalf("NewTemplateFormatter() error = %v", err)
+	}
+
+	report := &ebmlib.ValidationReport{
+		FilePath: "/books/test.epub",
+		Errors: []ebmlib.ValidationError{
+			{Code: "ERR1", Message: "Error 1", Severity: ebmlib.SeverityError},
+		},
+	}
+
+	got := f.FormatValidation(report)
+	want := "validation test.epub errors=1 [ERR1]"
+	if got != want {
+		t.Errorf("FormatValidation() = %q, want %q", got, want)
+	}
+}
+
+func TestTemplateFormatter_GroupByCode(t *testing.T) {
+	path := writeTemplate(t, `{{range groupByCode .Batch}}{{.Code}}={{.Count}};{{end}}`)
+	f, err := NewTemplateFormatter(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := &operations.BatchResult{
+		Invalid: []operations.Result{
+			{FilePath: "a.epub", Report: &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "E1"}, {Code: "E2"}}}},
+			{FilePath: "b.epub", Report: &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "E2"}}}},
+		},
+	}
+
+	got := f.FormatBatchValidation(result, false)
+	if got != "E2=2;E1=1;" {
+		t.Errorf("FormatBatchValidation() = %q, want %q", got, "E2=2;E1=1;")
+	}
+}
+
+func TestTemplateFormatter_SeverityColorDisabled(t *testing.T) {
+	path := writeTemplate(t, `{{range issues .Report}}{{severityColor .Severity .Code}}{{end}}`)
+	f, err := NewTemplateFormatter(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := &ebmlib.ValidationReport{
+		Errors:   []ebmlib.ValidationError{{Code: "E1", Severity: ebmlib.SeverityError}},
+		Warnings: []ebmlib.ValidationError{{Code: "W1", Severity: ebmlib.SeverityWarning}},
+	}
+
+	if got := f.FormatValidation(report); got != "E1W1" {
+		t.Errorf("FormatValidation() = %q, want %q", got, "E1W1")
+	}
+}
+
+func TestNewTemplateFormatter_ParseError(t *testing.T) {
+	path := writeTemplate(t, `{{.Kind`)
+	if _, err := NewTemplateFormatter(path, false); err == nil {
+		t.Fatal("Expected parse error")
+	}
+}
+
+func TestNewTemplateFormatter_MissingFile(t *testing.T) {
+	if _, err := NewTemplateFormatter(filepath.Join(t.TempDir(), "missing.tmpl"), false); err == nil {
+		t.Fatal("Expected error for missing template")
+	}
+}
+
+func TestTemplateFormatter_ExecuteError(t *testing.T) {
+	path := writeTemplate(t, `{{.Report.Nope}}`)
+	f, err := NewTemplateFormatter(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.Execute(TemplateData{Kind: TemplateKindValidation, Report: &ebmlib.ValidationReport{}})
+	if err == nil {
+		t.Fatal("Expected execution error")
+	}
+	if !strings.Contains(err.Error(), "report.tmpl") {
+		t.Errorf("Expected error to name the template, got %v", err)
+	}
+
+	if got := f.FormatValidation(&ebmlib.ValidationReport{}); !strings.HasPrefix(got, "template error:") {
+		t.Errorf("Expected formatter output to carry the error, got %q", got)
+	}
+}
+
+func TestNewReportOptions_Template(t *testing.T) {
+	path := writeTemplate(t, `{{.Kind}}`)
+
+	opts, err := NewReportOptions(&RootFlags{Format: "template", Template: path})
+	if err != nil {
+		t.Fatalf("NewReportOptions() error = %v", err)
+	}
+	if _, ok := opts.Formatter.(*TemplateFormatter); !ok {
+		t.Errorf("Expected *TemplateFormatter, got %T", opts.Formatter)
+	}
+
+	if _, err := NewReportOptions(&RootFlags{Format: "template"}); err == nil {
+		t.Error("Expected error when --template is missing")
+	}
+	if _, err := NewReportOptions(&RootFlags{Format: "json", Template: path}); err == nil {
+		t.Error("Expected error when --template is used without --format template")
+	}
+}
+
+func TestWriteReport_TemplateError(t *testing.T) {
+	path := writeTemplate(t, `{{.Report.Nope}}`)
+	opts, err := NewReportOptions(&RootFlags{Format: "template", Template: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.OutputPath = filepath.Join(t.TempDir(), "out.txt")
+
+	if err := WriteReport(&ebmlib.ValidationReport{FilePath: "test.epub"}, opts); err == nil {
+		t.Error("Expected template execution error from WriteReport")
+	}
+}
+
+func TestCount(t *testing.T) {
+	var nilSlice []string
+	tests := []struct {
+		name string
+		in   interface{}
+		want int
+	}{
+		{"nil", nil, 0},
+		{"nil slice", nilSlice, 0},
+		{"slice", []int{1, 2, 3}, 3},
+		{"map", map[string]int{"a": 1}, 1},
+		{"string", "abc", 3},
+		{"int", 5, 0},
+	}
+
+	for _, tt := range tests {
+		if got := count(tt.in); got != tt.want {
+			t.Errorf("count(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
+)
+
+type trashFlags struct {
+	dir       string
+	olderThan string
+	yes       bool
+}
+
+func newTrashCmd() *cobra.Command {
+	flags := &trashFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore or empty the books removed by cleanups",
+		Long: `Books and folders removed by a batch cleanup or the TUI Calibre cleanup go
+to the trash instead of being deleted. The trash is ebm's own,
+$XDG_DATA_HOME/ebm/trash (~/.local/share/ebm/trash), unless --trash-dir or
+the trash-dir setting names another directory. It is laid out as the
+freedesktop.org trash: the removed files in files/ and a .trashinfo file in
+info/ recording where each came from and when. Books on another filesystem
+than the trash are copied into it and removed once the copy is on disk.`,
+		Example: `  # List what was removed
+  ebm trash list
+
+  # Put a book back where it was
+  ebm trash restore ~/Books/Author/book.epub
+
+  # Permanently delete what was removed more than 30 days ago
+  ebm trash empty --older-than 30d`,
+	}
+
+	cmd.PersistentFlags().StringVar(&flags.dir, "trash-dir", "", "Trash directory (default $XDG_DATA_HOME/ebm/trash)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the trash, oldest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrashList(cmd.OutOrStdout(), cmd.ErrOrStderr(), trash.New(flags.dir))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restore <name|path>...",
+		Short: "Move entries of the trash back to where they were removed from",
+		Long: `Move entries of the trash back to where they were removed from. An entry is
+given by its name in the trash, as listed by 'ebm trash list', or by its
+original path, which restores the last entry removed from it. A folder is
+merged into one created there since; a file already there is never
+overwritten.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrashRestore(cmd.OutOrStdout(), trash.New(flags.dir), args)
+		},
+	})
+
+	emptyCmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete entries of the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrashEmpty(cmd.OutOrStdout(), cmd.ErrOrStderr(), trash.New(flags.dir), flags)
+		},
+	}
+	emptyCmd.Flags().StringVar(&flags.olderThan, "older-than", "", "Only delete entries removed longer ago than this, e.g. 30d or 12h")
+	emptyCmd.Flags().BoolVarP(&flags.yes, "yes", "y", false, "Delete without asking")
+	cmd.AddCommand(emptyCmd)
+
+	return cmd
+}
+
+// runTrashList prints the entries of the trash
+func runTrashList(out, errOut io.Writer, bin *trash.Trash) error {
+	entries, skipped, err := bin.List()
+	if err != nil {
+		return err
+	}
+	warnSkippedEntries(errOut, skipped)
+	if len(entries) == 0 {
+		fmt.Fprintf(out, "The trash in %s is empty\n", bin.Dir)
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "DELETED\tNAME\tORIGINAL PATH")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Deleted.Format("2006-01-02 15:04"), entry.Name, entry.Path)
+	}
+	return w.Flush()
+}
+
+// runTrashRestore restores the entries given by name or original path
+func runTrashRestore(out io.Writer, bin *trash.Trash, names []string) error {
+	failed := 0
+	for _, name := range names {
+		entry, err := bin.Find(name)
+		if err == nil {
+			err = bin.Restore(entry)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "✗ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "Restored %s to %s\n", entry.Name, entry.Path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d entries not restored", failed, len(names))
+	}
+	return nil
+}
+
+// runTrashEmpty permanently deletes the entries older than --older-than,
+// or all of them, once confirmed
+func runTrashEmpty(out, errOut io.Writer, bin *trash.Trash, flags *trashFlags) error {
+	before := time.Now().Add(time.Second) // Everything, including entries from this second
+	if flags.olderThan != "" {
+		age, err := parseAge(flags.olderThan)
+		if err != nil {
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+		before = time.Now().Add(-age)
+	}
+
+	entries, skipped, err := bin.List()
+	if err != nil {
+		return err
+	}
+	warnSkippedEntries(errOut, skipped)
+	n := 0
+	for _, entry := range entries {
+		if entry.Deleted.Before(before) {
+			n++
+		}
+	}
+	if n == 0 {
+		fmt.Fprintln(out, "Nothing to delete")
+		return nil
+	}
+	if !flags.yes && !confirm(out, fmt.Sprintf("Permanently delete %d of %d entries in %s?", n, len(entries), bin.Dir)) {
+		fmt.Fprintln(out, "Nothing was deleted; pass --yes to delete without asking.")
+		return nil
+	}
+
+	emptied, err := bin.Empty(before)
+	fmt.Fprintf(out, "Deleted %d entries\n", len(emptied))
+	if err != nil {
+		return fmt.Errorf("failed to empty the trash: %w", err)
+	}
+	return nil
+}
+
+// warnSkippedEntries reports the entries of the trash that could not be read
+func warnSkippedEntries(errOut io.Writer, skipped []error) {
+	for _, err := range skipped {
+		fmt.Fprintf(errOut, "Warning: skipped %v\n", err)
+	}
+}
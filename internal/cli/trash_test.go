@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
+)
+
+func TestTrashCmd(t *testing.T) {
+	dir := t.TempDir()
+	user := filepath.Join(dir, "config")
+	bin := trash.New(filepath.Join(dir, "trash"))
+	if err := os.WriteFile(user, []byte("trash-dir = \""+filepath.ToSlash(bin.Dir)+"\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useConfig(t, user)
+
+	lib := t.TempDir()
+	book := filepath.Join(lib, "Author", "book.epub")
+	if err := os.MkdirAll(filepath.Dir(book), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(book, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bin.Put(book); err != nil {
+		t.Fatal(err)
+	}
+
+	// An info file that cannot be read is skipped with a warning
+	bad := filepath.Join(bin.Dir, "info", "bad.epub.trashinfo")
+	if err := os.WriteFile(bad, []byte("[Trash Info]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var errOut bytes.Buffer
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		root := NewRootCmd()
+		root.SetOut(&out)
+		root.SetErr(&errOut)
+		root.SetArgs(append([]string{"trash"}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	// The configured trash is used
+	out, err := run("list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "ORIGINAL PATH") || !strings.Contains(out, "book.epub") || !strings.Contains(out, book) {
+		t.Errorf("Unexpected list:\n%s", out)
+	}
+	if !strings.Contains(errOut.String(), "Warning: skipped invalid trash info for bad.epub") {
+		t.Errorf("Expected a warning for the bad entry, got %q", errOut.String())
+	}
+
+	if _, err := run("restore", "nothing.epub"); err == nil {
+		t.Error("Expected an error for an entry not in the trash")
+	}
+	if out, err := run("restore", book); err != nil || !strings.Contains(out, "Restored book.epub to "+book) {
+		t.Fatalf("restore: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(book); err != nil {
+		t.Errorf("Expected the book to be back: %v", err)
+	}
+
+	if _, err := bin.Put(book); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := run("empty", "--older-than", "1d", "--yes"); err != nil || !strings.Contains(out, "Nothing to delete") {
+		t.Errorf("Expected a recent entry to be kept: %v\n%s", err, out)
+	}
+	if _, err := run("empty", "--older-than", "soon"); err == nil {
+		t.Error("Expected an error for a bad --older-than")
+	}
+
+	// Emptying asks first
+	previous := confirm
+	defer func() { confirm = previous }()
+	confirm = func(io.Writer, string) bool { return false }
+	if out, _ := run("empty"); !strings.Contains(out, "Nothing was deleted") {
+		t.Errorf("Expected nothing to be deleted without confirmation:\n%s", out)
+	}
+	confirm = func(io.Writer, string) bool { return true }
+	if out, err := run("empty"); err != nil || !strings.Contains(out, "Deleted 1 entries") {
+		t.Errorf("empty: %v\n%s", err, out)
+	}
+	if entries, _, _ := bin.List(); len(entries) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", entries)
+	}
+}
@@ -22,12 +22,15 @@ func newValidateCmd(rootFlags *RootFlags) *cobra.Command {
 	flags := &validateFlags{}
 
 	cmd := &cobra.Command{
-		Use:   "validate <file>|-",
-		Short: "Validate a single EPUB or PDF file",
-		Long: `Validate an EPUB or PDF file for errors, warnings, and informational issues.
+		Use:   "validate <path>...|-",
+		Short: "Validate EPUB and PDF files",
+		Long: `Validate EPUB and PDF files for errors, warnings, and informational issues.
 
-The file can be provided as a path or read from stdin using '-'.
-When reading from stdin, the --type flag is required to specify the file format.`,
+A single file can be provided as a path or read from stdin using '-'.
+When reading from stdin, the --type flag is required to specify the file format.
+
+Several files, directories or glob patterns are validated as a batch with
+results aggregated into one report.`,
 		Example: `  # Validate a file
   ebm validate book.epub
   ebm validate document.pdf
@@ -35,6 +38,9 @@ When reading from stdin, the --type flag is required to specify the file format.
   # Validate with JSON output
   ebm validate book.epub --format json
 
+  # Validate several files and directories in one report
+  ebm validate a.epub b.pdf ./more-books
+
   # Validate from stdin
   cat book.epub | ebm validate - --type epub
 
@@ -44,9 +50,9 @@ When reading from stdin, the --type flag is required to specify the file format.
   # Filter by severity
   ebm validate book.epub --min-severity error
   ebm validate book.epub --severity error --severity warning`,
-		Args: cobra.ExactArgs(1),
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runValidate(cmd.Context(), args[0], flags, rootFlags)
+			return runValidatePaths(cmd.Context(), args, flags, rootFlags)
 		},
 	}
 
@@ -55,6 +61,41 @@ When reading from stdin, the --type flag is required to specify the file format.
 	return cmd
 }
 
+// runValidatePaths validates a single file with a single-file report and any
+// other combination of paths as a batch
+func runValidatePaths(ctx context.Context, args []string, flags *validateFlags, rootFlags *RootFlags) error {
+	if isSingleFile(args) {
+		return runValidate(ctx, args[0], flags, rootFlags)
+	}
+	if err := checkNoStdin(args); err != nil {
+		return err
+	}
+	return runBatchValidate(ctx, args, defaultBatchFlags(), rootFlags)
+}
+
+// isSingleFile reports whether args name exactly one file or stdin, as
+// opposed to a directory, glob pattern or several paths
+func isSingleFile(args []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+	info, err := os.Stat(args[0])
+	if err != nil {
+		return args[0] == "-" || !operations.HasGlobMeta(args[0])
+	}
+	return !info.IsDir()
+}
+
+// checkNoStdin rejects '-' when it is mixed with other paths
+func checkNoStdin(args []string) error {
+	for _, arg := range args {
+		if arg == "-" {
+			return fmt.Errorf("'-' (stdin) cannot be combined with other paths")
+		}
+	}
+	return nil
+}
+
 func runValidate(ctx context.Context, target string, flags *validateFlags, rootFlags *RootFlags) error {
 	// Create report options
 	opts, err := NewReportOptions(rootFlags)
@@ -101,3 +101,70 @@ func TestRunValidate_WriteError(t *testing.T) {
 	}
 
 }
+
+func TestIsSingleFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "book.epub")
+	if err := os.WriteFile(src, []byte("t"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{src}, true},
+		{[]string{"-"}, true},
+		{[]string{filepath.Join(tmpDir, "missing.epub")}, true},
+		{[]string{tmpDir}, false},
+		{[]string{filepath.Join(tmpDir, "*.epub")}, false},
+		{[]string{src, src}, false},
+	}
+	for _, tt := range tests {
+		if got := isSingleFile(tt.args); got != tt.want {
+			t.Errorf("isSingleFile(%v) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRunValidatePaths_Multiple(t *testing.T) {
+	lib1 := t.TempDir()
+	lib2 := t.TempDir()
+	extra := filepath.Join(t.TempDir(), "extra.epub")
+	for _, path := range []string{filepath.Join(lib1, "a.epub"), filepath.Join(lib2, "b.epub"), extra} {
+		if err := os.WriteFile(path, []byte("t"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(t.TempDir(), "report.json")
+	rootFlags := &RootFlags{Format: "json", Output: output}
+
+	// lib1 is given twice, once through a pattern, and is only processed once
+	args := []string{lib1, lib2, extra, filepath.Join(lib1, "*.epub")}
+	if err := runValidatePaths(context.Background(), args, &validateFlags{}, rootFlags); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := ParseSavedReport(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Batch == nil || saved.Batch.Total != 3 {
+		t.Fatalf("Expected a batch report of 3 files, got %+v", saved)
+	}
+	if len(saved.Batch.Roots) != 3 {
+		t.Errorf("Expected 3 roots, got %+v", saved.Batch.Roots)
+	}
+}
+
+func TestRunValidatePaths_StdinWithPaths(t *testing.T) {
+	err := runValidatePaths(context.Background(), []string{"-", t.TempDir()}, &validateFlags{}, &RootFlags{})
+	if err == nil {
+		t.Error("Expected error mixing stdin with other paths")
+	}
+}
@@ -0,0 +1,292 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+type watchFlags struct {
+	batch        batchFlags // Jobs, timeouts and repair settings
+	repair       bool
+	validDir     string
+	invalidDir   string
+	errorDir     string
+	settle       float64
+	pollInterval float64
+	poll         bool
+	statusFile   string
+}
+
+func newWatchCmd(rootFlags *RootFlags) *cobra.Command {
+	flags := &watchFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "watch <dir>",
+		Short: "Validate or repair books as they arrive in a folder",
+		Long: `Watch an intake folder and process each EPUB or PDF file dropped into it
+once the file has stopped changing. Valid files are moved to valid/, invalid
+ones to invalid/ and files that could not be read to error/, inside the
+folder unless --valid-dir, --invalid-dir or --error-dir say otherwise.
+Relative destinations are inside the watched folder. Subfolders are not
+watched.
+
+On Linux new files are noticed with inotify; elsewhere, or with --poll, the
+folder is scanned every --poll-interval seconds. Files already in the
+folder are processed when the watch starts.
+
+Every event is printed as a line, or as a JSON object per line with
+--format json. A summary of the counts since the start and over the last
+hour is printed when the watch stops with Ctrl-C, on SIGUSR1, and written
+to --status-file after every event.`,
+		Example: `  # Sort new books in an inbox
+  ebm watch ~/inbox
+
+  # Repair books as they arrive, keeping backups outside the inbox
+  ebm watch ~/inbox --repair --backup-dir ~/backups
+
+  # Send the books to the library and log the events as JSON
+  ebm watch ~/inbox --valid-dir ~/library --format json --output watch.log
+
+  # Scan a network share every 10 seconds
+  ebm watch /mnt/nas/inbox --poll --poll-interval 10
+
+  # Print the summary of a running watch
+  kill -USR1 $(pgrep -f "ebm watch")`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], flags, rootFlags)
+		},
+	}
+
+	flags.batch.jobs = runtime.NumCPU()
+	cmd.Flags().VarP(jobsValue{&flags.batch}, "jobs", "j", "Number of files processed at once, or auto to tune")
+	addRetryFlags(cmd, &flags.batch, 60)
+	cmd.Flags().StringSliceVar(&flags.batch.extensions, "ext", nil, "File extensions to watch for (default: .epub, .pdf)")
+	cmd.Flags().BoolVar(&flags.repair, "repair", false, "Repair files instead of only validating them")
+	cmd.Flags().StringVar(&flags.batch.backupDir, "backup-dir", "", "Directory for backups of repaired files (default: backup/ in the watched folder)")
+	cmd.Flags().BoolVar(&flags.batch.noBackup, "no-backup", false, "Skip backup before in-place repair")
+	cmd.Flags().BoolVar(&flags.batch.aggressive, "aggressive", false, "Enable aggressive repairs (may drop content/structure)")
+	cmd.Flags().StringVar(&flags.validDir, "valid-dir", "valid", "Where valid and repaired files are moved")
+	cmd.Flags().StringVar(&flags.invalidDir, "invalid-dir", "invalid", "Where invalid and unrepairable files are moved")
+	cmd.Flags().StringVar(&flags.errorDir, "error-dir", "error", "Where files that could not be processed are moved")
+	cmd.Flags().Float64Var(&flags.settle, "settle", 2, "Seconds a file must stay unchanged before it is processed")
+	cmd.Flags().Float64Var(&flags.pollInterval, "poll-interval", 1, "Seconds between checks of settling files, and scans with --poll")
+	cmd.Flags().BoolVar(&flags.poll, "poll", false, "Scan the folder instead of using inotify, e.g. on network mounts")
+	cmd.Flags().StringVar(&flags.statusFile, "status-file", "", "Rewrite this file with the summary after every event")
+
+	return cmd
+}
+
+func runWatch(ctx context.Context, out, errOut io.Writer, dir string, flags *watchFlags, rootFlags *RootFlags) error {
+	jsonLines := false
+	switch rootFlags.Format {
+	case "", "text":
+	case "json":
+		jsonLines = true
+	default:
+		return fmt.Errorf("--format: watch supports text and json, not %q", rootFlags.Format)
+	}
+	if flags.settle < 0 || flags.pollInterval <= 0 {
+		return fmt.Errorf("--settle must not be negative and --poll-interval must be positive")
+	}
+	if flags.batch.noBackup && flags.batch.backupDir != "" {
+		return fmt.Errorf("--backup-dir is not supported with --no-backup")
+	}
+
+	config, err := watchConfig(dir, flags)
+	if err != nil {
+		return err
+	}
+	watcher, err := operations.NewWatcher(config)
+	if err != nil {
+		return err
+	}
+
+	if rootFlags.Output != "" {
+		f, err := os.OpenFile(rootFlags.Output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if len(summarySignals) > 0 {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, summarySignals...)
+		defer signal.Stop(signals)
+		go func() {
+			for {
+				select {
+				case <-signals:
+					writeWatchSummary(errOut, watcher.Summary())
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	base := config.Dir
+	err = watcher.Run(ctx, func(event operations.WatchEvent) {
+		if jsonLines {
+			writeWatchEventJSON(out, event)
+		} else {
+			writeWatchEvent(out, base, event)
+		}
+		if flags.statusFile != "" {
+			var b strings.Builder
+			writeWatchSummary(&b, watcher.Summary())
+			if err := os.WriteFile(flags.statusFile, []byte(b.String()), 0644); err != nil {
+				fmt.Fprintf(errOut, "Warning: failed to write status file: %v\n", err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	writeWatchSummary(errOut, watcher.Summary())
+	return nil
+}
+
+// watchConfig builds the watch settings from the flags
+func watchConfig(dir string, flags *watchFlags) (operations.WatchConfig, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return operations.WatchConfig{}, err
+	}
+	config := operations.DefaultWatchConfig(abs)
+	config.Extensions = flags.batch.extensions
+	config.ValidDir = flags.validDir
+	config.InvalidDir = flags.invalidDir
+	config.ErrorDir = flags.errorDir
+	config.Settle = time.Duration(flags.settle * float64(time.Second))
+	config.PollInterval = time.Duration(flags.pollInterval * float64(time.Second))
+	config.Poll = flags.poll
+
+	config.Batch.NumWorkers = flags.batch.jobs
+	config.Batch.Timeout = time.Duration(flags.batch.timeout) * time.Second
+	applyJobsFlag(&config.Batch, &flags.batch)
+	applyRetryFlags(&config.Batch, &flags.batch)
+	if flags.repair {
+		config.Operation = operations.OperationRepair
+		config.Batch.Aggressive = flags.batch.aggressive
+		config.Batch.RepairMode = operations.RepairSaveModeBackupOriginal
+		if flags.batch.noBackup {
+			config.Batch.RepairMode = operations.RepairSaveModeNoBackup
+		}
+		// Backups next to the books would be picked up as new books
+		config.Batch.BackupDir = flags.batch.backupDir
+		if config.Batch.BackupDir == "" {
+			config.Batch.BackupDir = filepath.Join(abs, "backup")
+		}
+	}
+	return config, nil
+}
+
+// writeWatchEvent prints an event as a line of the watch log, with paths
+// relative to the watched folder
+func writeWatchEvent(out io.Writer, base string, event operations.WatchEvent) {
+	line := fmt.Sprintf("%s  %-8s  %s", event.Time.Format("2006-01-02 15:04:05"), event.Kind, watchPath(base, event.Path))
+	if event.Dest != "" {
+		line += " -> " + watchPath(base, event.Dest)
+	}
+	if event.Detail != "" {
+		line += " (" + event.Detail + ")"
+	}
+	if event.MoveErr != nil {
+		line += "; not moved: " + event.MoveErr.Error()
+	}
+	fmt.Fprintln(out, line)
+}
+
+// watchEventDocument is an event of the watch log written with --format json
+type watchEventDocument struct {
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Path      string    `json:"path"`
+	Dest      string    `json:"dest,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	ErrorKind string    `json:"error_kind,omitempty"`
+	MoveError string    `json:"move_error,omitempty"`
+	Duration  float64   `json:"duration_seconds,omitempty"`
+}
+
+// writeWatchEventJSON prints an event as a JSON object on one line
+func writeWatchEventJSON(out io.Writer, event operations.WatchEvent) {
+	doc := watchEventDocument{
+		Time:   event.Time,
+		Event:  string(event.Kind),
+		Path:   event.Path,
+		Dest:   event.Dest,
+		Detail: event.Detail,
+	}
+	if event.MoveErr != nil {
+		doc.MoveError = event.MoveErr.Error()
+	}
+	if r := event.Result; r != nil {
+		doc.ErrorKind = string(r.ErrorKind)
+		doc.Duration = r.Duration.Seconds()
+	}
+	_ = json.NewEncoder(out).Encode(doc)
+}
+
+// writeWatchSummary prints the counts of a watch since it started and over
+// the last hour, and the last files processed
+func writeWatchSummary(out io.Writer, s operations.WatchSummary) {
+	fmt.Fprintf(out, "Watching %s with %s since %s", s.Dir, s.Method, s.Started.Format("2006-01-02 15:04:05"))
+	if s.Settling > 0 {
+		fmt.Fprintf(out, ", %d settling", s.Settling)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "\tTOTAL\tLAST HOUR")
+	rows := []struct {
+		name         string
+		total, since int
+	}{
+		{"Detected", s.Total.Detected, s.Recent.Detected},
+		{"Valid", s.Total.Valid, s.Recent.Valid},
+		{"Invalid", s.Total.Invalid, s.Recent.Invalid},
+		{"Errored", s.Total.Errored, s.Recent.Errored},
+		{"Not moved", s.Total.MoveFailed, s.Recent.MoveFailed},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", row.name, row.total, row.since)
+	}
+	_ = w.Flush()
+
+	if len(s.Files) > 0 {
+		fmt.Fprintln(out, "\nLast files:")
+		for _, event := range s.Files {
+			fmt.Fprint(out, "  ")
+			writeWatchEvent(out, s.Dir, event)
+		}
+	}
+}
+
+// watchPath shortens paths inside the watched folder to relative ones
+func watchPath(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+		return rel
+	}
+	return path
+}
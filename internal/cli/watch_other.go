@@ -0,0 +1,8 @@
+//go:build !unix
+
+package cli
+
+import "os"
+
+// summarySignals is empty where SIGUSR1 does not exist; use --status-file
+var summarySignals []os.Signal
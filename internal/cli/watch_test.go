@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+func TestRunWatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "book.epub"), []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status := filepath.Join(t.TempDir(), "status")
+
+	flags := &watchFlags{validDir: "valid", invalidDir: "invalid", errorDir: "error", settle: 0.01, pollInterval: 0.01, poll: true, statusFile: status}
+	flags.batch = *defaultBatchFlags()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Stop once the book has been sorted
+		for ctx.Err() == nil {
+			if _, err := os.Stat(filepath.Join(dir, "book.epub")); err != nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	defer cancel()
+
+	var out, errOut bytes.Buffer
+	if err := runWatch(ctx, &out, &errOut, dir, flags, &RootFlags{Format: "json"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []watchEventDocument
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var doc watchEventDocument
+		if err := json.Unmarshal([]byte(line), &doc); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", line, err)
+		}
+		events = append(events, doc)
+	}
+	if len(events) != 3 || events[0].Event != "started" || events[1].Event != "detected" {
+		t.Fatalf("Expected started, detected and an outcome, got %+v", events)
+	}
+	sorted := events[2]
+	if sorted.Dest == "" || filepath.Base(sorted.Dest) != "book.epub" || filepath.Base(filepath.Dir(sorted.Dest)) != sorted.Event {
+		t.Errorf("Expected the book in the folder of its outcome, got %+v", sorted)
+	}
+	if _, err := os.Stat(sorted.Dest); err != nil {
+		t.Errorf("Expected %s to exist: %v", sorted.Dest, err)
+	}
+
+	for _, want := range []string{"Watching " + dir + " with polling", "LAST HOUR", "Detected    1       1", "Last files:"} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Errorf("Summary missing %q:\n%s", want, errOut.String())
+		}
+	}
+	data, err := os.ReadFile(status)
+	if err != nil || !strings.Contains(string(data), "Detected    1       1") {
+		t.Errorf("Expected the status file to hold the summary, got %q (%v)", data, err)
+	}
+}
+
+func TestRunWatch_BadFlags(t *testing.T) {
+	dir := t.TempDir()
+	flags := &watchFlags{validDir: "valid", invalidDir: "invalid", errorDir: "error", settle: 1, pollInterval: 1}
+	if err := runWatch(context.Background(), &bytes.Buffer{}, &bytes.Buffer{}, dir, flags, &RootFlags{Format: "markdown"}); err == nil {
+		t.Error("Expected an error for --format markdown")
+	}
+
+	flags.pollInterval = 0
+	if err := runWatch(context.Background(), &bytes.Buffer{}, &bytes.Buffer{}, dir, flags, &RootFlags{}); err == nil {
+		t.Error("Expected an error for a zero --poll-interval")
+	}
+
+	flags.pollInterval = 1
+	flags.invalidDir = dir
+	if err := runWatch(context.Background(), &bytes.Buffer{}, &bytes.Buffer{}, dir, flags, &RootFlags{}); err == nil {
+		t.Error("Expected an error for a destination that is the watched folder")
+	}
+}
+
+func TestWatchConfig_Repair(t *testing.T) {
+	dir := t.TempDir()
+	flags := &watchFlags{repair: true, validDir: "valid", invalidDir: "invalid", errorDir: "error", settle: 0.5, pollInterval: 1}
+	flags.batch = *defaultBatchFlags()
+
+	config, err := watchConfig(dir, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Operation != operations.OperationRepair || config.Batch.RepairMode != operations.RepairSaveModeBackupOriginal {
+		t.Errorf("Expected repairs with backups, got %+v", config)
+	}
+	if config.Batch.BackupDir != filepath.Join(dir, "backup") {
+		t.Errorf("Expected backups inside the watched folder, got %q", config.Batch.BackupDir)
+	}
+	if config.Settle != 500*time.Millisecond {
+		t.Errorf("Expected a 500ms settle time, got %v", config.Settle)
+	}
+
+	flags.batch.noBackup = true
+	if config, _ = watchConfig(dir, flags); config.Batch.RepairMode != operations.RepairSaveModeNoBackup {
+		t.Errorf("Expected no backups, got %q", config.Batch.RepairMode)
+	}
+}
+
+func TestWriteWatchEvent(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "inbox")
+	when := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		event operations.WatchEvent
+		want  string
+	}{
+		{
+			operations.WatchEvent{Time: when, Kind: operations.WatchDetected, Path: filepath.Join(base, "a.epub")},
+			"2024-05-01 09:30:00  detected  a.epub\n",
+		},
+		{
+			operations.WatchEvent{Time: when, Kind: operations.WatchInvalid, Path: filepath.Join(base, "b.pdf"), Dest: filepath.Join(base, "invalid", "b.pdf"), Detail: "2 errors"},
+			"2024-05-01 09:30:00  invalid   b.pdf -> " + filepath.Join("invalid", "b.pdf") + " (2 errors)\n",
+		},
+		{
+			operations.WatchEvent{Time: when, Kind: operations.WatchValid, Path: filepath.Join(base, "c.epub"), MoveErr: errors.New("permission denied")},
+			"2024-05-01 09:30:00  valid     c.epub; not moved: permission denied\n",
+		},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		writeWatchEvent(&out, base, tt.event)
+		if out.String() != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, out.String())
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// summarySignals print the summary of a running watch
+var summarySignals = []os.Signal{syscall.SIGUSR1}
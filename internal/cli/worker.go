@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+// workerCmdName is the hidden command that processes one file for --isolate
+const workerCmdName = "worker"
+
+type workerFlags struct {
+	operation  string
+	noBackup   bool
+	backupDir  string
+	aggressive bool
+}
+
+// newWorkerCmd creates the hidden command run in child processes by
+// --isolate. It processes one file and writes the result as JSON to stdout.
+func newWorkerCmd() *cobra.Command {
+	flags := &workerFlags{}
+
+	cmd := &cobra.Command{
+		Use:    workerCmdName + " <file>",
+		Short:  "Process one file for an isolated batch run",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result := runWorker(cmd.Context(), args[0], flags)
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.operation, "op", string(operations.OperationValidate), "Operation (validate, repair)")
+	cmd.Flags().BoolVar(&flags.noBackup, "no-backup", false, "Skip backup before in-place repair")
+	cmd.Flags().StringVar(&flags.backupDir, "backup-dir", "", "Directory for backup files")
+	cmd.Flags().BoolVar(&flags.aggressive, "aggressive", false, "Enable aggressive repairs")
+
+	return cmd
+}
+
+func runWorker(ctx context.Context, filePath string, flags *workerFlags) operations.Result {
+	config := operations.BatchConfig{
+		RepairMode: operations.RepairSaveModeBackupOriginal,
+		BackupDir:  flags.backupDir,
+		Aggressive: flags.aggressive,
+	}
+	if flags.noBackup {
+		config.RepairMode = operations.RepairSaveModeNoBackup
+	}
+	task := operations.Task{FilePath: filePath, Operation: operations.OperationType(flags.operation)}
+	return operations.RunTask(ctx, config, task)
+}
+
+// isolateConfig builds the child command for --isolate, running this
+// executable's worker command with the batch's repair settings
+func isolateConfig(operation operations.OperationType, flags *batchFlags) (*operations.IsolateConfig, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("--isolate: cannot locate ebm executable: %w", err)
+	}
+
+	config := &operations.IsolateConfig{
+		Command: []string{exe, workerCmdName, "--op", string(operation)},
+	}
+	if operation == operations.OperationRepair {
+		if flags.noBackup {
+			config.Command = append(config.Command, "--no-backup")
+		}
+		if flags.backupDir != "" {
+			config.Command = append(config.Command, "--backup-dir", flags.backupDir)
+		}
+		if flags.aggressive {
+			config.Command = append(config.Command, "--aggressive")
+		}
+	}
+	// File names starting with '-' are not flags
+	config.Command = append(config.Command, "--")
+
+	if flags.isolateMemory != "" {
+		if config.MemoryLimit, err = parseSize(flags.isolateMemory); err != nil {
+			return nil, fmt.Errorf("--isolate-memory: %w", err)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+)
+
+func TestRunWorker_UnsupportedFile(t *testing.T) {
+	result := runWorker(context.Background(), "notes.txt", &workerFlags{operation: string(operations.OperationValidate)})
+	if result.FilePath != "notes.txt" || result.Error == nil {
+		t.Errorf("runWorker() = %+v", result)
+	}
+}
+
+func TestWorkerCmd_WritesResultJSON(t *testing.T) {
+	cmd := NewRootCmd()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{workerCmdName, "--op", "validate", "--", filepath.Join(t.TempDir(), "missing.epub")})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	var result operations.Result
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("worker output is not a result: %v\n%s", err, out.String())
+	}
+	if result.Error == nil {
+		t.Errorf("Expected an error for a missing file, got %+v", result)
+	}
+}
+
+func TestIsolateConfig(t *testing.T) {
+	flags := &batchFlags{noBackup: true, aggressive: true, isolateMemory: "512MB"}
+	config, err := isolateConfig(operations.OperationRepair, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{workerCmdName, "--op", "repair", "--no-backup", "--aggressive", "--"}
+	if !reflect.DeepEqual(config.Command[1:], want) {
+		t.Errorf("Command = %v, want %v", config.Command[1:], want)
+	}
+	if config.MemoryLimit != 512<<20 {
+		t.Errorf("MemoryLimit = %d", config.MemoryLimit)
+	}
+
+	// Repair settings are not passed to validation
+	config, err = isolateConfig(operations.OperationValidate, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{workerCmdName, "--op", "validate", "--"}; !reflect.DeepEqual(config.Command[1:], want) {
+		t.Errorf("Command = %v, want %v", config.Command[1:], want)
+	}
+
+	if _, err := isolateConfig(operations.OperationValidate, &batchFlags{isolateMemory: "lots"}); err == nil {
+		t.Error("Expected error for an invalid memory limit")
+	}
+}
@@ -0,0 +1,372 @@
+// Package config loads ebm settings from layered configuration files and
+// the environment. Later layers override earlier ones: the system file, the
+// user file, a per-library file, the chosen profile, EBM_* environment
+// variables and finally command-line flags.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// Source is the layer a setting came from
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceSystem  Source = "system"
+	SourceUser    Source = "user"
+	SourceLibrary Source = "library"
+	SourceProfile Source = "profile"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// LibraryFiles are the per-library config file names, looked for in the
+// library directory and its parents
+var LibraryFiles = []string{".ebm.toml", ".ebm.yaml", ".ebm.yml"}
+
+// EnvPrefix starts the environment variable of each setting, e.g. EBM_JOBS
+const EnvPrefix = "EBM_"
+
+// Setting is the effective value of a key
+type Setting struct {
+	Key    string
+	Value  string   // In the form accepted by the flag of the same name
+	List   []string // The items of a list setting
+	Source Source
+	Origin string // File path, environment variable or flag
+}
+
+// File is a config file considered while loading
+type File struct {
+	Path   string
+	Source Source
+	Found  bool
+}
+
+// Config holds the settings of all layers merged together
+type Config struct {
+	Files    []File
+	settings map[string]Setting
+	profile  string
+}
+
+// LoadOptions selects the layers to load. Empty paths are skipped.
+type LoadOptions struct {
+	SystemFile string
+	UserFile   string
+	LibraryDir string   // Searched upwards for a per-library file
+	Environ    []string // KEY=value pairs, as from os.Environ
+
+	// ProfilesFile holds the named profiles. Profile selects one, as
+	// --profile does; otherwise the profile setting of the other layers
+	// does.
+	ProfilesFile string
+	Profile      string
+}
+
+// DefaultLoadOptions loads the standard system and user files, the library
+// file for libraryDir and the process environment
+func DefaultLoadOptions(libraryDir string) LoadOptions {
+	return LoadOptions{
+		SystemFile:   SystemPath(),
+		UserFile:     UserPath(),
+		LibraryDir:   libraryDir,
+		Environ:      os.Environ(),
+		ProfilesFile: ProfilesPath(),
+	}
+}
+
+// SystemPath returns the system-wide config file
+func SystemPath() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return filepath.Join(dir, "ebm", "config")
+		}
+		return ""
+	}
+	return "/etc/ebm/config"
+}
+
+// UserPath returns the user config file, $XDG_CONFIG_HOME/ebm/config, or
+// the platform's user config directory when XDG_CONFIG_HOME is unset
+func UserPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "ebm", "config")
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ebm", "config")
+}
+
+// FindLibraryFile returns the per-library config file in dir or its
+// nearest parent that has one, or "" if there is none
+func FindLibraryFile(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, name := range LibraryFiles {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the layers selected by opts. Missing files are skipped;
+// unknown keys and invalid values are errors.
+func Load(opts LoadOptions) (*Config, error) {
+	c := &Config{settings: make(map[string]Setting)}
+
+	files := []File{
+		{Path: opts.SystemFile, Source: SourceSystem},
+		{Path: opts.UserFile, Source: SourceUser},
+	}
+	if opts.LibraryDir != "" {
+		if path := FindLibraryFile(opts.LibraryDir); path != "" {
+			files = append(files, File{Path: path, Source: SourceLibrary})
+		}
+	}
+	for _, f := range files {
+		if f.Path == "" {
+			continue
+		}
+		found, err := c.loadFile(f.Path, f.Source)
+		if err != nil {
+			return nil, err
+		}
+		f.Found = found
+		c.Files = append(c.Files, f)
+	}
+
+	env, err := readEnv(opts.Environ)
+	if err != nil {
+		return nil, err
+	}
+	name := opts.Profile
+	if name == "" {
+		if s, ok := env["profile"]; ok {
+			name = s.Value
+		} else if s, ok := c.settings["profile"]; ok {
+			name = s.Value
+		}
+	}
+	if name != "" {
+		if err := c.loadProfile(opts.ProfilesFile, name); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range env {
+		c.settings[s.Key] = s
+	}
+	return c, nil
+}
+
+// loadProfile merges the settings of the named profile
+func (c *Config) loadProfile(path, name string) error {
+	if path == "" {
+		return fmt.Errorf("unknown profile %q: no profiles file", name)
+	}
+	p, err := findProfile(path, name)
+	if err != nil {
+		return err
+	}
+	c.Files = append(c.Files, File{Path: path, Source: SourceProfile, Found: true})
+	c.profile = name
+	origin := fmt.Sprintf("%s [%s]", path, name)
+	for key, value := range p.Values {
+		c.settings[key] = Setting{Key: key, Value: value, Source: SourceProfile, Origin: origin}
+	}
+	for key, items := range p.Lists {
+		c.settings[key] = listSetting(key, items, SourceProfile, origin)
+	}
+	return nil
+}
+
+// loadFile merges the settings of a config file, reporting whether it
+// exists
+func (c *Config) loadFile(path string, source Source) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading config: %w", err)
+	}
+
+	entries, err := parse(path, data)
+	if err != nil {
+		return true, err
+	}
+	for _, e := range entries {
+		key, ok := Lookup(e.key)
+		if !ok {
+			return true, fmt.Errorf("%s:%d: unknown setting %q", path, e.line, e.key)
+		}
+		if e.list && key.Kind != KindList {
+			return true, fmt.Errorf("%s:%d: %s takes a single value, not a list", path, e.line, key.Name)
+		}
+		if err := key.check(e.value); err != nil {
+			return true, fmt.Errorf("%s:%d: %s: %w", path, e.line, key.Name, err)
+		}
+		if key.Kind == KindList {
+			c.settings[key.Name] = listSetting(key.Name, e.listItems(), source, path)
+			continue
+		}
+		c.settings[key.Name] = Setting{Key: key.Name, Value: e.value, Source: source, Origin: path}
+	}
+	return true, nil
+}
+
+// readEnv returns the settings given as EBM_* environment variables
+func readEnv(environ []string) (map[string]Setting, error) {
+	settings := make(map[string]Setting)
+	values := make(map[string]string)
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			values[name] = value
+		}
+	}
+	for _, key := range Keys {
+		name := key.EnvName()
+		value, ok := values[name]
+		if !ok {
+			continue
+		}
+		if err := key.check(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if key.Kind == KindList {
+			settings[key.Name] = listSetting(key.Name, splitList(value), SourceEnv, name)
+			continue
+		}
+		settings[key.Name] = Setting{Key: key.Name, Value: value, Source: SourceEnv, Origin: name}
+	}
+	return settings, nil
+}
+
+// Apply sets the flags in fs that were not given on the command line to
+// their configured values, and records the ones that were as flag settings
+func (c *Config) Apply(flags *pflag.FlagSet) error {
+	for _, key := range Keys {
+		for _, name := range key.flagNames() {
+			f := flags.Lookup(name)
+			if f == nil {
+				continue
+			}
+			slice, isSlice := f.Value.(pflag.SliceValue)
+			if f.Changed {
+				s := Setting{Key: key.Name, Value: f.Value.String(), Source: SourceFlag, Origin: "--" + name}
+				if isSlice {
+					s.List = slice.GetSlice()
+				}
+				c.settings[key.Name] = s
+				continue
+			}
+			s, ok := c.settings[key.Name]
+			if !ok {
+				continue
+			}
+			// Value.Set and Replace leave the flag unchanged, so commands
+			// can still tell explicit flags apart
+			var err error
+			if isSlice && s.List != nil {
+				err = slice.Replace(s.List)
+			} else {
+				err = f.Value.Set(s.Value)
+			}
+			if err != nil {
+				return fmt.Errorf("%s (from %s): %w", key.Name, s.Origin, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Profile returns the name of the profile loaded, or ""
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// Lookup returns the effective setting of a key, if any layer set it
+func (c *Config) Lookup(name string) (Setting, bool) {
+	s, ok := c.settings[name]
+	return s, ok
+}
+
+// Settings returns the keys set by any layer, in registry order
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	for _, key := range Keys {
+		if s, ok := c.settings[key.Name]; ok {
+			settings = append(settings, s)
+		}
+	}
+	return settings
+}
+
+// Bool returns a boolean setting, or def if it is unset
+func (c *Config) Bool(name string, def bool) bool {
+	if s, ok := c.settings[name]; ok {
+		if v, err := strconv.ParseBool(s.Value); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// Int returns an integer setting, or def if it is unset or not a number
+func (c *Config) Int(name string, def int) int {
+	if s, ok := c.settings[name]; ok {
+		if v, err := strconv.Atoi(s.Value); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// List returns the items of a list setting, or nil if it is unset
+func (c *Config) List(name string) []string {
+	s, ok := c.settings[name]
+	if !ok || len(s.List) == 0 {
+		return nil
+	}
+	return slices.Clone(s.List)
+}
+
+// listSetting makes the setting of a list key. Value shows the items as
+// they would be given in the environment.
+func listSetting(key string, items []string, source Source, origin string) Setting {
+	return Setting{Key: key, Value: strings.Join(items, ","), List: items, Source: source, Origin: origin}
+}
+
+// splitList splits a comma-separated list from the environment
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_Layers(t *testing.T) {
+	dir := t.TempDir()
+	system := filepath.Join(dir, "etc", "config")
+	user := filepath.Join(dir, "home", "config")
+	library := filepath.Join(dir, "library")
+	writeFile(t, system, "jobs = 2\nretries = 5\naggressive = true\n")
+	writeFile(t, user, "jobs = 4\nno_backup = true\n")
+	writeFile(t, filepath.Join(library, ".ebm.yaml"), "jobs: 8\next: [.epub]\n")
+	if err := os.MkdirAll(filepath.Join(library, "fiction"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(LoadOptions{
+		SystemFile: system,
+		UserFile:   user,
+		LibraryDir: filepath.Join(library, "fiction"),
+		Environ:    []string{"EBM_AGGRESSIVE=false", "EBM_UNRELATED=1", "HOME=/root"},
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	want := map[string]Setting{
+		"jobs":       {Key: "jobs", Value: "8", Source: SourceLibrary, Origin: filepath.Join(library, ".ebm.yaml")},
+		"retries":    {Key: "retries", Value: "5", Source: SourceSystem, Origin: system},
+		"aggressive": {Key: "aggressive", Value: "false", Source: SourceEnv, Origin: "EBM_AGGRESSIVE"},
+		"no-backup":  {Key: "no-backup", Value: "true", Source: SourceUser, Origin: user},
+		"ext":        {Key: "ext", Value: ".epub", List: []string{".epub"}, Source: SourceLibrary, Origin: filepath.Join(library, ".ebm.yaml")},
+	}
+	settings := cfg.Settings()
+	if len(settings) != len(want) {
+		t.Fatalf("Expected %d settings, got %+v", len(want), settings)
+	}
+	for _, s := range settings {
+		if !reflect.DeepEqual(s, want[s.Key]) {
+			t.Errorf("%s = %+v, want %+v", s.Key, s, want[s.Key])
+		}
+	}
+	if len(cfg.Files) != 3 || !cfg.Files[2].Found || cfg.Files[2].Source != SourceLibrary {
+		t.Errorf("Files = %+v", cfg.Files)
+	}
+	if cfg.Int("jobs", 1) != 8 || cfg.Bool("aggressive", true) || !cfg.Bool("cleanup-empty-dirs", true) {
+		t.Error("Typed getters returned the wrong values")
+	}
+	if ext := cfg.List("ext"); len(ext) != 1 || ext[0] != ".epub" || cfg.List("route") != nil {
+		t.Errorf("List returned %v", ext)
+	}
+}
+
+func TestLoad_MissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := Load(LoadOptions{SystemFile: filepath.Join(dir, "none"), UserFile: filepath.Join(dir, "also-none")})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Settings()) != 0 || len(cfg.Files) != 2 || cfg.Files[0].Found {
+		t.Errorf("Expected two missing files and no settings, got %+v %+v", cfg.Files, cfg.Settings())
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config")
+	tests := []struct {
+		content string
+		environ []string
+		want    string
+	}{
+		{"jobz = 4", nil, path + `:1: unknown setting "jobz"`},
+		{"\njobs = 0", nil, path + `:2: jobs: must be a positive number or "auto"`},
+		{"retries = \"many\"", nil, path + `:1: retries: invalid value "many"`},
+		{"order = [\"walk\"]", nil, path + ":1: order takes a single value, not a list"},
+		{"", []string{"EBM_NO_BACKUP=maybe"}, `EBM_NO_BACKUP: invalid value "maybe"`},
+	}
+	for _, tt := range tests {
+		writeFile(t, path, tt.content)
+		_, err := Load(LoadOptions{UserFile: path, Environ: tt.environ})
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("Load(%q) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
+
+func TestFindLibraryFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".ebm.toml"), "")
+	nested := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindLibraryFile(nested); got != filepath.Join(dir, ".ebm.toml") {
+		t.Errorf("FindLibraryFile = %q", got)
+	}
+}
+
+func TestUserPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	if got := UserPath(); got != filepath.Join("/xdg", "ebm", "config") {
+		t.Errorf("UserPath = %q", got)
+	}
+}
+
+func TestConfig_Apply(t *testing.T) {
+	cfg, err := Load(LoadOptions{Environ: []string{"EBM_JOBS=6", "EBM_AGGRESSIVE=true", "EBM_EXT=.epub,.pdf", "EBM_SKIP_VALIDATION=true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	jobs := fs.Int("jobs", 1, "")
+	aggressive := fs.Bool("aggressive", false, "")
+	ext := fs.StringSlice("ext", nil, "")
+	skip := fs.Bool("skip-validate", false, "")
+	if err := fs.Parse([]string{"--aggressive=false"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Apply(fs); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if *jobs != 6 || *aggressive || !*skip || strings.Join(*ext, " ") != ".epub .pdf" {
+		t.Errorf("jobs=%d aggressive=%v skip=%v ext=%v", *jobs, *aggressive, *skip, *ext)
+	}
+	if fs.Changed("jobs") {
+		t.Error("Expected configured flags to stay unchanged")
+	}
+	if s, _ := cfg.Lookup("aggressive"); s.Source != SourceFlag || s.Origin != "--aggressive" || s.Value != "false" {
+		t.Errorf("aggressive = %+v", s)
+	}
+
+	bad := pflag.NewFlagSet("bad", pflag.ContinueOnError)
+	bad.Duration("jobs", 0, "")
+	if err := cfg.Apply(bad); err == nil || !strings.Contains(err.Error(), "jobs (from EBM_JOBS)") {
+		t.Errorf("Expected an error naming the source, got %v", err)
+	}
+}
+
+func TestConfig_Apply_ListItems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeFile(t, path, "ignore = [\"drafts/{a,b}/**\", \"tmp\"]\n")
+	cfg, err := Load(LoadOptions{UserFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	ignore := fs.StringSlice("ignore", []string{"default"}, "")
+	if err := cfg.Apply(fs); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	// Items are passed whole, not split again at their commas
+	if want := []string{"drafts/{a,b}/**", "tmp"}; !reflect.DeepEqual(*ignore, want) {
+		t.Errorf("ignore = %q, want %q", *ignore, want)
+	}
+	if got := cfg.List("ignore"); len(got) != 2 || got[0] != "drafts/{a,b}/**" {
+		t.Errorf("List = %q", got)
+	}
+}
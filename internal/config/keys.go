@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a setting's value
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindBool
+	KindInt
+	KindFloat
+	KindList // Comma-separated in the environment
+	KindJobs // A positive number or "auto"
+)
+
+// Key is a setting that can be configured. Its name is the name of the
+// command-line flag it provides the default for.
+type Key struct {
+	Name    string
+	Kind    Kind
+	Aliases []string // Other flags taking the same setting
+}
+
+// Keys lists every configurable setting. Flags that only make sense for a
+// single run, such as --output or --files-from, are left out.
+var Keys = []Key{
+	{Name: "profile", Kind: KindString},
+	{Name: "format", Kind: KindString},
+	{Name: "template", Kind: KindString},
+	{Name: "color", Kind: KindBool},
+	{Name: "verbose", Kind: KindBool},
+	{Name: "min-severity", Kind: KindString},
+	{Name: "severity", Kind: KindList},
+	{Name: "max-errors", Kind: KindInt},
+	{Name: "context", Kind: KindInt},
+	{Name: "jobs", Kind: KindJobs},
+	{Name: "timeout", Kind: KindInt},
+	{Name: "timeout-per-mb", Kind: KindFloat},
+	{Name: "max-timeout", Kind: KindInt},
+	{Name: "retries", Kind: KindInt},
+	{Name: "final-pass-factor", Kind: KindFloat},
+	{Name: "order", Kind: KindString},
+	{Name: "memory-budget", Kind: KindString},
+	{Name: "isolate", Kind: KindBool},
+	{Name: "isolate-memory", Kind: KindString},
+	{Name: "recursive", Kind: KindBool},
+	{Name: "max-depth", Kind: KindInt},
+	{Name: "ext", Kind: KindList},
+	{Name: "ignore", Kind: KindList},
+	{Name: "ignore-file", Kind: KindList},
+	{Name: "follow-symlinks", Kind: KindBool},
+	{Name: "min-size", Kind: KindString},
+	{Name: "max-size", Kind: KindString},
+	{Name: "progress", Kind: KindString},
+	{Name: "summary-only", Kind: KindBool},
+	{Name: "continue-on-error", Kind: KindBool},
+	{Name: "backup-dir", Kind: KindString},
+	{Name: "no-backup", Kind: KindBool},
+	{Name: "aggressive", Kind: KindBool},
+	{Name: "skip-validation", Kind: KindBool, Aliases: []string{"skip-validate"}},
+	{Name: "remove-system-errors", Kind: KindBool},
+	{Name: "remove-kinds", Kind: KindList},
+	{Name: "move-failed-repairs", Kind: KindBool},
+	{Name: "route", Kind: KindList},
+	{Name: "cleanup-empty-dirs", Kind: KindBool},
+	{Name: "trash-dir", Kind: KindString},
+}
+
+// Lookup finds a key by name. Underscores are accepted in place of dashes,
+// as TOML and YAML files often use them.
+func Lookup(name string) (Key, bool) {
+	name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	for _, key := range Keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// EnvName returns the environment variable of the key, e.g. EBM_MAX_DEPTH
+func (k Key) EnvName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(k.Name, "-", "_"))
+}
+
+func (k Key) flagNames() []string {
+	return append([]string{k.Name}, k.Aliases...)
+}
+
+// check reports whether value is valid for the key's kind
+func (k Key) check(value string) error {
+	var err error
+	switch k.Kind {
+	case KindBool:
+		_, err = strconv.ParseBool(value)
+	case KindInt:
+		_, err = strconv.Atoi(value)
+	case KindFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case KindJobs:
+		if n, convErr := strconv.Atoi(value); value != "auto" && (convErr != nil || n < 1) {
+			return fmt.Errorf("must be a positive number or \"auto\", got %q", value)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q", value)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"max-depth", "max_depth", "MAX_DEPTH"} {
+		if key, ok := Lookup(name); !ok || key.Name != "max-depth" {
+			t.Errorf("Lookup(%q) = %+v, %v", name, key, ok)
+		}
+	}
+	if _, ok := Lookup("output"); ok {
+		t.Error("Expected --output not to be configurable")
+	}
+}
+
+func TestKey_EnvName(t *testing.T) {
+	key, _ := Lookup("timeout-per-mb")
+	if got := key.EnvName(); got != "EBM_TIMEOUT_PER_MB" {
+		t.Errorf("EnvName = %q", got)
+	}
+}
+
+func TestKey_Check(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"jobs", "auto", true},
+		{"jobs", "4", true},
+		{"jobs", "-1", false},
+		{"aggressive", "yes", false},
+		{"final-pass-factor", "1.5", true},
+		{"max-depth", "1.5", false},
+		{"order", "anything", true},
+	}
+	for _, tt := range tests {
+		key, _ := Lookup(tt.name)
+		if err := key.check(tt.value); (err == nil) != tt.ok {
+			t.Errorf("%s = %q: error %v", tt.name, tt.value, err)
+		}
+	}
+}
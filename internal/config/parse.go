@@ -0,0 +1,257 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// The config files are TOML, or YAML for library files ending in .yaml or
+// .yml. Keys are set at the top level or in one level of sections, to
+// strings, numbers, booleans or lists of those.
+
+// entry is a key and value read from a config file
+type entry struct {
+	key   string // Prefixed with the section and a dot inside a section
+	value string
+	items []string // The items of a list, which leaves value empty
+	list  bool
+	line  int
+}
+
+// syntaxError is a parse error on a line of a config file
+type syntaxError struct {
+	line int
+	msg  string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+// parse reads a config file in the format given by its extension
+func parse(path string, data []byte) ([]entry, error) {
+	var entries []entry
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		entries, err = parseYAML(data)
+	default:
+		entries, err = parseTOML(data)
+	}
+
+	var se *syntaxError
+	if errors.As(err, &se) {
+		return nil, fmt.Errorf("%s:%d: %s", path, se.line, se.msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// parseTOML decodes the document, then walks its expressions for the order
+// and line of each key
+func parseTOML(data []byte) ([]entry, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		var de *toml.DecodeError
+		if errors.As(err, &de) {
+			line, _ := de.Position()
+			return nil, &syntaxError{line, strings.TrimPrefix(de.Error(), "toml: ")}
+		}
+		// Such as a key set twice, which has no position
+		return nil, errors.New(strings.TrimPrefix(err.Error(), "toml: "))
+	}
+
+	var entries []entry
+	var section []string
+	var p unstable.Parser
+	p.Reset(data)
+	for p.NextExpression() {
+		expr := p.Expression()
+		path, line := tomlKey(&p, expr)
+		switch expr.Kind {
+		case unstable.ArrayTable:
+			return nil, &syntaxError{line, "arrays of tables are not supported"}
+		case unstable.Table:
+			if len(path) > 1 {
+				return nil, &syntaxError{line, "sections cannot be nested"}
+			}
+			section = path
+		case unstable.KeyValue:
+			path = slices.Concat(section, path)
+			if len(path) > 2 {
+				return nil, &syntaxError{line, "sections cannot be nested"}
+			}
+			var value any = doc
+			for _, part := range path {
+				value = value.(map[string]any)[part]
+			}
+			e, err := newEntry(strings.Join(path, "."), line, value)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// tomlKey returns the parts of the key of a table or key/value expression
+// and the line it starts on
+func tomlKey(p *unstable.Parser, expr *unstable.Node) ([]string, int) {
+	var parts []string
+	line := 0
+	it := expr.Key()
+	for it.Next() {
+		if line == 0 {
+			line = p.Shape(it.Node().Raw).Start.Line
+		}
+		parts = append(parts, string(it.Node().Data))
+	}
+	return parts, line
+}
+
+// yamlErrorPattern matches the line yaml.v3 gives in its syntax errors
+var yamlErrorPattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// parseYAML decodes the document as nodes, which keep the order and line
+// of each key. A mapping at the top level is a section.
+func parseYAML(data []byte) ([]entry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		if m := yamlErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return nil, &syntaxError{line, m[2]}
+		}
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := resolveAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, &syntaxError{root.Line, "expected key: value"}
+	}
+
+	var entries []entry
+	lines := make(map[string]int) // Of the keys read, to refuse duplicates
+	add := func(name string, key, value *yaml.Node) error {
+		if line, ok := lines[name]; ok {
+			return &syntaxError{key.Line, fmt.Sprintf("%s is already set on line %d", name, line)}
+		}
+		lines[name] = key.Line
+		e, err := yamlEntry(name, key, value)
+		entries = append(entries, e)
+		return err
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], resolveAlias(root.Content[i+1])
+		if value.Kind != yaml.MappingNode {
+			if err := add(key.Value, key, value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			k, v := value.Content[j], resolveAlias(value.Content[j+1])
+			if v.Kind == yaml.MappingNode {
+				return nil, &syntaxError{v.Line, "sections cannot be nested"}
+			}
+			if err := add(key.Value+"."+k.Value, k, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+// yamlEntry decodes the value of a key
+func yamlEntry(name string, key, value *yaml.Node) (entry, error) {
+	var v any
+	if err := value.Decode(&v); err != nil {
+		return entry{}, &syntaxError{value.Line, fmt.Sprintf("%s: %v", name, err)}
+	}
+	return newEntry(name, key.Line, v)
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// listItems returns the items of a list entry, or a scalar given for a list
+// key as its only item
+func (e entry) listItems() []string {
+	if e.list {
+		return e.items
+	}
+	if e.value == "" {
+		return []string{}
+	}
+	return []string{e.value}
+}
+
+// newEntry makes an entry from a decoded value, which must be a scalar or
+// a list of scalars
+func newEntry(key string, line int, v any) (entry, error) {
+	e := entry{key: key, line: line}
+	list, ok := v.([]any)
+	if !ok {
+		value, err := scalar(v)
+		if err != nil {
+			return entry{}, &syntaxError{line, fmt.Sprintf("%s: %v", key, err)}
+		}
+		e.value = value
+		return e, nil
+	}
+
+	e.list, e.items = true, []string{}
+	for _, item := range list {
+		if _, nested := item.([]any); nested {
+			return entry{}, &syntaxError{line, key + ": nested lists are not supported"}
+		}
+		value, err := scalar(item)
+		if err != nil {
+			return entry{}, &syntaxError{line, fmt.Sprintf("%s: %v", key, err)}
+		}
+		e.items = append(e.items, value)
+	}
+	return e, nil
+}
+
+// scalar formats a decoded string, number or boolean the way its flag
+// takes it. A null is empty.
+func scalar(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
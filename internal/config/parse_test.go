@@ -0,0 +1,127 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	text := `# Library defaults
+jobs = 8
+aggressive = true # trailing comment
+backup_dir = "/srv/backups # not a comment"
+timeout-per-mb = 0.5
+max-timeout = 1_800
+ext = [".epub",
+  '.pdf', ]
+ignore = ["drafts/{a,b}/**"]
+order = 'largest'
+staging.jobs = 2
+
+[archive]
+no-backup = false
+severity = []
+`
+	entries, err := parse("config", []byte(text))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []entry{
+		{key: "jobs", value: "8", line: 2},
+		{key: "aggressive", value: "true", line: 3},
+		{key: "backup_dir", value: "/srv/backups # not a comment", line: 4},
+		{key: "timeout-per-mb", value: "0.5", line: 5},
+		{key: "max-timeout", value: "1800", line: 6},
+		{key: "ext", items: []string{".epub", ".pdf"}, list: true, line: 7},
+		{key: "ignore", items: []string{"drafts/{a,b}/**"}, list: true, line: 9},
+		{key: "order", value: "largest", line: 10},
+		{key: "staging.jobs", value: "2", line: 11},
+		{key: "archive.no-backup", value: "false", line: 14},
+		{key: "archive.severity", items: []string{}, list: true, line: 15},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries =\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"jobs 8", "config:1: "},
+		{"\norder = largest", "config:2: "},
+		{"ext = [\".epub\"", "config:1: "},
+		{"jobs = 1\njobs = 2", "config: key jobs is already defined"},
+		{"[[profiles]]\njobs = 1", "config:1: arrays of tables are not supported"},
+		{"[a.b]\njobs = 1", "config:1: sections cannot be nested"},
+		{"[a]\nb.jobs = 1", "config:2: sections cannot be nested"},
+		{"ext = [[\"a\"]]", "config:1: ext: nested lists are not supported"},
+		{"when = 2024-01-02", "config:1: when: unsupported value"},
+	}
+	for _, tt := range tests {
+		_, err := parse("config", []byte(tt.text))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("parse(%q) error = %v, want %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	text := `---
+# Library defaults
+jobs: 8
+backup-dir: "/srv/backups"
+order: largest # comment
+ignore: ['**/drafts/**', "tmp#1", "{a,b}"]
+ext:
+  - .epub
+  - .pdf
+archive:
+  no-backup: true
+  remove-kinds:
+  - corrupt
+min-size:
+timeout-per-mb: 0.5
+`
+	entries, err := parse(".ebm.yaml", []byte(text))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []entry{
+		{key: "jobs", value: "8", line: 3},
+		{key: "backup-dir", value: "/srv/backups", line: 4},
+		{key: "order", value: "largest", line: 5},
+		{key: "ignore", items: []string{"**/drafts/**", "tmp#1", "{a,b}"}, list: true, line: 6},
+		{key: "ext", items: []string{".epub", ".pdf"}, list: true, line: 7},
+		{key: "archive.no-backup", value: "true", line: 11},
+		{key: "archive.remove-kinds", items: []string{"corrupt"}, list: true, line: 12},
+		{key: "min-size", value: "", line: 14},
+		{key: "timeout-per-mb", value: "0.5", line: 15},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries =\n%+v\nwant\n%+v", entries, want)
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"jobs 8", ".ebm.yml:1: expected key: value"},
+		{"- .epub", ".ebm.yml:1: expected key: value"},
+		{"jobs: 8\n  order: walk", ".ebm.yml:2: "},
+		{"a:\n  b:\n    c: 1", ".ebm.yml:3: sections cannot be nested"},
+		{"ext: [[a]]", ".ebm.yml:1: ext: nested lists are not supported"},
+		{"jobs:\n\t- 1", ".ebm.yml:2: "},
+		{"jobs: 1\njobs: 2", ".ebm.yml:2: jobs is already set on line 1"},
+	}
+	for _, tt := range tests {
+		_, err := parse(".ebm.yml", []byte(tt.text))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("parse(%q) error = %v, want %q", tt.text, err, tt.want)
+		}
+	}
+}
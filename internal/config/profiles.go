@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Profile is a named set of settings from the profiles file, chosen with
+// --profile NAME
+type Profile struct {
+	Name   string
+	Values map[string]string   // Single-valued settings, by key name
+	Lists  map[string][]string // List settings, by key name
+}
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidProfileName reports whether name can be used as a profile name
+func ValidProfileName(name string) bool {
+	return profileNamePattern.MatchString(name)
+}
+
+// ProfilesPath returns the profiles file, next to the user config file
+func ProfilesPath() string {
+	user := UserPath()
+	if user == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(user), "profiles")
+}
+
+// ReadProfiles reads the profiles in the TOML file at path, one section per
+// profile, in file order. A missing file has no profiles.
+func ReadProfiles(path string) ([]Profile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading profiles: %w", err)
+	}
+
+	entries, err := parse(path, data)
+	if err != nil {
+		return nil, err
+	}
+	var profiles []Profile
+	index := make(map[string]int)
+	for _, e := range entries {
+		name, keyName, ok := strings.Cut(e.key, ".")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: %s is not inside a [profile] section", path, e.line, e.key)
+		}
+		key, known := Lookup(keyName)
+		switch {
+		case !known:
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", path, e.line, keyName)
+		case key.Name == "profile":
+			return nil, fmt.Errorf("%s:%d: a profile cannot select another profile", path, e.line)
+		case e.list && key.Kind != KindList:
+			return nil, fmt.Errorf("%s:%d: %s takes a single value, not a list", path, e.line, key.Name)
+		}
+		if err := key.check(e.value); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, e.line, key.Name, err)
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(profiles)
+			index[name] = i
+			profiles = append(profiles, Profile{Name: name, Values: make(map[string]string), Lists: make(map[string][]string)})
+		}
+		if key.Kind == KindList {
+			profiles[i].Lists[key.Name] = e.listItems()
+			continue
+		}
+		profiles[i].Values[key.Name] = e.value
+	}
+	return profiles, nil
+}
+
+// findProfile returns the named profile of the profiles file
+func findProfile(path, name string) (Profile, error) {
+	profiles, err := ReadProfiles(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	var names []string
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	if len(names) == 0 {
+		return Profile{}, fmt.Errorf("unknown profile %q: no profiles defined in %s", name, path)
+	}
+	return Profile{}, fmt.Errorf("unknown profile %q (defined: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `# Profiles
+[archive]
+jobs = 2
+aggressive = false
+no-backup = false
+
+[staging]
+jobs = "auto"
+aggressive = true
+`
+
+func TestReadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles")
+	writeFile(t, path, testProfiles)
+
+	profiles, err := ReadProfiles(path)
+	if err != nil {
+		t.Fatalf("ReadProfiles: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "archive" || profiles[1].Name != "staging" {
+		t.Fatalf("Profiles = %+v", profiles)
+	}
+	if profiles[0].Values["jobs"] != "2" || profiles[1].Values["jobs"] != "auto" || len(profiles[0].Values) != 3 {
+		t.Errorf("Values = %+v", profiles)
+	}
+
+	writeFile(t, path, "[drafts]\nignore = [\"drafts/{a,b}/**\"]\n")
+	profiles, err = ReadProfiles(path)
+	if err != nil || len(profiles) != 1 || !reflect.DeepEqual(profiles[0].Lists["ignore"], []string{"drafts/{a,b}/**"}) || len(profiles[0].Values) != 0 {
+		t.Errorf("Expected the list kept whole, got %+v, %v", profiles, err)
+	}
+
+	missing, err := ReadProfiles(filepath.Join(t.TempDir(), "none"))
+	if err != nil || missing != nil {
+		t.Errorf("Expected no profiles from a missing file, got %+v, %v", missing, err)
+	}
+}
+
+func TestReadProfiles_Errors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles")
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"jobs = 2", path + ":1: jobs is not inside a [profile] section"},
+		{"[a]\njobz = 2", path + `:2: unknown setting "jobz"`},
+		{"[a]\nprofile = \"b\"", path + ":2: a profile cannot select another profile"},
+		{"[a]\njobs = 0", path + ":2: jobs: must be a positive number"},
+	}
+	for _, tt := range tests {
+		writeFile(t, path, tt.content)
+		_, err := ReadProfiles(path)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("ReadProfiles(%q) error = %v, want %q", tt.content, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_Profile(t *testing.T) {
+	dir := t.TempDir()
+	profiles := filepath.Join(dir, "profiles")
+	user := filepath.Join(dir, "config")
+	writeFile(t, profiles, testProfiles)
+	writeFile(t, user, "jobs = 6\nretries = 4\nprofile = \"staging\"\n")
+
+	// The profile named in the user file applies over it, and the
+	// environment over the profile
+	cfg, err := Load(LoadOptions{UserFile: user, ProfilesFile: profiles, Environ: []string{"EBM_AGGRESSIVE=false"}})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Profile() != "staging" {
+		t.Errorf("Profile = %q", cfg.Profile())
+	}
+	jobs, _ := cfg.Lookup("jobs")
+	if jobs.Value != "auto" || jobs.Source != SourceProfile || jobs.Origin != profiles+" [staging]" {
+		t.Errorf("jobs = %+v", jobs)
+	}
+	if s, _ := cfg.Lookup("aggressive"); s.Source != SourceEnv {
+		t.Errorf("aggressive = %+v", s)
+	}
+	if s, _ := cfg.Lookup("retries"); s.Value != "4" || s.Source != SourceUser {
+		t.Errorf("retries = %+v", s)
+	}
+
+	// An explicit profile wins over the configured one
+	cfg, err = Load(LoadOptions{UserFile: user, ProfilesFile: profiles, Profile: "archive"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Profile() != "archive" || cfg.Int("jobs", 0) != 2 {
+		t.Errorf("Profile = %q, jobs = %d", cfg.Profile(), cfg.Int("jobs", 0))
+	}
+	if last := cfg.Files[len(cfg.Files)-1]; last.Source != SourceProfile || last.Path != profiles {
+		t.Errorf("Files = %+v", cfg.Files)
+	}
+}
+
+func TestLoad_UnknownProfile(t *testing.T) {
+	dir := t.TempDir()
+	profiles := filepath.Join(dir, "profiles")
+	writeFile(t, profiles, testProfiles)
+
+	_, err := Load(LoadOptions{ProfilesFile: profiles, Profile: "nightly"})
+	if err == nil || err.Error() != `unknown profile "nightly" (defined: archive, staging)` {
+		t.Errorf("error = %v", err)
+	}
+	_, err = Load(LoadOptions{ProfilesFile: filepath.Join(dir, "none"), Environ: []string{"EBM_PROFILE=nightly"}})
+	if err == nil || !strings.Contains(err.Error(), "no profiles defined") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestSaveProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles")
+	writeFile(t, path, testProfiles)
+
+	if err := SaveProfile(path, "archive", map[string]string{"jobs": "3", "cleanup-empty-dirs": "false"}); err != nil {
+		t.Fatalf("SaveProfile: %v", err)
+	}
+	if err := SaveProfile(path, "nightly", map[string]string{"retries": "5"}); err != nil {
+		t.Fatalf("SaveProfile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Profiles
+[archive]
+jobs = 3
+aggressive = false
+no-backup = false
+cleanup-empty-dirs = false
+
+[staging]
+jobs = "auto"
+aggressive = true
+
+[nightly]
+retries = 5
+`
+	if string(data) != want {
+		t.Errorf("Saved file:\n%s\nwant:\n%s", data, want)
+	}
+
+	if err := SaveProfile(path, "../evil", nil); err == nil {
+		t.Error("Expected an invalid profile name to be rejected")
+	}
+}
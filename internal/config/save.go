@@ -0,0 +1,211 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Save writes settings to the TOML config file at path. Keys the file
+// already sets are replaced in place and new keys are added after the
+// others, so comments and other settings are kept. The file and its
+// directory are created if needed.
+func Save(path string, values map[string]string) error {
+	return save(path, "", values)
+}
+
+// SaveProfile writes settings to a profile in the profiles file at path,
+// adding the profile if it is new
+func SaveProfile(path, name string, values map[string]string) error {
+	if !ValidProfileName(name) {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return save(path, name, values)
+}
+
+// save writes values to a section of a TOML file, the top level when
+// section is empty
+func save(path, section string, values map[string]string) error {
+	for name := range values {
+		if _, ok := Lookup(name); !ok {
+			return fmt.Errorf("unknown setting %q", name)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	var lines []string
+	if text := strings.TrimRight(string(data), "\n"); text != "" {
+		lines = strings.Split(text, "\n")
+	} else if section == "" {
+		lines = []string{"# ebm settings, see docs/CLI_REFERENCE.md#configuration"}
+	} else {
+		lines = []string{"# ebm profiles, chosen with --profile NAME"}
+	}
+
+	written := make(map[string]bool)
+	var out []string
+	current := ""          // Section of the line being copied
+	found := section == "" // Whether the file has the section
+	end := 0               // Index in out after the section's last line
+	insertAt := -1         // Where keys new to the section go
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(stripComment(line))
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			if current == section && insertAt < 0 {
+				insertAt = end
+			}
+			current = unquoteKey(strings.TrimSpace(trimmed[1 : len(trimmed)-1]))
+			out = append(out, line)
+			if current == section {
+				found, end = true, len(out)
+			}
+			continue
+		}
+
+		if current == section {
+			if name, raw, ok := strings.Cut(trimmed, "="); ok {
+				key, known := Lookup(unquoteKey(strings.TrimSpace(name)))
+				if value, set := values[key.Name]; known && set {
+					// Skip the rest of a multi-line array
+					raw = strings.TrimSpace(raw)
+					for strings.HasPrefix(raw, "[") && !arrayClosed(raw) && i+1 < len(lines) {
+						i++
+						raw += strings.TrimSpace(stripComment(lines[i]))
+					}
+					line = key.Name + " = " + formatValue(key, value)
+					written[key.Name] = true
+				}
+			}
+		}
+		out = append(out, line)
+		if current == section && strings.TrimSpace(line) != "" {
+			end = len(out)
+		}
+	}
+	if current == section && insertAt < 0 {
+		insertAt = end
+	}
+
+	var added []string
+	for _, key := range Keys {
+		if value, ok := values[key.Name]; ok && !written[key.Name] {
+			added = append(added, key.Name+" = "+formatValue(key, value))
+		}
+	}
+	if found {
+		out = slices.Insert(out, insertAt, added...)
+	} else {
+		out = append(out, "", "["+section+"]")
+		out = append(out, added...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(out, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// formatValue renders a value as TOML for the key's kind
+func formatValue(key Key, value string) string {
+	switch key.Kind {
+	case KindBool, KindInt, KindFloat:
+		return value
+	case KindJobs:
+		if _, err := strconv.Atoi(value); err == nil {
+			return value
+		}
+	case KindList:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, strconv.Quote(item))
+			}
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return strconv.Quote(value)
+}
+
+// stripComment removes a # comment outside quotes
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// arrayClosed reports whether the brackets of s are balanced outside
+// quotes
+func arrayClosed(s string) bool {
+	depth := 0
+	for _, part := range splitOutsideQuotes(s, 0) {
+		depth += strings.Count(part, "[") - strings.Count(part, "]")
+	}
+	return depth <= 0
+}
+
+// splitOutsideQuotes splits s at sep outside quoted strings. With sep 0 it
+// returns the unquoted parts of s.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+				if sep == 0 {
+					start = i + 1
+				}
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			if sep == 0 {
+				parts = append(parts, s[start:i])
+			}
+		case sep != 0 && c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if quote == 0 || sep != 0 {
+		parts = append(parts, s[min(start, len(s)):])
+	}
+	return parts
+}
+
+func unquoteKey(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSave_NewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ebm", "config")
+	if err := Save(path, map[string]string{"jobs": "4", "aggressive": "true", "ext": ".epub, .pdf", "backup-dir": `C:\backups`}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	cfg, err := Load(LoadOptions{UserFile: path})
+	if err != nil {
+		t.Fatalf("Load after Save: %v", err)
+	}
+	for key, want := range map[string]string{"jobs": "4", "aggressive": "true", "ext": ".epub,.pdf", "backup-dir": `C:\backups`} {
+		if s, _ := cfg.Lookup(key); s.Value != want {
+			t.Errorf("%s = %q, want %q", key, s.Value, want)
+		}
+	}
+}
+
+func TestSave_KeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	writeFile(t, path, `# My settings
+jobs = 2 # old
+ext = [
+  ".epub",
+]
+retries = 3
+
+[archive]
+jobs = 1
+`)
+	if err := Save(path, map[string]string{"jobs": "auto", "ext": ".pdf", "no-backup": "true"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# My settings
+jobs = "auto"
+ext = [".pdf"]
+retries = 3
+no-backup = true
+
+[archive]
+jobs = 1
+`
+	if string(data) != want {
+		t.Errorf("Saved file:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestSave_UnknownKey(t *testing.T) {
+	if err := Save(filepath.Join(t.TempDir(), "config"), map[string]string{"output": "x"}); err == nil {
+		t.Error("Expected an error for an unknown key")
+	}
+}
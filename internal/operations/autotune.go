@@ -0,0 +1,134 @@
+package operations
+
+import (
+	"runtime"
+	"time"
+)
+
+// DefaultTuneInterval is how often BatchConfig.AutoTune measures throughput
+const DefaultTuneInterval = 2 * time.Second
+
+// AutoTuneMaxWorkers is the largest worker count auto-tuning will try
+func AutoTuneMaxWorkers() int {
+	return min(4*runtime.NumCPU(), 64)
+}
+
+// Auto-tuning thresholds
+const (
+	tuneTolerance = 0.05 // Change in files/sec treated as noise
+	cpuSaturated  = 0.95 // Busy share above which more workers only add contention
+	ioSaturated   = 0.50 // I/O wait share above which the disk is the bottleneck
+)
+
+// cpuTimes are cumulative CPU times in clock ticks
+type cpuTimes struct {
+	busy   uint64
+	iowait uint64
+	total  uint64
+}
+
+// cpuLoad is the share of CPU time spent busy and waiting for I/O between
+// two samples
+type cpuLoad struct {
+	busy   float64
+	iowait float64
+}
+
+// loadBetween returns the CPU load between two samples
+func loadBetween(before, after cpuTimes) (cpuLoad, bool) {
+	if after.total <= before.total {
+		return cpuLoad{}, false
+	}
+	total := float64(after.total - before.total)
+	return cpuLoad{
+		busy:   float64(after.busy-before.busy) / total,
+		iowait: float64(after.iowait-before.iowait) / total,
+	}, true
+}
+
+// autoTuner picks the number of files processed at once by hill climbing on
+// throughput: it keeps moving the worker count in one direction while files
+// per second improve and turns back when they drop. It does not add workers
+// while the CPU or the disk is saturated.
+type autoTuner struct {
+	max       int
+	workers   int
+	direction int
+	lastRate  float64
+}
+
+func newAutoTuner(start, max int) *autoTuner {
+	t := &autoTuner{max: max, direction: 1}
+	t.workers = t.clamp(start)
+	return t
+}
+
+func (t *autoTuner) clamp(workers int) int {
+	return max(1, min(workers, t.max))
+}
+
+// adjust returns the worker count for the next interval given the files
+// per second and CPU load measured over the last one
+func (t *autoTuner) adjust(rate float64, load cpuLoad, haveLoad bool) int {
+	switch {
+	case rate == 0 && t.lastRate == 0:
+		// Nothing has finished yet
+		return t.workers
+	case rate < t.lastRate*(1-tuneTolerance):
+		t.direction = -t.direction
+	case rate <= t.lastRate*(1+tuneTolerance):
+		t.lastRate = rate
+		return t.workers
+	}
+	t.lastRate = rate
+
+	if t.direction > 0 && haveLoad && (load.busy >= cpuSaturated || load.iowait >= ioSaturated) {
+		return t.workers
+	}
+	t.workers = t.clamp(t.workers + t.direction*max(1, t.workers/4))
+	return t.workers
+}
+
+// autoTune adjusts the number of files processed at once until the batch
+// is done
+func (bp *BatchProcessor) autoTune() {
+	interval := bp.config.TuneInterval
+	if interval <= 0 {
+		interval = DefaultTuneInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	tuner := newAutoTuner(bp.Workers(), bp.config.NumWorkers)
+	lastCompleted, lastTime := bp.completed.Load(), time.Now()
+	lastCPU, haveCPU := readCPUTimes()
+	for {
+		select {
+		case <-bp.ctx.Done():
+			return
+		case now := <-ticker.C:
+			completed := bp.completed.Load()
+			if bp.Paused() {
+				// Nothing starts while paused; measure afresh on resume
+				lastCompleted, lastTime = completed, now
+				lastCPU, haveCPU = readCPUTimes()
+				continue
+			}
+			rate := float64(completed-lastCompleted) / now.Sub(lastTime).Seconds()
+
+			cpu, ok := readCPUTimes()
+			var load cpuLoad
+			haveLoad := false
+			if ok && haveCPU {
+				load, haveLoad = loadBetween(lastCPU, cpu)
+			}
+
+			workers := tuner.adjust(rate, load, haveLoad)
+			bp.gate.setLimit(int64(workers))
+			bp.workers.Store(int64(workers))
+
+			lastCompleted, lastTime = completed, now
+			lastCPU, haveCPU = cpu, ok
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAutoTuner_Adjust(t *testing.T) {
+	tuner := newAutoTuner(4, 16)
+
+	if got := tuner.adjust(0, cpuLoad{}, false); got != 4 {
+		t.Errorf("Expected no change before any file finished, got %d", got)
+	}
+	// Throughput improves while adding workers
+	if got := tuner.adjust(10, cpuLoad{}, false); got != 5 {
+		t.Errorf("adjust(10) = %d, want 5", got)
+	}
+	if got := tuner.adjust(14, cpuLoad{}, false); got != 6 {
+		t.Errorf("adjust(14) = %d, want 6", got)
+	}
+	// A plateau holds
+	if got := tuner.adjust(14.2, cpuLoad{}, false); got != 6 {
+		t.Errorf("adjust(14.2) = %d, want 6", got)
+	}
+	// A drop turns back
+	if got := tuner.adjust(11, cpuLoad{}, false); got != 5 {
+		t.Errorf("adjust(11) = %d, want 5", got)
+	}
+	if got := tuner.adjust(13, cpuLoad{}, false); got != 4 {
+		t.Errorf("adjust(13) = %d, want 4", got)
+	}
+}
+
+func TestAutoTuner_SaturationAndBounds(t *testing.T) {
+	tuner := newAutoTuner(4, 16)
+	if got := tuner.adjust(10, cpuLoad{busy: 0.99}, true); got != 4 {
+		t.Errorf("Expected no workers added with the CPU saturated, got %d", got)
+	}
+	if got := tuner.adjust(20, cpuLoad{busy: 0.3, iowait: 0.6}, true); got != 4 {
+		t.Errorf("Expected no workers added with the disk saturated, got %d", got)
+	}
+
+	tuner = newAutoTuner(64, 8)
+	if tuner.workers != 8 {
+		t.Errorf("Expected start clamped to max, got %d", tuner.workers)
+	}
+	for rate := 1.0; rate < 100; rate *= 2 {
+		tuner.adjust(rate, cpuLoad{}, false)
+	}
+	if tuner.workers != 8 {
+		t.Errorf("Expected workers to stay at max, got %d", tuner.workers)
+	}
+}
+
+func TestLoadBetween(t *testing.T) {
+	load, ok := loadBetween(cpuTimes{busy: 100, iowait: 10, total: 200}, cpuTimes{busy: 150, iowait: 30, total: 300})
+	if !ok || load.busy != 0.5 || load.iowait != 0.2 {
+		t.Errorf("loadBetween() = %+v, %v", load, ok)
+	}
+	if _, ok := loadBetween(cpuTimes{total: 300}, cpuTimes{total: 300}); ok {
+		t.Error("Expected no load without elapsed CPU time")
+	}
+}
+
+func TestBatchProcessor_AutoTune(t *testing.T) {
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		time.Sleep(2 * time.Millisecond)
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	files := make([]string, 200)
+	for i := range files {
+		files[i] = fmt.Sprintf("book%d.epub", i)
+	}
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 16
+	config.AutoTune = true
+	config.TuneInterval = 10 * time.Millisecond
+	bp := NewBatchProcessor(context.Background(), config)
+	results := bp.Execute(files, OperationValidate)
+
+	if len(results) != len(files) {
+		t.Fatalf("Expected %d results, got %d", len(files), len(results))
+	}
+	if workers := bp.Workers(); workers < 1 || workers > 16 {
+		t.Errorf("Workers() = %d, want between 1 and 16", workers)
+	}
+}
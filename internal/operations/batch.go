@@ -2,10 +2,10 @@ package operations
 
 import (
 	"context"
-	"io/fs"
-	"path/filepath"
+	"errors"
+	"os"
 	"runtime"
-	"strings"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -28,104 +28,55 @@ type BatchConfig struct {
 	NumWorkers   int           // Number of concurrent workers
 	QueueSize    int           // Task queue buffer size
 	ProgressRate time.Duration // Progress update frequency
-	Timeout      time.Duration // Per-file operation timeout
+	Timeout      time.Duration // Per-file operation timeout, before scaling by size
 	RepairMode   RepairSaveMode
 	BackupDir    string
 	Aggressive   bool
+	Isolate      *IsolateConfig // Run each file in a child process when set
+
+	// Scheduling
+	Order        ScheduleOrder // Order files are handed to workers; empty for OrderWalk
+	MemoryBudget int64         // Estimated bytes of memory for files in progress; 0 for no limit
+	AutoTune     bool          // Adjust the files processed at once between 1 and NumWorkers from measured throughput
+	TuneInterval time.Duration // How often AutoTune measures; 0 uses DefaultTuneInterval
+
+	// Retry policy
+	TimeoutPerMB    time.Duration // Added to Timeout for each MiB of file size
+	MaxTimeout      time.Duration // Cap on the size-scaled timeout; 0 for none
+	Retries         int           // Extra attempts for files failing with a transient error
+	RetryBackoff    time.Duration // Pause before the first retry, doubled for each further retry
+	FinalPassFactor float64       // Timed-out files are retried after the batch with their timeout multiplied by this; 0 disables
 }
 
 // FindFilesOptions configures file discovery for batch operations
 type FindFilesOptions struct {
-	Recursive  bool
-	MaxDepth   int      // -1 for unlimited
-	Extensions []string // e.g., []string{".epub", ".pdf"}
-	Ignore     []string // glob patterns
+	Recursive      bool
+	MaxDepth       int      // -1 for unlimited
+	Extensions     []string // e.g., []string{".epub", ".pdf"}
+	Ignore         []string // gitignore-style patterns relative to the root
+	IgnoreFiles    []string // Extra ignore files whose patterns are relative to the root
+	FollowSymlinks bool     // Descend into symlinked directories
+	SkipHidden     bool     // Skip files and directories whose names start with '.'
+	WalkWorkers    int      // Directories read concurrently; 0 uses DefaultWalkWorkers
+
+	// Attribute filters; zero values disable a filter
+	MinSize   int64     // Minimum file size in bytes
+	MaxSize   int64     // Maximum file size in bytes
+	NewerThan time.Time // Only files modified after this time
+	OlderThan time.Time // Only files modified before this time
+
+	// Retry holds absolute paths of files found whatever their modification
+	// time, such as those that errored on the last run
+	Retry map[string]bool
 }
 
 // FindFiles finds all matching files in the given directory based on options
 func FindFiles(root string, opts FindFilesOptions) ([]string, error) {
-	var files []string
-
-	// Clean root path
-	root = filepath.Clean(root)
-
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Calculate current depth relative to root
-		rel, err := filepath.Rel(root, path)
-		if err != nil {
-			return err
-		}
-
-		depth := 0
-		if rel != "." {
-			depth = len(strings.Split(rel, string(filepath.Separator)))
-		}
-
-		// Check max depth
-		if opts.MaxDepth != -1 && depth > opts.MaxDepth {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Skip directories if not recursive (beyond the root)
-		if !opts.Recursive && d.IsDir() && path != root {
-			return filepath.SkipDir
-		}
-
-		// Handle ignores
-		for _, pattern := range opts.Ignore {
-			matched, err := filepath.Match(pattern, d.Name())
-			if err == nil && matched {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-
-			// Also try matching the full path
-			matched, err = filepath.Match(pattern, path)
-			if err == nil && matched {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
-
-		// Check if file is of desired extensions
-		if !d.IsDir() {
-			ext := strings.ToLower(filepath.Ext(path))
-			matchExt := false
-			if len(opts.Extensions) == 0 {
-				// Default to EPUB and PDF if none specified
-				matchExt = ext == ".epub" || ext == ".pdf"
-			} else {
-				for _, targetExt := range opts.Extensions {
-					if !strings.HasPrefix(targetExt, ".") {
-						targetExt = "." + targetExt
-					}
-					if ext == strings.ToLower(targetExt) {
-						matchExt = true
-						break
-					}
-				}
-			}
-
-			if matchExt {
-				files = append(files, path)
-			}
-		}
-
-		return nil
-	})
-
-	return files, err
+	discovery, err := Discover(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	return discovery.Files, nil
 }
 
 // DefaultBatchConfig returns sensible defaults for batch processing
@@ -135,21 +86,56 @@ func DefaultBatchConfig() BatchConfig {
 		QueueSize:    100,
 		ProgressRate: 100 * time.Millisecond,
 		Timeout:      30 * time.Second,
+
+		TimeoutPerMB:    500 * time.Millisecond,
+		MaxTimeout:      10 * time.Minute,
+		Retries:         2,
+		RetryBackoff:    500 * time.Millisecond,
+		FinalPassFactor: 4,
 	}
 }
 
+// TimeoutFor returns the timeout for a file of the given size: Timeout plus
+// TimeoutPerMB for each MiB, capped at MaxTimeout. The final pass for
+// timed-out files multiplies the result by FinalPassFactor.
+func (c BatchConfig) TimeoutFor(size int64, finalPass bool) time.Duration {
+	timeout := c.Timeout + time.Duration(float64(c.TimeoutPerMB)*float64(size)/(1<<20))
+	if c.MaxTimeout > 0 && timeout > c.MaxTimeout {
+		timeout = c.MaxTimeout
+	}
+	if finalPass && c.FinalPassFactor > 0 {
+		timeout = time.Duration(float64(timeout) * c.FinalPassFactor)
+	}
+	return timeout
+}
+
 // Task represents a single file to process
 type Task struct {
+	index     int           // Position in discovery order
+	attempts  int           // Attempts made before the final pass
+	finalPass bool          // Retrying after a timeout with a longer timeout
+	elapsed   time.Duration // Time spent before the final pass
+	previous  *Result       // Timed-out result, reported if the final pass does not run
+	size      int64
 	FilePath  string
+	Root      string // Command-line root the file was found under, if any
 	Operation OperationType
 }
 
 // Result contains the result of processing a single file
 type Result struct {
-	FilePath string
-	Report   *ebmlib.ValidationReport
-	Repair   *ebmlib.RepairResult
-	Error    error
+	index     int
+	FilePath  string
+	Root      string // Command-line root the file was found under, if any
+	Report    *ebmlib.ValidationReport
+	Repair    *ebmlib.RepairResult
+	Error     error
+	ErrorKind ErrorKind     // Classification of Error, empty when Error is nil
+	Attempts  int           // Times the file was processed, including retries
+	Timeout   time.Duration // Timeout of the last attempt
+	Duration  time.Duration // Wall time spent on the file, including retries
+	Bytes     int64         // File size
+	Phases    PhaseTimings  // Time spent in each phase of the last attempt
 }
 
 // ProgressUpdate contains progress information
@@ -157,6 +143,20 @@ type ProgressUpdate struct {
 	Completed int
 	Total     int
 	Current   string
+	Workers   int // Files processed at once
+
+	// Live statistics for dashboards
+	Valid          int
+	Invalid        int
+	Errored        int
+	BytesDone      int64 // Size of finished files
+	BytesTotal     int64 // Size of all files found so far
+	Elapsed        time.Duration
+	FilesPerSecond float64
+	BytesPerSecond float64
+	ETA            time.Duration  // Estimated from bytes; 0 when unknown
+	InFlight       []FileProgress // By worker number
+	RecentFailures []FailureEvent // Oldest first, at most RecentFailuresKept
 }
 
 // BatchProcessor handles concurrent batch processing of files
@@ -168,57 +168,96 @@ type BatchProcessor struct {
 	resultQueue chan Result
 	progressCh  chan ProgressUpdate
 	completed   atomic.Int64
-	total       int
+	total       atomic.Int64 // Grows as streamed inputs arrive
 	currentFile atomic.Value // stores string
+	live        *liveStats
+	control     *batchControl
+
+	memory  *semaphore   // nil without a memory budget
+	gate    *semaphore   // Limits files in progress when auto-tuning
+	workers atomic.Int64 // Files processed at once
+
+	timedOutMu sync.Mutex
+	timedOut   []Task // Tasks waiting for the final pass
 }
 
 // NewBatchProcessor creates a new batch processor with the given parent context
 func NewBatchProcessor(ctx context.Context, config BatchConfig) *BatchProcessor {
 	ctx, cancel := context.WithCancel(ctx)
 
-	return &BatchProcessor{
+	bp := &BatchProcessor{
 		config:      config,
 		ctx:         ctx,
 		cancel:      cancel,
 		taskQueue:   make(chan Task, config.QueueSize),
 		resultQueue: make(chan Result, config.QueueSize),
 		progressCh:  make(chan ProgressUpdate, 10),
+		live:        newLiveStats(),
+		control:     newBatchControl(),
+	}
+	if config.MemoryBudget > 0 {
+		bp.memory = newSemaphore(ctx, config.MemoryBudget)
+	}
+	bp.workers.Store(int64(config.NumWorkers))
+	if config.AutoTune {
+		start := max(1, min(runtime.NumCPU(), config.NumWorkers))
+		bp.gate = newSemaphore(ctx, int64(start))
+		bp.workers.Store(int64(start))
 	}
+	return bp
 }
 
 // Execute processes a batch of files with the given operation
 func (bp *BatchProcessor) Execute(files []string, operation OperationType) []Result {
-	bp.total = len(files)
+	inputs := make([]Input, len(files))
+	for i, f := range files {
+		inputs[i] = Input{Path: f}
+	}
+	return bp.ExecuteInputs(inputs, operation)
+}
+
+// ExecuteInputs processes resolved inputs, recording each result's root
+func (bp *BatchProcessor) ExecuteInputs(inputs []Input, operation OperationType) []Result {
+	ch := make(chan Input, len(inputs))
+	for _, in := range inputs {
+		ch <- in
+	}
+	close(ch)
+	return bp.ExecuteStream(ch, operation)
+}
+
+// ExecuteStream processes inputs as they arrive, so work can start while
+// files are still being discovered. The progress total grows with each
+// input received. Orders other than OrderWalk wait for the last input before
+// starting. Results are returned in the order inputs were received.
+func (bp *BatchProcessor) ExecuteStream(inputs <-chan Input, operation OperationType) []Result {
+	bp.total.Store(0)
 	bp.completed.Store(0)
+	bp.live = newLiveStats()
 
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < bp.config.NumWorkers; i++ {
 		wg.Add(1)
-		go bp.worker(i, &wg)
+		go bp.worker(i+1, bp.taskQueue, &wg)
 	}
 
 	// Feed tasks
-	go func() {
-		for _, file := range files {
-			select {
-			case bp.taskQueue <- Task{FilePath: file, Operation: operation}:
-			case <-bp.ctx.Done():
-				return
-			}
-		}
-		close(bp.taskQueue)
-	}()
+	go bp.feed(inputs, operation)
 
 	// Start progress reporter
 	go bp.reportProgress()
+	if bp.gate != nil {
+		go bp.autoTune()
+	}
 
 	// Collect results
-	results := make([]Result, 0, len(files))
+	var results []Result
 
 	// Wait for all workers to finish in a separate goroutine
 	go func() {
 		wg.Wait()
+		bp.finalPass()
 		close(bp.resultQueue)
 		bp.Cancel() // Stop progress reporting
 	}()
@@ -227,24 +266,105 @@ func (bp *BatchProcessor) Execute(files []string, operation OperationType) []Res
 		results = append(results, result)
 	}
 
+	// Workers finish in any order; report in discovery order
+	sort.Slice(results, func(i, j int) bool { return results[i].index < results[j].index })
+	if results == nil {
+		results = []Result{}
+	}
+
 	return results
 }
 
-// worker processes tasks from the queue
-func (bp *BatchProcessor) worker(id int, wg *sync.WaitGroup) {
+// feed turns inputs into tasks on the task queue in the configured order
+func (bp *BatchProcessor) feed(inputs <-chan Input, operation OperationType) {
+	defer close(bp.taskQueue)
+
+	var pending []Task
+	for index := 0; ; index++ {
+		var in Input
+		var ok bool
+		select {
+		case in, ok = <-inputs:
+		case <-bp.control.stop:
+			return
+		case <-bp.ctx.Done():
+			return
+		}
+		if !ok {
+			break
+		}
+
+		bp.total.Add(1)
+		task := Task{index: index, FilePath: in.Path, Root: in.Root, Operation: operation}
+		if info, err := os.Stat(in.Path); err == nil {
+			task.size = info.Size()
+		}
+		bp.live.add(task.size)
+		if !bp.config.Order.streams() {
+			pending = append(pending, task)
+			continue
+		}
+		if !bp.enqueue(task) {
+			return
+		}
+	}
+
+	sortTasks(pending, bp.config.Order)
+	for _, task := range pending {
+		if !bp.enqueue(task) {
+			return
+		}
+	}
+}
+
+// enqueue sends a task to the workers, reporting false if the batch was
+// stopped or cancelled first
+func (bp *BatchProcessor) enqueue(task Task) bool {
+	select {
+	case bp.taskQueue <- task:
+		return true
+	case <-bp.control.stop:
+		return false
+	case <-bp.ctx.Done():
+		return false
+	}
+}
+
+// worker processes tasks from the queue. Its number identifies it in
+// progress updates.
+func (bp *BatchProcessor) worker(id int, tasks <-chan Task, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for {
+		if !bp.waitResumed() {
+			return
+		}
 		select {
 		case <-bp.ctx.Done():
 			return
-		case task, ok := <-bp.taskQueue:
+		case <-bp.control.stop:
+			return
+		case task, ok := <-tasks:
 			if !ok {
 				return
 			}
 
-			// Process task
+			// Process task within the memory budget
+			cost, ok := bp.reserve(task)
+			if !ok {
+				return
+			}
+			if bp.Stopped() {
+				bp.release(cost)
+				return
+			}
+			bp.live.begin(id, task)
 			result := bp.processTask(task)
+			bp.release(cost)
+			if bp.deferTimeout(task, result) {
+				bp.live.end(id, task, nil)
+				continue
+			}
 
 			// Send result
 			select {
@@ -254,36 +374,193 @@ func (bp *BatchProcessor) worker(id int, wg *sync.WaitGroup) {
 			}
 
 			// Update progress
+			bp.live.end(id, task, &result)
 			bp.completed.Add(1)
 			bp.currentFile.Store(task.FilePath)
 		}
 	}
 }
 
-// processTask processes a single task
+// reserve waits for a worker slot when auto-tuning and for the task's
+// estimated memory to fit the budget, reporting false if the batch was
+// cancelled first
+func (bp *BatchProcessor) reserve(task Task) (int64, bool) {
+	if bp.gate != nil {
+		if _, ok := bp.gate.acquire(bp.ctx, 1); !ok {
+			return 0, false
+		}
+	}
+	if bp.memory == nil {
+		return 0, true
+	}
+	cost, ok := bp.memory.acquire(bp.ctx, EstimateMemory(task.FilePath, task.size))
+	if !ok && bp.gate != nil {
+		bp.gate.release(1)
+	}
+	return cost, ok
+}
+
+// release returns the worker slot and memory reserved for a task
+func (bp *BatchProcessor) release(cost int64) {
+	if bp.memory != nil {
+		bp.memory.release(cost)
+	}
+	if bp.gate != nil {
+		bp.gate.release(1)
+	}
+}
+
+// deferTimeout holds back a timed-out task for the final pass, reporting
+// whether it did
+func (bp *BatchProcessor) deferTimeout(task Task, result Result) bool {
+	if result.ErrorKind != ErrorKindTimeout || task.finalPass || bp.config.FinalPassFactor <= 0 || bp.ctx.Err() != nil || bp.Stopped() {
+		return false
+	}
+	task.attempts = result.Attempts
+	task.elapsed = result.Duration
+	task.previous = &result
+	task.finalPass = true
+	bp.timedOutMu.Lock()
+	bp.timedOut = append(bp.timedOut, task)
+	bp.timedOutMu.Unlock()
+	return true
+}
+
+// finalPass retries the tasks that timed out with a longer timeout once
+// every other file is done, so slow files do not hold up the batch
+func (bp *BatchProcessor) finalPass() {
+	bp.timedOutMu.Lock()
+	tasks := bp.timedOut
+	bp.timedOut = nil
+	bp.timedOutMu.Unlock()
+	if len(tasks) == 0 {
+		return
+	}
+
+	queue := make(chan Task, len(tasks))
+	for _, task := range tasks {
+		queue <- task
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < bp.config.NumWorkers && i < len(tasks) && !bp.Stopped(); i++ {
+		wg.Add(1)
+		go bp.worker(i+1, queue, &wg)
+	}
+	wg.Wait()
+
+	// After Stop, files the final pass did not reach keep their timeout
+	for task := range queue {
+		if bp.ctx.Err() != nil {
+			return
+		}
+		bp.resultQueue <- *task.previous
+		bp.completed.Add(1)
+	}
+}
+
+// processTask processes a single task. A panic while processing is reported
+// as the task's error instead of stopping the batch, and files failing with a
+// transient error are retried up to config.Retries times with exponential
+// backoff.
 func (bp *BatchProcessor) processTask(task Task) Result {
+	timeout := bp.config.TimeoutFor(task.size, task.finalPass)
+	start := time.Now()
+
+	var result Result
+	backoff := bp.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		result = bp.attemptTask(task, timeout)
+		result.Attempts = task.attempts + attempt + 1
+		if !result.ErrorKind.Transient() || attempt >= bp.config.Retries || bp.ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-bp.ctx.Done():
+		}
+		backoff *= 2
+	}
+	result.index = task.index
+	result.Timeout = timeout
+	result.Duration = task.elapsed + time.Since(start)
+	result.Bytes = task.size
+	return result
+}
+
+// attemptTask processes a task once with the given timeout
+func (bp *BatchProcessor) attemptTask(task Task, timeout time.Duration) (result Result) {
 	// Create timeout context for this operation
-	ctx, cancel := context.WithTimeout(bp.ctx, bp.config.Timeout)
+	ctx, cancel := context.WithTimeout(bp.ctx, timeout)
 	defer cancel()
 
+	defer func() {
+		if v := recover(); v != nil {
+			result = Result{
+				FilePath:  task.FilePath,
+				Root:      task.Root,
+				Error:     newPanicError(v),
+				ErrorKind: ErrorKindPanic,
+			}
+		}
+	}()
+
+	if bp.config.Isolate != nil {
+		result = runIsolated(ctx, *bp.config.Isolate, task)
+	} else {
+		result = taskRunner(ctx, bp.config, task)
+	}
+	if result.Error != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// Libraries report a missed deadline in their own words
+		result.ErrorKind = ErrorKindTimeout
+	}
+	if result.ErrorKind == "" {
+		result.ErrorKind = ClassifyError(result.Error)
+	}
+	return result
+}
+
+// RunTask processes a single task in the calling goroutine, recovering
+// panics like a batch worker. It is what a child process runs in isolated
+// mode; config.Isolate is ignored.
+func RunTask(ctx context.Context, config BatchConfig, task Task) (result Result) {
+	defer func() {
+		if v := recover(); v != nil {
+			result = Result{FilePath: task.FilePath, Root: task.Root, Error: newPanicError(v), ErrorKind: ErrorKindPanic}
+		}
+	}()
+	result = taskRunner(ctx, config, task)
+	result.ErrorKind = ClassifyError(result.Error)
+	return result
+}
+
+// taskRunner is runOperation, replaceable in tests
+var taskRunner = runOperation
+
+// runOperation performs the task's operation with the library
+func runOperation(ctx context.Context, config BatchConfig, task Task) Result {
 	result := Result{
 		FilePath: task.FilePath,
+		Root:     task.Root,
 	}
 
 	switch task.Operation {
 	case OperationValidate:
 		validator := NewValidateOperation(ctx)
+		start := time.Now()
 		report, err := validator.Execute(task.FilePath)
+		result.Phases.Validate = time.Since(start)
 		result.Report = report
 		result.Error = err
 
 	case OperationRepair:
-		repairer := NewRepairOperation(ctx).WithAggressive(bp.config.Aggressive)
-		mode := bp.config.RepairMode
+		repairer := NewRepairOperation(ctx).WithAggressive(config.Aggressive).WithTiming(&result.Phases)
+		mode := config.RepairMode
 		if mode == "" {
 			mode = RepairSaveModeBackupOriginal
 		}
-		repairResult, _, err := repairer.ExecuteWithSaveMode(task.FilePath, mode, bp.config.BackupDir)
+		repairResult, _, err := repairer.ExecuteWithSaveMode(task.FilePath, mode, config.BackupDir)
 		result.Repair = repairResult
 		result.Error = err
 
@@ -313,9 +590,11 @@ func (bp *BatchProcessor) reportProgress() {
 
 			update := ProgressUpdate{
 				Completed: completed,
-				Total:     bp.total,
+				Total:     int(bp.total.Load()),
 				Current:   current,
+				Workers:   bp.Workers(),
 			}
+			bp.live.fill(&update)
 
 			// Non-blocking send
 			select {
@@ -332,6 +611,17 @@ func (bp *BatchProcessor) ProgressChannel() <-chan ProgressUpdate {
 	return bp.progressCh
 }
 
+// Received returns the number of files handed to the batch so far
+func (bp *BatchProcessor) Received() int {
+	return int(bp.total.Load())
+}
+
+// Workers returns the number of files processed at once. With AutoTune it
+// is the count the tuner last chose.
+func (bp *BatchProcessor) Workers() int {
+	return int(bp.workers.Load())
+}
+
 // Cancel cancels the batch processing
 func (bp *BatchProcessor) Cancel() {
 	bp.cancel()
@@ -354,22 +644,53 @@ type BatchResult struct {
 	RepairsNoOp      int           // Number of successful no-op repairs (no actions applied)
 
 	// Post-processing cleanup tracking
-	RemovedFiles []string // Files removed during cleanup (system errors)
-	MovedFiles   []string // Files moved to INVALID during cleanup (failed repairs)
+	RemovedFiles []string     // Files removed during cleanup (system errors)
+	MovedFiles   []string     // Files moved by routing rules, before the move
+	Routed       []RoutedFile // Files routing rules applied to, including those left in place
+	Cleanup      CleanupPlan  // Planned cleanup; its operations are marked Done once applied
 
 	// Operation configuration
 	Options BatchOptions // Settings used for this batch operation
+
+	// Cross-file issue statistics, most common first
+	TopIssues []IssueStat
+
+	// Per-root counts when files came from several command-line roots
+	Roots []RootStat
+
+	// Symbolic links followed or skipped during discovery
+	Symlinks []SymlinkEvent
+
+	// Errored results counted by kind, most common first
+	ErrorKinds []KindStat
+
+	// Files that needed more than one attempt
+	Retried int
+
+	// Per-file processing times and throughput
+	Timing TimingStats
+
+	// Set when the batch was stopped before every file was processed
+	Incomplete  bool
+	Unprocessed int // Files found but not processed
+
+	// Set when finding files failed part way; files not found by then were
+	// not processed
+	ScanError error
 }
 
 // BatchOptions captures the operation settings for reporting
 type BatchOptions struct {
-	NumWorkers         int
+	Profile            string // Settings profile chosen, if any
+	NumWorkers         int    // Files processed at once; with AutoJobs, the count the run settled on
+	AutoJobs           bool   // NumWorkers was tuned during the run
 	SkipValidation     bool
 	NoBackup           bool
 	Aggressive         bool
 	RemoveSystemErrors bool
 	MoveFailedRepairs  bool
 	CleanupEmptyDirs   bool
+	Routes             []string // Routing rules, as ParseRouteRule reads them
 }
 
 // AggregateResults aggregates a list of results into a BatchResult
@@ -426,5 +747,22 @@ func AggregateResults(results []Result, duration time.Duration, operation Operat
 		}
 	}
 
+	br.TopIssues = ComputeIssueStats(results)
+	br.Roots = ComputeRootStats(results)
+	br.ErrorKinds = ComputeKindStats(results)
+	br.Retried = CountRetried(results)
+	br.Timing = ComputeTimingStats(results, duration)
+
 	return br
 }
+
+// CountRetried counts the results that needed more than one attempt
+func CountRetried(results []Result) int {
+	count := 0
+	for _, r := range results {
+		if r.Attempts > 1 {
+			count++
+		}
+	}
+	return count
+}
@@ -3,6 +3,7 @@ package operations
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -340,6 +341,35 @@ func TestBatchProcessor_Execute_ValidateUnsupported(t *testing.T) {
 	}
 }
 
+func TestBatchProcessor_ExecuteStream(t *testing.T) {
+	config := DefaultBatchConfig()
+	config.NumWorkers = 4
+	config.ProgressRate = 5 * time.Millisecond
+
+	bp := NewBatchProcessor(context.Background(), config)
+	inputs := make(chan Input)
+	go func() {
+		defer close(inputs)
+		for i := 0; i < 20; i++ {
+			inputs <- Input{Path: fmt.Sprintf("file%02d.txt", i), Root: "lib"}
+		}
+	}()
+
+	// Workers finish out of order, results follow the input order
+	results := bp.ExecuteStream(inputs, OperationType("noop"))
+	if len(results) != 20 {
+		t.Fatalf("Expected 20 results, got %d", len(results))
+	}
+	for i, r := range results {
+		if want := fmt.Sprintf("file%02d.txt", i); r.FilePath != want || r.Root != "lib" {
+			t.Errorf("result %d = %s (%s), want %s", i, r.FilePath, r.Root, want)
+		}
+	}
+	if got := bp.total.Load(); got != 20 {
+		t.Errorf("Expected total of 20, got %d", got)
+	}
+}
+
 func TestBatchProcessor_Execute_RepairUnsupported(t *testing.T) {
 	config := DefaultBatchConfig()
 	config.NumWorkers = 1
@@ -385,7 +415,7 @@ func TestBatchProcessor_ReportProgress(t *testing.T) {
 	config.ProgressRate = 5 * time.Millisecond
 
 	bp := NewBatchProcessor(context.Background(), config)
-	bp.total = 3
+	bp.total.Store(3)
 	bp.completed.Store(1)
 	bp.currentFile.Store("file1.txt")
 
@@ -428,3 +458,77 @@ func TestProgressUpdate(t *testing.T) {
 		t.Errorf("Expected Current to be 'file5.epub', got '%s'", update.Current)
 	}
 }
+
+func TestBatchConfig_TimeoutFor(t *testing.T) {
+	config := BatchConfig{
+		Timeout:         30 * time.Second,
+		TimeoutPerMB:    time.Second,
+		MaxTimeout:      5 * time.Minute,
+		FinalPassFactor: 2,
+	}
+
+	tests := []struct {
+		size      int64
+		finalPass bool
+		want      time.Duration
+	}{
+		{0, false, 30 * time.Second},
+		{10 << 20, false, 40 * time.Second},
+		{512 << 10, false, 30*time.Second + 500*time.Millisecond},
+		{400 << 20, false, 5 * time.Minute},
+		{10 << 20, true, 80 * time.Second},
+		{400 << 20, true, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := config.TimeoutFor(tt.size, tt.finalPass); got != tt.want {
+			t.Errorf("TimeoutFor(%d, %v) = %v, want %v", tt.size, tt.finalPass, got, tt.want)
+		}
+	}
+}
+
+func TestBatchProcessor_FinalPassForTimeouts(t *testing.T) {
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		if task.FilePath == "slow.pdf" {
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-ctx.Done():
+				return Result{FilePath: task.FilePath, Error: ctx.Err()}
+			}
+		}
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 2
+	config.Timeout = 20 * time.Millisecond
+	config.FinalPassFactor = 20
+	bp := NewBatchProcessor(context.Background(), config)
+	results := bp.Execute([]string{"a.epub", "slow.pdf", "b.epub"}, OperationValidate)
+
+	if len(results) != 3 || results[1].FilePath != "slow.pdf" {
+		t.Fatalf("Expected results in input order, got %+v", results)
+	}
+	slow := results[1]
+	if slow.Error != nil || slow.Attempts != 2 || slow.Timeout != 400*time.Millisecond {
+		t.Errorf("Expected slow.pdf to pass on the final pass, got error %v after %d attempts with timeout %v", slow.Error, slow.Attempts, slow.Timeout)
+	}
+	if results[0].Attempts != 1 || results[0].Timeout != 20*time.Millisecond {
+		t.Errorf("Expected one attempt for a.epub, got %d with timeout %v", results[0].Attempts, results[0].Timeout)
+	}
+
+	// Without a final pass the timeout is reported
+	config.FinalPassFactor = 0
+	bp = NewBatchProcessor(context.Background(), config)
+	results = bp.Execute([]string{"slow.pdf"}, OperationValidate)
+	if results[0].ErrorKind != ErrorKindTimeout || results[0].Attempts != 1 {
+		t.Errorf("Expected a single timed-out attempt, got %q after %d attempts", results[0].ErrorKind, results[0].Attempts)
+	}
+}
+
+func TestCountRetried(t *testing.T) {
+	results := []Result{{Attempts: 1}, {Attempts: 3}, {}, {Attempts: 2}}
+	if got := CountRetried(results); got != 2 {
+		t.Errorf("CountRetried() = %d, want 2", got)
+	}
+}
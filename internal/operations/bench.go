@@ -0,0 +1,103 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// BenchSample is the validation throughput measured with one worker count
+type BenchSample struct {
+	Workers  int
+	Files    int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// FilesPerSecond returns the files validated per second
+func (s BenchSample) FilesPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Files) / s.Duration.Seconds()
+}
+
+// BenchLevels returns the worker counts Bench tries: powers of two up to
+// maxWorkers, and maxWorkers itself
+func BenchLevels(maxWorkers int) []int {
+	var levels []int
+	for n := 1; n < maxWorkers; n *= 2 {
+		levels = append(levels, n)
+	}
+	return append(levels, max(1, maxWorkers))
+}
+
+// Bench validates sample files with each worker count in levels. Each count
+// gets its own files where the library is large enough, so that reads
+// cached by one run do not favour the next.
+func Bench(ctx context.Context, files []string, levels []int, sample int) ([]BenchSample, error) {
+	if len(files) == 0 || sample <= 0 {
+		return nil, errors.New("no files to benchmark")
+	}
+	picked := spread(files, sample*len(levels))
+	samples := make([]BenchSample, 0, len(levels))
+	for i, workers := range levels {
+		batch := make([]string, sample)
+		for j := range batch {
+			batch[j] = picked[(i*sample+j)%len(picked)]
+		}
+
+		config := DefaultBatchConfig()
+		config.NumWorkers = workers
+		config.Retries = 0
+		config.FinalPassFactor = 0
+		processor := NewBatchProcessor(ctx, config)
+
+		start := time.Now()
+		results := processor.Execute(batch, OperationValidate)
+		s := BenchSample{Workers: workers, Files: len(results), Duration: time.Since(start)}
+		if err := ctx.Err(); err != nil {
+			return samples, err
+		}
+		for _, path := range batch {
+			if info, err := os.Stat(path); err == nil {
+				s.Bytes += info.Size()
+			}
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+// spread picks up to n files evenly spaced through files
+func spread(files []string, n int) []string {
+	if len(files) <= n {
+		return files
+	}
+	picked := make([]string, n)
+	for i := range picked {
+		picked[i] = files[i*len(files)/n]
+	}
+	return picked
+}
+
+// benchTolerance is how close to the best throughput a smaller worker count
+// must come to be recommended
+const benchTolerance = 0.95
+
+// RecommendWorkers returns the fewest workers reaching close to the best
+// measured throughput, as more workers than that only add memory use
+func RecommendWorkers(samples []BenchSample) int {
+	best := 0.0
+	for _, s := range samples {
+		best = max(best, s.FilesPerSecond())
+	}
+	recommended := 0
+	for _, s := range samples {
+		if s.FilesPerSecond() >= best*benchTolerance && (recommended == 0 || s.Workers < recommended) {
+			recommended = s.Workers
+		}
+	}
+	return max(1, recommended)
+}
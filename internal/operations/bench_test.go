@@ -0,0 +1,78 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBenchLevels(t *testing.T) {
+	tests := []struct {
+		max  int
+		want []int
+	}{
+		{1, []int{1}},
+		{8, []int{1, 2, 4, 8}},
+		{12, []int{1, 2, 4, 8, 12}},
+	}
+	for _, tt := range tests {
+		if got := BenchLevels(tt.max); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BenchLevels(%d) = %v, want %v", tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendWorkers(t *testing.T) {
+	samples := []BenchSample{
+		{Workers: 1, Files: 10, Duration: 10 * time.Second},
+		{Workers: 2, Files: 10, Duration: 5 * time.Second},
+		{Workers: 4, Files: 10, Duration: 2600 * time.Millisecond},
+		{Workers: 8, Files: 10, Duration: 2500 * time.Millisecond},
+	}
+	if got := RecommendWorkers(samples); got != 4 {
+		t.Errorf("RecommendWorkers() = %d, want 4", got)
+	}
+	if got := RecommendWorkers(nil); got != 1 {
+		t.Errorf("RecommendWorkers(nil) = %d, want 1", got)
+	}
+}
+
+func TestBench(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		mu.Lock()
+		seen[task.FilePath] = true
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	files := make([]string, 100)
+	for i := range files {
+		files[i] = fmt.Sprintf("book%03d.epub", i)
+	}
+	samples, err := Bench(context.Background(), files, []int{1, 4}, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 || samples[0].Files != 8 || samples[1].Workers != 4 {
+		t.Fatalf("Unexpected samples %+v", samples)
+	}
+	if samples[1].FilesPerSecond() <= samples[0].FilesPerSecond() {
+		t.Errorf("Expected 4 workers to beat 1 on sleeping tasks, got %+v", samples)
+	}
+
+	// Each level gets different files
+	if len(seen) != 16 {
+		t.Errorf("Expected 16 distinct sample files, got %d", len(seen))
+	}
+
+	if _, err := Bench(context.Background(), nil, []int{1}, 8); err == nil {
+		t.Error("Expected error without files")
+	}
+}
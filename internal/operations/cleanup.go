@@ -0,0 +1,327 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
+)
+
+// CleanupAction is a change a cleanup plan makes to the library
+type CleanupAction string
+
+const (
+	CleanupRemove    CleanupAction = "remove" // Delete a file that could not be processed
+	CleanupMove      CleanupAction = "move"   // Move a file as a routing rule says
+	CleanupRemoveDir CleanupAction = "rmdir"  // Delete a directory left empty, with its Calibre metadata files
+)
+
+// CleanupOp is one operation of a cleanup plan
+type CleanupOp struct {
+	Action  CleanupAction
+	Path    string
+	Dest    string    // Where a file is moved
+	Outcome Outcome   // Outcome of a moved file
+	Kind    ErrorKind // Error kind of a removed file
+	Files   []string  // Names of the metadata files removed with a directory
+	Trashed string    // Name in the trash of a removed file or directory
+	Skipped bool      // A move left out because its destination is taken
+	Done    bool      // Applied successfully
+	Err     error     // Why the operation failed
+}
+
+// Pending reports whether the operation is still to be applied
+func (op CleanupOp) Pending() bool {
+	return !op.Done && !op.Skipped && op.Err == nil
+}
+
+// CleanupOptions selects the cleanup of a batch
+type CleanupOptions struct {
+	RemoveKinds []ErrorKind // Errored files of these kinds are removed; nil removes none
+	Routes      RouteRules
+	RemoveDirs  bool // Remove directories emptied by removals and moves
+
+	// RootOf gives the directory each file was found under. Nothing above
+	// it is removed, and relative routes are inside it.
+	RootOf func(Result) string
+}
+
+// CleanupPlan lists the operations of a cleanup in the order they are
+// applied: removals, moves, then directories, deepest first
+type CleanupPlan []CleanupOp
+
+// PlanCleanup lists the removals, moves and directory removals the options
+// ask for after a batch, without changing anything. Files removed are not
+// moved, and directories holding an ebook, a subdirectory or a move's
+// destination are kept.
+func PlanCleanup(result *BatchResult, opts CleanupOptions) CleanupPlan {
+	var plan CleanupPlan
+	gone := make(map[string]bool)
+	var roots []string // Root of each removed or moved file, by plan index
+
+	removable := FilterByKind(result.Errored, opts.RemoveKinds)
+	for _, r := range removable {
+		plan = append(plan, CleanupOp{Action: CleanupRemove, Path: r.FilePath, Kind: r.ErrorKind})
+		roots = append(roots, opts.RootOf(r))
+		gone[r.FilePath] = true
+	}
+
+	claimed := make(map[string]bool)
+	taken := func(path string) bool { return claimed[path] || exists(path) }
+	keep := make(map[string]bool) // Directories moves go into
+	if len(opts.Routes) > 0 {
+		for _, group := range [][]Result{result.Valid, result.Invalid, result.Errored} {
+			for _, r := range group {
+				if gone[r.FilePath] {
+					continue
+				}
+				root := opts.RootOf(r)
+				routed, ok := opts.Routes.Plan(r, root, taken)
+				if !ok {
+					continue
+				}
+				op := CleanupOp{Action: CleanupMove, Path: r.FilePath, Dest: routed.Dest, Outcome: routed.Outcome, Skipped: routed.Skipped, Err: routed.Err}
+				plan = append(plan, op)
+				roots = append(roots, root)
+				if !op.Pending() {
+					continue
+				}
+				gone[r.FilePath] = true
+				claimed[op.Dest] = true
+				for dir := filepath.Dir(op.Dest); !keep[dir]; dir = filepath.Dir(dir) {
+					keep[dir] = true
+				}
+			}
+		}
+	}
+
+	if opts.RemoveDirs {
+		files := len(plan)
+		for i := 0; i < files; i++ {
+			if gone[plan[i].Path] {
+				plan = planDirRemovals(plan, filepath.Dir(plan[i].Path), roots[i], gone, keep)
+			}
+		}
+	}
+	return plan
+}
+
+// planDirRemovals adds the removal of dir and its parents up to root while
+// they hold nothing but files that are gone and Calibre metadata
+func planDirRemovals(plan CleanupPlan, dir, root string, gone, keep map[string]bool) CleanupPlan {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && within(root, dir); dir = filepath.Dir(dir) {
+		if gone[dir] {
+			continue // Planned for another file
+		}
+		if keep[dir] {
+			break
+		}
+		// Bail out in large shallow hierarchies, which are slow to scan
+		parent := filepath.Dir(dir)
+		if siblings, err := remainingEntries(parent, gone); err == nil && parent != root && len(siblings) > 3 {
+			break
+		}
+		entries, err := remainingEntries(dir, gone)
+		if err != nil {
+			break
+		}
+		metadata, ok := metadataOnly(entries)
+		if !ok {
+			break
+		}
+		plan = append(plan, CleanupOp{Action: CleanupRemoveDir, Path: dir, Files: metadata})
+		gone[dir] = true
+	}
+	return plan
+}
+
+// remainingEntries lists the entries of dir that are not gone
+func remainingEntries(dir string, gone map[string]bool) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var remaining []os.DirEntry
+	for _, entry := range entries {
+		if !gone[filepath.Join(dir, entry.Name())] {
+			remaining = append(remaining, entry)
+		}
+	}
+	return remaining, nil
+}
+
+// metadataOnly reports whether the entries of a directory are nothing, or
+// the files Calibre leaves next to a book, such as cover.jpg and
+// metadata.opf, with no ebook or subdirectory. It returns the names of the
+// files.
+func metadataOnly(entries []os.DirEntry) ([]string, bool) {
+	var names []string
+	hasMetadata := false
+	for _, entry := range entries {
+		if entry.IsDir() {
+			return nil, false
+		}
+		name := strings.ToLower(entry.Name())
+		switch {
+		case strings.HasPrefix(name, "."):
+			// System files such as .DS_Store go with the directory
+		case isEbookExt(filepath.Ext(name)):
+			return nil, false
+		case name == "cover.jpg" || name == "cover.jpeg" || name == "cover.png" || name == "metadata.opf":
+			hasMetadata = true
+		}
+		names = append(names, entry.Name())
+	}
+	return names, len(entries) == 0 || hasMetadata
+}
+
+// isEbookExt reports whether a lower case extension is an ebook's
+func isEbookExt(ext string) bool {
+	switch ext {
+	case ".epub", ".pdf", ".mobi", ".azw", ".azw3":
+		return true
+	}
+	return false
+}
+
+// Apply carries out the plan, recording the outcome of each operation in
+// the copy it returns. Removed files and directories go to bin. An
+// operation that fails does not stop the others, but a directory is kept
+// when anything inside it could not be removed or moved.
+func (p CleanupPlan) Apply(bin *trash.Trash) CleanupPlan {
+	applied := append(CleanupPlan(nil), p...)
+	var failed []string
+	for i := range applied {
+		op := &applied[i]
+		if !op.Pending() {
+			continue
+		}
+		switch op.Action {
+		case CleanupRemove:
+			op.Trashed, op.Err = putInTrash(bin, op.Path)
+		case CleanupMove:
+			if exists(op.Dest) {
+				op.Err = fmt.Errorf("destination %s appeared after planning", op.Dest)
+			} else {
+				op.Err = moveInto(op.Path, op.Dest)
+			}
+		case CleanupRemoveDir:
+			op.Trashed, op.Err = removeDir(bin, op.Path, op.Files, failed)
+		}
+		op.Done = op.Err == nil
+		if op.Err != nil {
+			failed = append(failed, op.Path)
+		}
+	}
+	return applied
+}
+
+// removeDir moves a directory planned for removal to the trash with its
+// metadata files, unless one of the failed paths is inside it or it holds
+// anything else
+func removeDir(bin *trash.Trash, dir string, files, failed []string) (string, error) {
+	for _, path := range failed {
+		if within(dir, path) {
+			return "", fmt.Errorf("kept because %s is still there", path)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	planned := make(map[string]bool, len(files))
+	for _, name := range files {
+		planned[name] = true
+	}
+	for _, entry := range entries {
+		if !planned[entry.Name()] {
+			return "", fmt.Errorf("kept because %s appeared after planning", filepath.Join(dir, entry.Name()))
+		}
+	}
+	return putInTrash(bin, dir)
+}
+
+// putInTrash moves a file or directory to bin and returns its name there
+func putInTrash(bin *trash.Trash, path string) (string, error) {
+	entry, err := bin.Put(path)
+	return entry.Name, err
+}
+
+// Pending counts the operations still to be applied
+func (p CleanupPlan) Pending() int {
+	n := 0
+	for _, op := range p {
+		if op.Pending() {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the operations that could not be applied
+func (p CleanupPlan) Failed() []CleanupOp {
+	var failed []CleanupOp
+	for _, op := range p {
+		if op.Err != nil {
+			failed = append(failed, op)
+		}
+	}
+	return failed
+}
+
+// Summary counts the operations of the plan by action, e.g. "2 deletions,
+// 1 move, 1 directory removal"
+func (p CleanupPlan) Summary() string {
+	counts := make(map[CleanupAction]int)
+	for _, op := range p {
+		if !op.Skipped {
+			counts[op.Action]++
+		}
+	}
+	var parts []string
+	for _, c := range []struct {
+		action       CleanupAction
+		one, several string
+	}{
+		{CleanupRemove, "deletion", "deletions"},
+		{CleanupMove, "move", "moves"},
+		{CleanupRemoveDir, "directory removal", "directory removals"},
+	} {
+		switch n := counts[c.action]; n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+c.one)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %s", n, c.several))
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing to do"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// RecordCleanup stores a cleanup plan in the result, with the files it
+// removed and moved
+func (br *BatchResult) RecordCleanup(plan CleanupPlan) {
+	br.Cleanup = plan
+	br.RemovedFiles, br.MovedFiles, br.Routed = nil, nil, nil
+	for _, op := range plan {
+		switch op.Action {
+		case CleanupRemove:
+			if op.Done {
+				br.RemovedFiles = append(br.RemovedFiles, op.Path)
+			}
+		case CleanupMove:
+			if op.Done {
+				br.MovedFiles = append(br.MovedFiles, op.Path)
+			}
+			if !op.Pending() {
+				br.Routed = append(br.Routed, RoutedFile{Path: op.Path, Dest: op.Dest, Outcome: op.Outcome, Skipped: op.Skipped, Err: op.Err})
+			}
+		}
+	}
+}
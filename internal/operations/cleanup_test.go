@@ -0,0 +1,233 @@
+package operations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+// writeLibrary creates files below root, with their own paths as content
+func writeLibrary(t *testing.T, root string, rels ...string) []string {
+	t.Helper()
+	var paths []string
+	for _, rel := range rels {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, path, rel)
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestPlanCleanup(t *testing.T) {
+	root := t.TempDir()
+	p := writeLibrary(t, root,
+		filepath.Join("A", "Title", "book.epub"),
+		filepath.Join("A", "Title", "cover.jpg"),
+		filepath.Join("A", "Title", "metadata.opf"),
+		filepath.Join("B", "book.epub"),
+		filepath.Join("B", "other.pdf"),
+		filepath.Join("C", "broken.pdf"),
+		filepath.Join("D", "book.epub"),
+	)
+	book, other, broken, same := p[0], p[4], p[5], p[6]
+	result := &BatchResult{
+		Valid:   []Result{{FilePath: other, Report: &ebmlib.ValidationReport{IsValid: true}}},
+		Invalid: []Result{{FilePath: book, Report: &ebmlib.ValidationReport{}}, {FilePath: same, Report: &ebmlib.ValidationReport{}}},
+		Errored: []Result{{FilePath: broken, Error: errors.New("zip"), ErrorKind: ErrorKindCorrupt}},
+	}
+	rules := RouteRules{{Outcome: OutcomeInvalid, Dest: "INVALID"}}
+	plan := PlanCleanup(result, CleanupOptions{
+		RemoveKinds: DefaultRemovableKinds,
+		Routes:      rules,
+		RemoveDirs:  true,
+		RootOf:      func(Result) string { return root },
+	})
+
+	want := []CleanupOp{
+		{Action: CleanupRemove, Path: broken, Kind: ErrorKindCorrupt},
+		{Action: CleanupMove, Path: book, Dest: filepath.Join(root, "INVALID", "book.epub"), Outcome: OutcomeInvalid},
+		// Same-named books without keep-path are suffixed, not overwritten
+		{Action: CleanupMove, Path: same, Dest: filepath.Join(root, "INVALID", "book_1.epub"), Outcome: OutcomeInvalid},
+		{Action: CleanupRemoveDir, Path: filepath.Join(root, "C")},
+		{Action: CleanupRemoveDir, Path: filepath.Join(root, "A", "Title"), Files: []string{"cover.jpg", "metadata.opf"}},
+		{Action: CleanupRemoveDir, Path: filepath.Join(root, "A")},
+		{Action: CleanupRemoveDir, Path: filepath.Join(root, "D")},
+	}
+	if len(plan) != len(want) {
+		t.Fatalf("Expected %d operations, got %+v", len(want), plan)
+	}
+	for i, op := range plan {
+		w := want[i]
+		if op.Action != w.Action || op.Path != w.Path || op.Dest != w.Dest || op.Outcome != w.Outcome || op.Kind != w.Kind || strings.Join(op.Files, ",") != strings.Join(w.Files, ",") {
+			t.Errorf("Operation %d: expected %+v, got %+v", i, w, op)
+		}
+	}
+	if got := plan.Summary(); got != "1 deletion, 2 moves, 4 directory removals" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+
+	// Planning changes nothing
+	for _, path := range p {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be left alone by planning: %v", path, err)
+		}
+	}
+
+	bin := trash.New(t.TempDir())
+	applied := plan.Apply(bin)
+	if failed := applied.Failed(); len(failed) != 0 || applied.Pending() != 0 || plan.Pending() != len(plan) {
+		t.Fatalf("Expected every operation to be applied to a copy, got %+v", failed)
+	}
+	// Removals go to the trash
+	if entries, _, err := bin.List(); err != nil || len(entries) != 5 || applied[0].Trashed == "" {
+		t.Errorf("Expected the removals in the trash, got %+v (%v)", entries, err)
+	}
+	for _, dir := range []string{"A", "C", "D"} {
+		if _, err := os.Stat(filepath.Join(root, dir)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", dir, err)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "INVALID", "book_1.epub")); err != nil || string(data) != filepath.Join("D", "book.epub") {
+		t.Errorf("Expected the second book under its own name, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("Expected the directory with a valid book to stay: %v", err)
+	}
+
+	result.RecordCleanup(applied)
+	if len(result.RemovedFiles) != 1 || len(result.MovedFiles) != 2 || len(result.Routed) != 2 || len(result.Cleanup) != len(plan) {
+		t.Errorf("Unexpected record: removed %v, moved %v, routed %+v", result.RemovedFiles, result.MovedFiles, result.Routed)
+	}
+}
+
+func TestPlanCleanup_KeepsDestinationsAndSkips(t *testing.T) {
+	root := t.TempDir()
+	p := writeLibrary(t, root,
+		filepath.Join("INVALID", "x.pdf"),
+		filepath.Join("x", "x.pdf"),
+		filepath.Join("gone", "gone.pdf"),
+		filepath.Join("sorted", "a", "old.epub"),
+	)
+	taken, x, removed, sorted := p[0], p[1], p[2], p[3]
+	result := &BatchResult{
+		Invalid: []Result{{FilePath: x, Repair: &ebmlib.RepairResult{}}},
+		Errored: []Result{{FilePath: removed, Error: errors.New("boom"), ErrorKind: ErrorKindCorrupt}},
+	}
+	rules := RouteRules{
+		{Outcome: OutcomeReverted, Dest: "INVALID", Collision: CollisionSkip},
+		{Outcome: OutcomeErrored, Dest: "broken"},
+	}
+
+	// Without removals the errored file is routed instead
+	plan := PlanCleanup(result, CleanupOptions{Routes: rules, RemoveDirs: true, RootOf: func(Result) string { return root }})
+	if len(plan) != 3 || plan[0].Path != x || !plan[0].Skipped || plan[0].Dest != taken || plan[1].Path != removed || plan[2].Path != filepath.Join(root, "gone") {
+		t.Fatalf("Expected a skipped move, a move and its directory, got %+v", plan)
+	}
+	if got := plan.Summary(); got != "1 move, 1 directory removal" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+
+	// Files already in their destination, and folders moves go into, stay
+	rules = RouteRules{{Outcome: OutcomeReverted, Dest: "sorted", KeepPath: true}}
+	result.Invalid = append(result.Invalid, Result{FilePath: sorted, Repair: &ebmlib.RepairResult{}})
+	plan = PlanCleanup(&BatchResult{Invalid: result.Invalid}, CleanupOptions{Routes: rules, RemoveDirs: true, RootOf: func(Result) string { return root }})
+	if len(plan) != 2 || plan[0].Dest != filepath.Join(root, "sorted", "x", "x.pdf") || plan[1].Path != filepath.Join(root, "x") {
+		t.Fatalf("Expected only the move of %s and its folder, got %+v", x, plan)
+	}
+}
+
+func TestCleanupPlan_ApplyReportsFailures(t *testing.T) {
+	root := t.TempDir()
+	p := writeLibrary(t, root,
+		filepath.Join("A", "book.epub"),
+		filepath.Join("A", "cover.jpg"),
+		filepath.Join("B", "book.epub"),
+	)
+	book, cover, moved := p[0], p[1], p[2]
+	dest := filepath.Join(root, "INVALID", "book.epub")
+	plan := CleanupPlan{
+		{Action: CleanupRemove, Path: filepath.Join(root, "A", "missing.epub")},
+		{Action: CleanupMove, Path: moved, Dest: dest},
+		{Action: CleanupRemoveDir, Path: filepath.Join(root, "A"), Files: []string{"book.epub", "cover.jpg"}},
+	}
+	// Something appears at the destination after planning
+	writeLibrary(t, root, filepath.Join("INVALID", "book.epub"))
+
+	applied := plan.Apply(trash.New(t.TempDir()))
+	failed := applied.Failed()
+	if len(failed) != 3 {
+		t.Fatalf("Expected every operation to fail, got %+v", applied)
+	}
+	if !strings.Contains(failed[1].Err.Error(), "appeared after planning") || !strings.Contains(failed[2].Err.Error(), "kept because") {
+		t.Errorf("Unexpected errors %v and %v", failed[1].Err, failed[2].Err)
+	}
+	// The failed removal keeps the directory and its metadata
+	for _, path := range []string{book, cover, moved} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected %s to be kept: %v", path, err)
+		}
+	}
+
+	var result BatchResult
+	result.RecordCleanup(applied)
+	if len(result.RemovedFiles) != 0 || len(result.MovedFiles) != 0 || len(result.Routed) != 1 || result.Routed[0].Err == nil {
+		t.Errorf("Expected only the failed move to be recorded, got %+v", result)
+	}
+
+	// An unapplied plan records nothing as done
+	result.RecordCleanup(plan)
+	if len(result.Routed) != 0 || result.Cleanup.Pending() != 3 {
+		t.Errorf("Expected a pending plan, got %+v", result)
+	}
+
+	// A directory holding more than was planned is kept
+	plan = CleanupPlan{{Action: CleanupRemoveDir, Path: filepath.Join(root, "A"), Files: []string{"cover.jpg"}}}
+	if applied := plan.Apply(trash.New(t.TempDir())); applied[0].Err == nil || !strings.Contains(applied[0].Err.Error(), "appeared after planning") {
+		t.Errorf("Expected the directory to be kept, got %+v", applied[0])
+	}
+}
+
+func TestCleanupPlan_ApplyWithTrashOnAnotherDevice(t *testing.T) {
+	root, err := os.MkdirTemp("/dev/shm", "ebm-cleanup-test")
+	if err != nil {
+		t.Skip("no second filesystem to test with")
+	}
+	defer os.RemoveAll(root)
+	p := writeLibrary(t, root,
+		filepath.Join("A", "broken.epub"),
+		filepath.Join("B", "cover.jpg"),
+	)
+	bin := trash.New(t.TempDir())
+	if err := os.Link(p[0], filepath.Join(t.TempDir(), "probe")); err == nil {
+		t.Skip("the temporary directory is on the same filesystem")
+	}
+
+	plan := CleanupPlan{
+		{Action: CleanupRemove, Path: p[0]},
+		{Action: CleanupRemoveDir, Path: filepath.Join(root, "B"), Files: []string{"cover.jpg"}},
+	}
+	applied := plan.Apply(bin)
+	if failed := applied.Failed(); len(failed) != 0 {
+		t.Fatalf("Expected the cleanup to succeed, got %+v", failed)
+	}
+	for _, path := range []string{p[0], filepath.Join(root, "B")} {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", path, err)
+		}
+	}
+	entries, _, err := bin.List()
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected both in the trash, got %+v, %v", entries, err)
+	}
+	if err := bin.Restore(entries[0]); err != nil {
+		t.Fatal(err)
+	}
+}
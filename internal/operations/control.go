@@ -0,0 +1,89 @@
+package operations
+
+import "sync"
+
+// batchControl pauses and stops the scheduling of new files. Files already
+// being processed are never interrupted by it; cancelling the context does
+// that.
+type batchControl struct {
+	mu       sync.Mutex
+	paused   bool
+	resumed  chan struct{} // Closed on resume
+	stop     chan struct{} // Closed by Stop
+	stopOnce sync.Once
+}
+
+func newBatchControl() *batchControl {
+	return &batchControl{stop: make(chan struct{})}
+}
+
+// Pause stops workers from starting new files until Resume. Files in
+// progress finish normally.
+func (bp *BatchProcessor) Pause() {
+	c := bp.control
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.resumed = make(chan struct{})
+	}
+}
+
+// Resume lets workers start new files again after Pause
+func (bp *BatchProcessor) Resume() {
+	c := bp.control
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.paused = false
+		close(c.resumed)
+	}
+}
+
+// Paused reports whether the batch is paused
+func (bp *BatchProcessor) Paused() bool {
+	c := bp.control
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Stop ends the batch once the files in progress finish. Files not yet
+// started are left out of the results, and files waiting for the final pass
+// keep the result of their timed-out attempt.
+func (bp *BatchProcessor) Stop() {
+	bp.control.stopOnce.Do(func() { close(bp.control.stop) })
+}
+
+// Stopping returns a channel closed when Stop is called, so that file
+// discovery feeding the batch can stop too
+func (bp *BatchProcessor) Stopping() <-chan struct{} {
+	return bp.control.stop
+}
+
+// Stopped reports whether Stop was called
+func (bp *BatchProcessor) Stopped() bool {
+	select {
+	case <-bp.control.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitResumed blocks while the batch is paused, reporting false if it was
+// stopped or cancelled instead
+func (bp *BatchProcessor) waitResumed() bool {
+	c := bp.control
+	c.mu.Lock()
+	paused, resumed := c.paused, c.resumed
+	c.mu.Unlock()
+	if paused {
+		select {
+		case <-resumed:
+		case <-c.stop:
+		case <-bp.ctx.Done():
+		}
+	}
+	return !bp.Stopped() && bp.ctx.Err() == nil
+}
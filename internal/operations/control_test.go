@@ -0,0 +1,138 @@
+package operations
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchProcessor_PauseResume(t *testing.T) {
+	var started atomic.Int64
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		started.Add(1)
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 2
+	bp := NewBatchProcessor(context.Background(), config)
+	bp.Pause()
+	if !bp.Paused() {
+		t.Fatal("Expected the batch to be paused")
+	}
+
+	done := make(chan []Result)
+	go func() { done <- bp.Execute([]string{"a.epub", "b.epub", "c.epub"}, OperationValidate) }()
+
+	time.Sleep(50 * time.Millisecond)
+	if n := started.Load(); n != 0 {
+		t.Fatalf("Expected no file to start while paused, %d did", n)
+	}
+
+	bp.Resume()
+	select {
+	case results := <-done:
+		if len(results) != 3 {
+			t.Errorf("Expected 3 results after resuming, got %d", len(results))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Batch did not finish after resuming")
+	}
+}
+
+func TestBatchProcessor_StopKeepsFinishedFiles(t *testing.T) {
+	running := make(chan struct{})
+	release := make(chan struct{})
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		if task.FilePath == "a.epub" {
+			close(running)
+			<-release
+		}
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 1
+	bp := NewBatchProcessor(context.Background(), config)
+	bp.Pause()
+	bp.Resume()
+
+	done := make(chan []Result)
+	go func() { done <- bp.Execute([]string{"a.epub", "b.epub", "c.epub"}, OperationValidate) }()
+
+	<-running
+	bp.Stop()
+	bp.Stop() // Stopping twice is harmless
+	close(release)
+
+	results := <-done
+	if len(results) != 1 || results[0].FilePath != "a.epub" || results[0].Error != nil {
+		t.Fatalf("Expected only the running file to finish normally, got %+v", results)
+	}
+	if !bp.Stopped() || bp.Received() != 3 {
+		t.Errorf("Stopped() = %v, Received() = %d", bp.Stopped(), bp.Received())
+	}
+}
+
+func TestBatchProcessor_StopWakesPausedWorkers(t *testing.T) {
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	bp := NewBatchProcessor(context.Background(), DefaultBatchConfig())
+	bp.Pause()
+	done := make(chan []Result)
+	go func() { done <- bp.Execute([]string{"a.epub", "b.epub"}, OperationValidate) }()
+
+	time.Sleep(20 * time.Millisecond)
+	bp.Stop()
+	select {
+	case results := <-done:
+		if len(results) != 0 {
+			t.Errorf("Expected no results from a batch stopped while paused, got %+v", results)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not end a paused batch")
+	}
+}
+
+func TestBatchProcessor_StopReportsDeferredTimeouts(t *testing.T) {
+	running := make(chan struct{})
+	release := make(chan struct{})
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		if task.FilePath == "slow.pdf" {
+			<-ctx.Done()
+			return Result{FilePath: task.FilePath, Error: ctx.Err()}
+		}
+		close(running)
+		<-release
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 1
+	config.Timeout = 10 * time.Millisecond
+	config.TimeoutPerMB = 0
+	bp := NewBatchProcessor(context.Background(), config)
+
+	done := make(chan []Result)
+	go func() { done <- bp.Execute([]string{"slow.pdf", "a.epub", "b.epub"}, OperationValidate) }()
+
+	<-running
+	bp.Stop()
+	close(release)
+
+	results := <-done
+	if len(results) != 2 || results[0].FilePath != "slow.pdf" || results[1].FilePath != "a.epub" {
+		t.Fatalf("Expected slow.pdf and a.epub, got %+v", results)
+	}
+	if results[0].ErrorKind != ErrorKindTimeout || results[0].Attempts != 1 {
+		t.Errorf("Expected slow.pdf to keep its timeout without a final pass, got %q after %d attempts",
+			results[0].ErrorKind, results[0].Attempts)
+	}
+}
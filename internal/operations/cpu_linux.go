@@ -0,0 +1,44 @@
+//go:build linux
+
+package operations
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readCPUTimes returns the busy, I/O wait and total CPU time in clock ticks
+// since boot, summed over all CPUs
+func readCPUTimes() (cpuTimes, bool) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return cpuTimes{}, false
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 6 || fields[0] != "cpu" {
+		return cpuTimes{}, false
+	}
+
+	var times cpuTimes
+	for i, field := range fields[1:] {
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return cpuTimes{}, false
+		}
+		// user nice system idle iowait irq softirq steal guest guest_nice;
+		// guest time is already counted in user and nice
+		switch {
+		case i == 3:
+		case i == 4:
+			times.iowait = n
+		case i >= 8:
+			continue
+		default:
+			times.busy += n
+		}
+		times.total += n
+	}
+	return times, true
+}
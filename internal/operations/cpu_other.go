@@ -0,0 +1,9 @@
+//go:build !linux
+
+package operations
+
+// readCPUTimes is unavailable on this platform; auto-tuning relies on
+// throughput alone
+func readCPUTimes() (cpuTimes, bool) {
+	return cpuTimes{}, false
+}
@@ -0,0 +1,520 @@
+package operations
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultWalkWorkers is the number of directories read concurrently when
+// FindFilesOptions.WalkWorkers is not set
+const DefaultWalkWorkers = 8
+
+// readAheadPerWorker bounds how many directory listings may be held ahead of
+// the walk for each worker
+const readAheadPerWorker = 64
+
+// Reasons a symbolic link is skipped during discovery
+const (
+	SymlinkNotFollowed = "not followed (use --follow-symlinks)"
+	SymlinkLoop        = "directory already visited (loop or duplicate link)"
+	SymlinkDuplicate   = "file already found through another path"
+	SymlinkBroken      = "broken link"
+)
+
+// SymlinkEvent records a symbolic link met during discovery
+type SymlinkEvent struct {
+	Path     string // Link path as discovered
+	Target   string // Resolved target, empty for broken links
+	Followed bool
+	Reason   string // Why the link was skipped
+}
+
+// Discovery is the outcome of a directory walk
+type Discovery struct {
+	Files    []string
+	Symlinks []SymlinkEvent
+}
+
+// fileID identifies a file independently of the path used to reach it
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// walker walks a tree for Walk. Directories and files are tracked by device
+// and inode so that loops are detected and files reachable through several
+// links are only returned once.
+//
+// It is a sequential walk with read-ahead, not a concurrent walker: one
+// goroutine visits the tree, so files are found in the same order as
+// filepath.WalkDir would find them, while a prefetcher reads the directory
+// listings it will need next, and their stat calls, in the background. On
+// network mounts this hides most of the per-directory latency.
+type walker struct {
+	opts     FindFilesOptions
+	matcher  *IgnoreMatcher
+	fetch    *prefetcher
+	emit     func(path string) error
+	symlinks []SymlinkEvent
+	seen     map[fileID]bool
+	seenAt   map[string]bool // Resolved paths, used where inodes are unavailable
+}
+
+// Discover walks root like FindFiles and also reports the symbolic links it
+// met. Links to directories are only followed with opts.FollowSymlinks.
+func Discover(root string, opts FindFilesOptions) (*Discovery, error) {
+	result := &Discovery{}
+	symlinks, err := Walk(root, opts, func(path string) error {
+		result.Files = append(result.Files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Symlinks = symlinks
+	return result, nil
+}
+
+// Walk calls fn for each matching file under root as soon as it is found,
+// in the same order as Discover returns them. An error from fn stops the
+// walk and is returned.
+func Walk(root string, opts FindFilesOptions, fn func(path string) error) ([]SymlinkEvent, error) {
+	root = filepath.Clean(root)
+
+	matcher, err := NewIgnoreMatcher(root, opts.Ignore, opts.IgnoreFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &walker{
+		opts:    opts,
+		matcher: matcher,
+		emit:    fn,
+		seen:    make(map[fileID]bool),
+		seenAt:  make(map[string]bool),
+	}
+
+	if !info.IsDir() {
+		if w.matchExt(root) {
+			if err := fn(root); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	w.fetch = newPrefetcher(w)
+	defer w.fetch.stop()
+
+	w.markSeen(root, info)
+	if err := w.walkDir(root, 0); err != nil {
+		return nil, err
+	}
+	return w.symlinks, nil
+}
+
+// dirEntry is a directory entry with the stat results the walk needs
+type dirEntry struct {
+	fs.DirEntry
+	info      os.FileInfo // Lstat result, when needed
+	target    os.FileInfo // Stat result for symbolic links
+	targetErr error
+}
+
+func (e dirEntry) isLink() bool {
+	return e.Type()&fs.ModeSymlink != 0
+}
+
+// isDir reports whether the entry is a directory or a link to one
+func (e dirEntry) isDir() bool {
+	if e.isLink() {
+		return e.targetErr == nil && e.target.IsDir()
+	}
+	return e.IsDir()
+}
+
+func (w *walker) walkDir(dir string, depth int) error {
+	entries, err := w.fetch.take(dir, depth)
+	if err != nil {
+		return err
+	}
+
+	// Only directories with an ignore file need another read
+	for _, entry := range entries {
+		if entry.Name() == IgnoreFileName {
+			if err := w.matcher.EnterDir(dir); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	// Start reading the subdirectories the walk will enter next
+	for _, entry := range entries {
+		if entry.IsDir() {
+			w.fetch.prefetch(filepath.Join(dir, entry.Name()), depth+1)
+		}
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if err := w.visit(path, entry, depth+1); err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			// Release listings of directories the walk skipped
+			w.fetch.discard(path)
+		}
+	}
+	return nil
+}
+
+func (w *walker) visit(path string, e dirEntry, depth int) error {
+	isLink := e.isLink()
+	isDir := e.isDir()
+
+	if !w.opts.enters(path, e.Name(), isDir, depth) {
+		return nil
+	}
+	if w.ignored(path, isDir) {
+		return nil
+	}
+
+	if isLink && e.targetErr != nil {
+		w.skipLink(path, "", SymlinkBroken)
+		return nil
+	}
+
+	if isDir {
+		if isLink && !w.opts.FollowSymlinks {
+			w.skipLink(path, resolvedTarget(path), SymlinkNotFollowed)
+			return nil
+		}
+
+		info := e.target
+		if info == nil {
+			var err error
+			if info, err = e.Info(); err != nil {
+				return err
+			}
+		}
+		if w.opts.FollowSymlinks && !w.markSeen(path, info) {
+			if isLink {
+				w.skipLink(path, resolvedTarget(path), SymlinkLoop)
+			}
+			return nil
+		}
+		if isLink {
+			w.symlinks = append(w.symlinks, SymlinkEvent{Path: path, Target: resolvedTarget(path), Followed: true})
+		}
+		return w.walkDir(path, depth)
+	}
+
+	if !w.matchExt(path) {
+		return nil
+	}
+
+	info := e.target
+	if info == nil {
+		info = e.info
+	}
+	if info == nil && w.opts.needsInfo() {
+		var err error
+		if info, err = e.Info(); err != nil {
+			return err
+		}
+	}
+	if !w.opts.matchAttrs(path, info) {
+		return nil
+	}
+
+	// Files reachable through several links are returned once
+	if w.opts.FollowSymlinks {
+		if !w.markSeen(path, info) {
+			if isLink {
+				w.skipLink(path, resolvedTarget(path), SymlinkDuplicate)
+			}
+			return nil
+		}
+	}
+
+	return w.emit(path)
+}
+
+// enters applies the depth, recursion and hidden-file rules to an entry
+func (o FindFilesOptions) enters(path, name string, isDir bool, depth int) bool {
+	// Check max depth
+	if o.MaxDepth != -1 && depth > o.MaxDepth {
+		return false
+	}
+
+	// Skip directories if not recursive
+	if isDir && !o.Recursive {
+		return false
+	}
+
+	if o.SkipHidden && strings.HasPrefix(name, ".") {
+		return false
+	}
+	return true
+}
+
+// ignored applies .ebmignore rules and --ignore patterns
+func (w *walker) ignored(path string, isDir bool) bool {
+	return w.matcher.Ignored(path, isDir)
+}
+
+// matchExt reports whether path has one of the wanted extensions
+func (w *walker) matchExt(path string) bool {
+	return matchExtension(path, w.opts.Extensions)
+}
+
+// matchExtension reports whether path has one of extensions, given with or
+// without the dot in any case
+func matchExtension(path string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if len(extensions) == 0 {
+		// Default to EPUB and PDF if none specified
+		return ext == ".epub" || ext == ".pdf"
+	}
+	for _, targetExt := range extensions {
+		if !strings.HasPrefix(targetExt, ".") {
+			targetExt = "." + targetExt
+		}
+		if ext == strings.ToLower(targetExt) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsInfo reports whether files must be stat'ed during the walk
+func (o FindFilesOptions) needsInfo() bool {
+	return o.FollowSymlinks || o.hasAttrFilters()
+}
+
+// hasAttrFilters reports whether any size or time filter is set
+func (o FindFilesOptions) hasAttrFilters() bool {
+	return o.MinSize > 0 || o.MaxSize > 0 || !o.NewerThan.IsZero() || !o.OlderThan.IsZero()
+}
+
+// matchAttrs applies the size and modification time filters. Files to
+// retry skip the time filters.
+func (o FindFilesOptions) matchAttrs(path string, info os.FileInfo) bool {
+	if !o.hasAttrFilters() {
+		return true
+	}
+	if o.MinSize > 0 && info.Size() < o.MinSize {
+		return false
+	}
+	if o.MaxSize > 0 && info.Size() > o.MaxSize {
+		return false
+	}
+	if len(o.Retry) > 0 {
+		if abs, err := filepath.Abs(path); err == nil && o.Retry[abs] {
+			return true
+		}
+	}
+	if !o.NewerThan.IsZero() && !info.ModTime().After(o.NewerThan) {
+		return false
+	}
+	if !o.OlderThan.IsZero() && !info.ModTime().Before(o.OlderThan) {
+		return false
+	}
+	return true
+}
+
+// markSeen records a file or directory, returning false if it was seen before
+func (w *walker) markSeen(path string, info os.FileInfo) bool {
+	if id, ok := fileIDOf(info); ok {
+		if w.seen[id] {
+			return false
+		}
+		w.seen[id] = true
+		return true
+	}
+
+	key := realPath(path)
+	if w.seenAt[key] {
+		return false
+	}
+	w.seenAt[key] = true
+	return true
+}
+
+func (w *walker) skipLink(path, target, reason string) {
+	w.symlinks = append(w.symlinks, SymlinkEvent{Path: path, Target: target, Reason: reason})
+}
+
+// resolvedTarget returns the final target of a link, or "" if it cannot be resolved
+func resolvedTarget(path string) string {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	return target
+}
+
+// listing is a directory read that may still be in progress
+type listing struct {
+	entries []dirEntry
+	err     error
+	done    chan struct{}
+}
+
+// prefetcher reads directories ahead of the walk with bounded parallelism.
+// Prefetching is best effort: when too many listings are pending, the walk
+// reads the directory itself when it gets there. Every pending listing
+// holds a slot in ahead until the walk takes or discards it.
+type prefetcher struct {
+	w       *walker
+	mu      sync.Mutex
+	pending map[string]*listing
+	ahead   chan struct{} // Listings held ahead of the walk
+	workers chan struct{} // Concurrent directory reads
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newPrefetcher(w *walker) *prefetcher {
+	n := w.opts.WalkWorkers
+	if n <= 0 {
+		n = DefaultWalkWorkers
+	}
+	return &prefetcher{
+		w:       w,
+		pending: make(map[string]*listing),
+		ahead:   make(chan struct{}, n*readAheadPerWorker),
+		workers: make(chan struct{}, n),
+		quit:    make(chan struct{}),
+	}
+}
+
+// prefetch starts reading dir in the background if it is likely to be walked
+func (p *prefetcher) prefetch(dir string, depth int) {
+	select {
+	case <-p.quit:
+		return
+	default:
+	}
+	if !p.w.opts.enters(dir, filepath.Base(dir), true, depth) || p.w.matcher.Ignored(dir, true) {
+		return
+	}
+
+	select {
+	case p.ahead <- struct{}{}:
+	default:
+		return
+	}
+
+	l := &listing{done: make(chan struct{})}
+	p.mu.Lock()
+	if _, ok := p.pending[dir]; ok {
+		p.mu.Unlock()
+		<-p.ahead
+		return
+	}
+	p.pending[dir] = l
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(l.done)
+
+		select {
+		case p.workers <- struct{}{}:
+		case <-p.quit:
+			l.err = fs.ErrClosed
+			return
+		}
+		l.entries, l.err = readDir(dir, p.w.opts.needsInfo())
+		<-p.workers
+
+		// Keep reading further down while the walk catches up
+		if l.err == nil {
+			for _, entry := range l.entries {
+				if entry.IsDir() {
+					p.prefetch(filepath.Join(dir, entry.Name()), depth+1)
+				}
+			}
+		}
+	}()
+}
+
+// take returns the entries of dir, waiting for a pending read or reading it now
+func (p *prefetcher) take(dir string, depth int) ([]dirEntry, error) {
+	p.mu.Lock()
+	l, ok := p.pending[dir]
+	delete(p.pending, dir)
+	p.mu.Unlock()
+
+	if !ok {
+		return readDir(dir, p.w.opts.needsInfo())
+	}
+
+	<-l.done
+	<-p.ahead
+	return l.entries, l.err
+}
+
+// discard drops a listing the walk will not take, with those read ahead
+// below it, which the walk will not take either
+func (p *prefetcher) discard(dir string) {
+	p.mu.Lock()
+	l, ok := p.pending[dir]
+	delete(p.pending, dir)
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	// The subdirectories of a listing are prefetched before it is done
+	<-l.done
+	<-p.ahead
+	for _, entry := range l.entries {
+		if entry.IsDir() {
+			p.discard(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+// stop abandons pending reads, for walks that end early
+func (p *prefetcher) stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// readDir lists dir sorted by name, resolving symbolic links and, when
+// needInfo is set, stat'ing every entry
+func readDir(dir string, needInfo bool) ([]dirEntry, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]dirEntry, len(des))
+	for i, de := range des {
+		e := dirEntry{DirEntry: de}
+		if e.isLink() {
+			e.target, e.targetErr = os.Stat(filepath.Join(dir, de.Name()))
+		} else if needInfo && !de.IsDir() {
+			if info, err := de.Info(); err == nil {
+				e.info = info
+			}
+		}
+		entries[i] = e
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
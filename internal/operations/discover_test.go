@@ -0,0 +1,298 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+}
+
+// symlinkTree builds a library with an author folder on another "disk", a
+// second link to it, a loop back to the root and a broken link
+func symlinkTree(t *testing.T) (string, string) {
+	t.Helper()
+	root := writeTree(t, map[string]string{
+		"local/a.epub": "",
+	})
+	disk := writeTree(t, map[string]string{
+		"author/b.epub":     "",
+		"author/sub/c.epub": "",
+	})
+
+	symlink(t, filepath.Join(disk, "author"), filepath.Join(root, "author"))
+	symlink(t, filepath.Join(disk, "author"), filepath.Join(root, "local", "same-author"))
+	symlink(t, root, filepath.Join(root, "local", "loop"))
+	symlink(t, filepath.Join(disk, "author", "b.epub"), filepath.Join(root, "b-link.epub"))
+	symlink(t, filepath.Join(root, "missing.epub"), filepath.Join(root, "broken.epub"))
+	return root, disk
+}
+
+func symlinkReasons(d *Discovery, root string) map[string]string {
+	reasons := make(map[string]string)
+	for _, event := range d.Symlinks {
+		rel, _ := filepath.Rel(root, event.Path)
+		reason := event.Reason
+		if event.Followed {
+			reason = "followed"
+		}
+		reasons[filepath.ToSlash(rel)] = reason
+	}
+	return reasons
+}
+
+func TestDiscover_FollowSymlinks(t *testing.T) {
+	root, _ := symlinkTree(t)
+
+	d, err := Discover(root, FindFilesOptions{Recursive: true, MaxDepth: -1, FollowSymlinks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := relFiles(t, root, d.Files)
+	want := []string{"author/b.epub", "author/sub/c.epub", "local/a.epub"}
+	if len(got) != len(want) {
+		t.Fatalf("Discover() files = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Discover() files[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+
+	reasons := symlinkReasons(d, root)
+	wantReasons := map[string]string{
+		"author":            "followed",
+		"b-link.epub":       SymlinkDuplicate,
+		"broken.epub":       SymlinkBroken,
+		"local/loop":        SymlinkLoop,
+		"local/same-author": SymlinkLoop,
+	}
+	for path, reason := range wantReasons {
+		if reasons[path] != reason {
+			t.Errorf("symlink %s = %q, want %q", path, reasons[path], reason)
+		}
+	}
+}
+
+func TestDiscover_NoFollow(t *testing.T) {
+	root, _ := symlinkTree(t)
+
+	d, err := Discover(root, FindFilesOptions{Recursive: true, MaxDepth: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Links to files are still returned; links to directories are reported
+	got := relFiles(t, root, d.Files)
+	if len(got) != 2 || got[0] != "b-link.epub" || got[1] != "local/a.epub" {
+		t.Errorf("Discover() files = %v", got)
+	}
+
+	reasons := symlinkReasons(d, root)
+	if reasons["author"] != SymlinkNotFollowed || reasons["local/loop"] != SymlinkNotFollowed {
+		t.Errorf("Expected directory links to be reported as not followed, got %v", reasons)
+	}
+}
+
+func TestDiscover_MissingRoot(t *testing.T) {
+	if _, err := Discover(filepath.Join(t.TempDir(), "missing"), FindFilesOptions{MaxDepth: -1}); err == nil {
+		t.Error("Expected error for missing root")
+	}
+}
+
+func TestDiscover_AttributeFilters(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"stub.epub":  "x",
+		"book.epub":  string(make([]byte, 2048)),
+		"scan.pdf":   string(make([]byte, 8192)),
+		"old.epub":   string(make([]byte, 2048)),
+		"notes.epub": string(make([]byte, 2048)),
+	})
+	now := time.Now()
+	old := now.AddDate(-1, 0, 0)
+	if err := os.Chtimes(filepath.Join(root, "old.epub"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts FindFilesOptions
+		want []string
+	}{
+		{"min size", FindFilesOptions{MinSize: 1024}, []string{"book.epub", "notes.epub", "old.epub", "scan.pdf"}},
+		{"max size", FindFilesOptions{MaxSize: 4096}, []string{"book.epub", "notes.epub", "old.epub", "stub.epub"}},
+		{"newer than", FindFilesOptions{MinSize: 1024, MaxSize: 4096, NewerThan: now.AddDate(0, -1, 0)}, []string{"book.epub", "notes.epub"}},
+		{"older than", FindFilesOptions{OlderThan: now.AddDate(0, -1, 0)}, []string{"old.epub"}},
+	}
+	for _, tt := range tests {
+		tt.opts.Recursive = true
+		tt.opts.MaxDepth = -1
+		d, err := Discover(root, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := relFiles(t, root, d.Files)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Discover() = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: Discover()[%d] = %s, want %s", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+// wideTree creates a tree several directories wide and deep with books,
+// other files, hidden entries and an ignore file
+func wideTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for a := 0; a < 6; a++ {
+		for b := 0; b < 6; b++ {
+			dir := filepath.Join(root, fmt.Sprintf("author%d", a), fmt.Sprintf("book%d", b))
+			if err := os.MkdirAll(filepath.Join(dir, "extras"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"book.epub", "book.pdf", "cover.jpg", ".hidden.epub", "extras/notes.epub"} {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("t"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "author2", IgnoreFileName), []byte("book1/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// walkDirFiles is the reference result: filepath.WalkDir with the same rules
+func walkDirFiles(t *testing.T, root string, maxDepth int, skipHidden bool) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		depth := len(strings.Split(rel, string(filepath.Separator)))
+		if path == root {
+			return nil
+		}
+		if d.IsDir() && (filepath.ToSlash(rel) == "author2/book1" || (maxDepth != -1 && depth > maxDepth)) {
+			return filepath.SkipDir
+		}
+		if skipHidden && strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if !d.IsDir() && (ext == ".epub" || ext == ".pdf") && (maxDepth == -1 || depth <= maxDepth) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestWalk_MatchesSequentialOrder(t *testing.T) {
+	root := wideTree(t)
+
+	tests := []struct {
+		name       string
+		maxDepth   int
+		skipHidden bool
+	}{
+		{"unlimited", -1, false},
+		{"depth 3", 3, false},
+		{"skip hidden", -1, true},
+	}
+
+	for _, tt := range tests {
+		want := walkDirFiles(t, root, tt.maxDepth, tt.skipHidden)
+		for _, workers := range []int{1, 4, 32} {
+			t.Run(fmt.Sprintf("%s/%d workers", tt.name, workers), func(t *testing.T) {
+				var got []string
+				_, err := Walk(root, FindFilesOptions{Recursive: true, MaxDepth: tt.maxDepth, SkipHidden: tt.skipHidden, WalkWorkers: workers}, func(path string) error {
+					got = append(got, path)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Walk() found %d files, want %d in WalkDir order\ngot:  %v\nwant: %v", len(got), len(want), got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestWalk_StopsOnCallbackError(t *testing.T) {
+	root := wideTree(t)
+	stop := errors.New("stop")
+
+	count := 0
+	_, err := Walk(root, FindFilesOptions{Recursive: true, MaxDepth: -1}, func(string) error {
+		count++
+		if count == 3 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || count != 3 {
+		t.Errorf("Walk() = %v after %d files, want stop after 3", err, count)
+	}
+}
+
+func TestPrefetcher_DiscardReleasesReadAhead(t *testing.T) {
+	root := wideTree(t)
+	matcher, err := NewIgnoreMatcher(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &walker{opts: FindFilesOptions{Recursive: true, MaxDepth: -1, WalkWorkers: 4}, matcher: matcher}
+	p := newPrefetcher(w)
+	defer p.stop()
+
+	// Wait for the read-ahead to reach the bottom of the tree
+	author := filepath.Join(root, "author0")
+	p.prefetch(author, 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		l, ok := p.pending[filepath.Join(author, "book5", "extras")]
+		p.mu.Unlock()
+		if ok {
+			<-l.done
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("read-ahead did not reach the bottom of the tree")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	p.discard(author)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) != 0 || len(p.ahead) != 0 {
+		t.Errorf("Expected every listing below a discarded one released, %d pending and %d slots held", len(p.pending), len(p.ahead))
+	}
+}
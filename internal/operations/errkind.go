@@ -0,0 +1,169 @@
+package operations
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// ErrUnsupportedType is returned for files that are neither EPUB nor PDF
+var ErrUnsupportedType = errors.New("unsupported file type")
+
+// ErrorKind classifies why a file could not be processed. The values are
+// stable codes written to reports; see docs/ERROR_CODES.md.
+type ErrorKind string
+
+// Error kinds, from the most to the least specific
+const (
+	ErrorKindPanic       ErrorKind = "panic"             // The library panicked
+	ErrorKindMemory      ErrorKind = "memory_limit"      // Child process killed for using too much memory
+	ErrorKindTimeout     ErrorKind = "timeout"           // Processing took longer than the timeout
+	ErrorKindCanceled    ErrorKind = "canceled"          // The batch was cancelled
+	ErrorKindPermission  ErrorKind = "permission_denied" // The file or its directory could not be accessed
+	ErrorKindNotFound    ErrorKind = "not_found"         // The file disappeared during the run
+	ErrorKindDiskFull    ErrorKind = "disk_full"         // No space or quota left for repairs and backups
+	ErrorKindUnsupported ErrorKind = "unsupported_type"  // Not an EPUB or PDF
+	ErrorKindCorrupt     ErrorKind = "corrupt"           // Truncated or unreadable file structure
+	ErrorKindIO          ErrorKind = "io"                // Transient I/O failure, such as a network mount error
+	ErrorKindUnknown     ErrorKind = "unknown"           // Anything else
+)
+
+// ErrorKinds lists every error kind in documentation order
+func ErrorKinds() []ErrorKind {
+	return []ErrorKind{
+		ErrorKindPanic, ErrorKindMemory, ErrorKindTimeout, ErrorKindCanceled,
+		ErrorKindPermission, ErrorKindNotFound, ErrorKindDiskFull,
+		ErrorKindUnsupported, ErrorKindCorrupt, ErrorKindIO, ErrorKindUnknown,
+	}
+}
+
+// DefaultRemovableKinds are the kinds --remove-system-errors deletes by
+// default: files that will fail the same way on every run
+var DefaultRemovableKinds = []ErrorKind{ErrorKindCorrupt}
+
+// ParseErrorKind returns the kind named s
+func ParseErrorKind(s string) (ErrorKind, bool) {
+	for _, kind := range ErrorKinds() {
+		if string(kind) == s {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// Transient reports whether an error of this kind may succeed when retried
+func (k ErrorKind) Transient() bool {
+	return k == ErrorKindIO
+}
+
+// corruptMessages identify corrupt files in library errors that do not wrap
+// a standard error value
+var corruptMessages = []string{
+	"not a valid zip file",
+	"unexpected eof",
+	"truncated",
+	"corrupt",
+	"malformed",
+}
+
+// ClassifyError returns the kind of err, or "" for nil
+func ClassifyError(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var panicErr *PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		return ErrorKindPanic
+	case errors.Is(err, ErrMemoryLimit):
+		return ErrorKindMemory
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrorKindTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCanceled
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorKindPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorKindNotFound
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return ErrorKindDiskFull
+	case errors.Is(err, ErrUnsupportedType):
+		return ErrorKindUnsupported
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, zip.ErrFormat), errors.Is(err, zip.ErrChecksum), errors.Is(err, zip.ErrAlgorithm):
+		return ErrorKindCorrupt
+	case errors.Is(err, syscall.EIO), errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR),
+		errors.Is(err, syscall.EBUSY), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ETIMEDOUT):
+		return ErrorKindIO
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range corruptMessages {
+		if strings.Contains(msg, s) {
+			return ErrorKindCorrupt
+		}
+	}
+	return ErrorKindUnknown
+}
+
+// KindStat counts the errored results of one kind
+type KindStat struct {
+	Kind  ErrorKind
+	Count int
+}
+
+// ComputeKindStats counts errored results by kind, most common first
+func ComputeKindStats(results []Result) []KindStat {
+	counts := make(map[ErrorKind]int)
+	for _, r := range results {
+		if r.Error != nil {
+			counts[r.errorKind()]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	stats := make([]KindStat, 0, len(counts))
+	for kind, n := range counts {
+		stats = append(stats, KindStat{Kind: kind, Count: n})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Kind < stats[j].Kind
+	})
+	return stats
+}
+
+// FilterByKind returns the errored results whose kind is one of kinds
+func FilterByKind(results []Result, kinds []ErrorKind) []Result {
+	var out []Result
+	for _, r := range results {
+		if r.Error == nil {
+			continue
+		}
+		for _, kind := range kinds {
+			if r.errorKind() == kind {
+				out = append(out, r)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// errorKind returns the result's kind, classifying its error if unset
+func (r Result) errorKind() ErrorKind {
+	if r.ErrorKind != "" {
+		return r.ErrorKind
+	}
+	return ClassifyError(r.Error)
+}
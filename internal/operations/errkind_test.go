@@ -0,0 +1,139 @@
+package operations
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorKind
+	}{
+		{nil, ""},
+		{&PanicError{Value: "boom"}, ErrorKindPanic},
+		{fmt.Errorf("child process killed: %w", ErrMemoryLimit), ErrorKindMemory},
+		{fmt.Errorf("child process killed: %w", ErrTimeout), ErrorKindTimeout},
+		{fmt.Errorf("validate: %w", context.DeadlineExceeded), ErrorKindTimeout},
+		{context.Canceled, ErrorKindCanceled},
+		{&fs.PathError{Op: "open", Path: "a.epub", Err: syscall.EACCES}, ErrorKindPermission},
+		{&fs.PathError{Op: "open", Path: "a.epub", Err: syscall.ENOENT}, ErrorKindNotFound},
+		{&fs.PathError{Op: "write", Path: "a.epub", Err: syscall.ENOSPC}, ErrorKindDiskFull},
+		{fmt.Errorf("%w: .txt", ErrUnsupportedType), ErrorKindUnsupported},
+		{fmt.Errorf("open: %w", zip.ErrFormat), ErrorKindCorrupt},
+		{errors.New("mimetype entry truncated"), ErrorKindCorrupt},
+		{&fs.PathError{Op: "read", Path: "a.epub", Err: syscall.EIO}, ErrorKindIO},
+		{errors.New("something else"), ErrorKindUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrorKind(t *testing.T) {
+	for _, kind := range ErrorKinds() {
+		if got, ok := ParseErrorKind(string(kind)); !ok || got != kind {
+			t.Errorf("ParseErrorKind(%q) = %q, %v", kind, got, ok)
+		}
+	}
+	if _, ok := ParseErrorKind("gremlins"); ok {
+		t.Error("Expected unknown kind to be rejected")
+	}
+}
+
+func TestComputeKindStats(t *testing.T) {
+	results := []Result{
+		{FilePath: "a", Error: errors.New("x"), ErrorKind: ErrorKindTimeout},
+		{FilePath: "b", Error: errors.New("x"), ErrorKind: ErrorKindIO},
+		{FilePath: "c", Error: errors.New("x"), ErrorKind: ErrorKindTimeout},
+		{FilePath: "d", Error: errors.New("x")}, // Classified on demand
+		{FilePath: "e"},
+	}
+
+	stats := ComputeKindStats(results)
+	want := []KindStat{{ErrorKindTimeout, 2}, {ErrorKindIO, 1}, {ErrorKindUnknown, 1}}
+	if len(stats) != len(want) {
+		t.Fatalf("ComputeKindStats() = %v, want %v", stats, want)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("stat %d = %v, want %v", i, stats[i], want[i])
+		}
+	}
+
+	removable := FilterByKind(results, []ErrorKind{ErrorKindIO, ErrorKindUnknown})
+	if len(removable) != 2 || removable[0].FilePath != "b" || removable[1].FilePath != "d" {
+		t.Errorf("FilterByKind() = %v", removable)
+	}
+	if ComputeKindStats(results[4:]) != nil {
+		t.Error("Expected nil stats without errors")
+	}
+}
+
+func TestProcessTask_RetriesTransientErrors(t *testing.T) {
+	attempts := map[string]int{}
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		attempts[task.FilePath]++
+		result := Result{FilePath: task.FilePath}
+		switch task.FilePath {
+		case "flaky.epub":
+			if attempts[task.FilePath] < 2 {
+				result.Error = &fs.PathError{Op: "read", Path: task.FilePath, Err: syscall.EIO}
+			}
+		case "denied.epub":
+			result.Error = &fs.PathError{Op: "open", Path: task.FilePath, Err: syscall.EACCES}
+		case "nfs.epub":
+			result.Error = &fs.PathError{Op: "read", Path: task.FilePath, Err: syscall.EIO}
+		}
+		return result
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 1
+	config.Retries = 2
+	config.RetryBackoff = time.Millisecond
+	bp := NewBatchProcessor(context.Background(), config)
+	results := bp.Execute([]string{"flaky.epub", "denied.epub", "nfs.epub"}, OperationValidate)
+
+	if results[0].Error != nil || attempts["flaky.epub"] != 2 {
+		t.Errorf("Expected flaky.epub to succeed on retry, got %v after %d attempts", results[0].Error, attempts["flaky.epub"])
+	}
+	if results[1].ErrorKind != ErrorKindPermission || attempts["denied.epub"] != 1 {
+		t.Errorf("Expected no retry for permission errors, got %q after %d attempts", results[1].ErrorKind, attempts["denied.epub"])
+	}
+	if results[2].ErrorKind != ErrorKindIO || attempts["nfs.epub"] != 3 {
+		t.Errorf("Expected 3 attempts for a persistent I/O error, got %q after %d attempts", results[2].ErrorKind, attempts["nfs.epub"])
+	}
+	for i, want := range []int{2, 1, 3} {
+		if results[i].Attempts != want {
+			t.Errorf("%s: Attempts = %d, want %d", results[i].FilePath, results[i].Attempts, want)
+		}
+	}
+}
+
+func TestProcessTask_DeadlineIsTimeout(t *testing.T) {
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		<-ctx.Done()
+		return Result{FilePath: task.FilePath, Error: errors.New("parser gave up")}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.Timeout = 10 * time.Millisecond
+	bp := NewBatchProcessor(context.Background(), config)
+	results := bp.Execute([]string{"slow.pdf"}, OperationValidate)
+
+	if len(results) != 1 || results[0].ErrorKind != ErrorKindTimeout {
+		t.Errorf("Expected a timeout, got %+v", results)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package operations
+
+import "os"
+
+// fileIDOf is unavailable without inode numbers; callers fall back to
+// comparing resolved paths
+func fileIDOf(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package operations
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIDOf returns the device and inode identifying info's file. The
+// conversions are needed because the field types differ between platforms.
+func fileIDOf(info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
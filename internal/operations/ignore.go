@@ -0,0 +1,282 @@
+package operations
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// IgnoreFileName is the per-directory ignore file honoured during discovery
+const IgnoreFileName = ".ebmignore"
+
+// ignoreRule is a single compiled gitignore-style pattern
+type ignoreRule struct {
+	base    string // Directory the rule is relative to, slash-separated and relative to the root ("" for the root)
+	negate  bool   // Pattern started with '!'
+	dirOnly bool   // Pattern ended with '/'
+	re      *regexp.Regexp
+}
+
+// IgnoreMatcher applies gitignore-style rules during a directory walk.
+// Rules from .ebmignore files are loaded with EnterDir as directories are
+// visited and only apply beneath the directory that contains them. Later
+// rules take precedence, so deeper files override their parents and a
+// '!' pattern re-includes a path excluded earlier. A matcher is safe for
+// concurrent use.
+type IgnoreMatcher struct {
+	root  string
+	mu    sync.RWMutex
+	rules []ignoreRule
+}
+
+// NewIgnoreMatcher creates a matcher for a walk rooted at root. patterns and
+// the contents of ignoreFiles are interpreted relative to root and take
+// lower precedence than .ebmignore files found in the tree.
+func NewIgnoreMatcher(root string, patterns []string, ignoreFiles []string) (*IgnoreMatcher, error) {
+	m := &IgnoreMatcher{root: filepath.Clean(root)}
+
+	for _, path := range ignoreFiles {
+		lines, err := readIgnoreFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ignore file: %w", err)
+		}
+		if err := m.addPatterns("", lines); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	if err := m.addPatterns("", m.underRoot(patterns)); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// underRoot rewrites --ignore patterns that start with the root, as given
+// or as an absolute path, as patterns anchored at the root. Earlier
+// versions matched patterns against the path of each file, root included.
+// Other patterns, including a leading '/' outside the root, keep their
+// gitignore meaning.
+func (m *IgnoreMatcher) underRoot(patterns []string) []string {
+	roots := []string{m.root}
+	if abs, err := filepath.Abs(m.root); err == nil && abs != m.root {
+		roots = append(roots, abs)
+	}
+	out := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		negate, body := "", pattern
+		if strings.HasPrefix(body, "!") {
+			negate, body = "!", body[1:]
+		}
+		for _, root := range roots {
+			if m.root == "." && !filepath.IsAbs(root) {
+				continue // Every relative pattern would start with it
+			}
+			prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+			if rest, ok := strings.CutPrefix(filepath.FromSlash(body), prefix); ok && rest != "" {
+				pattern = negate + "/" + filepath.ToSlash(rest)
+				break
+			}
+		}
+		out = append(out, pattern)
+	}
+	return out
+}
+
+// EnterDir loads dir's .ebmignore file, if any. dir must be inside the root.
+func (m *IgnoreMatcher) EnterDir(dir string) error {
+	path := filepath.Join(dir, IgnoreFileName)
+	lines, err := readIgnoreFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	base, err := m.rel(dir)
+	if err != nil {
+		return err
+	}
+	if err := m.addPatterns(base, lines); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Ignored reports whether path should be skipped. The root is never ignored.
+func (m *IgnoreMatcher) Ignored(path string, isDir bool) bool {
+	rel, err := m.rel(path)
+	if err != nil || rel == "" {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		target := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			target = rel[len(rule.base)+1:]
+		}
+
+		if rule.re.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// rel returns path relative to the root with forward slashes ("" for the root)
+func (m *IgnoreMatcher) rel(path string) (string, error) {
+	rel, err := filepath.Rel(m.root, filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (m *IgnoreMatcher) addPatterns(base string, lines []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, line := range lines {
+		rule, ok, err := compileIgnoreRule(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			rule.base = base
+			m.rules = append(m.rules, rule)
+		}
+	}
+	return nil
+}
+
+func readIgnoreFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// compileIgnoreRule parses one gitignore line. ok is false for blank lines and comments.
+func compileIgnoreRule(line string) (ignoreRule, bool, error) {
+	var rule ignoreRule
+
+	line = strings.TrimRight(line, " \t\r")
+	if strings.HasSuffix(line, "\\") {
+		// A trailing backslash escapes a space that was trimmed
+		line += " "
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false, nil
+	}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule, false, nil
+	}
+
+	// Patterns containing a slash are anchored to the ignore file's directory
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := "^" + globToRegexp(line) + "$"
+	if !anchored {
+		expr = "^(?:.*/)?" + globToRegexp(line) + "$"
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return rule, false, fmt.Errorf("invalid ignore pattern %q: %w", line, err)
+	}
+	rule.re = re
+
+	return rule, true, nil
+}
+
+// globToRegexp translates gitignore glob syntax to a regular expression body
+func globToRegexp(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				atStart := i == 0 || p[i-1] == '/'
+				next := i + 2
+				switch {
+				case atStart && next < len(p) && p[next] == '/':
+					// "**/" matches zero or more directories
+					b.WriteString("(?:.*/)?")
+					i = next
+				case atStart && next == len(p):
+					// Trailing "/**" matches everything inside
+					b.WriteString(".*")
+					i = next - 1
+				default:
+					// Other consecutive asterisks behave like a single '*'
+					b.WriteString("[^/]*")
+					i = next - 1
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(p[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := p[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(p) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(p[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,268 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestCompileIgnoreRule(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		{"*.pdf", "a.pdf", false, true},
+		{"*.pdf", "deep/dir/a.pdf", false, true},
+		{"drafts/", "drafts", true, true},
+		{"drafts/", "drafts", false, false},
+		{"drafts/", "x/drafts", true, true},
+		{"/drafts", "drafts", true, true},
+		{"/drafts", "x/drafts", true, false},
+		{"authors/*.epub", "authors/a.epub", false, true},
+		{"authors/*.epub", "authors/x/a.epub", false, false},
+		{"authors/*.epub", "x/authors/a.epub", false, false},
+		{"**/drafts/**", "drafts/a.epub", false, true},
+		{"**/drafts/**", "x/y/drafts/z/a.epub", false, true},
+		{"**/drafts/**", "drafts", true, false},
+		{"a/**/b.epub", "a/b.epub", false, true},
+		{"a/**/b.epub", "a/x/y/b.epub", false, true},
+		{"vol?.epub", "vol1.epub", false, true},
+		{"vol?.epub", "vol10.epub", false, false},
+		{"vol[0-9].epub", "vol3.epub", false, true},
+		{"vol[!0-9].epub", "vol3.epub", false, false},
+		{`\#notes.epub`, "#notes.epub", false, true},
+		{"a**b.epub", "axxb.epub", false, true},
+		{"a**b.epub", "a/b.epub", false, false},
+	}
+
+	for _, tt := range tests {
+		rule, ok, err := compileIgnoreRule(tt.pattern)
+		if err != nil || !ok {
+			t.Fatalf("compileIgnoreRule(%q) = ok %v, err %v", tt.pattern, ok, err)
+		}
+		got := rule.re.MatchString(tt.path) && (!rule.dirOnly || tt.isDir)
+		if got != tt.want {
+			t.Errorf("pattern %q on %q (dir=%v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestCompileIgnoreRule_SkipsCommentsAndBlanks(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "!"} {
+		if _, ok, _ := compileIgnoreRule(line); ok {
+			t.Errorf("compileIgnoreRule(%q) expected no rule", line)
+		}
+	}
+
+	rule, ok, _ := compileIgnoreRule("!keep.epub")
+	if !ok || !rule.negate {
+		t.Error("Expected negated rule")
+	}
+}
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func relFiles(t *testing.T, root string, files []string) []string {
+	t.Helper()
+	rels := make([]string, 0, len(files))
+	for _, f := range files {
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+func TestFindFiles_EbmIgnore(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		".ebmignore":                      "*.pdf\n**/drafts/**\n/archive/\n",
+		"a.epub":                          "",
+		"a.pdf":                           "",
+		"drafts/b.epub":                   "",
+		"x/drafts/c.epub":                 "",
+		"archive/d.epub":                  "",
+		"x/archive/e.epub":                "",
+		"series/.ebmignore":               "!keep.pdf\nvol*.epub\n!vol1.epub\n",
+		"series/keep.pdf":                 "",
+		"series/vol1.epub":                "",
+		"series/vol2.epub":                "",
+		"other/vol2.epub":                 "",
+		"other/nested/.ebmignore":         "/only-here.epub\n",
+		"other/nested/only-here.epub":     "",
+		"other/nested/sub/only-here.epub": "",
+	})
+
+	files, err := FindFiles(root, FindFilesOptions{Recursive: true, MaxDepth: -1})
+	if err != nil {
+		t.Fatalf("FindFiles() error = %v", err)
+	}
+
+	want := []string{
+		"a.epub",
+		"other/nested/sub/only-here.epub",
+		"other/vol2.epub",
+		"series/keep.pdf",
+		"series/vol1.epub",
+		"x/archive/e.epub",
+	}
+	got := relFiles(t, root, files)
+	if len(got) != len(want) {
+		t.Fatalf("FindFiles() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FindFiles()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindFiles_IgnoreFileAndDoubleStar(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.epub":            "",
+		"drafts/b.epub":     "",
+		"x/drafts/c.epub":   "",
+		"x/keep/d.epub":     "",
+		"series/.ebmignore": "!e.epub\n",
+		"series/e.epub":     "",
+	})
+	ignoreFile := filepath.Join(t.TempDir(), "rules")
+	if err := os.WriteFile(ignoreFile, []byte("# library rules\nseries/*.epub\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := FindFiles(root, FindFilesOptions{
+		Recursive:   true,
+		MaxDepth:    -1,
+		Ignore:      []string{"**/drafts/**"},
+		IgnoreFiles: []string{ignoreFile},
+	})
+	if err != nil {
+		t.Fatalf("FindFiles() error = %v", err)
+	}
+
+	// series/.ebmignore re-includes e.epub over the --ignore-file rule
+	got := relFiles(t, root, files)
+	want := []string{"a.epub", "series/e.epub", "x/keep/d.epub"}
+	if len(got) != len(want) {
+		t.Fatalf("FindFiles() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FindFiles()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindFiles_IgnoreNegation(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a.pdf":       "",
+		"keep.pdf":    "",
+		"sub/b.pdf":   "",
+		"sub/c.epub":  "",
+		"!keep.epub":  "",
+		"other/d.pdf": "",
+	})
+
+	files, err := FindFiles(root, FindFilesOptions{
+		Recursive:  true,
+		MaxDepth:   -1,
+		Extensions: []string{".epub", ".pdf"},
+		Ignore:     []string{"*.pdf", "!keep.pdf", "sub/"},
+	})
+	if err != nil {
+		t.Fatalf("FindFiles() error = %v", err)
+	}
+
+	// A later --ignore pattern re-includes what an earlier one excluded, and
+	// a leading ! is never matched literally
+	got := relFiles(t, root, files)
+	want := []string{"!keep.epub", "keep.pdf"}
+	if len(got) != len(want) {
+		t.Fatalf("FindFiles() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FindFiles()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindFiles_IgnoreAbsolutePath(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"drafts/a.pdf": "",
+		"keep/b.pdf":   "",
+		"c.pdf":        "",
+		"sub/d.epub":   "",
+	})
+
+	// Patterns starting with the root still work as they did when patterns
+	// were matched against file paths; a leading / is otherwise anchored
+	files, err := FindFiles(root, FindFilesOptions{
+		Recursive:  true,
+		MaxDepth:   -1,
+		Extensions: []string{".epub", ".pdf"},
+		Ignore:     []string{filepath.Join(root, "drafts"), filepath.Join(root, "*.pdf"), "/sub/"},
+	})
+	if err != nil {
+		t.Fatalf("FindFiles() error = %v", err)
+	}
+	got := relFiles(t, root, files)
+	if len(got) != 1 || got[0] != filepath.Join("keep", "b.pdf") {
+		t.Errorf("FindFiles() = %v, want [keep/b.pdf]", got)
+	}
+
+	// So do patterns starting with the root as given
+	t.Chdir(filepath.Dir(root))
+	name := filepath.Base(root)
+	files, err = FindFiles(name, FindFilesOptions{
+		Recursive:  true,
+		MaxDepth:   -1,
+		Extensions: []string{".epub", ".pdf"},
+		Ignore:     []string{name + "/drafts/*", name + "/keep/", "c.pdf"},
+	})
+	if err != nil {
+		t.Fatalf("FindFiles() error = %v", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(name, "sub", "d.epub") {
+		t.Errorf("FindFiles() = %v, want [%s]", files, filepath.Join(name, "sub", "d.epub"))
+	}
+}
+
+func TestNewIgnoreMatcher_MissingIgnoreFile(t *testing.T) {
+	if _, err := NewIgnoreMatcher(t.TempDir(), nil, []string{filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("Expected error for missing --ignore-file")
+	}
+}
+
+func TestIgnoreMatcher_RootNeverIgnored(t *testing.T) {
+	root := t.TempDir()
+	m, err := NewIgnoreMatcher(root, []string{"*"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Ignored(root, true) {
+		t.Error("Expected root not to be ignored")
+	}
+	if !m.Ignored(filepath.Join(root, "a.epub"), false) {
+		t.Error("Expected a.epub to be ignored")
+	}
+}
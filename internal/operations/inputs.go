@@ -0,0 +1,190 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Input is a file to process together with the command-line argument it was
+// found under. Root is the argument itself for files named directly.
+type Input struct {
+	Path string
+	Root string
+}
+
+// RootStat counts the results found under one command-line root
+type RootStat struct {
+	Root    string
+	Total   int
+	Valid   int
+	Invalid int
+	Errored int
+}
+
+// ResolveInputs expands command-line arguments into the files to process.
+// Arguments may be files, directories (searched with opts) or glob patterns
+// the shell did not expand. Files reached more than once, including through
+// symlinks, are only returned for the first argument that reaches them.
+// The symbolic links met while searching directories are returned as well.
+func ResolveInputs(args []string, opts FindFilesOptions) ([]Input, []SymlinkEvent, error) {
+	var inputs []Input
+	symlinks, err := StreamInputs(args, opts, func(in Input) error {
+		inputs = append(inputs, in)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return inputs, symlinks, nil
+}
+
+// StreamInputs is like ResolveInputs but calls fn for each input as soon as
+// it is found. Arguments are checked before any directory is searched, so a
+// bad argument fails before fn is called. An error from fn stops the search.
+func StreamInputs(args []string, opts FindFilesOptions, fn func(Input) error) ([]SymlinkEvent, error) {
+	var symlinks []SymlinkEvent
+	seen := make(map[string]bool)
+
+	add := func(path, root string) error {
+		key := realPath(path)
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+		return fn(Input{Path: path, Root: root})
+	}
+
+	paths, err := ExpandArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot access %s: %w", path, err)
+		}
+		root := filepath.Clean(path)
+		if !info.IsDir() {
+			if err := add(root, root); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var walkErr error
+		found, err := Walk(path, opts, func(f string) error {
+			walkErr = add(f, root)
+			return walkErr
+		})
+		if walkErr != nil {
+			return nil, walkErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find files in %s: %w", path, err)
+		}
+		symlinks = append(symlinks, found...)
+	}
+
+	return symlinks, nil
+}
+
+// ExpandArgs checks command-line arguments, expanding glob patterns the
+// shell did not expand. The result is a list of existing paths.
+func ExpandArgs(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		expanded, err := expandArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, expanded...)
+	}
+	return paths, nil
+}
+
+// expandArg checks a command-line argument, expanding it if it is a glob
+// pattern the shell did not expand
+func expandArg(arg string) ([]string, error) {
+	_, err := os.Stat(arg)
+	if err == nil {
+		return []string{arg}, nil
+	}
+	if !os.IsNotExist(err) || !HasGlobMeta(arg) {
+		return nil, fmt.Errorf("cannot access %s: %w", arg, err)
+	}
+	matches, err := filepath.Glob(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", arg, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match %s", arg)
+	}
+	return matches, nil
+}
+
+// InputPaths returns the file paths of inputs
+func InputPaths(inputs []Input) []string {
+	paths := make([]string, len(inputs))
+	for i, in := range inputs {
+		paths[i] = in.Path
+	}
+	return paths
+}
+
+// ComputeRootStats groups results by Root, sorted by root. It returns nil
+// when results were not resolved from command-line roots.
+func ComputeRootStats(results []Result) []RootStat {
+	byRoot := make(map[string]*RootStat)
+	for _, r := range results {
+		if r.Root == "" {
+			continue
+		}
+		stat, ok := byRoot[r.Root]
+		if !ok {
+			stat = &RootStat{Root: r.Root}
+			byRoot[r.Root] = stat
+		}
+		stat.Total++
+		switch {
+		case r.Error != nil:
+			stat.Errored++
+		case r.Report != nil && !r.Report.IsValid:
+			stat.Invalid++
+		case r.Report == nil && r.Repair != nil && !r.Repair.Success:
+			stat.Invalid++
+		default:
+			stat.Valid++
+		}
+	}
+
+	if len(byRoot) == 0 {
+		return nil
+	}
+
+	stats := make([]RootStat, 0, len(byRoot))
+	for _, stat := range byRoot {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Root < stats[j].Root })
+	return stats
+}
+
+// realPath resolves symlinks so that the same file is only processed once
+func realPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return path
+}
+
+// HasGlobMeta reports whether path contains glob pattern characters
+func HasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
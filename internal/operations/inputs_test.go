@@ -0,0 +1,98 @@
+package operations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func TestResolveInputs(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"lib1/a.epub":     "",
+		"lib1/sub/b.pdf":  "",
+		"lib2/c.epub":     "",
+		"lib2/notes.txt":  "",
+		"extra/d.epub":    "",
+		"extra/e.epub":    "",
+		"extra/other.txt": "",
+	})
+	if err := os.Symlink(filepath.Join(root, "lib2", "c.epub"), filepath.Join(root, "extra", "link.epub")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	lib1 := filepath.Join(root, "lib1")
+	args := []string{
+		lib1,
+		filepath.Join(root, "lib2"),
+		filepath.Join(root, "extra", "d.epub"),
+		filepath.Join(root, "extra", "*.epub"),
+		filepath.Join(lib1, "a.epub"),
+	}
+	inputs, _, err := ResolveInputs(args, FindFilesOptions{Recursive: true, MaxDepth: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Input{
+		{Path: filepath.Join(lib1, "a.epub"), Root: lib1},
+		{Path: filepath.Join(lib1, "sub", "b.pdf"), Root: lib1},
+		{Path: filepath.Join(root, "lib2", "c.epub"), Root: filepath.Join(root, "lib2")},
+		{Path: filepath.Join(root, "extra", "d.epub"), Root: filepath.Join(root, "extra", "d.epub")},
+		{Path: filepath.Join(root, "extra", "e.epub"), Root: filepath.Join(root, "extra", "e.epub")},
+	}
+	if len(inputs) != len(want) {
+		t.Fatalf("ResolveInputs() = %+v, want %+v", inputs, want)
+	}
+	for i := range want {
+		if inputs[i] != want[i] {
+			t.Errorf("ResolveInputs()[%d] = %+v, want %+v", i, inputs[i], want[i])
+		}
+	}
+
+	if paths := InputPaths(inputs); len(paths) != len(want) || paths[0] != want[0].Path {
+		t.Errorf("InputPaths() = %v", paths)
+	}
+}
+
+func TestResolveInputs_Errors(t *testing.T) {
+	dir := t.TempDir()
+	opts := FindFilesOptions{Recursive: true, MaxDepth: -1}
+
+	if _, _, err := ResolveInputs([]string{filepath.Join(dir, "missing.epub")}, opts); err == nil {
+		t.Error("Expected error for missing path")
+	}
+	if _, _, err := ResolveInputs([]string{filepath.Join(dir, "*.epub")}, opts); err == nil {
+		t.Error("Expected error for pattern without matches")
+	}
+}
+
+func TestComputeRootStats(t *testing.T) {
+	results := []Result{
+		{FilePath: "b/1.epub", Root: "b", Report: &ebmlib.ValidationReport{IsValid: true}},
+		{FilePath: "a/1.epub", Root: "a", Report: &ebmlib.ValidationReport{IsValid: false}},
+		{FilePath: "a/2.epub", Root: "a", Error: errors.New("unreadable")},
+		{FilePath: "a/3.epub", Root: "a", Repair: &ebmlib.RepairResult{Success: false}},
+		{FilePath: "a/4.epub", Root: "a", Repair: &ebmlib.RepairResult{Success: true}},
+	}
+
+	stats := ComputeRootStats(results)
+	want := []RootStat{
+		{Root: "a", Total: 4, Valid: 1, Invalid: 2, Errored: 1},
+		{Root: "b", Total: 1, Valid: 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("ComputeRootStats() = %+v", stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("ComputeRootStats()[%d] = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+
+	if ComputeRootStats([]Result{{FilePath: "x.epub"}}) != nil {
+		t.Error("Expected no stats for results without roots")
+	}
+}
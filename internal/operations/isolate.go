@@ -0,0 +1,145 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Errors for child processes stopped in isolated mode
+var (
+	ErrTimeout     = errors.New("timed out")
+	ErrMemoryLimit = errors.New("memory limit exceeded")
+)
+
+// memoryPollInterval is how often a child's memory use is checked
+const memoryPollInterval = 50 * time.Millisecond
+
+// IsolateConfig runs each task in a child process, so that a crash or hang
+// in the library only affects the file being processed
+type IsolateConfig struct {
+	// Command and arguments that run a task; the file path is appended. The
+	// child must write the Result as JSON to stdout, as RunTask's result
+	// encodes.
+	Command []string
+	// MemoryLimit kills a child whose resident memory exceeds this many
+	// bytes; 0 disables the limit. It is only enforced where memory use can
+	// be measured (Linux).
+	MemoryLimit int64
+}
+
+// PanicError reports a panic recovered while processing a file
+type PanicError struct {
+	Value string // The value passed to panic
+	Stack string // Goroutine stack at the time of the panic
+}
+
+func (e *PanicError) Error() string {
+	return "panic: " + e.Value
+}
+
+func newPanicError(v any) *PanicError {
+	return &PanicError{Value: fmt.Sprint(v), Stack: string(debug.Stack())}
+}
+
+// runIsolated runs task in a child process. The child is killed when ctx is
+// done or it uses too much memory.
+func runIsolated(ctx context.Context, config IsolateConfig, task Task) Result {
+	result := Result{FilePath: task.FilePath, Root: task.Root}
+	if len(config.Command) == 0 {
+		result.Error = fmt.Errorf("no command configured for isolated mode")
+		return result
+	}
+
+	args := append(append([]string{}, config.Command[1:]...), task.FilePath)
+	cmd := exec.CommandContext(ctx, config.Command[0], args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		result.Error = fmt.Errorf("failed to start child process: %w", err)
+		return result
+	}
+
+	overLimit := make(chan int64, 1)
+	stop := make(chan struct{})
+	if config.MemoryLimit > 0 {
+		go watchMemory(cmd, config.MemoryLimit, overLimit, stop)
+	}
+	waitErr := cmd.Wait()
+	close(stop)
+
+	select {
+	case used := <-overLimit:
+		result.Error = fmt.Errorf("child process killed using %d MB: %w", used>>20, ErrMemoryLimit)
+		return result
+	default:
+	}
+	if ctx.Err() != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Error = fmt.Errorf("child process killed: %w", ErrTimeout)
+		} else {
+			result.Error = fmt.Errorf("child process killed: %w", ctx.Err())
+		}
+		return result
+	}
+
+	// The child exits non-zero for invalid files, so its output decides
+	var child Result
+	if err := json.Unmarshal(stdout.Bytes(), &child); err == nil && child.FilePath != "" {
+		child.FilePath = task.FilePath
+		child.Root = task.Root
+		return child
+	}
+
+	result.Error = childError(waitErr, stderr.String())
+	return result
+}
+
+// watchMemory kills cmd's process if its memory use goes over limit
+func watchMemory(cmd *exec.Cmd, limit int64, overLimit chan<- int64, stop <-chan struct{}) {
+	ticker := time.NewTicker(memoryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			used, ok := processMemory(cmd.Process.Pid)
+			if ok && used > limit {
+				overLimit <- used
+				_ = cmd.Process.Kill()
+				return
+			}
+		}
+	}
+}
+
+// childError describes a child that exited without a result. A Go panic or
+// fatal error in the child is reported as a PanicError with its trace.
+func childError(waitErr error, stderr string) error {
+	stderr = strings.TrimSpace(stderr)
+	first, _, _ := strings.Cut(stderr, "\n")
+
+	for _, prefix := range []string{"panic: ", "fatal error: "} {
+		if strings.HasPrefix(first, prefix) {
+			return &PanicError{Value: strings.TrimPrefix(first, prefix), Stack: stderr}
+		}
+	}
+
+	if waitErr == nil {
+		waitErr = errors.New("no result")
+	}
+	if first != "" {
+		return fmt.Errorf("child process failed (%v): %s", waitErr, first)
+	}
+	return fmt.Errorf("child process failed: %w", waitErr)
+}
@@ -0,0 +1,163 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestIsolatedHelper is the child process for the isolated mode tests. It
+// only runs when started by helperConfig.
+func TestIsolatedHelper(t *testing.T) {
+	if os.Getenv("EBM_ISOLATED_HELPER") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	mode, file := args[1], args[2]
+
+	switch mode {
+	case "ok":
+		_ = json.NewEncoder(os.Stdout).Encode(Result{FilePath: file, Error: errors.New("checked")})
+	case "hang":
+		time.Sleep(time.Minute)
+	case "memory":
+		hog := make([][]byte, 0)
+		for i := 0; i < 64; i++ {
+			chunk := make([]byte, 16<<20)
+			for j := range chunk {
+				chunk[j] = 1
+			}
+			hog = append(hog, chunk)
+			time.Sleep(10 * time.Millisecond)
+		}
+		fmt.Println(len(hog))
+	case "crash":
+		panic("corrupt spine")
+	case "fail":
+		fmt.Fprintln(os.Stderr, "cannot open book")
+		os.Exit(3)
+	}
+	os.Exit(0)
+}
+
+func helperConfig(t *testing.T, mode string) *IsolateConfig {
+	t.Helper()
+	t.Setenv("EBM_ISOLATED_HELPER", "1")
+	return &IsolateConfig{Command: []string{os.Args[0], "-test.run=^TestIsolatedHelper$", "--", mode}}
+}
+
+func TestProcessTask_RecoversPanic(t *testing.T) {
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		if strings.Contains(task.FilePath, "bad") {
+			panic("malformed book")
+		}
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 2
+	bp := NewBatchProcessor(context.Background(), config)
+	results := bp.Execute([]string{"a.epub", "bad.epub", "c.epub"}, OperationValidate)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected all 3 results after a panic, got %d", len(results))
+	}
+	var panicErr *PanicError
+	if !errors.As(results[1].Error, &panicErr) || panicErr.Value != "malformed book" {
+		t.Fatalf("Expected a PanicError for bad.epub, got %v", results[1].Error)
+	}
+	if !strings.Contains(panicErr.Stack, "goroutine") || results[1].FilePath != "bad.epub" {
+		t.Errorf("Expected stack and file path, got %+v", results[1])
+	}
+	if results[0].Error != nil || results[2].Error != nil {
+		t.Errorf("Expected other files unaffected, got %v and %v", results[0].Error, results[2].Error)
+	}
+
+	br := AggregateResults(results, 0, OperationValidate)
+	if len(br.Errored) != 1 {
+		t.Errorf("Expected the panicking file to be errored, got %d", len(br.Errored))
+	}
+}
+
+func TestRunTask_RecoversPanic(t *testing.T) {
+	taskRunner = func(context.Context, BatchConfig, Task) Result { panic(errors.New("boom")) }
+	defer func() { taskRunner = runOperation }()
+
+	result := RunTask(context.Background(), BatchConfig{}, Task{FilePath: "x.epub", Root: "lib"})
+	var panicErr *PanicError
+	if !errors.As(result.Error, &panicErr) || result.FilePath != "x.epub" || result.Root != "lib" {
+		t.Errorf("RunTask() = %+v", result)
+	}
+}
+
+func TestRunIsolated(t *testing.T) {
+	result := runIsolated(context.Background(), *helperConfig(t, "ok"), Task{FilePath: "book.epub", Root: "lib"})
+	if result.FilePath != "book.epub" || result.Root != "lib" || result.Error == nil || result.Error.Error() != "checked" {
+		t.Errorf("runIsolated() = %+v", result)
+	}
+}
+
+func TestRunIsolated_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := runIsolated(ctx, *helperConfig(t, "hang"), Task{FilePath: "book.epub"})
+	if !errors.Is(result.Error, ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", result.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Child was not killed promptly (%s)", elapsed)
+	}
+}
+
+func TestRunIsolated_MemoryLimit(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory limits are only enforced on Linux")
+	}
+	config := helperConfig(t, "memory")
+	config.MemoryLimit = 128 << 20
+
+	result := runIsolated(context.Background(), *config, Task{FilePath: "book.epub"})
+	if !errors.Is(result.Error, ErrMemoryLimit) {
+		t.Errorf("Expected ErrMemoryLimit, got %v", result.Error)
+	}
+}
+
+func TestRunIsolated_ChildCrash(t *testing.T) {
+	result := runIsolated(context.Background(), *helperConfig(t, "crash"), Task{FilePath: "book.epub"})
+	var panicErr *PanicError
+	if !errors.As(result.Error, &panicErr) || !strings.HasPrefix(panicErr.Value, "corrupt spine") || !strings.Contains(panicErr.Stack, "goroutine") {
+		t.Errorf("Expected the child's panic, got %#v", result.Error)
+	}
+
+	result = runIsolated(context.Background(), *helperConfig(t, "fail"), Task{FilePath: "book.epub"})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "cannot open book") {
+		t.Errorf("Expected the child's stderr in the error, got %v", result.Error)
+	}
+}
+
+func TestResultJSON_PanicError(t *testing.T) {
+	data, err := json.Marshal(Result{FilePath: "a.epub", Error: &PanicError{Value: "boom", Stack: "goroutine 1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	var panicErr *PanicError
+	if !errors.As(r.Error, &panicErr) || panicErr.Value != "boom" || panicErr.Stack != "goroutine 1" {
+		t.Errorf("Round trip lost the panic, got %#v", r.Error)
+	}
+}
@@ -0,0 +1,80 @@
+package operations
+
+import (
+	"sort"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+// MaxIssueExamples is the number of example files kept per IssueStat
+const MaxIssueExamples = 3
+
+// IssueStat aggregates every occurrence of one issue code across a batch
+type IssueStat struct {
+	Code        string
+	Severity    ebmlib.Severity
+	Message     string   // Message of the first occurrence
+	Occurrences int      // Total number of times the code was reported
+	Files       int      // Number of files reporting the code at least once
+	Examples    []string // Up to MaxIssueExamples affected file paths
+}
+
+// ComputeIssueStats ranks issue codes across the validation reports in
+// results, most occurrences first. Ties are broken by affected files and
+// then by code so the order is stable.
+func ComputeIssueStats(results []Result) []IssueStat {
+	index := make(map[string]int)
+	var stats []IssueStat
+
+	for _, r := range results {
+		if r.Report == nil {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		groups := []struct {
+			issues   []ebmlib.ValidationError
+			severity ebmlib.Severity
+		}{
+			{r.Report.Errors, ebmlib.SeverityError},
+			{r.Report.Warnings, ebmlib.SeverityWarning},
+			{r.Report.Info, ebmlib.SeverityInfo},
+		}
+
+		for _, group := range groups {
+			for _, issue := range group.issues {
+				i, ok := index[issue.Code]
+				if !ok {
+					i = len(stats)
+					index[issue.Code] = i
+					stats = append(stats, IssueStat{
+						Code:     issue.Code,
+						Severity: group.severity,
+						Message:  issue.Message,
+					})
+				}
+
+				stats[i].Occurrences++
+				if !seen[issue.Code] {
+					seen[issue.Code] = true
+					stats[i].Files++
+					if len(stats[i].Examples) < MaxIssueExamples {
+						stats[i].Examples = append(stats[i].Examples, r.FilePath)
+					}
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Occurrences != stats[j].Occurrences {
+			return stats[i].Occurrences > stats[j].Occurrences
+		}
+		if stats[i].Files != stats[j].Files {
+			return stats[i].Files > stats[j].Files
+		}
+		return stats[i].Code < stats[j].Code
+	})
+
+	return stats
+}
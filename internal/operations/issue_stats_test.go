@@ -0,0 +1,80 @@
+package operations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func TestComputeIssueStats(t *testing.T) {
+	results := []Result{
+		{FilePath: "a.epub", Report: &ebmlib.ValidationReport{
+			Errors:   []ebmlib.ValidationError{{Code: "E1", Message: "first"}, {Code: "E1"}, {Code: "E2"}},
+			Warnings: []ebmlib.ValidationError{{Code: "W1"}},
+		}},
+		{FilePath: "b.epub", Report: &ebmlib.ValidationReport{
+			Errors:   []ebmlib.ValidationError{{Code: "E2"}},
+			Warnings: []ebmlib.ValidationError{{Code: "W1"}},
+		}},
+		{FilePath: "c.epub", Error: errors.New("unreadable")},
+	}
+
+	stats := ComputeIssueStats(results)
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 codes, got %d: %+v", len(stats), stats)
+	}
+
+	// E2 and W1 tie with E1 on occurrences but affect more files
+	want := []struct {
+		code        string
+		occurrences int
+		files       int
+	}{
+		{"E2", 2, 2},
+		{"W1", 2, 2},
+		{"E1", 2, 1},
+	}
+	for i, w := range want {
+		if stats[i].Code != w.code || stats[i].Occurrences != w.occurrences || stats[i].Files != w.files {
+			t.Errorf("stats[%d] = %+v, want %s occurrences=%d files=%d", i, stats[i], w.code, w.occurrences, w.files)
+		}
+	}
+
+	if stats[1].Severity != ebmlib.SeverityWarning {
+		t.Errorf("Expected W1 to be a warning, got %v", stats[1].Severity)
+	}
+	if stats[2].Message != "first" {
+		t.Errorf("Expected first message to be kept, got %q", stats[2].Message)
+	}
+	if len(stats[0].Examples) != 2 || stats[0].Examples[0] != "a.epub" {
+		t.Errorf("Unexpected examples %v", stats[0].Examples)
+	}
+}
+
+func TestComputeIssueStats_ExampleLimit(t *testing.T) {
+	var results []Result
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		results = append(results, Result{
+			FilePath: name + ".epub",
+			Report:   &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "E1"}}},
+		})
+	}
+
+	stats := ComputeIssueStats(results)
+	if stats[0].Files != 5 || len(stats[0].Examples) != MaxIssueExamples {
+		t.Errorf("Expected 5 files with %d examples, got %+v", MaxIssueExamples, stats[0])
+	}
+}
+
+func TestAggregateResults_TopIssues(t *testing.T) {
+	results := []Result{
+		{FilePath: "a.epub", Report: &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "E1"}}}},
+	}
+
+	br := AggregateResults(results, time.Second, OperationValidate)
+	if len(br.TopIssues) != 1 || br.TopIssues[0].Code != "E1" {
+		t.Errorf("Expected TopIssues to be populated, got %+v", br.TopIssues)
+	}
+}
@@ -0,0 +1,146 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RecentFailuresKept is the number of failures carried in ProgressUpdate
+const RecentFailuresKept = 50
+
+// FileProgress is a file a worker is processing
+type FileProgress struct {
+	Worker   int // Worker number, from 1
+	FilePath string
+	Bytes    int64
+	Elapsed  time.Duration // Time since the worker picked up the file
+}
+
+// FailureEvent is a file that finished invalid or errored
+type FailureEvent struct {
+	FilePath string
+	Reason   string
+	Kind     ErrorKind // Empty for invalid files
+	Time     time.Time
+}
+
+// liveStats tracks what the workers of a batch are doing for progress
+// updates
+type liveStats struct {
+	mu       sync.Mutex
+	start    time.Time
+	inFlight map[int]inFlightFile
+	valid    int
+	invalid  int
+	errored  int
+	bytes    int64 // Size of finished files
+	total    int64 // Size of all files received so far
+	failures []FailureEvent
+}
+
+type inFlightFile struct {
+	path    string
+	bytes   int64
+	started time.Time
+}
+
+func newLiveStats() *liveStats {
+	return &liveStats{start: time.Now(), inFlight: make(map[int]inFlightFile)}
+}
+
+// add counts a received file towards the total size
+func (s *liveStats) add(size int64) {
+	s.mu.Lock()
+	s.total += size
+	s.mu.Unlock()
+}
+
+// begin records that worker picked up task
+func (s *liveStats) begin(worker int, task Task) {
+	s.mu.Lock()
+	s.inFlight[worker] = inFlightFile{path: task.FilePath, bytes: task.size, started: time.Now()}
+	s.mu.Unlock()
+}
+
+// end records that worker put task down. A result is nil when the file
+// was held back for the final pass.
+func (s *liveStats) end(worker int, task Task, result *Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, worker)
+	if result == nil {
+		return
+	}
+
+	s.bytes += task.size
+	switch {
+	case result.Error != nil:
+		s.errored++
+		s.fail(FailureEvent{FilePath: result.FilePath, Reason: result.Error.Error(), Kind: result.ErrorKind})
+	case result.Report != nil && !result.Report.IsValid:
+		s.invalid++
+		s.fail(FailureEvent{FilePath: result.FilePath, Reason: fmt.Sprintf("%d errors", result.Report.ErrorCount())})
+	case result.Repair != nil && !result.Repair.Success:
+		s.invalid++
+		reason := "repair failed"
+		if result.Repair.Error != nil {
+			reason = result.Repair.Error.Error()
+		}
+		s.fail(FailureEvent{FilePath: result.FilePath, Reason: reason})
+	default:
+		s.valid++
+	}
+}
+
+// fail appends to the failure log, dropping the oldest past
+// RecentFailuresKept. The caller holds mu.
+func (s *liveStats) fail(event FailureEvent) {
+	event.Time = time.Now()
+	if len(s.failures) == RecentFailuresKept {
+		s.failures = append(s.failures[:0], s.failures[1:]...)
+	}
+	s.failures = append(s.failures, event)
+}
+
+// fill copies the live statistics into a progress update
+func (s *liveStats) fill(update *ProgressUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	update.Valid, update.Invalid, update.Errored = s.valid, s.invalid, s.errored
+	update.BytesDone, update.BytesTotal = s.bytes, s.total
+	update.Elapsed = now.Sub(s.start)
+	if seconds := update.Elapsed.Seconds(); seconds > 0 {
+		update.FilesPerSecond = float64(update.Completed) / seconds
+		update.BytesPerSecond = float64(s.bytes) / seconds
+	}
+	update.ETA = estimateRemaining(update)
+
+	update.InFlight = make([]FileProgress, 0, len(s.inFlight))
+	for worker, file := range s.inFlight {
+		update.InFlight = append(update.InFlight, FileProgress{
+			Worker:   worker,
+			FilePath: file.path,
+			Bytes:    file.bytes,
+			Elapsed:  now.Sub(file.started),
+		})
+	}
+	sort.Slice(update.InFlight, func(i, j int) bool { return update.InFlight[i].Worker < update.InFlight[j].Worker })
+	update.RecentFailures = append([]FailureEvent(nil), s.failures...)
+}
+
+// estimateRemaining extrapolates the time left from the bytes processed so
+// far, falling back to file counts when sizes are unknown. It returns 0
+// until there is something to extrapolate from.
+func estimateRemaining(update *ProgressUpdate) time.Duration {
+	if update.BytesPerSecond > 0 && update.BytesTotal > update.BytesDone {
+		return time.Duration(float64(update.BytesTotal-update.BytesDone) / update.BytesPerSecond * float64(time.Second))
+	}
+	if update.BytesDone == 0 && update.FilesPerSecond > 0 && update.Total > update.Completed {
+		return time.Duration(float64(update.Total-update.Completed) / update.FilesPerSecond * float64(time.Second))
+	}
+	return 0
+}
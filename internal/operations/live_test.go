@@ -0,0 +1,120 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func TestLiveStats(t *testing.T) {
+	s := newLiveStats()
+	s.start = time.Now().Add(-2 * time.Second)
+	s.add(100)
+	s.add(300)
+	s.add(600)
+
+	a := Task{FilePath: "a.epub", size: 100}
+	b := Task{FilePath: "b.epub", size: 300}
+	c := Task{FilePath: "c.pdf", size: 600}
+	s.begin(2, b)
+	s.begin(1, a)
+	s.begin(3, c)
+
+	update := ProgressUpdate{Completed: 2, Total: 3}
+	s.end(1, a, &Result{FilePath: "a.epub", Report: &ebmlib.ValidationReport{IsValid: true}})
+	s.end(2, b, &Result{FilePath: "b.epub", Error: errors.New("zip: not a valid zip file"), ErrorKind: ErrorKindCorrupt})
+	s.fill(&update)
+
+	if update.Valid != 1 || update.Invalid != 0 || update.Errored != 1 {
+		t.Errorf("Tallies = %d valid, %d invalid, %d errored", update.Valid, update.Invalid, update.Errored)
+	}
+	if update.BytesDone != 400 || update.BytesTotal != 1000 {
+		t.Errorf("Bytes = %d of %d", update.BytesDone, update.BytesTotal)
+	}
+	if len(update.InFlight) != 1 || update.InFlight[0].Worker != 3 || update.InFlight[0].FilePath != "c.pdf" || update.InFlight[0].Elapsed <= 0 {
+		t.Errorf("InFlight = %+v", update.InFlight)
+	}
+	if len(update.RecentFailures) != 1 || update.RecentFailures[0].Kind != ErrorKindCorrupt || update.RecentFailures[0].Reason != "zip: not a valid zip file" {
+		t.Errorf("RecentFailures = %+v", update.RecentFailures)
+	}
+	// 400 bytes in ~2s leaves 600 bytes, ~3s
+	if update.ETA < 2*time.Second || update.ETA > 4*time.Second {
+		t.Errorf("ETA = %v, expected about 3s", update.ETA)
+	}
+
+	// A file held back for the final pass is neither in flight nor counted
+	s.end(3, c, nil)
+	s.fill(&update)
+	if len(update.InFlight) != 0 || update.Valid+update.Invalid+update.Errored != 2 {
+		t.Errorf("Expected the deferred file to be dropped, got %+v", update)
+	}
+}
+
+func TestLiveStats_FailureLogIsBounded(t *testing.T) {
+	s := newLiveStats()
+	for i := 0; i < RecentFailuresKept+5; i++ {
+		task := Task{FilePath: fmt.Sprintf("%02d.epub", i)}
+		s.end(1, task, &Result{FilePath: task.FilePath, Repair: &ebmlib.RepairResult{Success: false}})
+	}
+
+	var update ProgressUpdate
+	s.fill(&update)
+	if update.Invalid != RecentFailuresKept+5 || len(update.RecentFailures) != RecentFailuresKept {
+		t.Fatalf("Expected %d failures kept of %d, got %d", RecentFailuresKept, update.Invalid, len(update.RecentFailures))
+	}
+	if update.RecentFailures[0].FilePath != "05.epub" || update.RecentFailures[0].Reason != "repair failed" {
+		t.Errorf("Expected the oldest failures dropped, got %+v", update.RecentFailures[0])
+	}
+}
+
+func TestEstimateRemaining_WithoutSizes(t *testing.T) {
+	update := &ProgressUpdate{Completed: 10, Total: 30, FilesPerSecond: 5}
+	if eta := estimateRemaining(update); eta != 4*time.Second {
+		t.Errorf("ETA = %v, want 4s", eta)
+	}
+	if eta := estimateRemaining(&ProgressUpdate{Total: 30}); eta != 0 {
+		t.Errorf("Expected no ETA before any file finished, got %v", eta)
+	}
+}
+
+func TestBatchProcessor_ReportsInFlight(t *testing.T) {
+	release := make(chan struct{})
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		<-release
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 2
+	config.ProgressRate = 5 * time.Millisecond
+	processor := NewBatchProcessor(context.Background(), config)
+
+	done := make(chan []Result)
+	go func() { done <- processor.Execute([]string{"a.epub", "b.epub"}, OperationValidate) }()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case update := <-processor.ProgressChannel():
+			if len(update.InFlight) < 2 {
+				continue
+			}
+			if update.InFlight[0].Worker != 1 || update.InFlight[1].Worker != 2 {
+				t.Errorf("InFlight = %+v", update.InFlight)
+			}
+			close(release)
+			if results := <-done; len(results) != 2 {
+				t.Errorf("Expected 2 results, got %d", len(results))
+			}
+			return
+		case <-deadline:
+			close(release)
+			t.Fatal("No update listed both files in flight")
+		}
+	}
+}
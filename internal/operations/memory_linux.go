@@ -0,0 +1,27 @@
+//go:build linux
+
+package operations
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processMemory returns the resident memory of process pid in bytes
+func processMemory(pid int) (int64, bool) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * int64(os.Getpagesize()), true
+}
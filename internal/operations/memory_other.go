@@ -0,0 +1,9 @@
+//go:build !linux
+
+package operations
+
+// processMemory is unavailable on this platform; memory limits are not
+// enforced
+func processMemory(pid int) (int64, bool) {
+	return 0, false
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
 )
@@ -14,6 +15,7 @@ import (
 type RepairOperation struct {
 	ctx        context.Context
 	aggressive bool
+	timing     *PhaseTimings // Receives phase durations when set
 }
 
 // RepairSaveMode controls how repaired files are saved.
@@ -35,6 +37,30 @@ func (r *RepairOperation) WithAggressive(enabled bool) *RepairOperation {
 	return r
 }
 
+// WithTiming records the duration of the preview and repair phases of
+// ExecuteWithSaveMode in timing.
+func (r *RepairOperation) WithTiming(timing *PhaseTimings) *RepairOperation {
+	r.timing = timing
+	return r
+}
+
+// timedPreview runs Preview, recording its duration
+func (r *RepairOperation) timedPreview(filePath string) (*ebmlib.RepairPreview, error) {
+	start := time.Now()
+	preview, err := r.Preview(filePath)
+	if r.timing != nil {
+		r.timing.Preview = time.Since(start)
+	}
+	return preview, err
+}
+
+// timeRepair records the duration of the repair phase begun at start
+func (r *RepairOperation) timeRepair(start time.Time) {
+	if r.timing != nil {
+		r.timing.Repair = time.Since(start)
+	}
+}
+
 // Preview generates a repair preview for the given file
 func (r *RepairOperation) Preview(filePath string) (*ebmlib.RepairPreview, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
@@ -45,7 +71,7 @@ func (r *RepairOperation) Preview(filePath string) (*ebmlib.RepairPreview, error
 	case ".pdf":
 		return ebmlib.PreviewPDFRepairWithContext(r.ctx, filePath)
 	default:
-		return nil, fmt.Errorf("unsupported file type: %s (expected .epub or .pdf)", ext)
+		return nil, fmt.Errorf("%w: %s (expected .epub or .pdf)", ErrUnsupportedType, ext)
 	}
 }
 
@@ -68,7 +94,7 @@ func (r *RepairOperation) ExecuteWithPreview(filePath string, preview *ebmlib.Re
 	case ".pdf":
 		return ebmlib.RepairPDFWithPreviewContext(r.ctx, filePath, preview, outputPath)
 	default:
-		return nil, fmt.Errorf("unsupported file type: %s (expected .epub or .pdf)", ext)
+		return nil, fmt.Errorf("%w: %s (expected .epub or .pdf)", ErrUnsupportedType, ext)
 	}
 }
 
@@ -78,17 +104,20 @@ func (r *RepairOperation) ExecuteWithSaveMode(filePath string, mode RepairSaveMo
 		return nil, "", err
 	}
 
+	// Repair the link target so that symlinks are written through, never replaced
+	target := repairTarget(filePath)
+
 	switch mode {
 	case RepairSaveModeBackupOriginal:
-		result, outputPath, err := r.executeInPlaceWithBackup(filePath, backupDir)
-		return result, outputPath, err
+		result, outputPath, err := r.executeInPlaceWithBackup(target, backupDir)
+		return result, linkPath(outputPath, filePath), err
 
 	case RepairSaveModeNoBackup:
 		if backupDir != "" {
 			return nil, "", fmt.Errorf("backup dir is not supported with no-backup mode")
 		}
-		result, outputPath, err := r.executeInPlaceNoBackup(filePath)
-		return result, outputPath, err
+		result, outputPath, err := r.executeInPlaceNoBackup(target)
+		return result, linkPath(outputPath, filePath), err
 
 	default:
 		return nil, "", fmt.Errorf("unsupported save mode: %s", mode)
@@ -102,13 +131,32 @@ type RepairResult struct {
 	Error    error
 }
 
+// repairTarget resolves symbolic links so that in-place repairs replace the
+// real file. Paths that cannot be resolved are returned unchanged.
+func repairTarget(filePath string) string {
+	target, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		return filePath
+	}
+	return target
+}
+
+// linkPath reports the output of a repair of filePath's target as filePath,
+// the path it was asked for; an empty output stays empty
+func linkPath(outputPath, filePath string) string {
+	if outputPath == "" {
+		return ""
+	}
+	return filePath
+}
+
 func repairExtension(filePath string) (string, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 	switch ext {
 	case ".epub", ".pdf":
 		return ext, nil
 	default:
-		return ext, fmt.Errorf("unsupported file type: %s (expected .epub or .pdf)", ext)
+		return ext, fmt.Errorf("%w: %s (expected .epub or .pdf)", ErrUnsupportedType, ext)
 	}
 }
 
@@ -124,13 +172,14 @@ func withSuffix(filePath, suffix, dirOverride string) string {
 }
 
 func (r *RepairOperation) executeInPlaceWithBackup(filePath, backupDir string) (*ebmlib.RepairResult, string, error) {
-	preview, err := r.Preview(filePath)
+	preview, err := r.timedPreview(filePath)
 	if err != nil {
 		return nil, "", err
 	}
 	if len(preview.Actions) == 0 {
 		return &ebmlib.RepairResult{Success: true, ActionsApplied: []ebmlib.RepairAction{}}, filePath, nil
 	}
+	defer r.timeRepair(time.Now())
 
 	tmpPath, err := tempRepairPath(filePath)
 	if err != nil {
@@ -149,30 +198,32 @@ func (r *RepairOperation) executeInPlaceWithBackup(filePath, backupDir string) (
 		return result, filePath, nil
 	}
 
+	// The repair ran, so its actions are returned with any error saving it
+	result.BackupPath = ""
 	backupPath := withSuffix(filePath, "_original", backupDir)
 	if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
-		return nil, "", fmt.Errorf("failed to create backup directory: %w", err)
+		return result, "", fmt.Errorf("failed to create backup directory: %w", err)
 	}
 	if err := copyFile(filePath, backupPath); err != nil {
-		return nil, "", fmt.Errorf("failed to create backup: %w", err)
+		return result, "", fmt.Errorf("failed to create backup: %w", err)
 	}
 
+	result.BackupPath = backupPath
 	if err := replaceFile(tmpPath, filePath); err != nil {
-		return nil, "", err
+		return result, "", err
 	}
-
-	result.BackupPath = backupPath
 	return result, filePath, nil
 }
 
 func (r *RepairOperation) executeInPlaceNoBackup(filePath string) (*ebmlib.RepairResult, string, error) {
-	preview, err := r.Preview(filePath)
+	preview, err := r.timedPreview(filePath)
 	if err != nil {
 		return nil, "", err
 	}
 	if len(preview.Actions) == 0 {
 		return &ebmlib.RepairResult{Success: true, ActionsApplied: []ebmlib.RepairAction{}}, filePath, nil
 	}
+	defer r.timeRepair(time.Now())
 
 	tmpPath, err := tempRepairPath(filePath)
 	if err != nil {
@@ -191,11 +242,10 @@ func (r *RepairOperation) executeInPlaceNoBackup(filePath string) (*ebmlib.Repai
 		return result, filePath, nil
 	}
 
+	result.BackupPath = ""
 	if err := replaceFile(tmpPath, filePath); err != nil {
-		return nil, "", err
+		return result, "", err
 	}
-
-	result.BackupPath = ""
 	return result, filePath, nil
 }
 
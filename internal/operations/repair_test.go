@@ -2,6 +2,7 @@ package operations
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -22,6 +23,18 @@ func TestNewRepairOperation(t *testing.T) {
 	}
 }
 
+func TestRepairOperation_WithTiming(t *testing.T) {
+	var timing PhaseTimings
+	op := NewRepairOperation(context.Background()).WithTiming(&timing)
+
+	if _, _, err := op.ExecuteWithSaveMode("nonexistent.epub", RepairSaveModeBackupOriginal, ""); err == nil {
+		t.Fatal("Expected error for missing file")
+	}
+	if timing.Preview <= 0 || timing.Repair != 0 {
+		t.Errorf("Expected only the preview phase to be timed, got %+v", timing)
+	}
+}
+
 func TestRepairOperation_Preview_UnsupportedType(t *testing.T) {
 	ctx := context.Background()
 	op := NewRepairOperation(ctx)
@@ -356,3 +369,24 @@ func TestRepairResult_WithResult(t *testing.T) {
 		t.Error("Expected Result to match the provided repair result")
 	}
 }
+
+func TestRepairTarget_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real.epub")
+	if err := os.WriteFile(real, []byte("t"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.epub")
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	want, _ := filepath.EvalSymlinks(real)
+	if got := repairTarget(link); got != want {
+		t.Errorf("repairTarget() = %s, want %s", got, want)
+	}
+	missing := filepath.Join(dir, "missing.epub")
+	if got := repairTarget(missing); got != missing {
+		t.Errorf("repairTarget() = %s, want unchanged path", got)
+	}
+}
@@ -0,0 +1,95 @@
+package operations
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+// resultJSON is the serialized form of Result. Errors are stored as their
+// messages so that saved reports can be loaded back.
+type resultJSON struct {
+	FilePath    string
+	Root        string `json:",omitempty"`
+	Report      *ebmlib.ValidationReport
+	Repair      *ebmlib.RepairResult
+	Error       string        `json:",omitempty"`
+	ErrorKind   ErrorKind     `json:",omitempty"`
+	Stack       string        `json:",omitempty"` // Set when Error is a PanicError
+	RepairError string        `json:",omitempty"`
+	Attempts    int           `json:",omitempty"`
+	Timeout     time.Duration `json:",omitempty"`
+	Duration    time.Duration `json:",omitempty"`
+	Bytes       int64         `json:",omitempty"`
+	Phases      *PhaseTimings `json:",omitempty"`
+}
+
+// MarshalJSON encodes the result with error values as strings
+func (r Result) MarshalJSON() ([]byte, error) {
+	out := resultJSON{
+		FilePath: r.FilePath,
+		Root:     r.Root,
+		Report:   r.Report,
+		Attempts: r.Attempts,
+		Timeout:  r.Timeout,
+		Duration: r.Duration,
+		Bytes:    r.Bytes,
+	}
+	if r.Phases != (PhaseTimings{}) {
+		phases := r.Phases
+		out.Phases = &phases
+	}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+		out.ErrorKind = r.ErrorKind
+		var panicErr *PanicError
+		if errors.As(r.Error, &panicErr) {
+			out.Stack = panicErr.Stack
+		}
+	}
+	if r.Repair != nil {
+		repair := *r.Repair
+		if repair.Error != nil {
+			out.RepairError = repair.Error.Error()
+			repair.Error = nil
+		}
+		out.Repair = &repair
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a result written by MarshalJSON
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var in resultJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*r = Result{
+		FilePath:  in.FilePath,
+		Root:      in.Root,
+		Report:    in.Report,
+		Repair:    in.Repair,
+		ErrorKind: in.ErrorKind,
+		Attempts:  in.Attempts,
+		Timeout:   in.Timeout,
+		Duration:  in.Duration,
+		Bytes:     in.Bytes,
+	}
+	if in.Phases != nil {
+		r.Phases = *in.Phases
+	}
+	switch {
+	case in.Stack != "":
+		r.Error = &PanicError{Value: strings.TrimPrefix(in.Error, "panic: "), Stack: in.Stack}
+	case in.Error != "":
+		r.Error = errors.New(in.Error)
+	}
+	if r.Repair != nil && in.RepairError != "" {
+		r.Repair.Error = errors.New(in.RepairError)
+	}
+	return nil
+}
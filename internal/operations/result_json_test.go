@@ -0,0 +1,72 @@
+package operations
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func TestResult_JSONRoundTrip(t *testing.T) {
+	original := Result{
+		FilePath: "book.epub",
+		Repair:   &ebmlib.RepairResult{Success: false, Error: errors.New("repair failed")},
+		Error:    errors.New("permission denied"),
+		Attempts: 3,
+		Timeout:  90 * time.Second,
+		Duration: 2 * time.Second,
+		Bytes:    4096,
+		Phases:   PhaseTimings{Preview: time.Second, Repair: 500 * time.Millisecond},
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"permission denied"`) {
+		t.Errorf("Expected error message in JSON, got %s", data)
+	}
+
+	var decoded Result
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.FilePath != "book.epub" {
+		t.Errorf("Expected FilePath book.epub, got %s", decoded.FilePath)
+	}
+	if decoded.Error == nil || decoded.Error.Error() != "permission denied" {
+		t.Errorf("Expected error 'permission denied', got %v", decoded.Error)
+	}
+	if decoded.Repair == nil || decoded.Repair.Error == nil || decoded.Repair.Error.Error() != "repair failed" {
+		t.Errorf("Expected repair error 'repair failed', got %+v", decoded.Repair)
+	}
+	if decoded.Attempts != 3 || decoded.Timeout != 90*time.Second {
+		t.Errorf("Expected 3 attempts with a 90s timeout, got %d and %v", decoded.Attempts, decoded.Timeout)
+	}
+	if decoded.Duration != 2*time.Second || decoded.Bytes != 4096 || decoded.Phases != original.Phases {
+		t.Errorf("Expected timing to survive round trip, got %v, %d, %+v", decoded.Duration, decoded.Bytes, decoded.Phases)
+	}
+
+	// Marshalling must not mutate the original repair result
+	if original.Repair.Error == nil {
+		t.Error("Expected original repair error to be preserved")
+	}
+}
+
+func TestResult_JSONNoErrors(t *testing.T) {
+	data, err := json.Marshal(Result{FilePath: "ok.epub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Result
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Error != nil {
+		t.Errorf("Expected nil error, got %v", decoded.Error)
+	}
+}
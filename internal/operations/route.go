@@ -0,0 +1,366 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Outcome is what became of a file, used by routing rules to choose where
+// it goes
+type Outcome string
+
+const (
+	OutcomeValid    Outcome = "valid"    // Found valid, or repaired
+	OutcomeInvalid  Outcome = "invalid"  // Found invalid by validation
+	OutcomeReverted Outcome = "reverted" // Could not be repaired; the original was kept
+	OutcomeErrored  Outcome = "errored"  // Could not be processed
+)
+
+// Outcomes lists every outcome in documentation order
+func Outcomes() []Outcome {
+	return []Outcome{OutcomeValid, OutcomeInvalid, OutcomeReverted, OutcomeErrored}
+}
+
+// ResultOutcome classifies a result as AggregateResults does, telling
+// failed repairs apart from invalid files
+func ResultOutcome(r Result) Outcome {
+	switch {
+	case r.Error != nil:
+		return OutcomeErrored
+	case r.Report != nil && !r.Report.IsValid:
+		return OutcomeInvalid
+	case r.Repair != nil && !r.Repair.Success:
+		return OutcomeReverted
+	}
+	return OutcomeValid
+}
+
+// Collision is what a routing rule does when its destination is taken
+type Collision string
+
+const (
+	CollisionSuffix Collision = "suffix" // Add _1, _2 and so on to the name
+	CollisionSkip   Collision = "skip"   // Leave the file where it is
+)
+
+// RouteRule moves the files with an outcome to a destination
+type RouteRule struct {
+	Outcome   Outcome
+	Dest      string    // Directory; relative paths are under the root the file was found under
+	KeepPath  bool      // Keep the file's directories below the root
+	Name      string    // Template for the file name, e.g. "{{.Stem}}_{{.Date}}{{.Ext}}"; empty keeps the name
+	Collision Collision // Empty for CollisionSuffix
+}
+
+// MoveFailedRepairsRule is the rule --move-failed-repairs adds: files that
+// could not be repaired go to INVALID under the root, keeping the
+// library's structure
+var MoveFailedRepairsRule = RouteRule{Outcome: OutcomeReverted, Dest: "INVALID", KeepPath: true}
+
+// routeName is the data of a name template
+type routeName struct {
+	Name    string // File name, e.g. "Book.epub"
+	Stem    string // Name without the extension
+	Ext     string // Extension with the dot
+	Dir     string // Directory below the root, "" at the root
+	Outcome string
+	Kind    string // Error kind of errored files
+	Date    string // Day of the move, e.g. 2024-05-01
+}
+
+// ParseRouteRule parses a rule written as
+// OUTCOME=DIR[;keep-path][;name=TEMPLATE][;collision=suffix|skip]
+func ParseRouteRule(s string) (RouteRule, error) {
+	parts := strings.Split(s, ";")
+	outcome, dest, ok := strings.Cut(parts[0], "=")
+	outcome, dest = strings.TrimSpace(outcome), strings.TrimSpace(dest)
+	if !ok || dest == "" {
+		return RouteRule{}, fmt.Errorf("route %q: expected OUTCOME=DIR", s)
+	}
+	rule := RouteRule{Outcome: Outcome(strings.ToLower(outcome)), Dest: dest}
+	if !rule.Outcome.valid() {
+		return RouteRule{}, fmt.Errorf("route %q: unknown outcome %q (valid: %s)", s, outcome, outcomeNames())
+	}
+
+	for _, option := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "keep-path":
+			rule.KeepPath = true
+		case "name":
+			rule.Name = value
+		case "collision":
+			rule.Collision = Collision(value)
+			if rule.Collision != CollisionSuffix && rule.Collision != CollisionSkip {
+				return RouteRule{}, fmt.Errorf("route %q: collision must be suffix or skip, not %q", s, value)
+			}
+		case "":
+		default:
+			return RouteRule{}, fmt.Errorf("route %q: unknown option %q (valid: keep-path, name, collision)", s, key)
+		}
+	}
+	if _, err := rule.fileName(Result{FilePath: "book.epub"}, ""); err != nil {
+		return RouteRule{}, fmt.Errorf("route %q: %w", s, err)
+	}
+	return rule, nil
+}
+
+// String writes the rule in the form ParseRouteRule reads
+func (rule RouteRule) String() string {
+	s := string(rule.Outcome) + "=" + rule.Dest
+	if rule.KeepPath {
+		s += ";keep-path"
+	}
+	if rule.Name != "" {
+		s += ";name=" + rule.Name
+	}
+	if rule.Collision != "" && rule.Collision != CollisionSuffix {
+		s += ";collision=" + string(rule.Collision)
+	}
+	return s
+}
+
+// Target returns where the rule sends a result's file found under root,
+// before any collision is resolved
+func (rule RouteRule) Target(r Result, root string) (string, error) {
+	dest := rule.Dest
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(root, dest)
+	}
+	rel := relativeDir(root, r.FilePath)
+	if rule.KeepPath && rel != "" {
+		dest = filepath.Join(dest, rel)
+	}
+	name, err := rule.fileName(r, rel)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dest, name), nil
+}
+
+// fileName renders the name template for a file in directory rel below
+// the root
+func (rule RouteRule) fileName(r Result, rel string) (string, error) {
+	base := filepath.Base(r.FilePath)
+	if rule.Name == "" {
+		return base, nil
+	}
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(rule.Name)
+	if err != nil {
+		return "", fmt.Errorf("name template: %w", err)
+	}
+	ext := filepath.Ext(base)
+	data := routeName{
+		Name:    base,
+		Stem:    strings.TrimSuffix(base, ext),
+		Ext:     ext,
+		Dir:     filepath.ToSlash(rel),
+		Outcome: string(rule.Outcome),
+		Kind:    string(r.ErrorKind),
+		Date:    time.Now().Format("2006-01-02"),
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("name template: %w", err)
+	}
+	name := filepath.Clean(filepath.FromSlash(b.String()))
+	if name == "." || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("name template gave %q, which is not a file name", b.String())
+	}
+	return name, nil
+}
+
+// relativeDir returns the directory of path below root, or "" if it is at
+// or outside the root
+func relativeDir(root, path string) string {
+	dir := filepath.Dir(path)
+	if !within(root, dir) {
+		return ""
+	}
+	rel, _ := filepath.Rel(root, dir)
+	if rel == "." {
+		return ""
+	}
+	return rel
+}
+
+// within reports whether path is dir or below it
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (o Outcome) valid() bool {
+	for _, outcome := range Outcomes() {
+		if o == outcome {
+			return true
+		}
+	}
+	return false
+}
+
+func outcomeNames() string {
+	var names []string
+	for _, outcome := range Outcomes() {
+		names = append(names, string(outcome))
+	}
+	return strings.Join(names, ", ")
+}
+
+// RouteRules are the routing rules of a run, at most one per outcome
+type RouteRules []RouteRule
+
+// ParseRouteRules parses rules written as ParseRouteRule reads them
+func ParseRouteRules(specs []string) (RouteRules, error) {
+	var rules RouteRules
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		rule, err := ParseRouteRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := rules.For(rule.Outcome); ok {
+			return nil, fmt.Errorf("more than one route for %s files", rule.Outcome)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// For returns the rule for an outcome
+func (rs RouteRules) For(outcome Outcome) (RouteRule, bool) {
+	for _, rule := range rs {
+		if rule.Outcome == outcome {
+			return rule, true
+		}
+	}
+	return RouteRule{}, false
+}
+
+// WithMoveFailedRepairs adds MoveFailedRepairsRule unless a rule already
+// routes files that could not be repaired
+func (rs RouteRules) WithMoveFailedRepairs() RouteRules {
+	if _, ok := rs.For(OutcomeReverted); ok {
+		return rs
+	}
+	return append(append(RouteRules(nil), rs...), MoveFailedRepairsRule)
+}
+
+// Strings returns the rules in the form ParseRouteRules reads
+func (rs RouteRules) Strings() []string {
+	var specs []string
+	for _, rule := range rs {
+		specs = append(specs, rule.String())
+	}
+	return specs
+}
+
+// RoutedFile is a file a routing rule applied to
+type RoutedFile struct {
+	Path    string
+	Dest    string // Where the file was moved, or the destination that was taken
+	Outcome Outcome
+	Skipped bool  // Left in place because Dest was taken
+	Err     error // Why the file could not be moved
+}
+
+// Moved reports whether the file is now at Dest
+func (f RoutedFile) Moved() bool {
+	return !f.Skipped && f.Err == nil
+}
+
+// Plan returns where Route would move a result's file found under root,
+// without moving it. taken reports whether a destination is in use, so
+// that files planned together do not collide. It reports false when no
+// rule applies, or the file is already in the rule's destination.
+func (rs RouteRules) Plan(r Result, root string, taken func(path string) bool) (RoutedFile, bool) {
+	outcome := ResultOutcome(r)
+	rule, ok := rs.For(outcome)
+	if !ok {
+		return RoutedFile{}, false
+	}
+	dest := rule.Dest
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(root, dest)
+	}
+	if within(dest, r.FilePath) {
+		return RoutedFile{}, false // Sorted by an earlier run
+	}
+
+	routed := RoutedFile{Path: r.FilePath, Outcome: outcome}
+	target, err := rule.Target(r, root)
+	if err != nil {
+		routed.Err = err
+		return routed, true
+	}
+	routed.Dest = target
+	if taken(target) {
+		if rule.Collision == CollisionSkip {
+			routed.Skipped = true
+			return routed, true
+		}
+		routed.Dest = freePath(target, taken)
+	}
+	return routed, true
+}
+
+// Route moves a result's file found under root as the rule for its outcome
+// says. It reports false when no rule applies, or the file is already in
+// the rule's destination.
+func (rs RouteRules) Route(r Result, root string) (RoutedFile, bool) {
+	routed, ok := rs.Plan(r, root, exists)
+	if !ok || routed.Skipped || routed.Err != nil {
+		return routed, ok
+	}
+	routed.Err = moveInto(routed.Path, routed.Dest)
+	return routed, true
+}
+
+// moveInto moves src to dst, creating the directory of dst
+func moveInto(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create destination: %w", err)
+	}
+	return moveFile(src, dst)
+}
+
+// exists reports whether something is at path
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// freePath adds _1, _2 and so on to the name of path until it names a
+// destination that is not taken
+func freePath(path string, taken func(string) bool) string {
+	for n := 1; ; n++ {
+		candidate := withSuffix(path, fmt.Sprintf("_%d", n), "")
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// moveFile renames src to dst, copying across file systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	if err := os.Remove(src); err != nil {
+		_ = os.Remove(dst)
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package operations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+func TestParseRouteRule(t *testing.T) {
+	rule, err := ParseRouteRule("Reverted=failed; keep-path; name={{.Stem}}-{{.Outcome}}{{.Ext}};collision=skip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RouteRule{Outcome: OutcomeReverted, Dest: "failed", KeepPath: true, Name: "{{.Stem}}-{{.Outcome}}{{.Ext}}", Collision: CollisionSkip}
+	if rule != want {
+		t.Errorf("Expected %+v, got %+v", want, rule)
+	}
+	if got := rule.String(); got != "reverted=failed;keep-path;name={{.Stem}}-{{.Outcome}}{{.Ext}};collision=skip" {
+		t.Errorf("Unexpected String() %q", got)
+	}
+
+	for _, bad := range []string{
+		"invalid",
+		"invalid=",
+		"broken=dir",
+		"invalid=dir;flatten",
+		"invalid=dir;collision=overwrite",
+		"invalid=dir;name={{.Nope}}",
+		"invalid=dir;name={{.Stem",
+		"invalid=dir;name=../{{.Name}}",
+	} {
+		if _, err := ParseRouteRule(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestParseRouteRules(t *testing.T) {
+	rules, err := ParseRouteRules([]string{"valid=ok", "", "errored=broken"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules, got %+v", rules)
+	}
+	if _, ok := rules.For(OutcomeInvalid); ok {
+		t.Error("Expected no rule for invalid files")
+	}
+	if _, err := ParseRouteRules([]string{"valid=a", "valid=b"}); err == nil {
+		t.Error("Expected an error for two rules for the same outcome")
+	}
+
+	// --move-failed-repairs only fills a gap
+	withMove := rules.WithMoveFailedRepairs()
+	if rule, _ := withMove.For(OutcomeReverted); rule != MoveFailedRepairsRule || len(rules) != 2 {
+		t.Errorf("Expected the INVALID rule to be added to a copy, got %+v", withMove)
+	}
+	custom := RouteRules{{Outcome: OutcomeReverted, Dest: "failed"}}
+	if rule, _ := custom.WithMoveFailedRepairs().For(OutcomeReverted); rule.Dest != "failed" {
+		t.Errorf("Expected the explicit rule to win, got %+v", rule)
+	}
+}
+
+func TestResultOutcome(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   Outcome
+	}{
+		{Result{Report: &ebmlib.ValidationReport{IsValid: true}}, OutcomeValid},
+		{Result{Report: &ebmlib.ValidationReport{}}, OutcomeInvalid},
+		{Result{Repair: &ebmlib.RepairResult{Success: true}}, OutcomeValid},
+		{Result{Repair: &ebmlib.RepairResult{}}, OutcomeReverted},
+		{Result{Error: errors.New("boom")}, OutcomeErrored},
+	}
+	for _, tt := range tests {
+		if got := ResultOutcome(tt.result); got != tt.want {
+			t.Errorf("ResultOutcome(%+v) = %s, want %s", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestRouteRule_Target(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "library")
+	r := Result{FilePath: filepath.Join(root, "Author", "Title", "book.epub"), ErrorKind: ErrorKindCorrupt}
+	outside := filepath.Join(string(filepath.Separator), "elsewhere")
+
+	tests := []struct {
+		rule RouteRule
+		want string
+	}{
+		{RouteRule{Dest: "INVALID"}, filepath.Join(root, "INVALID", "book.epub")},
+		{RouteRule{Dest: "INVALID", KeepPath: true}, filepath.Join(root, "INVALID", "Author", "Title", "book.epub")},
+		{RouteRule{Dest: outside, KeepPath: true}, filepath.Join(outside, "Author", "Title", "book.epub")},
+		{RouteRule{Outcome: OutcomeErrored, Dest: "errors", Name: "{{.Kind}}/{{.Stem}}{{.Ext}}"}, filepath.Join(root, "errors", "corrupt", "book.epub")},
+		{RouteRule{Dest: "flat", Name: `{{.Dir | printf "%s"}}-{{.Name}}`}, filepath.Join(root, "flat", "Author", "Title-book.epub")},
+	}
+	for _, tt := range tests {
+		got, err := tt.rule.Target(r, root)
+		if err != nil {
+			t.Errorf("Target(%+v): %v", tt.rule, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Target(%+v) = %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestRouteRules_RouteSuffixesCollisions(t *testing.T) {
+	root := t.TempDir()
+	dest := filepath.Join(root, "valid")
+	os.Mkdir(dest, 0755)
+	writeTestFile(t, filepath.Join(dest, "book.epub"), "first")
+	writeTestFile(t, filepath.Join(dest, "book_1.epub"), "second")
+	src := filepath.Join(root, "book.epub")
+	writeTestFile(t, src, "third")
+
+	rules := RouteRules{{Outcome: OutcomeValid, Dest: "valid"}}
+	routed, ok := rules.Route(Result{FilePath: src}, root)
+	if !ok || routed.Err != nil {
+		t.Fatalf("Expected the file to be routed, got %+v", routed)
+	}
+	if want := filepath.Join(dest, "book_2.epub"); routed.Dest != want {
+		t.Errorf("Expected %s, got %s", want, routed.Dest)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "book.epub")); string(data) != "first" {
+		t.Errorf("Expected the existing file to be kept, got %q", data)
+	}
+}
@@ -0,0 +1,131 @@
+package operations
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ScheduleOrder selects the order in which files are handed to workers
+type ScheduleOrder string
+
+// Schedule orders
+const (
+	OrderWalk      ScheduleOrder = "walk"      // As discovered; work starts while directories are searched
+	OrderLargest   ScheduleOrder = "largest"   // Largest files first, to shorten the total run time
+	OrderSmallest  ScheduleOrder = "smallest"  // Smallest files first, for quick feedback
+	OrderDirectory ScheduleOrder = "directory" // One directory at a time, by path
+)
+
+// ScheduleOrders lists every schedule order
+func ScheduleOrders() []ScheduleOrder {
+	return []ScheduleOrder{OrderWalk, OrderLargest, OrderSmallest, OrderDirectory}
+}
+
+// ParseScheduleOrder returns the order named s
+func ParseScheduleOrder(s string) (ScheduleOrder, bool) {
+	for _, order := range ScheduleOrders() {
+		if string(order) == s {
+			return order, true
+		}
+	}
+	return "", false
+}
+
+// streams reports whether tasks can be scheduled as they arrive. Other
+// orders need every file before the first one starts.
+func (o ScheduleOrder) streams() bool {
+	return o == "" || o == OrderWalk
+}
+
+// sortTasks orders tasks in place. Ties keep discovery order.
+func sortTasks(tasks []Task, order ScheduleOrder) {
+	switch order {
+	case OrderLargest:
+		sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].size > tasks[j].size })
+	case OrderSmallest:
+		sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].size < tasks[j].size })
+	case OrderDirectory:
+		sort.SliceStable(tasks, func(i, j int) bool {
+			di, dj := filepath.Dir(tasks[i].FilePath), filepath.Dir(tasks[j].FilePath)
+			if di != dj {
+				return di < dj
+			}
+			return tasks[i].FilePath < tasks[j].FilePath
+		})
+	}
+}
+
+// memoryBaseCost is the estimated memory used by any file, whatever its size
+const memoryBaseCost = 16 << 20
+
+// EstimateMemory returns the memory a file of the given size is expected to
+// need while it is processed. EPUBs are unpacked in memory, so they cost more
+// per byte than PDFs.
+func EstimateMemory(path string, size int64) int64 {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epub":
+		return memoryBaseCost + 6*size
+	case ".pdf":
+		return memoryBaseCost + 3*size
+	default:
+		return memoryBaseCost + size
+	}
+}
+
+// semaphore is a weighted semaphore whose limit can change while it is in
+// use. It bounds the estimated memory of files in progress and, with
+// auto-tuning, the number of files processed at once.
+type semaphore struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	limit int64
+	used  int64
+}
+
+// newSemaphore creates a semaphore of the given weight. Waiters give up
+// when ctx is done.
+func newSemaphore(ctx context.Context, limit int64) *semaphore {
+	s := &semaphore{limit: limit}
+	s.cond = sync.NewCond(&s.mu)
+	context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	return s
+}
+
+// acquire blocks until n is free and reserves it, returning the amount
+// reserved. A request above the whole limit runs alone. It returns false if
+// ctx is done first.
+func (s *semaphore) acquire(ctx context.Context, n int64) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.used > 0 && s.used+min(n, s.limit) > s.limit && ctx.Err() == nil {
+		s.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return 0, false
+	}
+	s.used += n
+	return n, true
+}
+
+// release returns weight reserved by acquire
+func (s *semaphore) release(n int64) {
+	s.mu.Lock()
+	s.used -= n
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// setLimit changes the limit. Lowering it lets work in progress finish.
+func (s *semaphore) setLimit(limit int64) {
+	s.mu.Lock()
+	s.limit = limit
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
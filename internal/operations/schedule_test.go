@@ -0,0 +1,160 @@
+package operations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleOrder(t *testing.T) {
+	for _, order := range ScheduleOrders() {
+		if got, ok := ParseScheduleOrder(string(order)); !ok || got != order {
+			t.Errorf("ParseScheduleOrder(%q) = %q, %v", order, got, ok)
+		}
+	}
+	if _, ok := ParseScheduleOrder("random"); ok {
+		t.Error("Expected unknown order to be rejected")
+	}
+}
+
+func TestSortTasks(t *testing.T) {
+	tasks := func() []Task {
+		return []Task{
+			{FilePath: "b/one.epub", size: 20},
+			{FilePath: "a/two.pdf", size: 300},
+			{FilePath: "b/three.epub", size: 20},
+			{FilePath: "a/four.epub", size: 1},
+		}
+	}
+	paths := func(tasks []Task) []string {
+		var out []string
+		for _, task := range tasks {
+			out = append(out, task.FilePath)
+		}
+		return out
+	}
+
+	tests := []struct {
+		order ScheduleOrder
+		want  []string
+	}{
+		{OrderWalk, []string{"b/one.epub", "a/two.pdf", "b/three.epub", "a/four.epub"}},
+		{OrderLargest, []string{"a/two.pdf", "b/one.epub", "b/three.epub", "a/four.epub"}},
+		{OrderSmallest, []string{"a/four.epub", "b/one.epub", "b/three.epub", "a/two.pdf"}},
+		{OrderDirectory, []string{"a/four.epub", "a/two.pdf", "b/one.epub", "b/three.epub"}},
+	}
+	for _, tt := range tests {
+		got := tasks()
+		sortTasks(got, tt.order)
+		for i, want := range tt.want {
+			if filepath.ToSlash(got[i].FilePath) != want {
+				t.Errorf("%s: sortTasks() = %v, want %v", tt.order, paths(got), tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestEstimateMemory(t *testing.T) {
+	if got := EstimateMemory("a.EPUB", 1<<20); got != memoryBaseCost+6<<20 {
+		t.Errorf("EstimateMemory(epub) = %d", got)
+	}
+	if got := EstimateMemory("a.pdf", 1<<20); got != memoryBaseCost+3<<20 {
+		t.Errorf("EstimateMemory(pdf) = %d", got)
+	}
+	if EstimateMemory("a.pdf", 100<<20) <= EstimateMemory("a.epub", 1<<20) {
+		t.Error("Expected a large PDF to cost more than a small EPUB")
+	}
+}
+
+func TestSemaphore_CancelWakesWaiters(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	budget := newSemaphore(ctx, 100)
+	if _, ok := budget.acquire(ctx, 80); !ok {
+		t.Fatal("Expected the first reservation to succeed")
+	}
+
+	done := make(chan bool)
+	go func() {
+		_, ok := budget.acquire(ctx, 50)
+		done <- ok
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected acquire to block while the budget is used")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel()
+	if <-done {
+		t.Error("Expected acquire to fail after cancel")
+	}
+}
+
+func TestBatchProcessor_OrderAndMemoryBudget(t *testing.T) {
+	dir := t.TempDir()
+	sizes := map[string]int{"small1.pdf": 1 << 10, "huge1.pdf": 4 << 20, "small2.pdf": 2 << 10, "huge2.pdf": 5 << 20}
+	var files []string
+	for _, name := range []string{"small1.pdf", "huge1.pdf", "small2.pdf", "huge2.pdf"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, make([]byte, sizes[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	var mu sync.Mutex
+	var started []string
+	peakHuge, runningHuge := 0, 0
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		huge := task.size > 1<<20
+		mu.Lock()
+		started = append(started, filepath.Base(task.FilePath))
+		if huge {
+			runningHuge++
+			peakHuge = max(peakHuge, runningHuge)
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		if huge {
+			runningHuge--
+		}
+		mu.Unlock()
+		return Result{FilePath: task.FilePath}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	config := DefaultBatchConfig()
+	config.NumWorkers = 1
+	config.Order = OrderLargest
+	results := NewBatchProcessor(context.Background(), config).Execute(files, OperationValidate)
+
+	if len(results) != 4 || results[1].FilePath != files[1] {
+		t.Fatalf("Expected results in input order, got %+v", results)
+	}
+	want := []string{"huge2.pdf", "huge1.pdf", "small2.pdf", "small1.pdf"}
+	for i := range want {
+		if started[i] != want[i] {
+			t.Errorf("Expected files to start largest first, got %v", started)
+			break
+		}
+	}
+
+	// Room for one huge PDF and a small one, but not two huge PDFs
+	config.NumWorkers = 4
+	config.MemoryBudget = EstimateMemory("x.pdf", 5<<20) + EstimateMemory("x.pdf", 2<<10)
+	peakHuge = 0
+	results = NewBatchProcessor(context.Background(), config).Execute(files, OperationValidate)
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	if peakHuge != 1 {
+		t.Errorf("Expected huge files to run one at a time, got %d at once", peakHuge)
+	}
+}
@@ -0,0 +1,187 @@
+package operations
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetExtensions lists the container entry types that can be shown as source context
+var snippetExtensions = map[string]bool{
+	".xhtml": true,
+	".html":  true,
+	".htm":   true,
+	".xml":   true,
+	".opf":   true,
+	".ncx":   true,
+	".css":   true,
+	".svg":   true,
+}
+
+// maxSnippetLineLength truncates very long lines (e.g. minified XHTML)
+const maxSnippetLineLength = 200
+
+// Snippet holds the lines surrounding a reported location inside an EPUB container
+type Snippet struct {
+	Entry     string   // Container entry the lines were read from
+	StartLine int      // Line number of Lines[0]
+	Line      int      // Reported line, within [StartLine, StartLine+len(Lines))
+	Lines     []string // Source lines, without line endings
+}
+
+// LineNumber returns the line number of Lines[i]
+func (s *Snippet) LineNumber(i int) int {
+	return s.StartLine + i
+}
+
+// FormatLine renders Lines[i] with a line-number gutter, marking the reported line with '>'
+func (s *Snippet) FormatLine(i int) string {
+	width := len(fmt.Sprintf("%d", s.LineNumber(len(s.Lines)-1)))
+	marker := " "
+	if s.LineNumber(i) == s.Line {
+		marker = ">"
+	}
+	return fmt.Sprintf("%s %*d | %s", marker, width, s.LineNumber(i), s.Lines[i])
+}
+
+// SnippetReader reads snippets from one EPUB, which it opens on the first
+// read and keeps open for the next ones until closed
+type SnippetReader struct {
+	path string
+	zr   *zip.ReadCloser
+	err  error
+}
+
+// NewSnippetReader returns a reader of snippets from the EPUB at containerPath
+func NewSnippetReader(containerPath string) *SnippetReader {
+	return &SnippetReader{path: containerPath}
+}
+
+// Close closes the container if it was opened
+func (r *SnippetReader) Close() error {
+	if r.zr == nil {
+		return nil
+	}
+	err := r.zr.Close()
+	r.zr = nil
+	return err
+}
+
+// ReadSnippet reads up to context lines either side of line from entry inside
+// the EPUB at containerPath. To read several snippets from one EPUB, use a
+// SnippetReader.
+func ReadSnippet(containerPath, entry string, line, context int) (*Snippet, error) {
+	r := NewSnippetReader(containerPath)
+	defer r.Close()
+	return r.Read(entry, line, context)
+}
+
+// Read reads up to context lines either side of line from entry. Entry names
+// are matched exactly first and then by path suffix, since validators may
+// report paths relative to the OPF.
+func (r *SnippetReader) Read(entry string, line, context int) (*Snippet, error) {
+	if line <= 0 {
+		return nil, fmt.Errorf("no line number for %s", entry)
+	}
+	if context < 0 {
+		context = 0
+	}
+	if !snippetExtensions[strings.ToLower(path.Ext(entry))] {
+		return nil, fmt.Errorf("%s is not a text entry", entry)
+	}
+
+	if r.zr == nil && r.err == nil {
+		if r.zr, r.err = zip.OpenReader(r.path); r.err != nil {
+			r.err = fmt.Errorf("failed to open container: %w", r.err)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	file, err := findEntry(r.zr.File, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	snippet := &Snippet{
+		Entry:     file.Name,
+		StartLine: line - context,
+		Line:      line,
+	}
+	if snippet.StartLine < 1 {
+		snippet.StartLine = 1
+	}
+
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		if n < snippet.StartLine {
+			continue
+		}
+		if n > line+context {
+			break
+		}
+		snippet.Lines = append(snippet.Lines, truncateLine(strings.TrimRight(scanner.Text(), "\r")))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	if n < line {
+		return nil, fmt.Errorf("%s has %d lines, line %d not found", file.Name, n, line)
+	}
+
+	return snippet, nil
+}
+
+// findEntry locates entry in the container, falling back to a unique suffix match
+func findEntry(files []*zip.File, entry string) (*zip.File, error) {
+	name := strings.TrimPrefix(path.Clean(strings.ReplaceAll(entry, "\\", "/")), "/")
+
+	var matches []*zip.File
+	for _, f := range files {
+		if f.Name == name {
+			return f, nil
+		}
+		if strings.HasSuffix(f.Name, "/"+name) {
+			matches = append(matches, f)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("entry %s not found in container", entry)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("entry %s is ambiguous (%d matches)", entry, len(matches))
+	}
+}
+
+// truncateLine cuts s to at most maxSnippetLineLength bytes, on a rune
+// boundary so that multi-byte characters are not split
+func truncateLine(s string) string {
+	if len(s) <= maxSnippetLineLength {
+		return s
+	}
+	n := 0
+	for n < len(s) {
+		_, size := utf8.DecodeRuneInString(s[n:])
+		if n+size > maxSnippetLineLength {
+			break
+		}
+		n += size
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,159 @@
+package operations
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func createTestContainer(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func numberedLines(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = "line " + string(rune('a'+i))
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func TestReadSnippet(t *testing.T) {
+	path := createTestContainer(t, map[string]string{
+		"OEBPS/text/ch1.xhtml": numberedLines(10),
+	})
+
+	snippet, err := ReadSnippet(path, "OEBPS/text/ch1.xhtml", 5, 2)
+	if err != nil {
+		t.Fatalf("ReadSnippet() error = %v", err)
+	}
+	if snippet.StartLine != 3 || len(snippet.Lines) != 5 {
+		t.Fatalf("Expected lines 3-7, got start %d with %d lines", snippet.StartLine, len(snippet.Lines))
+	}
+	if snippet.Lines[2] != "line e" || snippet.LineNumber(2) != 5 {
+		t.Errorf("Expected highlighted line 5 = %q, got %q", "line e", snippet.Lines[2])
+	}
+	if got := snippet.FormatLine(2); got != "> 5 | line e" {
+		t.Errorf("FormatLine(2) = %q", got)
+	}
+	if got := snippet.FormatLine(0); got != "  3 | line c" {
+		t.Errorf("FormatLine(0) = %q", got)
+	}
+}
+
+func TestReadSnippet_Edges(t *testing.T) {
+	path := createTestContainer(t, map[string]string{
+		"OEBPS/content.opf": numberedLines(3),
+	})
+
+	snippet, err := ReadSnippet(path, "content.opf", 1, 5)
+	if err != nil {
+		t.Fatalf("ReadSnippet() error = %v", err)
+	}
+	if snippet.Entry != "OEBPS/content.opf" {
+		t.Errorf("Expected suffix match on OEBPS/content.opf, got %s", snippet.Entry)
+	}
+	if snippet.StartLine != 1 || len(snippet.Lines) != 3 {
+		t.Errorf("Expected whole file, got start %d with %d lines", snippet.StartLine, len(snippet.Lines))
+	}
+}
+
+func TestReadSnippet_Errors(t *testing.T) {
+	path := createTestContainer(t, map[string]string{
+		"a/ch1.xhtml": numberedLines(3),
+		"b/ch1.xhtml": numberedLines(3),
+		"mimetype":    "application/epub+zip",
+	})
+
+	tests := []struct {
+		name  string
+		entry string
+		line  int
+	}{
+		{"no line", "a/ch1.xhtml", 0},
+		{"line past end", "a/ch1.xhtml", 10},
+		{"missing entry", "c/ch2.xhtml", 1},
+		{"ambiguous entry", "ch1.xhtml", 1},
+		{"binary entry", "mimetype", 1},
+	}
+
+	for _, tt := range tests {
+		if _, err := ReadSnippet(path, tt.entry, tt.line, 2); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	if _, err := ReadSnippet(filepath.Join(t.TempDir(), "missing.epub"), "a/ch1.xhtml", 1, 2); err == nil {
+		t.Error("Expected error for missing container")
+	}
+}
+
+func TestSnippetReader_ReadsSeveral(t *testing.T) {
+	path := createTestContainer(t, map[string]string{
+		"OEBPS/ch1.xhtml": numberedLines(5),
+		"OEBPS/ch2.xhtml": numberedLines(5),
+	})
+	r := NewSnippetReader(path)
+	defer r.Close()
+
+	for _, entry := range []string{"ch1.xhtml", "ch2.xhtml", "ch1.xhtml"} {
+		snippet, err := r.Read(entry, 2, 1)
+		if err != nil {
+			t.Fatalf("Read(%s) error = %v", entry, err)
+		}
+		if snippet.Entry != "OEBPS/"+entry || len(snippet.Lines) != 3 {
+			t.Errorf("Read(%s) = %+v", entry, snippet)
+		}
+	}
+
+	// A container that cannot be opened fails every read
+	missing := NewSnippetReader(filepath.Join(t.TempDir(), "missing.epub"))
+	for i := 0; i < 2; i++ {
+		if _, err := missing.Read("ch1.xhtml", 1, 1); err == nil {
+			t.Error("Expected error for missing container")
+		}
+	}
+	if err := missing.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestTruncateLine_RuneBoundary(t *testing.T) {
+	// 199 bytes of ASCII, then a 3-byte rune that would cross the limit
+	line := strings.Repeat("a", maxSnippetLineLength-1) + strings.Repeat("€", 5)
+	got := truncateLine(line)
+	if !utf8.ValidString(got) {
+		t.Fatalf("Expected valid UTF-8, got %q", got)
+	}
+	if want := strings.Repeat("a", maxSnippetLineLength-1) + "…"; got != want {
+		t.Errorf("truncateLine() = %q, want %q", got, want)
+	}
+	if short := "é"; truncateLine(short) != short {
+		t.Error("Expected short lines to be kept")
+	}
+}
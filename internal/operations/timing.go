@@ -0,0 +1,76 @@
+package operations
+
+import (
+	"sort"
+	"time"
+)
+
+// PhaseTimings records the time spent in each phase of processing a file.
+// Phases that did not run are zero.
+type PhaseTimings struct {
+	Validate time.Duration // Validating the file
+	Preview  time.Duration // Planning repairs
+	Repair   time.Duration // Applying repairs, backing up and saving
+}
+
+// FileTiming is the processing time of one file
+type FileTiming struct {
+	FilePath string
+	Duration time.Duration
+	Bytes    int64
+	Phases   PhaseTimings
+}
+
+// TimingStats summarises the processing times of a batch
+type TimingStats struct {
+	Files          int     // Files with a recorded duration
+	Bytes          int64   // Total size of those files
+	FilesPerSecond float64 // Over the batch wall time
+	BytesPerSecond float64
+	P50            time.Duration
+	P90            time.Duration
+	P99            time.Duration
+	Max            time.Duration
+	Slowest        []FileTiming // Slowest first, at most SlowestFilesKept
+}
+
+// SlowestFilesKept is the number of files listed in TimingStats.Slowest
+const SlowestFilesKept = 10
+
+// ComputeTimingStats summarises per-file durations of results processed in
+// the given wall time. Results without a duration, such as those loaded from
+// older reports, are left out.
+func ComputeTimingStats(results []Result, wall time.Duration) TimingStats {
+	var timings []FileTiming
+	var stats TimingStats
+	for _, r := range results {
+		if r.Duration <= 0 {
+			continue
+		}
+		timings = append(timings, FileTiming{FilePath: r.FilePath, Duration: r.Duration, Bytes: r.Bytes, Phases: r.Phases})
+		stats.Bytes += r.Bytes
+	}
+	if len(timings) == 0 {
+		return TimingStats{}
+	}
+
+	sort.SliceStable(timings, func(i, j int) bool { return timings[i].Duration > timings[j].Duration })
+	stats.Files = len(timings)
+	stats.Max = timings[0].Duration
+	stats.P50 = percentile(timings, 50)
+	stats.P90 = percentile(timings, 90)
+	stats.P99 = percentile(timings, 99)
+	if wall > 0 {
+		stats.FilesPerSecond = float64(stats.Files) / wall.Seconds()
+		stats.BytesPerSecond = float64(stats.Bytes) / wall.Seconds()
+	}
+	stats.Slowest = timings[:min(len(timings), SlowestFilesKept)]
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of timings sorted
+// slowest first
+func percentile(timings []FileTiming, p int) time.Duration {
+	rank := (p*len(timings) + 99) / 100 // ceil(p/100 * n), counted from the fastest
+	return timings[len(timings)-max(rank, 1)].Duration
+}
@@ -0,0 +1,67 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestComputeTimingStats(t *testing.T) {
+	var results []Result
+	for i := 1; i <= 20; i++ {
+		results = append(results, Result{
+			FilePath: fmt.Sprintf("book%02d.epub", i),
+			Duration: time.Duration(i) * time.Second,
+			Bytes:    1 << 20,
+		})
+	}
+	// Loaded from an old report: no timing
+	results = append(results, Result{FilePath: "old.epub"})
+
+	stats := ComputeTimingStats(results, 10*time.Second)
+	if stats.Files != 20 || stats.Bytes != 20<<20 {
+		t.Errorf("Files, Bytes = %d, %d", stats.Files, stats.Bytes)
+	}
+	if stats.FilesPerSecond != 2 || stats.BytesPerSecond != 2<<20 {
+		t.Errorf("Throughput = %v files/s, %v B/s", stats.FilesPerSecond, stats.BytesPerSecond)
+	}
+	if stats.P50 != 10*time.Second || stats.P90 != 18*time.Second || stats.P99 != 20*time.Second || stats.Max != 20*time.Second {
+		t.Errorf("Percentiles = %v %v %v %v", stats.P50, stats.P90, stats.P99, stats.Max)
+	}
+	if len(stats.Slowest) != SlowestFilesKept || stats.Slowest[0].FilePath != "book20.epub" || stats.Slowest[9].FilePath != "book11.epub" {
+		t.Errorf("Slowest = %+v", stats.Slowest)
+	}
+
+	if got := ComputeTimingStats([]Result{{FilePath: "old.epub"}}, time.Second); got.Files != 0 || got.Slowest != nil {
+		t.Errorf("Expected empty stats without durations, got %+v", got)
+	}
+}
+
+func TestComputeTimingStats_SingleFile(t *testing.T) {
+	stats := ComputeTimingStats([]Result{{FilePath: "a.pdf", Duration: time.Second}}, 0)
+	if stats.P50 != time.Second || stats.P99 != time.Second || stats.FilesPerSecond != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestBatchProcessor_RecordsTiming(t *testing.T) {
+	taskRunner = func(ctx context.Context, config BatchConfig, task Task) Result {
+		time.Sleep(5 * time.Millisecond)
+		return Result{FilePath: task.FilePath, Phases: PhaseTimings{Validate: 4 * time.Millisecond}}
+	}
+	defer func() { taskRunner = runOperation }()
+
+	path := filepath.Join(t.TempDir(), "book.epub")
+	if err := os.WriteFile(path, make([]byte, 1234), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := NewBatchProcessor(context.Background(), DefaultBatchConfig()).Execute([]string{path}, OperationValidate)
+
+	r := results[0]
+	if r.Duration < 5*time.Millisecond || r.Bytes != 1234 || r.Phases.Validate != 4*time.Millisecond {
+		t.Errorf("Expected timing to be recorded, got duration %v, %d bytes, phases %+v", r.Duration, r.Bytes, r.Phases)
+	}
+}
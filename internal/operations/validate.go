@@ -30,7 +30,7 @@ func (v *ValidateOperation) Execute(filePath string) (*ebmlib.ValidationReport,
 	case ".pdf":
 		return ebmlib.ValidatePDFWithContext(v.ctx, filePath)
 	default:
-		return nil, fmt.Errorf("unsupported file type: %s (expected .epub or .pdf)", ext)
+		return nil, fmt.Errorf("%w: %s (expected .epub or .pdf)", ErrUnsupportedType, ext)
 	}
 }
 
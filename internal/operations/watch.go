@@ -0,0 +1,437 @@
+package operations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchConfig configures a Watcher
+type WatchConfig struct {
+	Dir        string
+	Operation  OperationType // OperationValidate or OperationRepair
+	Batch      BatchConfig   // Used to process the files that have settled
+	Extensions []string      // e.g., []string{".epub", ".pdf"}; empty for both
+
+	// Destinations by outcome; relative paths are under Dir
+	ValidDir   string
+	InvalidDir string
+	ErrorDir   string
+
+	Settle       time.Duration // How long a file must stay unchanged before it is processed
+	PollInterval time.Duration // How often settling files are checked, and Dir scanned when polling
+	Poll         bool          // Scan Dir instead of using file system notifications
+}
+
+// DefaultWatchConfig returns the watch settings for dir: validate EPUB and
+// PDF files and sort them into valid, invalid and error folders inside it
+func DefaultWatchConfig(dir string) WatchConfig {
+	return WatchConfig{
+		Dir:          dir,
+		Operation:    OperationValidate,
+		Batch:        DefaultBatchConfig(),
+		Extensions:   []string{".epub", ".pdf"},
+		ValidDir:     "valid",
+		InvalidDir:   "invalid",
+		ErrorDir:     "error",
+		Settle:       2 * time.Second,
+		PollInterval: time.Second,
+	}
+}
+
+// WatchEventKind is what happened to a watched file
+type WatchEventKind string
+
+const (
+	WatchStarted  WatchEventKind = "started"  // Watching began; Detail names the method
+	WatchDetected WatchEventKind = "detected" // A new or changed file appeared
+	WatchValid    WatchEventKind = "valid"    // Processed and found valid, or repaired
+	WatchInvalid  WatchEventKind = "invalid"  // Processed and found invalid, or could not be repaired
+	WatchErrored  WatchEventKind = "error"    // Could not be processed
+)
+
+// WatchEvent is an entry of the watch log
+type WatchEvent struct {
+	Time    time.Time
+	Kind    WatchEventKind
+	Path    string
+	Dest    string  // Where a processed file was moved
+	Detail  string  // The watch method, or why a file was invalid or errored
+	MoveErr error   // Why a processed file could not be moved
+	Result  *Result // For processed files
+}
+
+// WatchCounts counts watch events by kind
+type WatchCounts struct {
+	Detected   int
+	Valid      int
+	Invalid    int
+	Errored    int
+	MoveFailed int // Processed files left in place
+}
+
+// WatchWindow is the span of the rolling counts in WatchSummary
+const WatchWindow = time.Hour
+
+// WatchRecentKept is the number of processed files listed in WatchSummary
+const WatchRecentKept = 10
+
+// WatchSummary is the state of a watch so far
+type WatchSummary struct {
+	Dir      string
+	Method   string // "inotify" or "polling"
+	Started  time.Time
+	Settling int          // Files waiting to stop changing
+	Total    WatchCounts  // Since the watch started
+	Recent   WatchCounts  // Within the last WatchWindow
+	Files    []WatchEvent // The last processed files, oldest first
+}
+
+// Watcher processes the files that arrive in a directory and moves each to
+// the destination for its outcome
+type Watcher struct {
+	config WatchConfig
+	dir    string                    // Absolute
+	dests  map[WatchEventKind]string // Absolute destination by outcome
+	routes RouteRules
+
+	// Owned by Run
+	settling  map[string]*settlingFile
+	processed map[string]fileState // State when processed, for files left in place
+
+	mu      sync.Mutex
+	method  string
+	started time.Time
+	total   WatchCounts
+	window  []windowEvent // Events within WatchWindow, oldest first
+	files   []WatchEvent
+	waiting int
+}
+
+// windowEvent is what the rolling counts keep of an event
+type windowEvent struct {
+	time       time.Time
+	kind       WatchEventKind
+	moveFailed bool
+}
+
+// fileState is what is compared to tell that a file changed
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+type settlingFile struct {
+	state fileState
+	since time.Time // When state was first seen
+}
+
+// NewWatcher checks the config and resolves the destinations of a watch
+func NewWatcher(config WatchConfig) (*Watcher, error) {
+	info, err := os.Stat(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("watch directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("watch directory: %s is not a directory", config.Dir)
+	}
+	dir, err := filepath.Abs(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("watch directory: %w", err)
+	}
+	if config.PollInterval <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive")
+	}
+
+	w := &Watcher{
+		config:    config,
+		dir:       dir,
+		dests:     make(map[WatchEventKind]string),
+		settling:  make(map[string]*settlingFile),
+		processed: make(map[string]fileState),
+	}
+	for kind, dest := range map[WatchEventKind]string{WatchValid: config.ValidDir, WatchInvalid: config.InvalidDir, WatchErrored: config.ErrorDir} {
+		if dest == "" {
+			return nil, fmt.Errorf("no destination for %s files", kind)
+		}
+		if !filepath.IsAbs(dest) {
+			dest = filepath.Join(dir, dest)
+		}
+		if filepath.Clean(dest) == dir {
+			return nil, fmt.Errorf("the %s destination cannot be the watched directory", kind)
+		}
+		w.dests[kind] = filepath.Clean(dest)
+	}
+	w.routes = RouteRules{
+		{Outcome: OutcomeValid, Dest: w.dests[WatchValid]},
+		{Outcome: OutcomeInvalid, Dest: w.dests[WatchInvalid]},
+		{Outcome: OutcomeReverted, Dest: w.dests[WatchInvalid]},
+		{Outcome: OutcomeErrored, Dest: w.dests[WatchErrored]},
+	}
+	return w, nil
+}
+
+// Dest returns the absolute destination of files with the given outcome
+func (w *Watcher) Dest(kind WatchEventKind) string {
+	return w.dests[kind]
+}
+
+// Run watches until ctx is cancelled, calling onEvent for every event.
+// Files already in the directory are processed first. It uses file system
+// notifications where available and falls back to scanning the directory
+// every PollInterval.
+func (w *Watcher) Run(ctx context.Context, onEvent func(WatchEvent)) error {
+	for _, dest := range w.dests {
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return fmt.Errorf("failed to create destination: %w", err)
+		}
+	}
+
+	var notify <-chan string
+	method, detail := "polling", "polling"
+	if !w.config.Poll {
+		ch, err := watchNotify(ctx, w.dir)
+		if err == nil {
+			notify, method, detail = ch, "inotify", "inotify"
+		} else {
+			detail = fmt.Sprintf("polling, notifications unavailable: %v", err)
+		}
+	}
+	w.mu.Lock()
+	w.method, w.started = method, time.Now()
+	w.mu.Unlock()
+	w.emit(onEvent, WatchEvent{Kind: WatchStarted, Path: w.dir, Detail: detail})
+
+	w.scan(onEvent)
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case name, ok := <-notify:
+			switch {
+			case !ok:
+				// The watch was removed, e.g. with the directory
+				notify = nil
+				w.mu.Lock()
+				w.method = "polling"
+				w.mu.Unlock()
+			case name == "":
+				w.scan(onEvent) // Events were lost
+			default:
+				w.notice(filepath.Join(w.dir, name), onEvent)
+			}
+
+		case <-ticker.C:
+			if notify == nil {
+				w.scan(onEvent)
+			}
+			if ready := w.settled(time.Now()); len(ready) > 0 {
+				w.process(ctx, ready, onEvent)
+			}
+		}
+	}
+}
+
+// scan notices every file in the directory
+func (w *Watcher) scan(onEvent func(WatchEvent)) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		w.notice(filepath.Join(w.dir, entry.Name()), onEvent)
+	}
+}
+
+// notice starts waiting for a new or changed file to settle
+func (w *Watcher) notice(path string, onEvent func(WatchEvent)) {
+	if _, ok := w.settling[path]; ok || !w.candidate(filepath.Base(path)) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	state := fileState{size: info.Size(), modTime: info.ModTime()}
+	if done, ok := w.processed[path]; ok && done == state {
+		return // Left in place after a failed move
+	}
+	w.settling[path] = &settlingFile{state: state, since: time.Now()}
+	w.setWaiting()
+	w.emit(onEvent, WatchEvent{Kind: WatchDetected, Path: path})
+}
+
+// candidate reports whether a file name has a watched extension. Hidden
+// files, such as partial downloads, are skipped.
+func (w *Watcher) candidate(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	return matchExtension(name, w.config.Extensions)
+}
+
+// settled returns the files unchanged for the settle time and stops
+// tracking files that disappeared
+func (w *Watcher) settled(now time.Time) []string {
+	var ready []string
+	for path, file := range w.settling {
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(w.settling, path)
+			continue
+		}
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		switch {
+		case state != file.state:
+			file.state, file.since = state, now
+		case now.Sub(file.since) >= w.config.Settle:
+			ready = append(ready, path)
+			delete(w.settling, path)
+		}
+	}
+	slices.Sort(ready)
+	w.setWaiting()
+	return ready
+}
+
+// process runs the operation on settled files and moves each to its
+// destination. Files cancelled with the watch are left in place.
+func (w *Watcher) process(ctx context.Context, files []string, onEvent func(WatchEvent)) {
+	inputs := make([]Input, len(files))
+	for i, f := range files {
+		inputs[i] = Input{Path: f, Root: w.dir}
+	}
+	results := NewBatchProcessor(ctx, w.config.Batch).ExecuteInputs(inputs, w.config.Operation)
+	for i := range results {
+		r := results[i]
+		if ctx.Err() != nil && r.ErrorKind == ErrorKindCanceled {
+			continue
+		}
+		kind, detail := watchOutcome(r)
+		event := WatchEvent{Kind: kind, Path: r.FilePath, Detail: detail, Result: &r}
+		if routed, ok := w.routes.Route(r, w.dir); ok {
+			event.Dest, event.MoveErr = routed.Dest, routed.Err
+		}
+		if event.MoveErr != nil {
+			event.Dest = ""
+			if info, err := os.Stat(r.FilePath); err == nil {
+				w.processed[r.FilePath] = fileState{size: info.Size(), modTime: info.ModTime()}
+			}
+		} else {
+			delete(w.processed, r.FilePath)
+		}
+		w.emit(onEvent, event)
+	}
+}
+
+// watchOutcome classifies a result as liveStats does, with the reason a
+// file failed
+func watchOutcome(r Result) (WatchEventKind, string) {
+	switch {
+	case r.Error != nil:
+		if r.ErrorKind != "" {
+			return WatchErrored, fmt.Sprintf("%s: %v", r.ErrorKind, r.Error)
+		}
+		return WatchErrored, r.Error.Error()
+	case r.Report != nil && !r.Report.IsValid:
+		return WatchInvalid, fmt.Sprintf("%d errors", r.Report.ErrorCount())
+	case r.Repair != nil && !r.Repair.Success:
+		if r.Repair.Error != nil {
+			return WatchInvalid, r.Repair.Error.Error()
+		}
+		return WatchInvalid, "repair failed"
+	case r.Repair != nil && len(r.Repair.ActionsApplied) > 0:
+		return WatchValid, "repaired"
+	}
+	return WatchValid, ""
+}
+
+// emit records an event in the summary and passes it on
+func (w *Watcher) emit(onEvent func(WatchEvent), event WatchEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	w.mu.Lock()
+	w.total.add(event.Kind, event.MoveErr != nil)
+	if event.Kind != WatchStarted {
+		w.pruneWindow(event.Time)
+		w.window = append(w.window, windowEvent{time: event.Time, kind: event.Kind, moveFailed: event.MoveErr != nil})
+	}
+	if event.Result != nil {
+		if len(w.files) == WatchRecentKept {
+			w.files = append(w.files[:0], w.files[1:]...)
+		}
+		w.files = append(w.files, event)
+	}
+	w.mu.Unlock()
+	if onEvent != nil {
+		onEvent(event)
+	}
+}
+
+// setWaiting publishes the number of settling files for Summary
+func (w *Watcher) setWaiting() {
+	w.mu.Lock()
+	w.waiting = len(w.settling)
+	w.mu.Unlock()
+}
+
+// Summary returns the counts so far and over the last WatchWindow. It is
+// safe to call while Run is running.
+func (w *Watcher) Summary() WatchSummary {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pruneWindow(time.Now())
+	summary := WatchSummary{
+		Dir:      w.dir,
+		Method:   w.method,
+		Started:  w.started,
+		Settling: w.waiting,
+		Total:    w.total,
+		Files:    append([]WatchEvent(nil), w.files...),
+	}
+	for _, event := range w.window {
+		summary.Recent.add(event.kind, event.moveFailed)
+	}
+	return summary
+}
+
+// pruneWindow drops the events older than WatchWindow before now, so a
+// long watch nobody asks for a summary of does not keep them all. The
+// caller holds w.mu.
+func (w *Watcher) pruneWindow(now time.Time) {
+	cutoff := now.Add(-WatchWindow)
+	i := 0
+	for i < len(w.window) && w.window[i].time.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		w.window = append(w.window[:0], w.window[i:]...)
+	}
+}
+
+// add counts an event
+func (c *WatchCounts) add(kind WatchEventKind, moveFailed bool) {
+	switch kind {
+	case WatchDetected:
+		c.Detected++
+	case WatchValid:
+		c.Valid++
+	case WatchInvalid:
+		c.Invalid++
+	case WatchErrored:
+		c.Errored++
+	}
+	if moveFailed {
+		c.MoveFailed++
+	}
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package operations
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// watchNotify reports the names of files created, written or moved into
+// dir using inotify. An empty name means events were lost and dir should be
+// scanned. The channel is closed when ctx is done or the watch is removed.
+func watchNotify(ctx context.Context, dir string) (<-chan string, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify: %w", err)
+	}
+	const mask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO
+	if _, err := syscall.InotifyAddWatch(fd, dir, mask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("inotify: %w", err)
+	}
+
+	// A non-blocking descriptor uses the runtime poller, so closing the
+	// file ends a pending Read
+	file := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		_ = file.Close()
+	}()
+
+	names := make(chan string)
+	go func() {
+		defer close(names)
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				eventMask := binary.NativeEndian.Uint32(buf[offset+4:])
+				nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+				start := offset + syscall.SizeofInotifyEvent
+				name := strings.TrimRight(string(buf[start:min(start+nameLen, n)]), "\x00")
+				offset = start + nameLen
+
+				switch {
+				case eventMask&syscall.IN_IGNORED != 0:
+					return
+				case eventMask&syscall.IN_Q_OVERFLOW != 0:
+					name = ""
+				case name == "":
+					continue
+				}
+				select {
+				case names <- name:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return names, nil
+}
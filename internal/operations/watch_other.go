@@ -0,0 +1,14 @@
+//go:build !linux
+
+package operations
+
+import (
+	"context"
+	"errors"
+)
+
+// watchNotify is unavailable on this platform; watches scan the directory
+// instead
+func watchNotify(ctx context.Context, dir string) (<-chan string, error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -0,0 +1,220 @@
+package operations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
+)
+
+// fakeWatchRunner validates files by name: "bad" files are invalid and
+// "broken" files cannot be read
+func fakeWatchRunner(ctx context.Context, config BatchConfig, task Task) Result {
+	name := filepath.Base(task.FilePath)
+	switch {
+	case strings.HasPrefix(name, "broken"):
+		return Result{FilePath: task.FilePath, Error: errors.New("zip: not a valid zip file")}
+	case strings.HasPrefix(name, "bad"):
+		return Result{FilePath: task.FilePath, Report: &ebmlib.ValidationReport{Errors: make([]ebmlib.ValidationError, 2)}}
+	}
+	return Result{FilePath: task.FilePath, Report: &ebmlib.ValidationReport{IsValid: true}}
+}
+
+func testWatchConfig(dir string, poll bool) WatchConfig {
+	config := DefaultWatchConfig(dir)
+	config.Batch.NumWorkers = 2
+	config.Settle = 20 * time.Millisecond
+	config.PollInterval = 10 * time.Millisecond
+	config.Poll = poll
+	return config
+}
+
+func TestWatcher_RoutesFiles(t *testing.T) {
+	taskRunner = fakeWatchRunner
+	defer func() { taskRunner = runOperation }()
+
+	for _, poll := range []bool{false, true} {
+		dir := t.TempDir()
+		writeTestFile(t, filepath.Join(dir, "good.epub"), "existing")
+
+		w, err := NewWatcher(testWatchConfig(dir, poll))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		events := make(chan WatchEvent, 100)
+		done := make(chan error)
+		go func() { done <- w.Run(ctx, func(e WatchEvent) { events <- e }) }()
+
+		// Wait for the watch to start before adding files
+		if e := <-events; e.Kind != WatchStarted {
+			t.Fatalf("Expected a started event first, got %+v", e)
+		}
+		writeTestFile(t, filepath.Join(dir, "bad.pdf"), "new")
+		writeTestFile(t, filepath.Join(dir, "broken.epub"), "new")
+		writeTestFile(t, filepath.Join(dir, "notes.txt"), "ignored")
+		writeTestFile(t, filepath.Join(dir, ".partial.epub"), "ignored")
+
+		processed := make(map[string]WatchEvent)
+		timeout := time.After(5 * time.Second)
+		for len(processed) < 3 {
+			select {
+			case e := <-events:
+				if e.Result != nil {
+					processed[filepath.Base(e.Path)] = e
+				}
+			case <-timeout:
+				t.Fatalf("poll=%v: timed out with %d files processed", poll, len(processed))
+			}
+		}
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		want := map[string]WatchEventKind{"good.epub": WatchValid, "bad.pdf": WatchInvalid, "broken.epub": WatchErrored}
+		for name, kind := range want {
+			e := processed[name]
+			wantDest := filepath.Join(w.Dest(kind), name)
+			if e.Kind != kind || e.Dest != wantDest || e.MoveErr != nil {
+				t.Errorf("poll=%v: %s: expected %s moved to %s, got %+v", poll, name, kind, wantDest, e)
+			}
+			if _, err := os.Stat(wantDest); err != nil {
+				t.Errorf("poll=%v: expected %s to exist: %v", poll, wantDest, err)
+			}
+		}
+		if processed["bad.pdf"].Detail != "2 errors" {
+			t.Errorf("Expected the invalid file's error count, got %q", processed["bad.pdf"].Detail)
+		}
+		for _, name := range []string{"notes.txt", ".partial.epub"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Errorf("Expected %s to be left alone: %v", name, err)
+			}
+		}
+
+		summary := w.Summary()
+		if summary.Total.Detected != 3 || summary.Total.Valid != 1 || summary.Total.Invalid != 1 || summary.Total.Errored != 1 {
+			t.Errorf("poll=%v: unexpected totals %+v", poll, summary.Total)
+		}
+		if summary.Recent != summary.Total || len(summary.Files) != 3 {
+			t.Errorf("poll=%v: expected the recent counts to match, got %+v with %d files", poll, summary.Recent, len(summary.Files))
+		}
+		if poll && summary.Method != "polling" {
+			t.Errorf("Expected polling, got %q", summary.Method)
+		}
+	}
+}
+
+func TestWatcher_WaitsForFileToSettle(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "book.epub")
+	writeTestFile(t, path, "part")
+
+	config := testWatchConfig(dir, true)
+	config.Settle = time.Minute
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.notice(path, nil)
+	start := w.settling[path].since
+
+	if ready := w.settled(start.Add(30 * time.Second)); len(ready) != 0 {
+		t.Fatalf("Expected the file to be settling, got %v", ready)
+	}
+
+	// Growing restarts the wait
+	writeTestFile(t, path, "part and more")
+	if ready := w.settled(start.Add(time.Minute)); len(ready) != 0 {
+		t.Fatalf("Expected a growing file to keep settling, got %v", ready)
+	}
+	if ready := w.settled(start.Add(2 * time.Minute)); len(ready) != 1 || ready[0] != path {
+		t.Fatalf("Expected the file to have settled, got %v", ready)
+	}
+	if summary := w.Summary(); summary.Settling != 0 || summary.Total.Detected != 1 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	// A file that disappears is forgotten
+	w.notice(path, nil)
+	os.Remove(path)
+	if ready := w.settled(start.Add(time.Hour)); len(ready) != 0 || len(w.settling) != 0 {
+		t.Errorf("Expected a removed file to be dropped, got %v", ready)
+	}
+}
+
+func TestNewWatcher_Destinations(t *testing.T) {
+	dir := t.TempDir()
+	elsewhere := t.TempDir()
+
+	config := DefaultWatchConfig(dir)
+	config.ErrorDir = elsewhere
+	w, err := NewWatcher(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs, _ := filepath.Abs(dir)
+	if w.Dest(WatchValid) != filepath.Join(abs, "valid") || w.Dest(WatchErrored) != elsewhere {
+		t.Errorf("Unexpected destinations %v", w.dests)
+	}
+
+	config.InvalidDir = "."
+	if _, err := NewWatcher(config); err == nil {
+		t.Error("Expected an error for a destination that is the watched directory")
+	}
+	if _, err := NewWatcher(DefaultWatchConfig(filepath.Join(dir, "missing"))); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
+
+func TestWatchOutcome(t *testing.T) {
+	tests := []struct {
+		result Result
+		kind   WatchEventKind
+		detail string
+	}{
+		{Result{Report: &ebmlib.ValidationReport{IsValid: true}}, WatchValid, ""},
+		{Result{Error: errors.New("boom"), ErrorKind: ErrorKindCorrupt}, WatchErrored, "corrupt: boom"},
+		{Result{Repair: &ebmlib.RepairResult{}}, WatchInvalid, "repair failed"},
+		{Result{Repair: &ebmlib.RepairResult{Success: true, ActionsApplied: make([]ebmlib.RepairAction, 1)}}, WatchValid, "repaired"},
+	}
+	for _, tt := range tests {
+		kind, detail := watchOutcome(tt.result)
+		if kind != tt.kind || detail != tt.detail {
+			t.Errorf("watchOutcome(%+v) = %s, %q; want %s, %q", tt.result, kind, detail, tt.kind, tt.detail)
+		}
+	}
+}
+
+func TestWatcher_EmitPrunesWindow(t *testing.T) {
+	w, err := NewWatcher(DefaultWatchConfig(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * WatchWindow)
+	for i := 0; i < 3; i++ {
+		w.emit(nil, WatchEvent{Time: old, Kind: WatchDetected, Path: "old.epub"})
+	}
+	w.emit(nil, WatchEvent{Kind: WatchInvalid, Path: "new.epub", MoveErr: errors.New("denied")})
+
+	// Without a call to Summary, the old events are dropped as new ones come
+	if len(w.window) != 1 {
+		t.Errorf("Expected only the new event in the window, got %d", len(w.window))
+	}
+	summary := w.Summary()
+	if summary.Total.Detected != 3 || summary.Recent.Detected != 0 || summary.Recent.Invalid != 1 || summary.Recent.MoveFailed != 1 {
+		t.Errorf("Total = %+v, Recent = %+v", summary.Total, summary.Recent)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,359 @@
+// Package trash moves deleted files and folders to a trash directory laid
+// out as the freedesktop.org trash, so they can be restored. The files are
+// kept in files/, and info/ holds a NAME.trashinfo file for each, giving its
+// original path and when it was deleted; together the info files are the
+// index of the trash. The trash is ebm's own, not the desktop's, so listing
+// and emptying it never touch what other programs deleted.
+package trash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	infoExt    = ".trashinfo"
+	dateLayout = "2006-01-02T15:04:05" // Local time, as the specification says
+)
+
+// Trash is a trash directory
+type Trash struct {
+	Dir string
+}
+
+// Entry is a file or folder in the trash
+type Entry struct {
+	Name    string    // Name in the trash, unique within it
+	Path    string    // Where it was deleted from
+	Deleted time.Time // When it was deleted
+}
+
+// New returns the trash in dir, or ebm's trash when dir is empty
+func New(dir string) *Trash {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	return &Trash{Dir: dir}
+}
+
+// DefaultDir returns ebm's trash, $XDG_DATA_HOME/ebm/trash, or
+// ~/.local/share/ebm/trash when XDG_DATA_HOME is unset
+func DefaultDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "ebm", "trash")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "ebm-trash")
+	}
+	return filepath.Join(home, ".local", "share", "ebm", "trash")
+}
+
+func (t *Trash) filesDir() string { return filepath.Join(t.Dir, "files") }
+func (t *Trash) infoDir() string  { return filepath.Join(t.Dir, "info") }
+
+// Put moves a file or folder to the trash. Names already in the trash get
+// a suffix, so book.epub may become book_1.epub. Files on another
+// filesystem than the trash are copied into it, synced to disk, and only
+// then removed.
+func (t *Trash) Put(path string) (Entry, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return Entry{}, err
+	}
+	for _, dir := range []string{t.filesDir(), t.infoDir()} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return Entry{}, fmt.Errorf("failed to create trash: %w", err)
+		}
+	}
+
+	entry := Entry{Path: path, Deleted: time.Now().Truncate(time.Second)}
+	info, err := t.reserve(filepath.Base(path), &entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	dst := filepath.Join(t.filesDir(), entry.Name)
+	if err := move(path, dst); err != nil {
+		if _, statErr := os.Lstat(dst); statErr == nil {
+			// Copied in full but not all removed: the entry stays restorable
+			return entry, fmt.Errorf("failed to remove %s after copying it to the trash: %w", path, err)
+		}
+		_ = os.Remove(info)
+		return Entry{}, fmt.Errorf("failed to move to trash: %w", err)
+	}
+	return entry, nil
+}
+
+// reserve writes the info file of an entry under a free name, which claims
+// the name, and returns its path
+func (t *Trash) reserve(name string, entry *Entry) (string, error) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: entry.Path}).EscapedPath(), entry.Deleted.Format(dateLayout))
+	for i := 0; ; i++ {
+		entry.Name = name
+		if i > 0 {
+			entry.Name = fmt.Sprintf("%s_%d%s", stem, i, ext)
+		}
+		if _, err := os.Lstat(filepath.Join(t.filesDir(), entry.Name)); err == nil {
+			continue // Left behind without an info file
+		}
+		info := filepath.Join(t.infoDir(), entry.Name+infoExt)
+		f, err := os.OpenFile(info, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write trash info: %w", err)
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(info)
+			return "", fmt.Errorf("failed to write trash info: %w", err)
+		}
+		return info, nil
+	}
+}
+
+// List returns the entries of the trash, oldest first. Files without an
+// info file, such as those left by a failed move, are not listed, and
+// entries whose info file cannot be read are skipped and returned as
+// errors instead.
+func (t *Trash) List() ([]Entry, []error, error) {
+	infos, err := os.ReadDir(t.infoDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+	var entries []Entry
+	var skipped []error
+	for _, info := range infos {
+		name, ok := strings.CutSuffix(info.Name(), infoExt)
+		if !ok || info.IsDir() {
+			continue
+		}
+		entry, err := t.readInfo(name)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Deleted.Before(entries[j].Deleted) })
+	return entries, skipped, nil
+}
+
+// readInfo reads the info file of the entry called name
+func (t *Trash) readInfo(name string) (Entry, error) {
+	f, err := os.Open(filepath.Join(t.infoDir(), name+infoExt))
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to read trash info: %w", err)
+	}
+	defer f.Close()
+
+	entry := Entry{Name: name}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if entry.Path, err = url.PathUnescape(value); err != nil {
+				return Entry{}, fmt.Errorf("invalid trash info for %s: %w", name, err)
+			}
+		case "DeletionDate":
+			if entry.Deleted, err = time.ParseInLocation(dateLayout, value, time.Local); err != nil {
+				return Entry{}, fmt.Errorf("invalid trash info for %s: %w", name, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Entry{}, fmt.Errorf("failed to read trash info: %w", err)
+	}
+	if entry.Path == "" {
+		return Entry{}, fmt.Errorf("invalid trash info for %s: no Path", name)
+	}
+	return entry, nil
+}
+
+// Find returns the entry called name, or else the last one deleted from
+// the path name
+func (t *Trash) Find(name string) (Entry, error) {
+	entries, _, err := t.List()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, entry := range entries {
+		if entry.Name == name {
+			return entry, nil
+		}
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Path == abs {
+				return entries[i], nil
+			}
+		}
+	}
+	return Entry{}, fmt.Errorf("%s is not in the trash", name)
+}
+
+// Restore moves an entry back to where it was deleted from. A folder is
+// merged into a folder that has been created there since, but a file that
+// is already there is never overwritten.
+func (t *Trash) Restore(entry Entry) error {
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+	}
+	if err := restoreInto(filepath.Join(t.filesDir(), entry.Name), entry.Path); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", entry.Path, err)
+	}
+	return os.Remove(filepath.Join(t.infoDir(), entry.Name+infoExt))
+}
+
+// restoreInto moves src to dst, merging folders
+func restoreInto(src, dst string) error {
+	dstInfo, err := os.Lstat(dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return move(src, dst)
+	}
+	if err != nil {
+		return err
+	}
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !srcInfo.IsDir() || !dstInfo.IsDir() {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	children, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := restoreInto(filepath.Join(src, child.Name()), filepath.Join(dst, child.Name())); err != nil {
+			return err
+		}
+	}
+	return os.Remove(src)
+}
+
+// Empty permanently deletes the entries deleted before the given time, and
+// returns those it deleted. An entry that cannot be deleted does not stop
+// the others, and entries that cannot be read are left alone.
+func (t *Trash) Empty(before time.Time) ([]Entry, error) {
+	entries, _, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+	var emptied []Entry
+	var errs []error
+	for _, entry := range entries {
+		if !entry.Deleted.Before(before) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(t.filesDir(), entry.Name)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.Remove(filepath.Join(t.infoDir(), entry.Name+infoExt)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		emptied = append(emptied, entry)
+	}
+	return emptied, errors.Join(errs...)
+}
+
+// move renames src to dst. Across filesystems it copies src, syncs the
+// copy and removes src, so that files can be trashed from other disks and
+// entries restored even when the trash has been moved since. A failed copy
+// is removed again; dst only exists after an error if src could not be
+// removed.
+func move(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies a file, link or folder, syncing each file copied
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.Mkdir(target, info.Mode().Perm()|0o700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return fmt.Errorf("cannot copy %s: not a regular file", path)
+		}
+	})
+}
+
+// copyFile copies a regular file and syncs the copy to disk
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
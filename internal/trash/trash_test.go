@@ -0,0 +1,238 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	if got := DefaultDir(); got != filepath.Join("/data", "ebm", "trash") {
+		t.Errorf("DefaultDir() = %s", got)
+	}
+	if got := New("").Dir; got != filepath.Join("/data", "ebm", "trash") {
+		t.Errorf("New(\"\") uses %s", got)
+	}
+}
+
+func TestTrash_PutListRestore(t *testing.T) {
+	lib := t.TempDir()
+	bin := New(t.TempDir())
+	first := filepath.Join(lib, "A", "my book.epub")
+	second := filepath.Join(lib, "B", "my book.epub")
+	writeFile(t, first, "first")
+	writeFile(t, second, "second")
+
+	e1, err := bin.Put(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, err := bin.Put(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e1.Name != "my book.epub" || e2.Name != "my book_1.epub" {
+		t.Errorf("Expected unique names, got %q and %q", e1.Name, e2.Name)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("Expected the file to leave the library, got %v", err)
+	}
+
+	info, err := os.ReadFile(filepath.Join(bin.Dir, "info", "my book.epub.trashinfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(info), "[Trash Info]\nPath=") || !strings.Contains(string(info), "my%20book.epub\nDeletionDate=") {
+		t.Errorf("Unexpected trash info:\n%s", info)
+	}
+
+	entries, _, err := bin.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Path != first || time.Since(entries[0].Deleted) > time.Minute {
+		t.Fatalf("Unexpected entries %+v", entries)
+	}
+
+	// A path finds the last file deleted from it
+	found, err := bin.Find(second)
+	if err != nil || found.Name != e2.Name {
+		t.Fatalf("Find(%s) = %+v, %v", second, found, err)
+	}
+	if _, err := bin.Find("nothing.epub"); err == nil {
+		t.Error("Expected an error for a name not in the trash")
+	}
+
+	writeFile(t, second, "new")
+	if err := bin.Restore(found); err == nil {
+		t.Error("Expected restoring over an existing file to fail")
+	}
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := bin.Restore(found); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(second); string(data) != "second" {
+		t.Errorf("Expected the file to be restored, got %q", data)
+	}
+	if entries, _, _ := bin.List(); len(entries) != 1 {
+		t.Errorf("Expected one entry left, got %+v", entries)
+	}
+}
+
+func TestTrash_ListSkipsInvalidInfo(t *testing.T) {
+	lib := t.TempDir()
+	bin := New(t.TempDir())
+	writeFile(t, filepath.Join(lib, "book.epub"), "book")
+	if _, err := bin.Put(filepath.Join(lib, "book.epub")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(bin.Dir, "info", "broken.epub.trashinfo"), "[Trash Info]\nDeletionDate=yesterday\n")
+
+	entries, skipped, err := bin.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "book.epub" {
+		t.Errorf("Expected the good entry to be listed, got %+v", entries)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "broken.epub") {
+		t.Errorf("Expected the broken entry to be skipped, got %v", skipped)
+	}
+
+	// Emptying leaves the entry it cannot read alone
+	if _, err := bin.Empty(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(bin.Dir, "info", "broken.epub.trashinfo")); err != nil {
+		t.Errorf("Expected the broken entry to be kept: %v", err)
+	}
+}
+
+func TestTrash_PutAcrossFilesystems(t *testing.T) {
+	lib, err := os.MkdirTemp("/dev/shm", "ebm-trash-test")
+	if err != nil {
+		t.Skip("no second filesystem to test with")
+	}
+	defer os.RemoveAll(lib)
+	book := filepath.Join(lib, "book.epub")
+	writeFile(t, book, "book")
+	writeFile(t, filepath.Join(lib, "Title", "cover.jpg"), "cover")
+	if err := os.Symlink("cover.jpg", filepath.Join(lib, "Title", "thumb.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	bin := New(t.TempDir())
+	if err := os.Link(book, filepath.Join(t.TempDir(), "probe")); err == nil {
+		t.Skip("the temporary directory is on the same filesystem")
+	}
+	bookEntry, err := bin.Put(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirEntry, err := bin.Put(filepath.Join(lib, "Title"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(book); !os.IsNotExist(err) {
+		t.Errorf("Expected the book to be removed, got %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(bin.Dir, "files", "Title", "cover.jpg")); err != nil || string(data) != "cover" {
+		t.Errorf("Expected the folder copied into the trash, got %q, %v", data, err)
+	}
+	if link, err := os.Readlink(filepath.Join(bin.Dir, "files", "Title", "thumb.jpg")); err != nil || link != "cover.jpg" {
+		t.Errorf("Expected the link copied as a link, got %q, %v", link, err)
+	}
+
+	for _, entry := range []Entry{bookEntry, dirEntry} {
+		if err := bin.Restore(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, err := os.ReadFile(book); err != nil || string(data) != "book" {
+		t.Errorf("Expected the book restored, got %q, %v", data, err)
+	}
+	if entries, _, _ := bin.List(); len(entries) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", entries)
+	}
+}
+
+func TestTrash_RestoreMergesFolders(t *testing.T) {
+	lib := t.TempDir()
+	bin := New(t.TempDir())
+	book := filepath.Join(lib, "Author", "Title", "book.epub")
+	writeFile(t, book, "book")
+	writeFile(t, filepath.Join(lib, "Author", "Title", "cover.jpg"), "cover")
+
+	bookEntry, err := bin.Put(book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirEntry, err := bin.Put(filepath.Join(lib, "Author", "Title"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The book comes back first, creating its folder again
+	if err := bin.Restore(bookEntry); err != nil {
+		t.Fatal(err)
+	}
+	if err := bin.Restore(dirEntry); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"book.epub", "cover.jpg"} {
+		if _, err := os.Stat(filepath.Join(lib, "Author", "Title", name)); err != nil {
+			t.Errorf("Expected %s to be restored: %v", name, err)
+		}
+	}
+}
+
+func TestTrash_Empty(t *testing.T) {
+	lib := t.TempDir()
+	bin := New(t.TempDir())
+	for _, name := range []string{"old.epub", "new.epub"} {
+		writeFile(t, filepath.Join(lib, name), name)
+		if _, err := bin.Put(filepath.Join(lib, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Age the first entry
+	info := filepath.Join(bin.Dir, "info", "old.epub.trashinfo")
+	old := time.Now().Add(-48 * time.Hour).Format(dateLayout)
+	content := "[Trash Info]\nPath=" + filepath.ToSlash(filepath.Join(lib, "old.epub")) + "\nDeletionDate=" + old + "\n"
+	if err := os.WriteFile(info, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	emptied, err := bin.Empty(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emptied) != 1 || emptied[0].Name != "old.epub" {
+		t.Fatalf("Expected only the old entry to go, got %+v", emptied)
+	}
+	if _, err := os.Stat(filepath.Join(bin.Dir, "files", "old.epub")); !os.IsNotExist(err) {
+		t.Errorf("Expected the old file to be deleted, got %v", err)
+	}
+
+	if emptied, err := bin.Empty(time.Now().Add(time.Second)); err != nil || len(emptied) != 1 {
+		t.Errorf("Expected the rest to go, got %+v, %v", emptied, err)
+	}
+	if entries, _, _ := bin.List(); len(entries) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", entries)
+	}
+}
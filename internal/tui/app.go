@@ -2,15 +2,16 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/petergi/ebook-mechanic-cli/internal/config"
 	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
 	"github.com/petergi/ebook-mechanic-cli/internal/tui/models"
 	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
 )
@@ -44,12 +45,18 @@ type App struct {
 	skipValidation     bool
 	noBackup           bool
 	aggressive         bool
-	removeSystemErrors bool // Remove books with system errors
-	moveFailedRepairs  bool // Move unrepairable books to INVALID folder
-	cleanupEmptyDirs   bool // Clean up empty parent directories after removal/move
+	removeSystemErrors bool                  // Remove books with system errors
+	moveFailedRepairs  bool                  // Move unrepairable books to INVALID folder
+	routes             operations.RouteRules // Where books are moved by outcome, from the route setting
+	cleanupEmptyDirs   bool                  // Clean up empty parent directories after removal/move
+	trash              *trash.Trash          // Where removed books and folders go
+	configPath         string                // User config file the settings screen saves to
+	profilesPath       string                // Profiles file the settings screen edits
+	profile            string                // Active settings profile, "" for none
 	width              int
 	height             int
 	progressCh         <-chan operations.ProgressUpdate
+	batch              *operations.BatchProcessor // Running batch, for pause and stop
 }
 
 // NewApp creates a new TUI application
@@ -68,9 +75,35 @@ func NewApp() App {
 		removeSystemErrors: false,
 		moveFailedRepairs:  false,
 		cleanupEmptyDirs:   true,
+		trash:              trash.New(""),
 	}
 }
 
+// withConfig takes the settings shown on the settings screen and the
+// routing rules from cfg, and saves changes to them to userFile or a
+// profile of profilesFile
+func (a App) withConfig(cfg *config.Config, userFile, profilesFile string) (App, error) {
+	routes, err := operations.ParseRouteRules(cfg.List("route"))
+	if err != nil {
+		return a, fmt.Errorf("route: %w", err)
+	}
+	a.routes = routes
+	a.batchJobs = cfg.Int("jobs", a.batchJobs)
+	a.skipValidation = cfg.Bool("skip-validation", a.skipValidation)
+	a.noBackup = cfg.Bool("no-backup", a.noBackup)
+	a.aggressive = cfg.Bool("aggressive", a.aggressive)
+	a.removeSystemErrors = cfg.Bool("remove-system-errors", a.removeSystemErrors)
+	a.moveFailedRepairs = cfg.Bool("move-failed-repairs", a.moveFailedRepairs)
+	a.cleanupEmptyDirs = cfg.Bool("cleanup-empty-dirs", a.cleanupEmptyDirs)
+	if s, ok := cfg.Lookup("trash-dir"); ok && s.Value != "" {
+		a.trash = trash.New(s.Value)
+	}
+	a.configPath = userFile
+	a.profilesPath = profilesFile
+	a.profile = cfg.Profile()
+	return a, nil
+}
+
 // Init initializes the application
 func (a App) Init() tea.Cmd {
 	return a.menuModel.Init()
@@ -166,7 +199,11 @@ func (a App) updateMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, a.browserModel.Init()
 
 		case "settings":
-			a.settingsModel = models.NewSettingsModel(a.batchJobs, a.skipValidation, a.noBackup, a.aggressive, a.removeSystemErrors, a.moveFailedRepairs, a.cleanupEmptyDirs, a.width, a.height)
+			a.settingsModel = models.NewSettingsModel(a.batchJobs, a.skipValidation, a.noBackup, a.aggressive, a.removeSystemErrors, a.moveFailedRepairs, a.cleanupEmptyDirs, a.width, a.height).
+				WithConfigPath(a.configPath)
+			if a.profilesPath != "" {
+				a.settingsModel = a.settingsModel.WithProfiles(a.profilesPath, a.profile)
+			}
 			a.state = StateSettings
 			return a, a.settingsModel.Init()
 
@@ -201,6 +238,13 @@ func (a App) updateSettings(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.removeSystemErrors = msg.RemoveSystemErrors
 		a.moveFailedRepairs = msg.MoveFailedRepairs
 		a.cleanupEmptyDirs = msg.CleanupEmptyDirs
+		a.profile = msg.Profile
+		if msg.Err != nil {
+			// The settings apply to this session; stay to show the error
+			m, _ := a.settingsModel.Update(msg)
+			a.settingsModel = m.(models.SettingsModel)
+			return a, nil
+		}
 		a.state = StateMenu
 		return a, nil
 
@@ -294,6 +338,12 @@ func (a App) updateProgress(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, a.reportModel.Init()
 
 		case operations.BatchResult:
+			if result.Incomplete {
+				// Cancelled: go straight to what was processed
+				a.reportModel = models.NewBatchReportModel(&result, a.width, a.height).WithTrash(a.trash)
+				a.state = StateReport
+				return a, a.reportModel.Init()
+			}
 			var m tea.Model
 			m, cmd = a.progressModel.Update(msg)
 			a.progressModel = m.(models.ProgressModel)
@@ -313,11 +363,27 @@ func (a App) updateProgress(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case models.ViewReportMsg:
 		switch result := msg.Result.(type) {
 		case operations.BatchResult:
-			a.reportModel = models.NewBatchReportModel(&result, a.width, a.height)
+			a.reportModel = models.NewBatchReportModel(&result, a.width, a.height).WithTrash(a.trash)
 			a.state = StateReport
 			return a, a.reportModel.Init()
 		}
 
+	case models.PauseBatchMsg:
+		if a.batch != nil {
+			if msg.Paused {
+				a.batch.Pause()
+			} else {
+				a.batch.Resume()
+			}
+		}
+		return a, nil
+
+	case models.StopBatchMsg:
+		if a.batch != nil {
+			a.batch.Stop()
+		}
+		return a, nil
+
 	case models.OperationCancelMsg:
 		a.cancel()
 		a.state = StateMenu
@@ -350,104 +416,75 @@ func (a App) startBatchDirectory(path string, opType operations.OperationType) (
 	a.progressCh = progressCh
 	doneCh := make(chan operations.BatchResult)
 
+	config := operations.DefaultBatchConfig()
+	if a.noBackup {
+		config.RepairMode = operations.RepairSaveModeNoBackup
+	} else {
+		config.RepairMode = operations.RepairSaveModeBackupOriginal
+	}
+	config.Aggressive = a.aggressive
+	if a.batchJobs > 0 {
+		config.NumWorkers = a.batchJobs
+	}
+	batch := operations.NewBatchProcessor(a.ctx, config)
+	a.batch = batch
+
+	// Everything the batch goroutine needs from the App is taken now, as the
+	// App keeps changing while it runs
+	ctx := a.ctx
+	options, cleanup := a.batchSettings(config, opType, path)
+
 	go func() {
 		// Emit initial scanning status
 		progressCh <- operations.ProgressUpdate{Completed: 0, Total: 0, Current: "Scanning library..."}
 
-		files, err := collectBatchFiles(path, func(count int, sample string) {
-			if count%200 == 0 {
-				progressCh <- operations.ProgressUpdate{Completed: count, Total: 0, Current: fmt.Sprintf("Scanning library... (%d)", count)}
-			}
-		})
-		if err != nil {
-			doneCh <- operations.BatchResult{
-				Failed: []operations.Result{{FilePath: path, Error: fmt.Errorf("batch scan failed: %w", err)}},
-				Total:  1,
-			}
-			close(progressCh)
-			return
-		}
-
-		// Announce total so the progress bar activates when batch starts
-		progressCh <- operations.ProgressUpdate{Completed: 0, Total: len(files), Current: fmt.Sprintf("Found %d files", len(files))}
-
-		config := operations.DefaultBatchConfig()
-		if a.noBackup {
-			config.RepairMode = operations.RepairSaveModeNoBackup
-		} else {
-			config.RepairMode = operations.RepairSaveModeBackupOriginal
-		}
-		config.Aggressive = a.aggressive
-		if a.batchJobs > 0 {
-			config.NumWorkers = a.batchJobs
-		}
-
-		batch := operations.NewBatchProcessor(a.ctx, config)
-		batchPath := path
 		batchStart := time.Now()
 
-		// Forward batch progress into our unified channel so the UI keeps streaming
+		// Forward batch progress into our unified channel so the UI keeps streaming.
+		// The total grows while the library is still being scanned.
 		go func() {
 			for update := range batch.ProgressChannel() {
 				progressCh <- update
 			}
 		}()
 
-		results := batch.Execute(files, opType)
-		aggregated := operations.AggregateResults(results, time.Since(batchStart), opType)
-
-		// Record the options used for this batch
-		aggregated.Options = operations.BatchOptions{
-			NumWorkers:         config.NumWorkers,
-			SkipValidation:     a.skipValidation,
-			NoBackup:           a.noBackup,
-			Aggressive:         a.aggressive,
-			RemoveSystemErrors: a.removeSystemErrors,
-			MoveFailedRepairs:  a.moveFailedRepairs,
-			CleanupEmptyDirs:   a.cleanupEmptyDirs,
-		}
+		// Stream files into the batch as they are found
+		inputs := make(chan operations.Input)
+		scanDone := make(chan error, 1)
+		go func() {
+			defer close(inputs)
+			scanDone <- walkBatchFiles(path, func(file string) error {
+				select {
+				case inputs <- operations.Input{Path: file}:
+					return nil
+				case <-batch.Stopping():
+					return errBatchStopped
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
 
-		// Capture intended cleanup lists (non-blocking), then perform cleanup async
-		if a.removeSystemErrors && len(aggregated.Errored) > 0 {
-			aggregated.RemovedFiles = make([]string, 0, len(aggregated.Errored))
-			for _, r := range aggregated.Errored {
-				aggregated.RemovedFiles = append(aggregated.RemovedFiles, r.FilePath)
-			}
+		results := batch.ExecuteStream(inputs, opType)
+		scanErr := <-scanDone
+		aggregated := operations.AggregateResults(results, time.Since(batchStart), opType)
+		if scanErr != nil && ctx.Err() == nil && !errors.Is(scanErr, errBatchStopped) {
+			// The files found before the scan failed are still reported
+			aggregated.ScanError = fmt.Errorf("batch scan failed: %w", scanErr)
 		}
-		if a.moveFailedRepairs && opType == operations.OperationRepair && len(aggregated.Invalid) > 0 {
-			aggregated.MovedFiles = make([]string, 0, len(aggregated.Invalid))
-			for _, r := range aggregated.Invalid {
-				aggregated.MovedFiles = append(aggregated.MovedFiles, r.FilePath)
-			}
+		if batch.Stopped() {
+			// Files still undiscovered when the scan stopped are not counted
+			aggregated.Incomplete = true
+			aggregated.Unprocessed = batch.Received() - len(results)
 		}
 
+		aggregated.Options = options
+
+		// The cleanup is only planned here; the report asks before applying it
+		aggregated.RecordCleanup(operations.PlanCleanup(&aggregated, cleanup))
+
 		doneCh <- aggregated
 		close(progressCh)
-
-		// Run filesystem cleanup asynchronously so the UI/progress is not blocked
-		go func(erred []operations.Result, invalid []operations.Result) {
-			// Remove errored files
-			if a.removeSystemErrors {
-				for _, r := range erred {
-					_ = os.Remove(r.FilePath)
-					if a.cleanupEmptyDirs {
-						removeEmptyParentDirs(filepath.Dir(r.FilePath), batchPath)
-					}
-				}
-			}
-			// Move invalid repairs
-			if a.moveFailedRepairs && opType == operations.OperationRepair {
-				invalidDir := filepath.Join(batchPath, "INVALID")
-				_ = os.MkdirAll(invalidDir, 0755)
-				for _, r := range invalid {
-					dstPath := filepath.Join(invalidDir, filepath.Base(r.FilePath))
-					_ = os.Rename(r.FilePath, dstPath)
-					if a.cleanupEmptyDirs {
-						removeEmptyParentDirs(filepath.Dir(r.FilePath), batchPath)
-					}
-				}
-			}
-		}(aggregated.Errored, aggregated.Invalid)
 	}()
 
 	return a, tea.Batch(
@@ -496,65 +533,26 @@ func (a App) startBatchWithFiles(files []string, action string) (tea.Model, tea.
 		config.NumWorkers = a.batchJobs
 	}
 	batch := operations.NewBatchProcessor(a.ctx, config)
+	a.batch = batch
 	doneCh := make(chan operations.BatchResult)
 	start := time.Now()
-	batchPath := filepath.Dir(files[0]) // Get common parent directory
+	// Taken now, as the App keeps changing while the batch runs
+	options, cleanup := a.batchSettings(config, opType, filepath.Dir(files[0]))
 
 	go func() {
 		results := batch.Execute(files, opType)
 		aggregated := operations.AggregateResults(results, time.Since(start), opType)
-
-		// Record the options used for this batch
-		aggregated.Options = operations.BatchOptions{
-			NumWorkers:         config.NumWorkers,
-			SkipValidation:     a.skipValidation,
-			NoBackup:           a.noBackup,
-			Aggressive:         a.aggressive,
-			RemoveSystemErrors: a.removeSystemErrors,
-			MoveFailedRepairs:  a.moveFailedRepairs,
-			CleanupEmptyDirs:   a.cleanupEmptyDirs,
+		if batch.Stopped() {
+			aggregated.Incomplete = true
+			aggregated.Unprocessed = len(files) - len(results)
 		}
 
-		// Capture intended cleanup lists (non-blocking), then perform cleanup async
-		if a.removeSystemErrors && len(aggregated.Errored) > 0 {
-			aggregated.RemovedFiles = make([]string, 0, len(aggregated.Errored))
-			for _, r := range aggregated.Errored {
-				aggregated.RemovedFiles = append(aggregated.RemovedFiles, r.FilePath)
-			}
-		}
-		if a.moveFailedRepairs && opType == operations.OperationRepair && len(aggregated.Invalid) > 0 {
-			aggregated.MovedFiles = make([]string, 0, len(aggregated.Invalid))
-			for _, r := range aggregated.Invalid {
-				aggregated.MovedFiles = append(aggregated.MovedFiles, r.FilePath)
-			}
-		}
+		aggregated.Options = options
 
-		doneCh <- aggregated
+		// The cleanup is only planned here; the report asks before applying it
+		aggregated.RecordCleanup(operations.PlanCleanup(&aggregated, cleanup))
 
-		// Run filesystem cleanup asynchronously so the UI/progress is not blocked
-		go func(erred []operations.Result, invalid []operations.Result) {
-			// Remove errored files
-			if a.removeSystemErrors {
-				for _, r := range erred {
-					_ = os.Remove(r.FilePath)
-					if a.cleanupEmptyDirs {
-						removeEmptyParentDirs(filepath.Dir(r.FilePath), batchPath)
-					}
-				}
-			}
-			// Move invalid repairs
-			if a.moveFailedRepairs && opType == operations.OperationRepair {
-				invalidDir := filepath.Join(batchPath, "INVALID")
-				_ = os.MkdirAll(invalidDir, 0755)
-				for _, r := range invalid {
-					dstPath := filepath.Join(invalidDir, filepath.Base(r.FilePath))
-					_ = os.Rename(r.FilePath, dstPath)
-					if a.cleanupEmptyDirs {
-						removeEmptyParentDirs(filepath.Dir(r.FilePath), batchPath)
-					}
-				}
-			}
-		}(aggregated.Errored, aggregated.Invalid)
+		doneCh <- aggregated
 	}()
 
 	a.progressCh = batch.ProgressChannel()
@@ -571,58 +569,55 @@ func (a App) startBatchWithFiles(files []string, action string) (tea.Model, tea.
 	)
 }
 
-func collectBatchFiles(path string, onFound func(count int, sample string)) ([]string, error) {
-	info, err := os.Stat(path)
-	if err != nil {
-		return nil, err
+// routeRules returns the routing rules of a batch, adding the rule of the
+// move failed repairs setting to repairs
+func (a App) routeRules(opType operations.OperationType) operations.RouteRules {
+	if a.moveFailedRepairs && opType == operations.OperationRepair {
+		return a.routes.WithMoveFailedRepairs()
 	}
+	return a.routes
+}
 
-	if !info.IsDir() {
-		if isEbookFile(path) {
-			return []string{path}, nil
-		}
-		return []string{}, nil
+// batchSettings returns the options recorded in the report of a batch and
+// the cleanup the settings ask for after it, for books found under batchPath
+func (a App) batchSettings(config operations.BatchConfig, opType operations.OperationType, batchPath string) (operations.BatchOptions, operations.CleanupOptions) {
+	rules := a.routeRules(opType)
+	options := operations.BatchOptions{
+		Profile:            a.profile,
+		NumWorkers:         config.NumWorkers,
+		SkipValidation:     a.skipValidation,
+		NoBackup:           a.noBackup,
+		Aggressive:         a.aggressive,
+		RemoveSystemErrors: a.removeSystemErrors,
+		MoveFailedRepairs:  a.moveFailedRepairs,
+		CleanupEmptyDirs:   a.cleanupEmptyDirs,
+		Routes:             rules.Strings(),
 	}
-
-	var files []string
-	err = filepath.WalkDir(path, func(entryPath string, entry fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-
-		if strings.HasPrefix(entry.Name(), ".") {
-			if entry.IsDir() && entryPath != path {
-				return filepath.SkipDir
-			}
-			if !entry.IsDir() {
-				return nil
-			}
-		}
-
-		if entry.IsDir() {
-			return nil
-		}
-
-		if isEbookFile(entryPath) {
-			files = append(files, entryPath)
-			if onFound != nil {
-				onFound(len(files), entryPath)
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
+	cleanup := operations.CleanupOptions{
+		Routes:     rules,
+		RemoveDirs: a.cleanupEmptyDirs,
+		RootOf:     func(operations.Result) string { return batchPath },
 	}
-
-	return files, nil
+	if a.removeSystemErrors {
+		// Only errors that will recur on every run are removed
+		cleanup.RemoveKinds = operations.DefaultRemovableKinds
+	}
+	return options, cleanup
 }
 
-func isEbookFile(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	return ext == ".epub" || ext == ".pdf"
+// errBatchStopped ends file discovery when the user cancels a batch
+var errBatchStopped = errors.New("batch stopped")
+
+// walkBatchFiles calls fn for each ebook under path as it is found. Hidden
+// files and directories are skipped and .ebmignore files are honoured the
+// same way as CLI discovery.
+func walkBatchFiles(path string, fn func(file string) error) error {
+	_, err := operations.Walk(path, operations.FindFilesOptions{
+		Recursive:  true,
+		MaxDepth:   -1,
+		SkipHidden: true,
+	}, fn)
+	return err
 }
 
 // updateReport handles report state updates
@@ -675,7 +670,7 @@ func (a App) updateCalibre(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case models.CalibreCleanupMsg:
 		// Perform cleanup
 		return a, func() tea.Msg {
-			dirs, files, err := models.CleanupCalibreLibrary(a.calibreModel.GetScanResult())
+			dirs, files, err := models.CleanupCalibreLibrary(a.calibreModel.GetScanResult(), a.trash)
 			return models.CalibreCleanupCompleteMsg{
 				CleanedDirs:  dirs,
 				CleanedFiles: files,
@@ -782,7 +777,14 @@ func (a App) startRepair(filePath string) (tea.Model, tea.Cmd) {
 
 // Run starts the TUI application
 func Run() error {
-	app := NewApp()
+	cfg, err := config.Load(config.DefaultLoadOptions("."))
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	app, err := NewApp().withConfig(cfg, config.UserPath(), config.ProfilesPath())
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
 	p := tea.NewProgram(app, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
@@ -801,94 +803,3 @@ func batchProgressCmd(ch <-chan operations.ProgressUpdate) tea.Cmd {
 		return models.ConvertBatchProgress(update)
 	}
 }
-
-// isCalibreMetadataDirectory checks if a directory contains only Calibre metadata files
-// (no ebooks, only cover.jpg, metadata.opf, .DS_Store, etc.)
-func isCalibreMetadataDirectory(dirPath string) bool {
-	entries, err := os.ReadDir(dirPath)
-	if err != nil {
-		return false
-	}
-
-	// Empty directory counts as removable
-	if len(entries) == 0 {
-		return true
-	}
-
-	// Check for ebook files - if any exist, not metadata-only
-	hasEbook := false
-	hasMetadata := false
-
-	for _, entry := range entries {
-		name := strings.ToLower(entry.Name())
-
-		// Skip hidden files and common system files
-		if strings.HasPrefix(name, ".") {
-			continue
-		}
-
-		if entry.IsDir() {
-			// Nested directory means not a simple Calibre metadata folder
-			return false
-		}
-
-		ext := filepath.Ext(name)
-		if ext == ".epub" || ext == ".pdf" || ext == ".mobi" || ext == ".azw" || ext == ".azw3" {
-			hasEbook = true
-			break
-		}
-
-		// Check for Calibre metadata files
-		if name == "cover.jpg" || name == "cover.jpeg" || name == "cover.png" ||
-			name == "metadata.opf" {
-			hasMetadata = true
-		}
-	}
-
-	// If no ebooks and has metadata files, or just empty/system files, it's metadata-only
-	return !hasEbook && (hasMetadata || len(entries) == 0)
-}
-
-// removeEmptyParentDirs removes empty parent directories and Calibre metadata-only directories up to the batch root
-// Bails out if a parent has more than 3 siblings to avoid scanning large shallow hierarchies
-func removeEmptyParentDirs(dir string, rootPath string) {
-	// Ensure both paths are absolute and clean
-	rootPath = filepath.Clean(rootPath)
-	dir = filepath.Clean(dir)
-
-	// Walk up the directory tree
-	for dir != rootPath && strings.HasPrefix(dir, rootPath) {
-		// Check sibling count before attempting removal
-		parent := filepath.Dir(dir)
-		if parent != rootPath { // Don't bail at root level
-			entries, err := os.ReadDir(parent)
-			if err == nil && len(entries) > 3 {
-				// Too many siblings, skip this entire branch to avoid performance hit
-				break
-			}
-		}
-
-		// First try to remove if it's a Calibre metadata-only directory
-		if isCalibreMetadataDirectory(dir) {
-			// Remove all files in the directory first
-			entries, err := os.ReadDir(dir)
-			if err == nil {
-				for _, entry := range entries {
-					if !entry.IsDir() {
-						_ = os.Remove(filepath.Join(dir, entry.Name()))
-					}
-				}
-			}
-		}
-
-		// Try to remove the directory if empty
-		err := os.Remove(dir)
-		if err != nil {
-			// Directory not empty or other error, stop
-			break
-		}
-
-		// Move to parent
-		dir = parent
-	}
-}
@@ -1,13 +1,19 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/petergi/ebook-mechanic-cli/internal/config"
 	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
 	"github.com/petergi/ebook-mechanic-cli/internal/tui/models"
 	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
 )
@@ -206,6 +212,108 @@ func TestAppUpdateSettings_Save(t *testing.T) {
 	}
 }
 
+func TestAppUpdateSettings_SaveError(t *testing.T) {
+	app := NewApp()
+	app.state = StateSettings
+	app.settingsModel = models.NewSettingsModel(2, false, false, false, false, false, true, 80, 24)
+
+	model, _ := app.Update(models.SettingsSaveMsg{Jobs: 5, CleanupEmptyDirs: true, Err: errors.New("permission denied")})
+	updated := model.(App)
+	if updated.state != StateSettings {
+		t.Errorf("Expected to stay on the settings screen, got %v", updated.state)
+	}
+	if updated.batchJobs != 5 {
+		t.Errorf("Expected the settings to apply to the session, got %d jobs", updated.batchJobs)
+	}
+	if !strings.Contains(updated.View(), "permission denied") {
+		t.Error("Expected the save error to be shown")
+	}
+}
+
+func TestApp_WithConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("jobs = 3\nno-backup = true\ncleanup-empty-dirs = false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(config.LoadOptions{UserFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := NewApp().withConfig(cfg, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.batchJobs != 3 || !app.noBackup || app.cleanupEmptyDirs || app.aggressive {
+		t.Errorf("Settings not taken from config: %+v", app)
+	}
+
+	app.state = StateSettings
+	model, _ := app.Update(models.SettingsSaveMsg{Jobs: 3, Profile: "archive"})
+	if model.(App).profile != "archive" {
+		t.Errorf("Expected the chosen profile to become active, got %q", model.(App).profile)
+	}
+	app.state = StateMenu
+
+	model, _ = app.Update(models.MenuSelectMsg{Action: "settings"})
+	if view := model.(App).View(); !strings.Contains(view, "Saved to "+path) {
+		t.Error("Expected the settings screen to save to the config file")
+	}
+}
+
+func TestApp_Routes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte("route = [\"invalid=Rejected;keep-path\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(config.LoadOptions{UserFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := NewApp().withConfig(cfg, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.moveFailedRepairs = true
+	if got := app.routeRules(operations.OperationValidate).Strings(); len(got) != 1 || got[0] != "invalid=Rejected;keep-path" {
+		t.Errorf("Unexpected validation routes %v", got)
+	}
+	if got := app.routeRules(operations.OperationRepair); len(got) != 2 {
+		t.Errorf("Expected failed repairs to be routed too, got %v", got)
+	}
+
+	root := t.TempDir()
+	book := filepath.Join(root, "Author", "book.epub")
+	if err := os.MkdirAll(filepath.Dir(book), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(book, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := operations.BatchResult{Invalid: []operations.Result{{FilePath: book, Report: &ebmlib.ValidationReport{}}}}
+	_, cleanup := app.batchSettings(operations.DefaultBatchConfig(), operations.OperationValidate, root)
+	plan := operations.PlanCleanup(&result, cleanup)
+	if _, err := os.Stat(book); err != nil {
+		t.Fatalf("Expected planning to leave the book alone, got %v", err)
+	}
+	result.RecordCleanup(plan.Apply(trash.New(t.TempDir())))
+	want := filepath.Join(root, "Rejected", "Author", "book.epub")
+	if len(result.Routed) != 1 || result.Routed[0].Dest != want {
+		t.Fatalf("Expected the book to go to %s, got %+v", want, result.Routed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "Author")); !os.IsNotExist(err) {
+		t.Errorf("Expected the emptied folder to be removed, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("route = [\"broken=x\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ = config.Load(config.LoadOptions{UserFile: path})
+	if _, err := NewApp().withConfig(cfg, path, ""); err == nil {
+		t.Error("Expected an error for a bad route")
+	}
+}
+
 func TestAppUpdateBrowser_StartValidation(t *testing.T) {
 	app := NewApp()
 	app.state = StateBrowser
@@ -451,7 +559,7 @@ func extractOperationDoneFromMsg(t *testing.T, msg tea.Msg) models.OperationDone
 	return models.OperationDoneMsg{}
 }
 
-func TestCollectBatchFiles(t *testing.T) {
+func TestWalkBatchFiles(t *testing.T) {
 	tmpDir := t.TempDir()
 	if err := os.WriteFile(filepath.Join(tmpDir, "1.epub"), []byte("t"), 0644); err != nil {
 		t.Fatal(err)
@@ -469,16 +577,45 @@ func TestCollectBatchFiles(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	files, err := collectBatchFiles(tmpDir, nil)
-	if err != nil {
-		t.Fatalf("collectBatchFiles failed: %v", err)
-	}
+	files := walkedBatchFiles(t, tmpDir)
 
 	if len(files) != 2 {
 		t.Errorf("Expected 2 files, got %d: %v", len(files), files)
 	}
 }
 
+func TestWalkBatchFiles_EbmIgnore(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		".ebmignore":          "**/drafts/**\n*.pdf\n",
+		"1.epub":              "t",
+		"2.pdf":               "t",
+		"drafts/3.epub":       "t",
+		"series/.ebmignore":   "!keep.pdf\n",
+		"series/keep.pdf":     "t",
+		"series/drafts/4.pdf": "t",
+	} {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := walkedBatchFiles(t, tmpDir)
+
+	cliFiles, err := operations.FindFiles(tmpDir, operations.FindFilesOptions{Recursive: true, MaxDepth: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || len(cliFiles) != 2 {
+		t.Errorf("Expected TUI and CLI to find the same 2 files, got %v and %v", files, cliFiles)
+	}
+}
+
 func TestApp_startBatchWithFiles(t *testing.T) {
 	app := NewApp()
 	files := []string{"1.epub", "2.epub"}
@@ -584,6 +721,85 @@ func TestAppUpdateProgress_ViewReport(t *testing.T) {
 	}
 }
 
+func TestAppUpdateProgress_PauseAndStop(t *testing.T) {
+	app := NewApp()
+	app.state = StateProgress
+	app.batch = operations.NewBatchProcessor(context.Background(), operations.DefaultBatchConfig())
+
+	model, _ := app.Update(models.PauseBatchMsg{Paused: true})
+	app = model.(App)
+	if !app.batch.Paused() {
+		t.Error("Expected the batch to be paused")
+	}
+	model, _ = app.Update(models.PauseBatchMsg{Paused: false})
+	app = model.(App)
+	if app.batch.Paused() {
+		t.Error("Expected the batch to be resumed")
+	}
+
+	model, _ = app.Update(models.StopBatchMsg{})
+	app = model.(App)
+	if !app.batch.Stopped() || app.state != StateProgress {
+		t.Errorf("Expected the batch stopped while progress is shown, state %v", app.state)
+	}
+	if app.ctx.Err() != nil {
+		t.Error("Stopping a batch should not cancel the application context")
+	}
+}
+
+func TestAppUpdateProgress_IncompleteBatchOpensReport(t *testing.T) {
+	app := NewApp()
+	app.state = StateProgress
+
+	result := operations.BatchResult{Total: 2, Incomplete: true, Unprocessed: 5}
+	model, _ := app.Update(models.OperationDoneMsg{Result: result})
+	updated := model.(App)
+	if updated.state != StateReport {
+		t.Fatalf("Expected StateReport for a cancelled batch, got %v", updated.state)
+	}
+	if view := updated.reportModel.View(); !strings.Contains(view, "INCOMPLETE") {
+		t.Errorf("Expected the report labelled incomplete, got:\n%s", view)
+	}
+}
+
+func TestApp_startBatchWithFiles_Stop(t *testing.T) {
+	app := NewApp()
+	model, cmd := app.startBatchWithFiles([]string{"1.epub", "2.epub"}, "validate")
+	updated := model.(App)
+	if updated.batch == nil {
+		t.Fatal("Expected the running batch to be kept for pause and stop")
+	}
+	updated.batch.Stop()
+
+	// The result command is one of the batched commands; run them all
+	for _, c := range cmd().(tea.BatchMsg) {
+		if c == nil {
+			continue
+		}
+		msg := runWithTimeout(c, 5*time.Second)
+		if done, ok := msg.(models.OperationDoneMsg); ok {
+			result := done.Result.(operations.BatchResult)
+			if !result.Incomplete || result.Total+result.Unprocessed != 2 {
+				t.Errorf("Expected an incomplete result covering 2 files, got %+v", result)
+			}
+			return
+		}
+	}
+	t.Fatal("No OperationDoneMsg")
+}
+
+// runWithTimeout runs a command, returning nil if it blocks too long
+func runWithTimeout(c tea.Cmd, timeout time.Duration) tea.Msg {
+	ch := make(chan tea.Msg, 1)
+	go func() { ch <- c() }()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
 func TestBatchProgressCmd(t *testing.T) {
 	ch := make(chan operations.ProgressUpdate, 1)
 	ch <- operations.ProgressUpdate{Completed: 1, Total: 1}
@@ -606,22 +822,34 @@ func TestBatchProgressCmd(t *testing.T) {
 	}
 }
 
-func TestIsEbookFile(t *testing.T) {
-	tests := []struct {
-		path     string
-		expected bool
-	}{
-		{"test.epub", true},
-		{"TEST.EPUB", true},
-		{"test.pdf", true},
-		{"test.txt", false},
-		{"noext", false},
+func TestWalkBatchFiles_Extensions(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"test.epub", "TEST.EPUB", "test.pdf", "test.txt", "noext"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("t"), 0644); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	for _, tt := range tests {
-		got := isEbookFile(tt.path)
-		if got != tt.expected {
-			t.Errorf("isEbookFile(%q) = %v, want %v", tt.path, got, tt.expected)
-		}
+	files := walkedBatchFiles(t, tmpDir)
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	slices.Sort(names)
+	if want := []string{"TEST.EPUB", "test.epub", "test.pdf"}; !slices.Equal(names, want) {
+		t.Errorf("walkBatchFiles found %v, want %v", names, want)
 	}
 }
+
+// walkedBatchFiles returns the files walkBatchFiles finds under path
+func walkedBatchFiles(t *testing.T, path string) []string {
+	t.Helper()
+	var files []string
+	if err := walkBatchFiles(path, func(file string) error {
+		files = append(files, file)
+		return nil
+	}); err != nil {
+		t.Fatalf("walkBatchFiles failed: %v", err)
+	}
+	return files
+}
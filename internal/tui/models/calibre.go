@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
 	"github.com/petergi/ebook-mechanic-cli/internal/tui/styles"
 )
 
@@ -570,7 +572,7 @@ func (m CalibreModel) renderCleanupConfirm() string {
 	}
 
 	warning := fmt.Sprintf(
-		"This will permanently delete:\n\n%s\n\nThis action cannot be undone!",
+		"This will move to the trash:\n\n%s\n\nThey can be put back with 'ebm trash restore'.",
 		strings.Join(warningParts, "\n\n"),
 	)
 
@@ -614,15 +616,13 @@ func (m CalibreModel) renderCleanupProgress() string {
 func (m CalibreModel) renderCleanupDone() string {
 	title := styles.RenderTitle("✅ Cleanup Complete")
 
-	var resultText string
+	resultText := fmt.Sprintf(
+		"Moved %d directories to the trash\nwith %d files",
+		len(m.scanResult.CleanedDirs),
+		len(m.scanResult.CleanedFiles),
+	)
 	if m.scanResult.CleanupError != nil {
-		resultText = styles.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.scanResult.CleanupError))
-	} else {
-		resultText = fmt.Sprintf(
-			"Removed %d directories\nRemoved %d files",
-			len(m.scanResult.CleanedDirs),
-			len(m.scanResult.CleanedFiles),
-		)
+		resultText += "\n\n" + styles.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.scanResult.CleanupError))
 	}
 
 	resultBox := styles.BorderStyle.Width(50).Render(resultText)
@@ -766,11 +766,13 @@ func ScanCalibreLibrary(libraryPath string, progressCh chan<- CalibreScanProgres
 	return result
 }
 
-// CleanupCalibreLibrary removes book folders that have no ebook files
-// and removes empty author directories
-func CleanupCalibreLibrary(result *CalibreScanResult) ([]string, []string, error) {
+// CleanupCalibreLibrary moves book folders that have no ebook files, and
+// author directories left empty, to bin. It returns the folders and the
+// files in them that were moved, and every failure.
+func CleanupCalibreLibrary(result *CalibreScanResult, bin *trash.Trash) ([]string, []string, error) {
 	var cleanedDirs []string
 	var cleanedFiles []string
+	var errs []error
 
 	// Track author directories that may become empty after removing books
 	authorPaths := make(map[string]bool)
@@ -779,51 +781,54 @@ func CleanupCalibreLibrary(result *CalibreScanResult) ([]string, []string, error
 		// Track the author path for later cleanup
 		authorPaths[book.AuthorPath] = true
 
-		// Remove all files in the book directory
 		entries, err := os.ReadDir(book.BookPath)
 		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
-
+		var files []string
 		for _, entry := range entries {
-			if !entry.IsDir() {
-				filePath := filepath.Join(book.BookPath, entry.Name())
-				if err := os.Remove(filePath); err == nil {
-					cleanedFiles = append(cleanedFiles, filePath)
-				}
+			if entry.IsDir() {
+				// Nested folders are not a Calibre book folder; leave it be
+				files = nil
+				break
 			}
+			files = append(files, filepath.Join(book.BookPath, entry.Name()))
+		}
+		if len(files) < len(entries) {
+			continue
 		}
 
-		// Remove the book directory
-		if err := os.Remove(book.BookPath); err == nil {
-			cleanedDirs = append(cleanedDirs, book.BookPath)
+		if _, err := bin.Put(book.BookPath); err != nil {
+			errs = append(errs, err)
+			continue
 		}
+		cleanedDirs = append(cleanedDirs, book.BookPath)
+		cleanedFiles = append(cleanedFiles, files...)
 	}
 
-	// After all books are removed, check and remove empty author directories
-	for authorPath := range authorPaths {
+	// After all books are removed, check and remove empty author directories,
+	// then the empty author directories found during the scan
+	removeIfEmpty := func(authorPath string) {
 		entries, err := os.ReadDir(authorPath)
-		if err == nil && len(entries) == 0 {
-			if err := os.Remove(authorPath); err == nil {
-				cleanedDirs = append(cleanedDirs, authorPath)
-			}
+		if err != nil || len(entries) > 0 {
+			return
 		}
+		if _, err := bin.Put(authorPath); err != nil {
+			errs = append(errs, err)
+			return
+		}
+		cleanedDirs = append(cleanedDirs, authorPath)
+	}
+	for authorPath := range authorPaths {
+		removeIfEmpty(authorPath)
 	}
-
-	// Also remove pre-existing empty author directories found during scan
 	for _, authorPath := range result.EmptyAuthors {
 		// Skip if we already tried to remove this one above
-		if authorPaths[authorPath] {
-			continue
-		}
-		// Double-check it's still empty before removing
-		entries, err := os.ReadDir(authorPath)
-		if err == nil && len(entries) == 0 {
-			if err := os.Remove(authorPath); err == nil {
-				cleanedDirs = append(cleanedDirs, authorPath)
-			}
+		if !authorPaths[authorPath] {
+			removeIfEmpty(authorPath)
 		}
 	}
 
-	return cleanedDirs, cleanedFiles, nil
+	return cleanedDirs, cleanedFiles, errors.Join(errs...)
 }
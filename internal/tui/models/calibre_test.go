@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
 )
 
 func TestNewCalibreModel(t *testing.T) {
@@ -133,10 +135,15 @@ func TestCleanupCalibreLibrary(t *testing.T) {
 	}
 
 	// Run cleanup
-	cleanedDirs, cleanedFiles, err := CleanupCalibreLibrary(result)
+	bin := trash.New(t.TempDir())
+	cleanedDirs, cleanedFiles, err := CleanupCalibreLibrary(result, bin)
 	if err != nil {
 		t.Fatalf("cleanup failed: %v", err)
 	}
+	// The folders can be restored from the trash
+	if entries, _, err := bin.List(); err != nil || len(entries) != 3 {
+		t.Errorf("expected 3 folders in the trash, got %+v (%v)", entries, err)
+	}
 
 	if len(cleanedFiles) != 3 {
 		t.Errorf("expected 3 files to be cleaned (2 metadata.opf + 1 cover.jpg), got %d", len(cleanedFiles))
@@ -246,7 +253,7 @@ func TestCleanupCalibreLibrary_EmptyAuthors(t *testing.T) {
 	}
 
 	// Run cleanup
-	cleanedDirs, _, err := CleanupCalibreLibrary(result)
+	cleanedDirs, _, err := CleanupCalibreLibrary(result, trash.New(t.TempDir()))
 	if err != nil {
 		t.Fatalf("cleanup failed: %v", err)
 	}
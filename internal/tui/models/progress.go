@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/progress"
@@ -25,8 +27,18 @@ type ProgressModel struct {
 	done        bool
 	result      interface{} // Holds the final result when done
 	progress    progress.Model
+	live        *operations.ProgressUpdate // Latest batch statistics; nil for single files
+	logOffset   int                        // Failures scrolled back from the newest
+	paused      bool                       // No new files start until resumed
+	stopping    bool                       // Cancelled; waiting for files in progress
 }
 
+// Dashboard limits
+const (
+	maxWorkerRows  = 8 // Files in progress listed before "… and N more"
+	failureLogRows = 5 // Failures visible at once in the log
+)
+
 // NewProgressModel creates a new progress model
 func NewProgressModel(operation string, filePath string, total int, width, height int) ProgressModel {
 	if width == 0 {
@@ -91,6 +103,26 @@ func (m ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, func() tea.Msg {
 					return OperationCancelMsg{}
 				}
+			case "p", " ":
+				if m.isBatch() && !m.stopping {
+					m.paused = !m.paused
+					paused := m.paused
+					return m, func() tea.Msg {
+						return PauseBatchMsg{Paused: paused}
+					}
+				}
+			case "c":
+				if m.isBatch() && !m.stopping {
+					m.stopping = true
+					m.paused = false
+					return m, func() tea.Msg {
+						return StopBatchMsg{}
+					}
+				}
+			case "up", "k":
+				m.logOffset = min(m.logOffset+1, m.maxLogOffset())
+			case "down", "j":
+				m.logOffset = max(m.logOffset-1, 0)
 			}
 		}
 
@@ -106,6 +138,10 @@ func (m ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.current = msg.Current
 		m.currentFile = msg.CurrentFile
+		if msg.Batch != nil {
+			m.live = msg.Batch
+			m.logOffset = min(m.logOffset, m.maxLogOffset())
+		}
 		if m.total > 0 {
 			cmd := m.progress.SetPercent(float64(m.current) / float64(m.total))
 			return m, cmd
@@ -139,6 +175,12 @@ func (m ProgressModel) View() string {
 	// Title with animated spinner
 	spinnerChar := string(styles.IconSpinner[m.spinner])
 	title := styles.RenderTitle(spinnerChar + "  " + m.operation)
+	switch {
+	case m.stopping:
+		title = styles.RenderTitle(spinnerChar + "  " + m.operation + " - cancelling, finishing files in progress")
+	case m.paused:
+		title = styles.RenderTitle("⏸  " + m.operation + " - paused")
+	}
 
 	// Status box with operation-specific information
 	statusText := "Processing..."
@@ -186,12 +228,12 @@ func (m ProgressModel) View() string {
 
 		progressText := fmt.Sprintf("Completed: %d / %d (%.0f%%)", m.current, m.total, percentage)
 
-		progressContent := lipgloss.JoinVertical(
-			lipgloss.Left,
-			progressText,
-			"",
-			m.progress.View(),
-		)
+		lines := []string{progressText}
+		if m.live != nil {
+			lines = append(lines, m.renderTallies(), m.renderRate())
+		}
+		lines = append(lines, "", m.progress.View())
+		progressContent := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
 		progressBox = lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder()).
@@ -218,14 +260,22 @@ func (m ProgressModel) View() string {
 		BorderForeground(styles.ColorMuted).
 		Padding(1, 2).
 		Width(m.width - 4).
-		Render(styles.RenderKeyBinding("ctrl+c", "cancel operation"))
+		Render(m.renderHelp())
 
 	// Combine all parts
 	var parts []string
-	parts = append(parts, title, "", statusBox)
+	parts = append(parts, title, "")
+	if m.live != nil && len(m.live.InFlight) > 0 {
+		parts = append(parts, m.renderWorkers())
+	} else {
+		parts = append(parts, statusBox)
+	}
 	if progressBox != "" {
 		parts = append(parts, "", progressBox)
 	}
+	if m.live != nil && len(m.live.RecentFailures) > 0 {
+		parts = append(parts, "", m.renderFailureLog())
+	}
 	parts = append(parts, "", timeBar, helpBox)
 
 	content := lipgloss.JoinVertical(lipgloss.Left, parts...)
@@ -239,6 +289,132 @@ func (m ProgressModel) View() string {
 	)
 }
 
+// renderHelp lists the keys available while the operation runs
+func (m ProgressModel) renderHelp() string {
+	if !m.isBatch() {
+		return styles.RenderKeyBinding("ctrl+c", "cancel operation")
+	}
+
+	var help string
+	if !m.stopping {
+		action := "pause"
+		if m.paused {
+			action = "resume"
+		}
+		help = styles.RenderKeyBinding("p", action) + "  " +
+			styles.RenderKeyBinding("c", "cancel and show results so far") + "  "
+	}
+	help += styles.RenderKeyBinding("ctrl+c", "abort")
+	if m.maxLogOffset() > 0 {
+		help += "  " + styles.RenderKeyBinding("↑/↓", "scroll failures")
+	}
+	return help
+}
+
+// renderTallies renders the running valid, invalid and errored counts
+func (m ProgressModel) renderTallies() string {
+	return fmt.Sprintf("%s   %s   %s",
+		lipgloss.NewStyle().Foreground(styles.ColorSuccess).Render(fmt.Sprintf("%s %d valid", styles.IconCheck, m.live.Valid)),
+		lipgloss.NewStyle().Foreground(styles.ColorError).Render(fmt.Sprintf("%s %d invalid", styles.IconCross, m.live.Invalid)),
+		lipgloss.NewStyle().Foreground(styles.ColorWarning).Render(fmt.Sprintf("%s %d errored", styles.IconWarning, m.live.Errored)),
+	)
+}
+
+// renderRate renders throughput, bytes processed and the time remaining
+func (m ProgressModel) renderRate() string {
+	rate := fmt.Sprintf("%.1f files/s · %.1f MB/s · %s / %s",
+		m.live.FilesPerSecond, m.live.BytesPerSecond/(1<<20), formatMB(m.live.BytesDone), formatMB(m.live.BytesTotal))
+	if m.live.ETA > 0 {
+		rate += " · ETA " + m.live.ETA.Round(time.Second).String()
+	}
+	return lipgloss.NewStyle().Foreground(styles.ColorMuted).Render(rate)
+}
+
+// renderWorkers lists the file each worker is processing and for how long
+func (m ProgressModel) renderWorkers() string {
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Workers (%d busy)", len(m.live.InFlight))))
+	for i, file := range m.live.InFlight {
+		if i == maxWorkerRows {
+			b.WriteString(lipgloss.NewStyle().Foreground(styles.ColorMuted).Render(
+				fmt.Sprintf("\n… and %d more", len(m.live.InFlight)-maxWorkerRows)))
+			break
+		}
+		elapsed := lipgloss.NewStyle().Foreground(styles.ColorMuted)
+		if file.Elapsed > 30*time.Second {
+			elapsed = elapsed.Foreground(styles.ColorWarning)
+		}
+		b.WriteString(fmt.Sprintf("\n%3d  %s  %s  %s",
+			file.Worker,
+			truncateName(filepath.Base(file.FilePath), m.width-40),
+			lipgloss.NewStyle().Foreground(styles.ColorMuted).Render(formatMB(file.Bytes)),
+			elapsed.Render(file.Elapsed.Round(time.Second).String())))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.ColorInfo).
+		Padding(0, 2).
+		Width(m.width - 4).
+		Render(b.String())
+}
+
+// renderFailureLog renders a window of the recent failures, newest last
+func (m ProgressModel) renderFailureLog() string {
+	failures := m.live.RecentFailures
+	end := len(failures) - m.logOffset
+	start := max(0, end-failureLogRows)
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render(
+		fmt.Sprintf("Recent Failures (%d-%d of %d)", start+1, end, len(failures))))
+	for _, failure := range failures[start:end] {
+		icon, color := styles.IconCross, styles.ColorError
+		if failure.Kind != "" {
+			icon, color = styles.IconWarning, styles.ColorWarning
+		}
+		line := fmt.Sprintf("%s %s %s: %s", failure.Time.Format("15:04:05"), icon,
+			truncateName(filepath.Base(failure.FilePath), m.width/3), failure.Reason)
+		b.WriteString("\n" + lipgloss.NewStyle().Foreground(color).MaxWidth(m.width-10).Render(line))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.ColorError).
+		Padding(0, 2).
+		Width(m.width - 4).
+		Render(b.String())
+}
+
+// isBatch reports whether the operation is a batch, which can be paused
+// and cancelled with partial results
+func (m ProgressModel) isBatch() bool {
+	return m.live != nil || m.total > 1
+}
+
+// maxLogOffset is how far the failure log can scroll back
+func (m ProgressModel) maxLogOffset() int {
+	if m.live == nil {
+		return 0
+	}
+	return max(0, len(m.live.RecentFailures)-failureLogRows)
+}
+
+// formatMB formats a byte count in megabytes
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
+
+// truncateName shortens a name to width runes with an ellipsis
+func truncateName(name string, width int) string {
+	width = max(width, 12)
+	runes := []rune(name)
+	if len(runes) <= width {
+		return name
+	}
+	return string(runes[:width-1]) + "…"
+}
+
 // renderDone renders the completion screen
 func (m ProgressModel) renderDone() string {
 	elapsed := time.Since(m.startTime)
@@ -317,6 +493,7 @@ type ProgressUpdateMsg struct {
 	Current     int
 	Total       int
 	CurrentFile string
+	Batch       *operations.ProgressUpdate // Live statistics of a batch, if any
 }
 
 // OperationDoneMsg signals that the operation is complete
@@ -327,6 +504,15 @@ type OperationDoneMsg struct {
 // OperationCancelMsg signals that the user wants to cancel
 type OperationCancelMsg struct{}
 
+// PauseBatchMsg asks for the running batch to pause or resume
+type PauseBatchMsg struct {
+	Paused bool
+}
+
+// StopBatchMsg asks for the running batch to stop once the files in
+// progress finish, keeping their results
+type StopBatchMsg struct{}
+
 // ViewReportMsg signals request to view the report
 type ViewReportMsg struct {
 	Result interface{}
@@ -338,5 +524,6 @@ func ConvertBatchProgress(update operations.ProgressUpdate) ProgressUpdateMsg {
 		Current:     update.Completed,
 		Total:       update.Total,
 		CurrentFile: update.Current,
+		Batch:       &update,
 	}
 }
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -343,6 +345,116 @@ func TestConvertBatchProgress(t *testing.T) {
 	}
 }
 
+func TestConvertBatchProgress_Live(t *testing.T) {
+	msg := ConvertBatchProgress(operations.ProgressUpdate{Completed: 1, Total: 2, Valid: 1})
+	if msg.Batch == nil || msg.Batch.Valid != 1 {
+		t.Errorf("Expected live statistics in the message, got %+v", msg.Batch)
+	}
+}
+
+func TestProgressModel_View_Dashboard(t *testing.T) {
+	m := NewProgressModel("Batch Validation", "", 20, 120, 60)
+	updated, _ := m.Update(ConvertBatchProgress(operations.ProgressUpdate{
+		Completed:      8,
+		Total:          20,
+		Valid:          5,
+		Invalid:        2,
+		Errored:        1,
+		BytesDone:      8 << 20,
+		BytesTotal:     20 << 20,
+		FilesPerSecond: 4,
+		BytesPerSecond: 4 << 20,
+		ETA:            3 * time.Second,
+		InFlight: []operations.FileProgress{
+			{Worker: 1, FilePath: "/lib/moby-dick.epub", Bytes: 2 << 20, Elapsed: 5 * time.Second},
+			{Worker: 3, FilePath: "/lib/scan.pdf", Bytes: 40 << 20, Elapsed: 45 * time.Second},
+		},
+		RecentFailures: []operations.FailureEvent{
+			{FilePath: "/lib/broken.epub", Reason: "3 errors", Time: time.Now()},
+			{FilePath: "/lib/gone.epub", Reason: "permission denied", Kind: operations.ErrorKindPermission, Time: time.Now()},
+		},
+	}))
+	view := updated.(ProgressModel).View()
+
+	for _, want := range []string{
+		"Workers (2 busy)", "moby-dick.epub", "scan.pdf", "45s",
+		"5 valid", "2 invalid", "1 errored",
+		"4.0 files/s · 4.0 MB/s · 8.0 MB / 20.0 MB · ETA 3s",
+		"Recent Failures (1-2 of 2)", "broken.epub: 3 errors", "gone.epub: permission denied",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Dashboard missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestProgressModel_ScrollFailureLog(t *testing.T) {
+	var failures []operations.FailureEvent
+	for i := 1; i <= 8; i++ {
+		failures = append(failures, operations.FailureEvent{FilePath: fmt.Sprintf("book%d.epub", i), Reason: "bad", Time: time.Now()})
+	}
+	m := NewProgressModel("Batch Validation", "", 20, 120, 60)
+	updated, _ := m.Update(ConvertBatchProgress(operations.ProgressUpdate{Completed: 8, Total: 20, RecentFailures: failures}))
+	m = updated.(ProgressModel)
+	if view := m.View(); !strings.Contains(view, "(4-8 of 8)") || strings.Contains(view, "book3.epub") {
+		t.Errorf("Expected the newest failures, got:\n%s", view)
+	}
+
+	for i := 0; i < 5; i++ {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+		m = updated.(ProgressModel)
+	}
+	if m.logOffset != 3 {
+		t.Errorf("Expected scrolling to stop at the oldest failure, offset %d", m.logOffset)
+	}
+	if view := m.View(); !strings.Contains(view, "(1-5 of 8)") || !strings.Contains(view, "book1.epub") {
+		t.Errorf("Expected the oldest failures, got:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if updated.(ProgressModel).logOffset != 2 {
+		t.Errorf("Expected j to scroll towards the newest failures")
+	}
+}
+
+func TestProgressModel_PauseAndCancel(t *testing.T) {
+	m := NewProgressModel("Batch Validation", "", 10, 120, 40)
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	m = updated.(ProgressModel)
+	if msg, ok := cmd().(PauseBatchMsg); !ok || !msg.Paused || !m.paused {
+		t.Fatalf("Expected p to pause, got %#v", cmd())
+	}
+	if view := m.View(); !strings.Contains(view, "paused") || !strings.Contains(view, "resume") {
+		t.Errorf("Expected the paused state in the view:\n%s", view)
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	m = updated.(ProgressModel)
+	if msg, ok := cmd().(PauseBatchMsg); !ok || msg.Paused || m.paused {
+		t.Fatalf("Expected p to resume, got %#v", cmd())
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = updated.(ProgressModel)
+	if _, ok := cmd().(StopBatchMsg); !ok || !m.stopping {
+		t.Fatalf("Expected c to stop the batch, got %#v", cmd())
+	}
+	if view := m.View(); !strings.Contains(view, "cancelling") {
+		t.Errorf("Expected the cancelling state in the view:\n%s", view)
+	}
+	if _, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}); cmd != nil {
+		t.Error("Expected no pause while cancelling")
+	}
+}
+
+func TestProgressModel_PauseIgnoredForSingleFile(t *testing.T) {
+	m := NewProgressModel("Validating", "test.epub", 1, 80, 24)
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}}); cmd != nil {
+		t.Error("Expected c to do nothing for a single file")
+	}
+}
+
 func TestProgressUpdateMsg(t *testing.T) {
 	// Test that ProgressUpdateMsg type exists and can be created
 	msg := ProgressUpdateMsg{
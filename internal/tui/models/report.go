@@ -12,6 +12,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
 	"github.com/petergi/ebook-mechanic-cli/internal/tui/styles"
 	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
 )
@@ -34,11 +35,30 @@ type ReportModel struct {
 	savedReportPath string // Path where report was saved
 	saveError       error  // Error from last save attempt
 	saveStatusShow  bool
-	openAfterSave   bool  // If true, open the report right after saving
-	openError       error // Error from last open attempt
-	openStatusShow  bool  // Whether to show open status/error
+	openAfterSave   bool                           // If true, open the report right after saving
+	openError       error                          // Error from last open attempt
+	openStatusShow  bool                           // Whether to show open status/error
+	contextLines    int                            // Lines of source shown around issue locations (0 = off)
+	snippets        map[string]*operations.Snippet // Loaded source context keyed by issue location
+	confirmCleanup  bool                           // Asking whether to apply the planned cleanup of a batch
+	cleanupRunning  bool                           // The cleanup is being applied
+	trash           *trash.Trash                   // Where the cleanup removes files to
 }
 
+// CleanupDoneMsg carries a batch cleanup plan once it has been applied
+type CleanupDoneMsg struct {
+	Plan operations.CleanupPlan
+}
+
+// SnippetsLoadedMsg carries the source context read for a report's issues
+type SnippetsLoadedMsg struct {
+	Report   *ebmlib.ValidationReport
+	Snippets map[string]*operations.Snippet
+}
+
+// defaultContextLines is the amount of source context shown when toggled with 'c'
+const defaultContextLines = 3
+
 // NewReportModel creates a new report model for validation results
 func NewReportModel(report *ebmlib.ValidationReport, width, height int) ReportModel {
 	if width == 0 {
@@ -86,9 +106,17 @@ func NewBatchReportModel(result *operations.BatchResult, width, height int) Repo
 		showErrors:     true,
 		showWarnings:   true,
 		showInfo:       true,
+		confirmCleanup: result.Cleanup.Pending() > 0,
+		trash:          trash.New(""),
 	}
 }
 
+// WithTrash makes the cleanup of a batch remove files to bin
+func (m ReportModel) WithTrash(bin *trash.Trash) ReportModel {
+	m.trash = bin
+	return m
+}
+
 // NewRepairReportModel creates a new report model for repair results
 func NewRepairReportModel(result *ebmlib.RepairResult, width, height int) ReportModel {
 	return NewRepairReportModelWithValidation(result, nil, width, height)
@@ -157,6 +185,9 @@ func (m ReportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.confirmCleanup || m.cleanupRunning {
+			return m.handleCleanupConfirmKeys(msg)
+		}
 		switch msg.String() {
 		case "up", "k":
 			if m.viewportTop > 0 {
@@ -214,6 +245,34 @@ func (m ReportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.viewportTop = 0
 			}
 
+		case "c":
+			// Toggle source context for issue locations
+			if m.reportType == "validation" && m.report != nil {
+				if m.contextLines > 0 {
+					m.contextLines = 0
+				} else {
+					m.contextLines = defaultContextLines
+					if m.snippets == nil {
+						report, contextLines := m.report, m.contextLines
+						return m, func() tea.Msg {
+							return SnippetsLoadedMsg{Report: report, Snippets: loadSnippets(report, contextLines)}
+						}
+					}
+				}
+			}
+
+		case "5":
+			if m.reportType == "batch" {
+				m.selectedFilter = 4 // Top issues
+				m.viewportTop = 0
+			}
+
+		case "x":
+			// Ask again about a cleanup that was not applied
+			if m.batchResult != nil && m.batchResult.Cleanup.Pending() > 0 {
+				m.confirmCleanup = true
+			}
+
 		case "s":
 			// Save report to file
 			return m, func() tea.Msg {
@@ -288,17 +347,125 @@ func (m ReportModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.openError = msg.Error
 		m.openStatusShow = msg.Error != nil
 		return m, nil
+
+	case SnippetsLoadedMsg:
+		if msg.Report == m.report {
+			m.snippets = msg.Snippets
+		}
+		return m, nil
+
+	case CleanupDoneMsg:
+		m.cleanupRunning = false
+		if m.batchResult != nil {
+			result := *m.batchResult
+			result.RecordCleanup(msg.Plan)
+			m.batchResult = &result
+		}
+		return m, nil
 	}
 
 	return m, nil
 }
 
+// handleCleanupConfirmKeys answers the cleanup confirmation. Other keys
+// are ignored until the cleanup is applied or dismissed.
+func (m ReportModel) handleCleanupConfirmKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		if m.cleanupRunning {
+			return m, nil
+		}
+		m.confirmCleanup = false
+		m.cleanupRunning = true
+		plan, bin := m.batchResult.Cleanup, m.trash
+		return m, func() tea.Msg {
+			return CleanupDoneMsg{Plan: plan.Apply(bin)}
+		}
+	case "n", "N", "esc":
+		m.confirmCleanup = false
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// maxConfirmOps is the number of operations listed in the cleanup confirmation
+const maxConfirmOps = 8
+
+// renderCleanupConfirm asks whether to apply the planned cleanup of a batch
+func (m ReportModel) renderCleanupConfirm() string {
+	title := styles.RenderTitle("⚠️  Confirm Cleanup")
+
+	plan := m.batchResult.Cleanup
+	var lines []string
+	for _, op := range plan {
+		if !op.Pending() {
+			continue
+		}
+		if len(lines) == maxConfirmOps {
+			lines = append(lines, fmt.Sprintf("… and %d more", plan.Pending()-maxConfirmOps))
+			break
+		}
+		lines = append(lines, "• "+cleanupOpLine(op))
+	}
+
+	warning := fmt.Sprintf(
+		"The batch is done. This cleanup will make %s:\n\n%s\n\nRemoved files and folders go to the trash in %s\nand can be put back with 'ebm trash restore'.",
+		plan.Summary(),
+		strings.Join(lines, "\n"),
+		m.trash.Dir,
+	)
+
+	warningBox := lipgloss.NewStyle().
+		Foreground(styles.ColorWarning).
+		Border(lipgloss.RoundedBorder()).
+		Padding(1, 2).
+		Width(min(m.width-8, 80)).
+		Render(warning)
+
+	help := styles.RenderKeyBinding("y", "yes, apply") + "  " +
+		styles.RenderKeyBinding("n", "no, keep everything")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Center,
+		title,
+		"",
+		warningBox,
+		"",
+		help,
+	)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// cleanupOpLine describes a cleanup operation in a line
+func cleanupOpLine(op operations.CleanupOp) string {
+	var line string
+	switch op.Action {
+	case operations.CleanupRemove:
+		line = "delete " + op.Path
+	case operations.CleanupMove:
+		line = fmt.Sprintf("move %s → %s", op.Path, op.Dest)
+	case operations.CleanupRemoveDir:
+		line = "remove folder " + op.Path
+	default:
+		line = fmt.Sprintf("%s %s", op.Action, op.Path)
+	}
+	if op.Err != nil {
+		line += fmt.Sprintf(": %v", op.Err)
+	}
+	return line
+}
+
 // View renders the report
 func (m ReportModel) View() string {
 	switch m.reportType {
 	case "repair":
 		return m.renderRepairReport()
 	case "batch":
+		if m.confirmCleanup {
+			return m.renderCleanupConfirm()
+		}
 		return m.renderBatchReport()
 	default:
 		return m.renderValidationReport()
@@ -391,6 +558,7 @@ func (m ReportModel) renderValidationReport() string {
 		Render(
 			styles.RenderKeyBinding("1-4", "filter") + "  " +
 				styles.RenderKeyBinding("↑/↓", "scroll") + "  " +
+				styles.RenderKeyBinding("c", "context") + "  " +
 				styles.RenderKeyBinding("s", "save") + "  " +
 				styles.RenderKeyBinding("o", "open") + "  " +
 				styles.RenderKeyBinding("enter", "continue"),
@@ -632,6 +800,18 @@ func (m ReportModel) renderBatchReport() string {
 			statusColor = styles.ColorError
 		}
 	}
+	if m.batchResult.Incomplete {
+		title = styles.RenderTitle("📦 Batch Report (Incomplete)")
+		statusText = fmt.Sprintf("%s  INCOMPLETE: cancelled after %d file(s), %d not processed\n%s",
+			styles.IconWarning, m.batchResult.Total, m.batchResult.Unprocessed, statusText)
+		statusColor = styles.ColorWarning
+	}
+	if m.batchResult.ScanError != nil {
+		title = styles.RenderTitle("📦 Batch Report (Incomplete)")
+		statusText = fmt.Sprintf("%s  INCOMPLETE: %v\nFiles not found by then were not processed\n%s",
+			styles.IconWarning, m.batchResult.ScanError, statusText)
+		statusColor = styles.ColorWarning
+	}
 
 	statusBox := lipgloss.NewStyle().
 		Foreground(statusColor).
@@ -680,9 +860,6 @@ func (m ReportModel) renderBatchReport() string {
 		if len(m.batchResult.RemovedFiles) > 0 {
 			rows = append(rows, []string{"Files Removed", fmt.Sprintf("%d", len(m.batchResult.RemovedFiles))})
 		}
-		if len(m.batchResult.MovedFiles) > 0 {
-			rows = append(rows, []string{"Files Moved to INVALID", fmt.Sprintf("%d", len(m.batchResult.MovedFiles))})
-		}
 	} else {
 		// Validation operation - show validation metrics
 		rows = [][]string{
@@ -697,6 +874,33 @@ func (m ReportModel) renderBatchReport() string {
 			rows = append(rows, []string{"Files Removed", fmt.Sprintf("%d", len(m.batchResult.RemovedFiles))})
 		}
 	}
+	if len(m.batchResult.MovedFiles) > 0 {
+		rows = append(rows, []string{"Files Moved", fmt.Sprintf("%d", len(m.batchResult.MovedFiles))})
+	}
+	if notMoved := len(m.batchResult.Routed) - len(m.batchResult.MovedFiles); notMoved > 0 {
+		rows = append(rows, []string{"Files Not Moved", fmt.Sprintf("%d", notMoved)})
+	}
+	if failed := len(m.batchResult.Cleanup.Failed()); failed > 0 {
+		rows = append(rows, []string{"Cleanup Failed", fmt.Sprintf("%d", failed)})
+	}
+	if pending := m.batchResult.Cleanup.Pending(); pending > 0 {
+		status := fmt.Sprintf("%d (press x to review)", pending)
+		if m.cleanupRunning {
+			status = fmt.Sprintf("%d (applying…)", pending)
+		}
+		rows = append(rows, []string{"Cleanup Not Applied", status})
+	}
+	if m.batchResult.Incomplete {
+		rows = append(rows, []string{"Not Processed (cancelled)", fmt.Sprintf("%d", m.batchResult.Unprocessed)})
+	}
+	if m.batchResult.Options.Profile != "" {
+		rows = append(rows, []string{"Profile", m.batchResult.Options.Profile})
+	}
+	if timing := m.batchResult.Timing; timing.Files > 0 {
+		rows = append(rows, []string{"Throughput", fmt.Sprintf("%.1f files/s, %.1f MB/s", timing.FilesPerSecond, timing.BytesPerSecond/(1<<20))})
+		rows = append(rows, []string{"p50 / p90 / p99", fmt.Sprintf("%s / %s / %s",
+			timing.P50.Round(time.Millisecond), timing.P90.Round(time.Millisecond), timing.P99.Round(time.Millisecond))})
+	}
 	summaryContent := styles.RenderTable(headers, rows)
 	summaryBox := lipgloss.NewStyle().
 		Border(lipgloss.NormalBorder()).
@@ -712,7 +916,7 @@ func (m ReportModel) renderBatchReport() string {
 	var listContent string
 	var items []string
 
-	// Filter: 0=Invalid, 1=Errored, 2=Valid, 3=All
+	// Filter: 0=Invalid, 1=Errored, 2=Valid, 3=All, 4=Top Issues
 	switch m.selectedFilter {
 	case 0: // Invalid
 		for _, r := range m.batchResult.Invalid {
@@ -736,6 +940,10 @@ func (m ReportModel) renderBatchReport() string {
 		for _, r := range m.batchResult.Valid {
 			items = append(items, m.formatBatchItem(r, "valid"))
 		}
+	case 4: // Top Issues
+		for i, stat := range m.batchResult.TopIssues {
+			items = append(items, m.formatIssueStat(i+1, stat))
+		}
 	}
 
 	if len(items) > 0 {
@@ -777,6 +985,10 @@ func (m ReportModel) renderBatchReport() string {
 		Render(listContent)
 
 	// Help text
+	cleanupBinding := ""
+	if m.batchResult.Cleanup.Pending() > 0 && !m.cleanupRunning {
+		cleanupBinding = styles.RenderKeyBinding("x", "cleanup") + "  "
+	}
 	helpBox := lipgloss.NewStyle().
 		Foreground(styles.ColorMuted).
 		Border(lipgloss.NormalBorder(), true, false, false, false).
@@ -784,8 +996,9 @@ func (m ReportModel) renderBatchReport() string {
 		Padding(1, 2).
 		Width(m.width - 8).
 		Render(
-			styles.RenderKeyBinding("1-4", "filter") + "  " +
+			styles.RenderKeyBinding("1-5", "filter") + "  " +
 				styles.RenderKeyBinding("↑/↓", "scroll") + "  " +
+				cleanupBinding +
 				styles.RenderKeyBinding("s", "save") + "  " +
 				styles.RenderKeyBinding("o", "open") + "  " +
 				styles.RenderKeyBinding("enter", "continue"),
@@ -826,6 +1039,7 @@ func (m ReportModel) renderBatchFilters() string {
 		"2: Errored",
 		"3: Valid",
 		"4: All",
+		"5: Top Issues",
 	}
 
 	var rendered []string
@@ -840,6 +1054,44 @@ func (m ReportModel) renderBatchFilters() string {
 	return lipgloss.JoinHorizontal(lipgloss.Top, rendered...)
 }
 
+// formatIssueStat formats one ranked row of the Top Issues tab
+func (m ReportModel) formatIssueStat(rank int, stat operations.IssueStat) string {
+	var style lipgloss.Style
+	switch stat.Severity {
+	case ebmlib.SeverityError:
+		style = styles.ErrorStyle
+	case ebmlib.SeverityWarning:
+		style = styles.WarningStyle
+	default:
+		style = styles.InfoStyle
+	}
+
+	examples := make([]string, len(stat.Examples))
+	for i, path := range stat.Examples {
+		examples[i] = filepath.Base(path)
+	}
+
+	header := style.Render(fmt.Sprintf("%2d. [%s] %s", rank, stat.Code, stat.Message))
+	detail := styles.MutedStyle.Render(fmt.Sprintf("    %d occurrence(s) in %d file(s), e.g. %s",
+		stat.Occurrences, stat.Files, strings.Join(examples, ", ")))
+
+	return header + "\n" + detail
+}
+
+// incompleteNote warns that a saved batch report covers only part of the
+// files
+func (m ReportModel) incompleteNote() string {
+	var note string
+	if m.batchResult.Incomplete {
+		note += fmt.Sprintf("INCOMPLETE: cancelled after %d file(s), %d not processed\n\n",
+			m.batchResult.Total, m.batchResult.Unprocessed)
+	}
+	if m.batchResult.ScanError != nil {
+		note += fmt.Sprintf("INCOMPLETE: %v; files not found by then were not processed\n\n", m.batchResult.ScanError)
+	}
+	return note
+}
+
 func (m ReportModel) formatBatchItem(r operations.Result, category string) string {
 	var icon string
 	var style lipgloss.Style
@@ -1003,6 +1255,15 @@ func (m ReportModel) formatIssue(issue ebmlib.ValidationError, issueType string)
 	if location != "" {
 		formatted += "\n" + styles.MutedStyle.Render(location)
 	}
+	if snippet := m.snippets[snippetKey(issue)]; m.contextLines > 0 && snippet != nil {
+		for i := range snippet.Lines {
+			line := "  " + snippet.FormatLine(i)
+			if snippet.LineNumber(i) != snippet.Line {
+				line = styles.MutedStyle.Render(line)
+			}
+			formatted += "\n" + line
+		}
+	}
 
 	switch style {
 	case "error":
@@ -1016,6 +1277,37 @@ func (m ReportModel) formatIssue(issue ebmlib.ValidationError, issueType string)
 	return formatted
 }
 
+// loadSnippets reads source context for every located issue in the report.
+// Locations that cannot be read are skipped.
+func loadSnippets(report *ebmlib.ValidationReport, contextLines int) map[string]*operations.Snippet {
+	reader := operations.NewSnippetReader(report.FilePath)
+	defer reader.Close()
+	snippets := make(map[string]*operations.Snippet)
+	for _, group := range [][]ebmlib.ValidationError{report.Errors, report.Warnings, report.Info} {
+		for _, issue := range group {
+			if issue.Location == nil || issue.Location.Line <= 0 {
+				continue
+			}
+			key := snippetKey(issue)
+			if _, ok := snippets[key]; ok {
+				continue
+			}
+			snippet, err := reader.Read(issue.Location.File, issue.Location.Line, contextLines)
+			if err == nil {
+				snippets[key] = snippet
+			}
+		}
+	}
+	return snippets
+}
+
+func snippetKey(issue ebmlib.ValidationError) string {
+	if issue.Location == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", issue.Location.File, issue.Location.Line)
+}
+
 func (m ReportModel) makeClickable(path string) string {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
@@ -1120,9 +1412,13 @@ func (m ReportModel) saveReport() (string, error) {
 		if m.batchResult.Operation == "repair" {
 			b.WriteString("=== Batch Repair Report ===\n")
 			b.WriteString(fmt.Sprintf("Generated: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+			b.WriteString(m.incompleteNote())
 
 			// Options section
 			b.WriteString("Options:\n")
+			if m.batchResult.Options.Profile != "" {
+				b.WriteString(fmt.Sprintf("  Profile: %s\n", m.batchResult.Options.Profile))
+			}
 			b.WriteString(fmt.Sprintf("  Workers: %d\n", m.batchResult.Options.NumWorkers))
 			b.WriteString(fmt.Sprintf("  Skip Post-Repair Validation: %v\n", m.batchResult.Options.SkipValidation))
 			b.WriteString(fmt.Sprintf("  No Backup: %v\n", m.batchResult.Options.NoBackup))
@@ -1141,9 +1437,13 @@ func (m ReportModel) saveReport() (string, error) {
 		} else {
 			b.WriteString("=== Batch Validation Report ===\n")
 			b.WriteString(fmt.Sprintf("Generated: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+			b.WriteString(m.incompleteNote())
 
 			// Options section
 			b.WriteString("Options:\n")
+			if m.batchResult.Options.Profile != "" {
+				b.WriteString(fmt.Sprintf("  Profile: %s\n", m.batchResult.Options.Profile))
+			}
 			b.WriteString(fmt.Sprintf("  Workers: %d\n", m.batchResult.Options.NumWorkers))
 			b.WriteString(fmt.Sprintf("  Remove System Errors: %v\n\n", m.batchResult.Options.RemoveSystemErrors))
 
@@ -1185,6 +1485,28 @@ func (m ReportModel) saveReport() (string, error) {
 			b.WriteString("\n")
 		}
 
+		if len(m.batchResult.TopIssues) > 0 {
+			b.WriteString("Top Issues:\n")
+			for i, stat := range m.batchResult.TopIssues {
+				b.WriteString(fmt.Sprintf("%d. [%s] %s - %d occurrence(s) in %d file(s)\n",
+					i+1, stat.Code, stat.Message, stat.Occurrences, stat.Files))
+			}
+			b.WriteString("\n")
+		}
+
+		if timing := m.batchResult.Timing; timing.Files > 0 {
+			b.WriteString(fmt.Sprintf("Throughput: %.1f files/s, %.1f MB/s\n", timing.FilesPerSecond, timing.BytesPerSecond/(1<<20)))
+			b.WriteString(fmt.Sprintf("Per File: p50 %v, p90 %v, p99 %v, max %v\n\n",
+				timing.P50.Round(time.Millisecond), timing.P90.Round(time.Millisecond),
+				timing.P99.Round(time.Millisecond), timing.Max.Round(time.Millisecond)))
+			b.WriteString("Slowest Files:\n")
+			for i, file := range timing.Slowest {
+				b.WriteString(fmt.Sprintf("%d. %s - %v (%.1f MB)\n",
+					i+1, filepath.Base(file.FilePath), file.Duration.Round(time.Millisecond), float64(file.Bytes)/(1<<20)))
+			}
+			b.WriteString("\n")
+		}
+
 		// Post-processing cleanup sections
 		if len(m.batchResult.RemovedFiles) > 0 {
 			b.WriteString("Files Removed (System Errors):\n")
@@ -1194,10 +1516,38 @@ func (m ReportModel) saveReport() (string, error) {
 			b.WriteString("\n")
 		}
 
-		if len(m.batchResult.MovedFiles) > 0 {
-			b.WriteString("Files Moved to INVALID:\n")
-			for _, path := range m.batchResult.MovedFiles {
-				b.WriteString(fmt.Sprintf("- %s\n", filepath.Base(path)))
+		if len(m.batchResult.Routed) > 0 {
+			b.WriteString("Files Moved:\n")
+			for _, f := range m.batchResult.Routed {
+				switch {
+				case f.Skipped:
+					b.WriteString(fmt.Sprintf("- %s: not moved, %s exists\n", filepath.Base(f.Path), f.Dest))
+				case f.Err != nil:
+					b.WriteString(fmt.Sprintf("- %s: not moved: %v\n", filepath.Base(f.Path), f.Err))
+				default:
+					b.WriteString(fmt.Sprintf("- %s -> %s\n", filepath.Base(f.Path), f.Dest))
+				}
+			}
+			b.WriteString("\n")
+		}
+
+		if failed := m.batchResult.Cleanup.Failed(); len(failed) > 0 {
+			b.WriteString("Cleanup Failed:\n")
+			for _, op := range failed {
+				if op.Action == operations.CleanupMove {
+					continue // Listed with the moved files
+				}
+				b.WriteString(fmt.Sprintf("- %s\n", cleanupOpLine(op)))
+			}
+			b.WriteString("\n")
+		}
+
+		if m.batchResult.Cleanup.Pending() > 0 {
+			b.WriteString("Cleanup Not Applied:\n")
+			for _, op := range m.batchResult.Cleanup {
+				if op.Pending() {
+					b.WriteString(fmt.Sprintf("- %s\n", cleanupOpLine(op)))
+				}
 			}
 			b.WriteString("\n")
 		}
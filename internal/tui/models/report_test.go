@@ -1,13 +1,17 @@
 package models
 
 import (
+	"archive/zip"
 	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/petergi/ebook-mechanic-cli/internal/operations"
+	"github.com/petergi/ebook-mechanic-cli/internal/trash"
 	"github.com/petergi/ebook-mechanic-lib/pkg/ebmlib"
 )
 
@@ -523,6 +527,54 @@ func TestReportModel_formatIssue_WithLocation(t *testing.T) {
 	}
 }
 
+func TestReportModel_Update_ToggleContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("OEBPS/ch1.xhtml")
+	_, _ = w.Write([]byte("<html>\n<body>\n<p>broken\n</body>\n</html>\n"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	issue := ebmlib.ValidationError{
+		Code:     "HTM-004",
+		Message:  "Unclosed element",
+		Severity: ebmlib.SeverityError,
+		Location: &ebmlib.ErrorLocation{File: "ch1.xhtml", Line: 3},
+	}
+	m := NewReportModel(&ebmlib.ValidationReport{FilePath: path, Errors: []ebmlib.ValidationError{issue}}, 80, 24)
+
+	if strings.Contains(m.formatIssue(issue, "error"), "<p>broken") {
+		t.Error("Expected no source context by default")
+	}
+
+	// The source is read by a command, not in Update
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = updated.(ReportModel)
+	if m.contextLines != defaultContextLines {
+		t.Fatalf("Expected contextLines %d, got %d", defaultContextLines, m.contextLines)
+	}
+	if cmd == nil || m.snippets != nil {
+		t.Fatal("Expected the source to be loaded by a command")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(ReportModel)
+	if formatted := m.formatIssue(issue, "error"); !strings.Contains(formatted, "> 3 | <p>broken") {
+		t.Errorf("Expected highlighted source line, got:\n%s", formatted)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = updated.(ReportModel)
+	if strings.Contains(m.formatIssue(issue, "error"), "<p>broken") {
+		t.Error("Expected source context to be toggled off")
+	}
+}
+
 func TestReportModel_ViewportScrolling(t *testing.T) {
 	// Create report with many errors to test scrolling
 	errors := make([]ebmlib.ValidationError, 50)
@@ -667,6 +719,70 @@ func TestReportModel_Update_SaveReport_Batch(t *testing.T) {
 	_ = os.RemoveAll("reports")
 }
 
+func TestReportModel_View_Batch_Throughput(t *testing.T) {
+	valid := []operations.Result{{FilePath: "v.epub", Duration: 250 * time.Millisecond, Bytes: 1 << 20}}
+	result := operations.AggregateResults(valid, time.Second, operations.OperationValidate)
+	m := NewBatchReportModel(&result, 120, 40)
+
+	view := m.View()
+	if !strings.Contains(view, "Throughput") || !strings.Contains(view, "1.0 files/s, 1.0 MB/s") {
+		t.Errorf("Expected throughput in the summary, got:\n%s", view)
+	}
+}
+
+func TestReportModel_Batch_Incomplete(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{{FilePath: "v.epub"}}, time.Second, operations.OperationValidate)
+	result.Incomplete = true
+	result.Unprocessed = 4
+	m := NewBatchReportModel(&result, 120, 40)
+
+	view := m.View()
+	for _, want := range []string{"Incomplete", "cancelled after 1 file(s), 4 not processed", "Not Processed (cancelled)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View missing %q:\n%s", want, view)
+		}
+	}
+
+	path, err := m.saveReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll("reports") }()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "INCOMPLETE: cancelled after 1 file(s), 4 not processed") {
+		t.Errorf("Saved report not labelled incomplete:\n%s", data)
+	}
+}
+
+func TestReportModel_Batch_ScanError(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{{FilePath: "v.epub"}}, time.Second, operations.OperationValidate)
+	result.ScanError = errors.New("batch scan failed: permission denied")
+	m := NewBatchReportModel(&result, 120, 40)
+
+	view := m.View()
+	for _, want := range []string{"Incomplete", "batch scan failed: permission denied", "Valid"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View missing %q:\n%s", want, view)
+		}
+	}
+
+	path, err := m.saveReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll("reports") }()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "INCOMPLETE: batch scan failed: permission denied") {
+		t.Errorf("Saved report not labelled incomplete:\n%s", data)
+	}
+}
+
 func TestReportModel_View_Batch_Filters(t *testing.T) {
 	result := &operations.BatchResult{
 		Total:   3,
@@ -698,6 +814,28 @@ func TestReportModel_Update_Filters(t *testing.T) {
 	}
 }
 
+func TestReportModel_View_Batch_TopIssues(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{
+		{FilePath: "/lib/a.epub", Report: &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "OPF-014", Message: "Missing property"}}}},
+		{FilePath: "/lib/b.epub", Report: &ebmlib.ValidationReport{Errors: []ebmlib.ValidationError{{Code: "OPF-014", Message: "Missing property"}}}},
+	}, 0, operations.OperationValidate)
+	m := NewBatchReportModel(&result, 120, 40)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'5'}})
+	m = updated.(ReportModel)
+	if m.selectedFilter != 4 {
+		t.Fatalf("Expected Top Issues filter (4), got %d", m.selectedFilter)
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "[OPF-014] Missing property") {
+		t.Error("Expected Top Issues tab to list OPF-014")
+	}
+	if !strings.Contains(view, "2 occurrence(s) in 2 file(s)") {
+		t.Error("Expected Top Issues tab to show occurrence counts")
+	}
+}
+
 func TestReportModel_View_NilBatch(t *testing.T) {
 	m := ReportModel{reportType: "batch", batchResult: nil}
 	view := m.View()
@@ -773,3 +911,128 @@ func TestReportModel_Update_ReportSaveMsg(t *testing.T) {
 		t.Error("Expected save status to be visible after save")
 	}
 }
+
+func TestReportModel_Batch_Profile(t *testing.T) {
+	result := operations.AggregateResults([]operations.Result{{FilePath: "v.epub"}}, time.Second, operations.OperationRepair)
+	result.Options = operations.BatchOptions{Profile: "archive", NumWorkers: 2}
+	m := NewBatchReportModel(&result, 120, 40)
+
+	if view := m.View(); !strings.Contains(view, "Profile") || !strings.Contains(view, "archive") {
+		t.Errorf("View missing the profile:\n%s", view)
+	}
+
+	path, err := m.saveReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll("reports") }()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "  Profile: archive\n") {
+		t.Errorf("Saved report missing the profile:\n%s", data)
+	}
+}
+
+func TestReportModel_Batch_Routed(t *testing.T) {
+	result := operations.AggregateResults(nil, time.Second, operations.OperationValidate)
+	result.Routed = []operations.RoutedFile{
+		{Path: "lib/a/book.epub", Dest: "lib/INVALID/a/book.epub", Outcome: operations.OutcomeInvalid},
+		{Path: "lib/b/book.epub", Dest: "lib/INVALID/b/book.epub", Outcome: operations.OutcomeInvalid, Skipped: true},
+	}
+	result.MovedFiles = []string{"lib/a/book.epub"}
+	m := NewBatchReportModel(&result, 120, 40)
+
+	view := m.View()
+	for _, want := range []string{"Files Moved", "Files Not Moved"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View missing %q:\n%s", want, view)
+		}
+	}
+
+	path, err := m.saveReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll("reports") }()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"- book.epub -> lib/INVALID/a/book.epub\n", "- book.epub: not moved, lib/INVALID/b/book.epub exists\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Saved report missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestReportModel_Batch_CleanupConfirm(t *testing.T) {
+	dir := t.TempDir()
+	book := filepath.Join(dir, "broken.epub")
+	if err := os.WriteFile(book, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result := operations.AggregateResults(nil, time.Second, operations.OperationValidate)
+	result.RecordCleanup(operations.CleanupPlan{
+		{Action: operations.CleanupRemove, Path: book, Kind: operations.ErrorKindCorrupt},
+		{Action: operations.CleanupRemove, Path: filepath.Join(dir, "gone.epub")},
+	})
+	bin := trash.New(t.TempDir())
+	m := NewBatchReportModel(&result, 120, 40).WithTrash(bin)
+
+	if view := m.View(); !strings.Contains(view, "Confirm Cleanup") || !strings.Contains(view, "delete "+book) {
+		t.Fatalf("Expected the cleanup to be confirmed first:\n%s", view)
+	}
+
+	// Declining keeps the files and the plan, which x brings back
+	model, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = model.(ReportModel)
+	if cmd != nil || m.confirmCleanup {
+		t.Fatal("Expected the confirmation to be dismissed")
+	}
+	if _, err := os.Stat(book); err != nil {
+		t.Fatalf("Expected the book to be kept, got %v", err)
+	}
+	if view := m.View(); !strings.Contains(view, "Cleanup Not Applied") {
+		t.Errorf("Expected the report to show the cleanup not applied:\n%s", view)
+	}
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	m = model.(ReportModel)
+	if !m.confirmCleanup {
+		t.Fatal("Expected x to ask again")
+	}
+
+	model, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	m = model.(ReportModel)
+	if cmd == nil {
+		t.Fatal("Expected y to apply the cleanup")
+	}
+	model, _ = m.Update(cmd())
+	m = model.(ReportModel)
+	if _, err := os.Stat(book); !os.IsNotExist(err) {
+		t.Errorf("Expected the book to be deleted, got %v", err)
+	}
+	if _, err := bin.Find(book); err != nil {
+		t.Errorf("Expected the book in the trash: %v", err)
+	}
+	if len(m.batchResult.RemovedFiles) != 1 || len(m.batchResult.Cleanup.Failed()) != 1 || len(result.RemovedFiles) != 0 {
+		t.Errorf("Expected one deletion and one failure on a copy, got %+v", m.batchResult.Cleanup)
+	}
+	if view := m.View(); !strings.Contains(view, "Cleanup Failed") {
+		t.Errorf("Expected the failure in the report:\n%s", view)
+	}
+
+	path, err := m.saveReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll("reports") }()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Cleanup Failed:\n- delete "+filepath.Join(dir, "gone.epub")) {
+		t.Errorf("Saved report missing the failed deletion:\n%s", data)
+	}
+}
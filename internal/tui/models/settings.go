@@ -4,14 +4,20 @@ import (
 	"fmt"
 	"runtime"
 
+	"strconv"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/petergi/ebook-mechanic-cli/internal/config"
 	"github.com/petergi/ebook-mechanic-cli/internal/tui/styles"
 )
 
 const (
 	settingsMinJobs = 1
 	settingsMaxJobs = 64
+
+	settingsProfileRow = 7
+	settingsDoneRow    = 8
 )
 
 // SettingsModel manages TUI settings.
@@ -23,6 +29,15 @@ type SettingsModel struct {
 	removeSystemErrors bool
 	moveFailedRepairs  bool
 	cleanupEmptyDirs   bool
+	configPath         string            // User config file saved to, if any
+	initial            map[string]string // Settings when the screen opened
+	saveErr            error
+	profilesPath       string // Profiles file, if profiles are enabled
+	profiles           []config.Profile
+	profile            string // Active profile, "" for none
+	profilesErr        error
+	naming             bool // Typing the name of a new profile
+	newName            string
 	selected           int
 	width              int
 	height             int
@@ -37,6 +52,8 @@ type SettingsSaveMsg struct {
 	RemoveSystemErrors bool
 	MoveFailedRepairs  bool
 	CleanupEmptyDirs   bool
+	Profile            string // Active profile, "" for none
+	Err                error  // Writing the config file failed
 }
 
 // NewSettingsModel creates a new settings model.
@@ -54,7 +71,7 @@ func NewSettingsModel(jobs int, skipValidation bool, noBackup bool, aggressive b
 		jobs = settingsMinJobs
 	}
 
-	return SettingsModel{
+	m := SettingsModel{
 		jobs:               jobs,
 		skipValidation:     skipValidation,
 		noBackup:           noBackup,
@@ -66,6 +83,23 @@ func NewSettingsModel(jobs int, skipValidation bool, noBackup bool, aggressive b
 		width:              width,
 		height:             height,
 	}
+	m.initial = m.values()
+	return m
+}
+
+// WithConfigPath makes saving also write the settings to a config file.
+func (m SettingsModel) WithConfigPath(path string) SettingsModel {
+	m.configPath = path
+	return m
+}
+
+// WithProfiles lets the settings screen pick, create and edit the profiles
+// in the profiles file at path, starting with the active one.
+func (m SettingsModel) WithProfiles(path, active string) SettingsModel {
+	m.profilesPath = path
+	m.profile = active
+	m.profiles, m.profilesErr = config.ReadProfiles(path)
+	return m
 }
 
 // Init initializes the model.
@@ -82,16 +116,23 @@ func (m SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		styles.AdaptToTerminal(m.width, m.height)
 		return m, nil
 
+	case SettingsSaveMsg:
+		m.saveErr = msg.Err
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.naming {
+			return m.updateNaming(msg), nil
+		}
 		switch msg.String() {
 		case "up", "k":
 			m.selected--
 			if m.selected < 0 {
-				m.selected = 7
+				m.selected = settingsDoneRow
 			}
 		case "down", "j":
 			m.selected++
-			if m.selected > 7 {
+			if m.selected > settingsDoneRow {
 				m.selected = 0
 			}
 		case "left", "h", "-", "_", "kp-":
@@ -101,6 +142,9 @@ func (m SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.jobs = settingsMinJobs
 				}
 			}
+			if m.selected == settingsProfileRow {
+				m = m.switchProfile(-1)
+			}
 		case "right", "l", "+", "=", "kp+":
 			if m.selected == 0 {
 				m.jobs++
@@ -108,6 +152,14 @@ func (m SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.jobs = settingsMaxJobs
 				}
 			}
+			if m.selected == settingsProfileRow {
+				m = m.switchProfile(1)
+			}
+		case "n":
+			if m.profilesPath != "" {
+				m.naming = true
+				m.newName = ""
+			}
 		case " ":
 			switch m.selected {
 			case 1:
@@ -124,17 +176,7 @@ func (m SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cleanupEmptyDirs = !m.cleanupEmptyDirs
 			}
 		case "enter":
-			return m, func() tea.Msg {
-				return SettingsSaveMsg{
-					Jobs:               m.jobs,
-					SkipValidation:     m.skipValidation,
-					NoBackup:           m.noBackup,
-					Aggressive:         m.aggressive,
-					RemoveSystemErrors: m.removeSystemErrors,
-					MoveFailedRepairs:  m.moveFailedRepairs,
-					CleanupEmptyDirs:   m.cleanupEmptyDirs,
-				}
-			}
+			return m, m.save
 		case "esc", "q":
 			return m, func() tea.Msg {
 				return BackToMenuMsg{}
@@ -145,6 +187,131 @@ func (m SettingsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// switchProfile moves to the next or previous profile, with "none" before
+// the first, and applies the settings of the profile reached
+func (m SettingsModel) switchProfile(step int) SettingsModel {
+	names := []string{""}
+	current := 0
+	for i, p := range m.profiles {
+		names = append(names, p.Name)
+		if p.Name == m.profile {
+			current = i + 1
+		}
+	}
+	current = (current + step + len(names)) % len(names)
+	m.profile = names[current]
+	if current > 0 {
+		m = m.applyValues(m.profiles[current-1].Values)
+	}
+	return m
+}
+
+// updateNaming handles typing the name of a new profile, which starts with
+// the settings on screen
+func (m SettingsModel) updateNaming(msg tea.KeyMsg) SettingsModel {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.naming = false
+	case tea.KeyBackspace:
+		if len(m.newName) > 0 {
+			m.newName = m.newName[:len(m.newName)-1]
+		}
+	case tea.KeyEnter:
+		if !config.ValidProfileName(m.newName) {
+			return m
+		}
+		for _, p := range m.profiles {
+			if p.Name == m.newName {
+				return m // Pick existing profiles with left and right
+			}
+		}
+		m.profiles = append(m.profiles, config.Profile{Name: m.newName, Values: m.values()})
+		m.profile = m.newName
+		m.naming = false
+	case tea.KeyRunes:
+		if candidate := m.newName + string(msg.Runes); config.ValidProfileName(candidate) {
+			m.newName = candidate
+		}
+	}
+	return m
+}
+
+// applyValues sets the settings given in a profile
+func (m SettingsModel) applyValues(values map[string]string) SettingsModel {
+	if v, err := strconv.Atoi(values["jobs"]); err == nil {
+		m.jobs = min(max(v, settingsMinJobs), settingsMaxJobs)
+	}
+	for key, field := range map[string]*bool{
+		"skip-validation":      &m.skipValidation,
+		"no-backup":            &m.noBackup,
+		"aggressive":           &m.aggressive,
+		"remove-system-errors": &m.removeSystemErrors,
+		"move-failed-repairs":  &m.moveFailedRepairs,
+		"cleanup-empty-dirs":   &m.cleanupEmptyDirs,
+	} {
+		if v, err := strconv.ParseBool(values[key]); err == nil {
+			*field = v
+		}
+	}
+	return m
+}
+
+// values returns the settings as config values
+func (m SettingsModel) values() map[string]string {
+	return map[string]string{
+		"jobs":                 strconv.Itoa(m.jobs),
+		"skip-validation":      strconv.FormatBool(m.skipValidation),
+		"no-backup":            strconv.FormatBool(m.noBackup),
+		"aggressive":           strconv.FormatBool(m.aggressive),
+		"remove-system-errors": strconv.FormatBool(m.removeSystemErrors),
+		"move-failed-repairs":  strconv.FormatBool(m.moveFailedRepairs),
+		"cleanup-empty-dirs":   strconv.FormatBool(m.cleanupEmptyDirs),
+	}
+}
+
+// changed returns the settings that differ from when the screen opened
+func (m SettingsModel) changed() map[string]string {
+	values := make(map[string]string)
+	for key, value := range m.values() {
+		if m.initial[key] != value {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// save writes the settings to the active profile or the user config file,
+// and reports them to the app. The user file records the active profile and
+// only the settings changed on this screen, so values that came from the
+// system file, a library file or the environment are not copied into it.
+func (m SettingsModel) save() tea.Msg {
+	msg := SettingsSaveMsg{
+		Jobs:               m.jobs,
+		SkipValidation:     m.skipValidation,
+		NoBackup:           m.noBackup,
+		Aggressive:         m.aggressive,
+		RemoveSystemErrors: m.removeSystemErrors,
+		MoveFailedRepairs:  m.moveFailedRepairs,
+		CleanupEmptyDirs:   m.cleanupEmptyDirs,
+		Profile:            m.profile,
+	}
+	if m.profile != "" && m.profilesPath != "" {
+		msg.Err = config.SaveProfile(m.profilesPath, m.profile, m.values())
+		if msg.Err == nil && m.configPath != "" {
+			msg.Err = config.Save(m.configPath, map[string]string{"profile": m.profile})
+		}
+	} else if m.configPath != "" {
+		values := m.changed()
+		if m.profilesPath != "" {
+			values["profile"] = "" // Stop using the profile chosen before
+		}
+		if len(values) > 0 {
+			msg.Err = config.Save(m.configPath, values)
+		}
+	}
+	return msg
+}
+
 // View renders the settings.
 func (m SettingsModel) View() string {
 	title := styles.RenderTitle("⚙ Settings")
@@ -157,9 +324,16 @@ func (m SettingsModel) View() string {
 	removeErrorsLabel := fmt.Sprintf("Remove system error books: %v", m.removeSystemErrors)
 	moveFailedLabel := fmt.Sprintf("Move failed repairs to INVALID: %v", m.moveFailedRepairs)
 	cleanupDirsLabel := fmt.Sprintf("Remove entire book directory: %v", m.cleanupEmptyDirs)
+	profileLabel := "Profile: none"
+	if m.profile != "" {
+		profileLabel = "Profile: " + m.profile
+	}
+	if m.naming {
+		profileLabel = "New profile name: " + m.newName + "_"
+	}
 	doneLabel := "Done"
 
-	items := []string{jobsLabel, validationLabel, backupLabel, aggressiveLabel, removeErrorsLabel, moveFailedLabel, cleanupDirsLabel, doneLabel}
+	items := []string{jobsLabel, validationLabel, backupLabel, aggressiveLabel, removeErrorsLabel, moveFailedLabel, cleanupDirsLabel, profileLabel, doneLabel}
 	var rendered string
 	for i, item := range items {
 		cursor := "  "
@@ -178,15 +352,35 @@ func (m SettingsModel) View() string {
 	warning := styles.ErrorStyle.Render("Warning: No backup permanently overwrites the original file.")
 	aggressiveWarning := styles.WarningStyle.Render("Warning: Aggressive repair may drop content or reorder sections.")
 
+	footer := note + "\n" + warning + "\n" + aggressiveWarning
+	if m.profilesErr != nil {
+		footer += "\n" + styles.ErrorStyle.Render(fmt.Sprintf("Could not read profiles: %v", m.profilesErr))
+	}
+	if m.saveErr != nil {
+		footer += "\n" + styles.ErrorStyle.Render(fmt.Sprintf("Could not save settings: %v", m.saveErr))
+	} else if m.profile != "" && m.profilesPath != "" {
+		footer += "\n" + styles.MutedStyle.Render(fmt.Sprintf("Saved to profile %q in %s", m.profile, m.profilesPath))
+	} else if m.configPath != "" {
+		footer += "\n" + styles.MutedStyle.Render("Saved to "+m.configPath)
+	}
+
 	settingsBox := styles.BorderStyle.
 		Width(70).
-		Render(rendered + "\n" + note + "\n" + warning + "\n" + aggressiveWarning)
+		Render(rendered + "\n" + footer)
 
 	helpText := styles.RenderKeyBinding("↑/↓", "navigate") + "  " +
 		styles.RenderKeyBinding("+/-", "change jobs") + "  " +
 		styles.RenderKeyBinding("space", "toggle") + "  " +
 		styles.RenderKeyBinding("enter", "save") + "  " +
 		styles.RenderKeyBinding("esc", "back")
+	if m.profilesPath != "" {
+		helpText += "  " + styles.RenderKeyBinding("←/→", "profile") + "  " +
+			styles.RenderKeyBinding("n", "new profile")
+	}
+	if m.naming {
+		helpText = styles.RenderKeyBinding("enter", "create profile") + "  " +
+			styles.RenderKeyBinding("esc", "cancel")
+	}
 
 	helpBox := lipgloss.NewStyle().
 		Foreground(styles.ColorMuted).
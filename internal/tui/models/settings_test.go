@@ -1,9 +1,14 @@
 package models
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/petergi/ebook-mechanic-cli/internal/config"
 )
 
 func TestNewSettingsModel(t *testing.T) {
@@ -60,3 +65,144 @@ func TestSettingsModel_Update_Save(t *testing.T) {
 		t.Error("expected aggressive false")
 	}
 }
+
+func TestSettingsModel_Save_WritesConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ebm", "config")
+	m := NewSettingsModel(6, false, true, false, false, false, true, 80, 24).WithConfigPath(path)
+	press := func(m SettingsModel, key tea.KeyMsg) SettingsModel {
+		updated, _ := m.Update(key)
+		return updated.(SettingsModel)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	m.selected = 3
+	m = press(m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg := cmd().(SettingsSaveMsg); msg.Err != nil {
+		t.Fatalf("Save failed: %v", msg.Err)
+	}
+
+	cfg, err := config.Load(config.LoadOptions{UserFile: path})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Int("jobs", 0) != 7 || !cfg.Bool("aggressive", false) {
+		t.Errorf("Saved settings = %+v", cfg.Settings())
+	}
+	// Settings left alone may come from another file and are not copied
+	for _, key := range []string{"no-backup", "cleanup-empty-dirs", "skip-validation"} {
+		if s, ok := cfg.Lookup(key); ok {
+			t.Errorf("Expected %s not to be saved, got %+v", key, s)
+		}
+	}
+	if view := m.View(); !strings.Contains(view, "Saved to") {
+		t.Error("Expected the view to name the config file")
+	}
+}
+
+func TestSettingsModel_Save_Error(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewSettingsModel(4, false, false, false, false, false, true, 80, 24).WithConfigPath(filepath.Join(blocker, "config"))
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	m = updated.(SettingsModel)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg := cmd().(SettingsSaveMsg)
+	if msg.Err == nil {
+		t.Fatal("Expected saving under a file to fail")
+	}
+
+	updated, _ = m.Update(msg)
+	if view := updated.(SettingsModel).View(); !strings.Contains(view, "Could not save settings") {
+		t.Error("Expected the save error in the view")
+	}
+}
+
+func TestSettingsModel_Profiles(t *testing.T) {
+	dir := t.TempDir()
+	profiles := filepath.Join(dir, "profiles")
+	user := filepath.Join(dir, "config")
+	if err := os.WriteFile(profiles, []byte("[archive]\njobs = 2\naggressive = false\n\n[staging]\njobs = 12\naggressive = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewSettingsModel(4, false, false, false, false, false, true, 80, 24).
+		WithConfigPath(user).
+		WithProfiles(profiles, "")
+	m.selected = settingsProfileRow
+
+	press := func(m SettingsModel, key tea.KeyMsg) SettingsModel {
+		updated, _ := m.Update(key)
+		return updated.(SettingsModel)
+	}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	m = press(m, right)
+	m = press(m, right)
+	if m.profile != "staging" || m.jobs != 12 || !m.aggressive {
+		t.Fatalf("Expected the staging settings, got profile %q, %d jobs, aggressive %v", m.profile, m.jobs, m.aggressive)
+	}
+	if view := m.View(); !strings.Contains(view, "Profile: staging") {
+		t.Errorf("View missing the profile:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyLeft})
+	if m.profile != "archive" || m.jobs != 2 || m.aggressive {
+		t.Fatalf("Expected the archive settings, got profile %q, %d jobs", m.profile, m.jobs)
+	}
+
+	// Edit the profile and save it
+	m.selected = 0
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'+'}})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg := cmd().(SettingsSaveMsg)
+	if msg.Err != nil || msg.Profile != "archive" || msg.Jobs != 3 {
+		t.Fatalf("Save = %+v", msg)
+	}
+	cfg, err := config.Load(config.LoadOptions{UserFile: user, ProfilesFile: profiles})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile() != "archive" || cfg.Int("jobs", 0) != 3 {
+		t.Errorf("Expected the user file to select the saved archive profile, got %q with %d jobs", cfg.Profile(), cfg.Int("jobs", 0))
+	}
+}
+
+func TestSettingsModel_NewProfile(t *testing.T) {
+	dir := t.TempDir()
+	profiles := filepath.Join(dir, "profiles")
+	m := NewSettingsModel(5, false, true, false, false, false, true, 80, 24).WithProfiles(profiles, "")
+
+	press := func(m SettingsModel, key tea.KeyMsg) SettingsModel {
+		updated, _ := m.Update(key)
+		return updated.(SettingsModel)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	for _, r := range "night/ly" {
+		m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if m.newName != "nightly" {
+		t.Errorf("Expected invalid characters to be ignored, got %q", m.newName)
+	}
+	if view := m.View(); !strings.Contains(view, "New profile name: nightly_") {
+		t.Errorf("View missing the name being typed:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.naming || m.profile != "nightly" {
+		t.Fatalf("Expected the new profile to be active, got %q (naming %v)", m.profile, m.naming)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if msg := cmd().(SettingsSaveMsg); msg.Err != nil {
+		t.Fatalf("Save failed: %v", msg.Err)
+	}
+	saved, err := config.ReadProfiles(profiles)
+	if err != nil || len(saved) != 1 || saved[0].Values["jobs"] != "5" || saved[0].Values["no-backup"] != "true" {
+		t.Errorf("Saved profiles = %+v, %v", saved, err)
+	}
+
+	// Esc cancels naming without leaving the screen
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if updated.(SettingsModel).naming || cmd != nil {
+		t.Error("Expected esc to cancel naming only")
+	}
+}